@@ -0,0 +1,134 @@
+package core
+
+// AntichessStartingFEN is the starting position of Antichess, without
+// castling rights.
+const AntichessStartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1"
+
+// antichessRules make the king an ordinary piece that can be captured and
+// is never in check. Captures are compulsory, pawns can promote to kings
+// and there is no castling. A player without pieces or moves wins.
+type antichessRules struct {
+	standardRules
+}
+
+// NewAntichessBoard creates an Antichess board from a FEN. Invalid FENs
+// result in an empty board.
+func NewAntichessBoard(fen string, chess960 bool) Board {
+	board := newStandardBoard(chess960)
+
+	board.rules = antichessRules{}
+	board.aliases = []string{"Antichess", "Anti chess", "Anti", "Giveaway", "Giveaway chess"}
+	board.uciVariant = "antichess"
+	board.startingFen = AntichessStartingFEN
+	board.tbwSuffix = ".gtbw"
+	board.tbzSuffix = ".gtbz"
+	board.tbwMagic = [4]byte{0xbc, 0x55, 0xbc, 0x21}
+	board.tbzMagic = [4]byte{0xd6, 0xf5, 0x1b, 0x50}
+	board.connectedKings = true
+	board.oneKing = false
+	board.capturesCompulsory = true
+
+	board.setUp(fen)
+	return board
+}
+
+func (r antichessRules) generatePseudoLegalMoves(b *Board, moves []Move, fromMask, toMask Bitboard) []Move {
+	start := len(moves)
+	moves = r.standardRules.generatePseudoLegalMoves(b, moves, fromMask, toMask)
+
+	// Drop castling moves, keep the others in place
+	n := start
+	for i := start; i < len(moves); i++ {
+		if !b.IsCastling(&moves[i]) {
+			moves[n] = moves[i]
+			n++
+		}
+	}
+	moves = moves[:n]
+
+	for i := start; i < n; i++ {
+		if moves[i].Promotion == Queen {
+			moves = append(moves, Move{moves[i].FromSquare, moves[i].ToSquare, King, NoPiece})
+		}
+	}
+
+	return moves
+}
+
+func (b *Board) hasPseudoLegalCapture() bool {
+	var buf [maxMoves]Move
+	return len(b.generatePseudoLegalCaptures(buf[:0], BBAll, BBAll)) > 0
+}
+
+// Without check every pseudo legal move is legal, but only captures if
+// there are any.
+func (antichessRules) generateLegalMoves(b *Board, moves []Move, fromMask, toMask Bitboard) []Move {
+	if b.IsVariantEnd() {
+		return moves
+	}
+
+	if b.capturesCompulsory && b.hasPseudoLegalCapture() {
+		return b.generatePseudoLegalCaptures(moves, fromMask, toMask)
+	}
+
+	return b.generatePseudoLegalMoves(moves, fromMask, toMask)
+}
+
+func (r antichessRules) isPseudoLegal(b *Board, m *Move) bool {
+	return !b.IsCastling(m) && r.standardRules.isPseudoLegal(b, m)
+}
+
+func (r antichessRules) isLegal(b *Board, m *Move) bool {
+	if !r.standardRules.isLegal(b, m) {
+		return false
+	}
+
+	return !b.capturesCompulsory || b.IsCapture(m) || !b.hasPseudoLegalCapture()
+}
+
+func (antichessRules) isCheck(b *Board) bool {
+	return false
+}
+
+func (antichessRules) isIntoCheck(b *Board, m *Move) bool {
+	return false
+}
+
+func (antichessRules) wasIntoCheck(b *Board) bool {
+	return false
+}
+
+func (antichessRules) attackedForKing(b *Board, path, occupied Bitboard) bool {
+	return false
+}
+
+func (antichessRules) isVariantEnd(b *Board) bool {
+	return b.baseBoard.occupiedColor[White] == BBVoid || b.baseBoard.occupiedColor[Black] == BBVoid
+}
+
+func (antichessRules) isVariantWin(b *Board) bool {
+	return b.baseBoard.occupiedColor[b.turn] == BBVoid || b.IsStalemate()
+}
+
+// A position with only bishops is drawn if no bishop can ever be captured,
+// when the bishops of each color stand on squares of a different color.
+func (antichessRules) isInsufficientMaterial(b *Board) bool {
+	if b.baseBoard.occupied != b.baseBoard.bishops {
+		return false
+	}
+
+	white, black := b.baseBoard.occupiedColor[White], b.baseBoard.occupiedColor[Black]
+	return (white&BBLightsquares == BBVoid && black&BBDarkSquares == BBVoid) ||
+		(white&BBDarkSquares == BBVoid && black&BBLightsquares == BBVoid)
+}
+
+// Kings are ordinary pieces and castling is not allowed.
+func (r antichessRules) status(b *Board) uint {
+	status := r.standardRules.status(b)
+
+	if b.castlingRights != BBVoid {
+		status |= StatusBadCastlingRights
+	}
+
+	return status
+}
@@ -0,0 +1,183 @@
+package core
+
+// atomicRules make captures explode: the capturing piece and all pieces
+// but pawns next to the target square are removed. Exploding the enemy
+// king wins, so kings can not capture and a king next to the enemy king
+// can not be checked.
+type atomicRules struct {
+	standardRules
+}
+
+// NewAtomicBoard creates an Atomic board from a FEN. Invalid FENs result in
+// an empty board.
+func NewAtomicBoard(fen string, chess960 bool) Board {
+	board := newStandardBoard(chess960)
+
+	board.rules = atomicRules{}
+	board.aliases = []string{"Atomic", "Atom", "Atomic chess"}
+	board.uciVariant = "atomic"
+	board.tbwSuffix = ".atbw"
+	board.tbzSuffix = ".atbz"
+	board.tbwMagic = [4]byte{0x55, 0x8d, 0xa4, 0x49}
+	board.tbzMagic = [4]byte{0x91, 0xa9, 0x5e, 0xeb}
+	board.connectedKings = true
+
+	board.setUp(fen)
+	return board
+}
+
+// kingsConnected checks if the kings stand next to each other.
+func (b *Board) kingsConnected() bool {
+	whiteKings := b.baseBoard.kings & b.baseBoard.occupiedColor[White]
+	blackKings := b.baseBoard.kings & b.baseBoard.occupiedColor[Black]
+
+	for whiteKings != BBVoid {
+		if kingAttacks[whiteKings.PopMsb()]&blackKings != BBVoid {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (atomicRules) isVariantEnd(b *Board) bool {
+	return !b.baseBoard.occupiedColor[White].IsMaskingBB(b.baseBoard.kings) ||
+		!b.baseBoard.occupiedColor[Black].IsMaskingBB(b.baseBoard.kings)
+}
+
+func (atomicRules) isVariantWin(b *Board) bool {
+	return b.baseBoard.kings != BBVoid && !b.baseBoard.occupiedColor[b.turn.Swap()].IsMaskingBB(b.baseBoard.kings)
+}
+
+func (atomicRules) isVariantLoss(b *Board) bool {
+	return b.baseBoard.kings != BBVoid && !b.baseBoard.occupiedColor[b.turn].IsMaskingBB(b.baseBoard.kings)
+}
+
+func (r atomicRules) isCheck(b *Board) bool {
+	return !b.kingsConnected() && r.standardRules.isCheck(b)
+}
+
+func (r atomicRules) wasIntoCheck(b *Board) bool {
+	return !b.kingsConnected() && r.standardRules.wasIntoCheck(b)
+}
+
+func (atomicRules) isIntoCheck(b *Board, m *Move) bool {
+	b.Push(m)
+	defer b.Pop()
+	return b.WasIntoCheck()
+}
+
+// A move is legal if it does not explode the own king, and does not leave
+// it in check unless the enemy king explodes.
+func (atomicRules) isLegal(b *Board, m *Move) bool {
+	if b.IsVariantEnd() || !b.IsPseudoLegal(m) {
+		return false
+	}
+
+	b.Push(m)
+	defer b.Pop()
+	return b.baseBoard.kings != BBVoid && !b.IsVariantWin() && (b.IsVariantLoss() || !b.WasIntoCheck())
+}
+
+func (atomicRules) generateLegalMoves(b *Board, moves []Move, fromMask, toMask Bitboard) []Move {
+	if b.IsVariantEnd() {
+		return moves
+	}
+
+	start := len(moves)
+	moves = b.generatePseudoLegalMoves(moves, fromMask, toMask)
+
+	n := start
+	for i := start; i < len(moves); i++ {
+		if b.IsLegal(&moves[i]) {
+			moves[n] = moves[i]
+			n++
+		}
+	}
+
+	return moves[:n]
+}
+
+// Kings can castle onto squares next to the enemy king, as attacking them
+// would explode both kings.
+func (r atomicRules) attackedForKing(b *Board, path, occupied Bitboard) bool {
+	for enemyKings := b.baseBoard.kings & b.baseBoard.occupiedColor[b.turn.Swap()]; enemyKings != BBVoid; {
+		path &= ^kingAttacks[enemyKings.PopMsb()]
+	}
+
+	return r.standardRules.attackedForKing(b, path, occupied)
+}
+
+func (atomicRules) pushCapture(b *Board, m *Move, captureSquare Square, pt PieceType, wasPromoted bool) {
+	explosion := kingAttacks[m.ToSquare] & ^b.baseBoard.pawns
+
+	// Exploded rooks and kings lose their castling rights
+	b.castlingRights &= ^explosion
+	if explosion&b.baseBoard.kings&b.baseBoard.occupiedColor[White]&^b.baseBoard.promoted != BBVoid {
+		b.castlingRights &= ^BBRank1
+	}
+	if explosion&b.baseBoard.kings&b.baseBoard.occupiedColor[Black]&^b.baseBoard.promoted != BBVoid {
+		b.castlingRights &= ^BBRank8
+	}
+
+	// The capturing piece explodes with the pieces around
+	b.removePieceAt(m.ToSquare)
+	for explosion != BBVoid {
+		b.removePieceAt(explosion.PopMsb())
+	}
+}
+
+func (atomicRules) isInsufficientMaterial(b *Board) bool {
+	return b.atomicHasInsufficientMaterial(White) && b.atomicHasInsufficientMaterial(Black)
+}
+
+// atomicHasInsufficientMaterial checks if a color can not explode the enemy
+// king, whatever the moves.
+func (b *Board) atomicHasInsufficientMaterial(c Color) bool {
+	bb := &b.baseBoard
+
+	// The enemy king already exploded
+	if !bb.occupiedColor[c.Swap()].IsMaskingBB(bb.kings) {
+		return false
+	}
+
+	// A bare king can not win
+	if bb.occupiedColor[c]&^bb.kings == BBVoid {
+		return true
+	}
+
+	// Enemy pieces can explode next to their king, unless there are only
+	// bishops that can never capture each other
+	if bb.occupiedColor[c.Swap()]&^bb.kings != BBVoid {
+		if bb.occupied == bb.bishops|bb.kings {
+			whiteBishops := bb.bishops & bb.occupiedColor[White]
+			blackBishops := bb.bishops & bb.occupiedColor[Black]
+
+			if whiteBishops&BBDarkSquares == BBVoid && blackBishops&BBLightsquares == BBVoid {
+				return true
+			}
+			if whiteBishops&BBLightsquares == BBVoid && blackBishops&BBDarkSquares == BBVoid {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// A queen or a pawn, a future queen, can win against a bare king
+	if bb.queens != BBVoid || bb.pawns != BBVoid {
+		return false
+	}
+
+	// A single minor piece or rook can not
+	if (bb.knights | bb.bishops | bb.rooks).PopCount() == 1 {
+		return true
+	}
+
+	// Neither can two knights
+	if bb.occupied == bb.knights|bb.kings {
+		return bb.knights.PopCount() <= 2
+	}
+
+	return false
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"math/bits"
 	"strings"
+	"sync"
 )
 
 type Bitboard uint64
@@ -86,6 +87,7 @@ const (
 	BBH8 Bitboard = 1 << H8
 
 	BBCorners = BBA1 | BBH1 | BBA8 | BBH8
+	BBCenter  = BBD4 | BBE4 | BBD5 | BBE5
 
 	BBLightsquares Bitboard = 0x55aa55aa55aa55aa
 	BBDarkSquares  Bitboard = 0xaa55aa55aa55aa55
@@ -147,23 +149,30 @@ func (b Bitboard) Lsb() int {
 	return bits.Len(uint(b&-b)) - 1
 }
 
-func (b Bitboard) ScanForward() chan int {
-	ch := make(chan int)
-
-	go func(mask Bitboard) {
-		for {
-			if mask == BBVoid {
-				break
-			}
+// PopLsb removes the lowest square of the bitboard and returns it. Looping
+// until the bitboard is empty visits all squares without allocating.
+func (b *Bitboard) PopLsb() Square {
+	s := Square(bits.TrailingZeros64(uint64(*b)))
+	*b &= *b - 1
+	return s
+}
 
-			r := mask & -mask
-			ch <- bits.Len(uint(r)) - 1
-			mask ^= r
-		}
+// PopMsb removes the highest square of the bitboard and returns it.
+func (b *Bitboard) PopMsb() Square {
+	s := Square(63 - bits.LeadingZeros64(uint64(*b)))
+	*b ^= Bitboard(1) << s
+	return s
+}
 
-		close(ch)
-	}(b)
+// ScanForward returns the squares in ascending order. The channel is filled
+// and closed before it is returned, so it can be left early.
+func (b Bitboard) ScanForward() chan int {
+	ch := make(chan int, b.PopCount())
+	for b != BBVoid {
+		ch <- int(b.PopLsb())
+	}
 
+	close(ch)
 	return ch
 }
 
@@ -171,23 +180,14 @@ func (b Bitboard) Msb() int {
 	return bits.Len64(uint64(b)) - 1
 }
 
+// ScanReversed returns the squares in descending order, see ScanForward.
 func (b Bitboard) ScanReversed() chan int {
-	ch := make(chan int)
-
-	go func(mask Bitboard) {
-		for {
-			if mask == BBVoid {
-				break
-			}
-
-			r := bits.Len64(uint64(mask)) - 1
-			ch <- r
-			mask ^= NewBitboardFromSquareIndex(uint8(r))
-		}
-
-		close(ch)
-	}(b)
+	ch := make(chan int, b.PopCount())
+	for b != BBVoid {
+		ch <- int(b.PopMsb())
+	}
 
+	close(ch)
 	return ch
 }
 
@@ -366,21 +366,20 @@ func Edges(s Square) Bitboard {
 	return edges[s]
 }
 
+// CarryRippler returns all subsets of the mask, see ScanForward.
 func CarryRippler(mask Bitboard) chan Bitboard {
-	ch := make(chan Bitboard)
-
-	go func(m Bitboard) {
-		subset := BBVoid
-		for {
-			ch <- subset
-			subset = (subset - mask) & mask
-			if subset == 0 {
-				close(ch)
-				break
-			}
+	ch := make(chan Bitboard, 1<<uint(mask.PopCount()))
+
+	subset := BBVoid
+	for {
+		ch <- subset
+		subset = (subset - mask) & mask
+		if subset == BBVoid {
+			break
 		}
-	}(mask)
+	}
 
+	close(ch)
 	return ch
 }
 
@@ -403,32 +402,75 @@ func AttackTable(deltas []int) ([]Bitboard, []map[Bitboard]Bitboard) {
 	return maskTable, attackTable
 }
 
-var diagMasks, diagAttacks = func() ([]Bitboard, []map[Bitboard]Bitboard) { return AttackTable([]int{-9, -7, 7, 9}) }()
+var diagMasks = func() []Bitboard {
+	bbs := []Bitboard{}
+	for i := Square(0); i < 64; i++ {
+		bbs = append(bbs, SlidingAttacks(i, BBVoid, []int{-9, -7, 7, 9}) & ^Edges(i))
+	}
+	return bbs
+}()
 
 func DiagMasks(s Square) Bitboard {
 	return diagMasks[s]
 }
 
+var diagAttacks []map[Bitboard]Bitboard
+var diagAttacksOnce sync.Once
+
+// DiagAttacks returns the diagonal attacks from the square by the occupied
+// squares of DiagMasks.
+//
+// Deprecated: Use BishopAttacks, which needs no table of maps.
 func DiagAttacks(s Square) map[Bitboard]Bitboard {
+	diagAttacksOnce.Do(func() { _, diagAttacks = AttackTable([]int{-9, -7, 7, 9}) })
 	return diagAttacks[s]
 }
 
-var fileMasks, fileAttacks = func() ([]Bitboard, []map[Bitboard]Bitboard) { return AttackTable([]int{-8, 8}) }()
+var fileMasks = func() []Bitboard {
+	bbs := []Bitboard{}
+	for i := Square(0); i < 64; i++ {
+		bbs = append(bbs, SlidingAttacks(i, BBVoid, []int{-8, 8}) & ^Edges(i))
+	}
+	return bbs
+}()
 
 func FileMasks(s Square) Bitboard {
 	return fileMasks[s]
 }
 
+var fileAttacks []map[Bitboard]Bitboard
+var fileAttacksOnce sync.Once
+
+// FileAttacks returns the attacks along the file from the square by the
+// occupied squares of FileMasks.
+//
+// Deprecated: Use RookAttacks, which needs no table of maps.
 func FileAttacks(s Square) map[Bitboard]Bitboard {
+	fileAttacksOnce.Do(func() { _, fileAttacks = AttackTable([]int{-8, 8}) })
 	return fileAttacks[s]
 }
 
-var rankMasks, rankAttacks = func() ([]Bitboard, []map[Bitboard]Bitboard) { return AttackTable([]int{-1, 1}) }()
+var rankMasks = func() []Bitboard {
+	bbs := []Bitboard{}
+	for i := Square(0); i < 64; i++ {
+		bbs = append(bbs, SlidingAttacks(i, BBVoid, []int{-1, 1}) & ^Edges(i))
+	}
+	return bbs
+}()
 
 func RankMasks(s Square) Bitboard {
 	return rankMasks[s]
 }
+
+var rankAttacks []map[Bitboard]Bitboard
+var rankAttacksOnce sync.Once
+
+// RankAttacks returns the attacks along the rank from the square by the
+// occupied squares of RankMasks.
+//
+// Deprecated: Use RookAttacks, which needs no table of maps.
 func RankAttacks(s Square) map[Bitboard]Bitboard {
+	rankAttacksOnce.Do(func() { _, rankAttacks = AttackTable([]int{-1, 1}) })
 	return rankAttacks[s]
 }
 
@@ -445,15 +487,15 @@ func Rays() ([][]Bitboard, [][]Bitboard) {
 		for b := Square(0); b < 64; b++ {
 			bbB := NewBitboardFromSquare(b)
 
-			if diagAttacks[a][0].IsMaskingBB(bbB) {
-				rays_row = append(rays_row, ((diagAttacks[a][0] & diagAttacks[b][0]) | bbA | bbB))
-				between_row = append(between_row, (diagAttacks[a][diagMasks[a]&bbB] & diagAttacks[b][diagMasks[b]&bbA]))
-			} else if rankAttacks[a][0].IsMaskingBB(bbB) {
-				rays_row = append(rays_row, (rankAttacks[a][0] | bbA))
-				between_row = append(between_row, (rankAttacks[a][rankMasks[a]&bbB] & rankAttacks[b][rankMasks[b]&bbA]))
-			} else if fileAttacks[a][0].IsMaskingBB(bbB) {
-				rays_row = append(rays_row, (fileAttacks[a][0] | bbA))
-				between_row = append(between_row, (fileAttacks[a][fileMasks[a]&bbB] & fileAttacks[b][fileMasks[b]&bbA]))
+			if BishopAttacks(a, BBVoid).IsMaskingBB(bbB) {
+				rays_row = append(rays_row, ((BishopAttacks(a, BBVoid) & BishopAttacks(b, BBVoid)) | bbA | bbB))
+				between_row = append(between_row, (BishopAttacks(a, bbB) & BishopAttacks(b, bbA)))
+			} else if rankSliderAttacks(a, BBVoid).IsMaskingBB(bbB) {
+				rays_row = append(rays_row, (rankSliderAttacks(a, BBVoid) | bbA))
+				between_row = append(between_row, (rankSliderAttacks(a, bbB) & rankSliderAttacks(b, bbA)))
+			} else if fileSliderAttacks(a, BBVoid).IsMaskingBB(bbB) {
+				rays_row = append(rays_row, (fileSliderAttacks(a, BBVoid) | bbA))
+				between_row = append(between_row, (fileSliderAttacks(a, bbB) & fileSliderAttacks(b, bbA)))
 			} else {
 				rays_row = append(rays_row, 0)
 				between_row = append(between_row, 0)
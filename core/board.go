@@ -52,6 +52,8 @@ const (
 	StartingBoardFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR"
 )
 
+// NewBaseBoard creates a board from the position part of a FEN. Invalid
+// FENs result in an empty board, see ParseBoardFEN.
 func NewBaseBoard(fen string) BaseBoard {
 	b := BaseBoard{}
 	b.occupiedColor = []Bitboard{BBVoid, BBVoid}
@@ -204,29 +206,24 @@ func (b *BaseBoard) Attacks(s Square) Bitboard {
 	attacks := BBVoid
 
 	if mask.IsMaskingBB(b.bishops) || mask.IsMaskingBB(b.queens) {
-		attacks |= DiagAttacks(s)[DiagMasks(s)&b.occupied]
+		attacks |= BishopAttacks(s, b.occupied)
 	}
 
 	if mask.IsMaskingBB(b.rooks) || mask.IsMaskingBB(b.queens) {
-		attacks |= (RankAttacks(s)[RankMasks(s)&b.occupied]) | (FileAttacks(s)[FileMasks(s)&b.occupied])
+		attacks |= RookAttacks(s, b.occupied)
 	}
 
 	return attacks
 }
 
 func (b *BaseBoard) attackersMask(c Color, s Square, occupied Bitboard) Bitboard {
-	rankPieces := RankMasks(s) & occupied
-	filePieces := FileMasks(s) & occupied
-	diagPieces := DiagMasks(s) & occupied
-
 	queensAndRooks := b.queens | b.rooks
 	queensAndBishops := b.queens | b.bishops
 
 	attackers := (KingAttacks(s) & b.kings) |
 		(KnightAttacks(s) & b.knights) |
-		(RankAttacks(s)[rankPieces] & queensAndRooks) |
-		(FileAttacks(s)[filePieces] & queensAndRooks) |
-		(DiagAttacks(s)[diagPieces] & queensAndBishops) |
+		(RookAttacks(s, occupied) & queensAndRooks) |
+		(BishopAttacks(s, occupied) & queensAndBishops) |
 		(PawnAttacks(s, c.Swap()) & b.pawns)
 
 	return attackers & b.occupiedColor[c]
@@ -252,16 +249,17 @@ func (b *BaseBoard) PinMask(c Color, s Square) Bitboard {
 
 	squareMask := NewBitboardFromSquare(s)
 
-	ks := [][]map[Bitboard]Bitboard{fileAttacks, rankAttacks, diagAttacks}
-	vs := []Bitboard{b.rooks | b.queens, b.rooks | b.queens, b.bishops | b.queens}
+	ks := [...]func(Square, Bitboard) Bitboard{fileSliderAttacks, rankSliderAttacks, BishopAttacks}
+	vs := [...]Bitboard{b.rooks | b.queens, b.rooks | b.queens, b.bishops | b.queens}
 
 	for i, _ := range ks {
 		attacks, sliders := ks[i], vs[i]
 
-		rays := attacks[kingSq][0]
+		rays := attacks(kingSq, BBVoid)
 		if rays.IsMaskingBB(squareMask) {
 			snipers := rays & sliders & b.occupiedColor[c.Swap()]
-			for sniper := range snipers.ScanReversed() {
+			for snipers != BBVoid {
+				sniper := snipers.PopMsb()
 				if bbBetween[sniper][kingSq]&(b.occupied|squareMask) == squareMask {
 					return bbRays[kingSq][sniper]
 				}
@@ -319,6 +317,19 @@ func (b *Board) PieceAt(s Square) *Piece {
 	return b.baseBoard.PieceAt(s)
 }
 
+func (b *Board) PieceTypeAt(s Square) PieceType {
+	return b.baseBoard.PieceTypeAt(s)
+}
+
+func (b *Board) Pieces(t PieceType, c Color) Bitboard {
+	return b.baseBoard.Pieces(t, c)
+}
+
+// BaseBoard returns a copy of the pieces of the board.
+func (b *Board) BaseBoard() BaseBoard {
+	return NewBaseBoardFromBaseBoard(&b.baseBoard)
+}
+
 func (b *BaseBoard) setPieceAt(s Square, pt PieceType, c Color, promoted bool) {
 	b.RemovePieceAt(s)
 
@@ -392,82 +403,51 @@ func (b *BaseBoard) FEN(promoted bool) string {
 	return strings.Join(builder, "")
 }
 
-func (b *BaseBoard) SetFEN(fen string) {
-	fen = strings.TrimSpace(fen)
-	if strings.Contains(fen, " ") {
-		panic("expected position part of fen, got multiple parts")
+// SetFEN sets up the position part of a FEN. The board is left unchanged
+// if the FEN is invalid and the *FENError is returned.
+func (b *BaseBoard) SetFEN(fen string) error {
+	parts := splitFEN(fen)
+	if len(parts) == 0 {
+		return fenError(fen, FENFieldBoard, len(fen), "empty fen")
 	}
 
-	// Ensure the FEN is valid
-	rows := strings.Split(fen, "/")
-	if len(rows) != 8 {
-		panic("expected 8 rows in position part of fen")
+	if len(parts) > 1 {
+		return fenError(fen, FENFieldBoard, parts[1].offset, "expected position part of fen, got multiple parts")
 	}
 
-	fenNumbers := map[string]bool{"1": true, "2": true, "3": true, "4": true, "5": true, "6": true, "7": true, "8": true}
-	fenPieces := map[string]bool{"p": true, "n": true, "b": true, "r": true, "q": true, "k": true}
-
-	// Validate each row.
-	for _, row := range rows {
-		fieldSum := 0
-		previousWasDigit := false
-		previousWasPiece := false
-
-		for _, c := range strings.Split(row, "") {
-			if _, ok := fenNumbers[c]; ok {
-				if previousWasDigit {
-					panic("two subsequent digits in position part of fen")
-				}
-
-				i, _ := strconv.Atoi(c)
-				fieldSum += i
-				previousWasDigit = true
-				previousWasPiece = false
-			} else if c == "~" {
-				if !previousWasPiece {
-					panic("~ not after piece in position part of fen")
-				}
-				previousWasDigit = false
-				previousWasPiece = false
-			} else if _, ok := fenPieces[strings.ToLower(c)]; ok {
-				fieldSum++
-				previousWasDigit = false
-				previousWasPiece = true
-			} else {
-				panic("invalid character in position part of fen")
-			}
-		}
-
-		if fieldSum != 8 {
-			panic("expected 8 columns per row in position part of fen")
-		}
+	if err := validateBoardFEN(fen, parts[0]); err != nil {
+		return err
 	}
 
-	// Clear the board.
+	b.setBoardFEN(parts[0].text)
+	return nil
+}
+
+// setBoardFEN sets up the position part of a validated FEN.
+func (b *BaseBoard) setBoardFEN(fen string) {
 	b.Clear()
 
-	// Put pieces on the board.
 	squareIndex := 0
-	for _, c := range strings.Split(fen, "") {
-		if _, ok := fenNumbers[c]; ok {
-			i, _ := strconv.Atoi(c)
-			squareIndex += i
-		} else if _, ok := fenPieces[strings.ToLower(c)]; ok {
-			piece := NewPieceFromSymbol(c)
+	for _, c := range fen {
+		if c >= '1' && c <= '8' {
+			squareIndex += int(c - '0')
+		} else if c == '~' {
+			b.promoted |= NewBitboardFromSquare(squares180[squareIndex-1])
+		} else if c != '/' {
+			piece := NewPieceFromSymbol(string(c))
 			b.SetPieceAt(squares180[squareIndex], &piece, false)
 			squareIndex++
-		} else if c == "~" {
-			b.promoted |= NewBitboardFromSquare(squares[squares180[squareIndex-1]])
 		}
 	}
 }
 
 func (b *BaseBoard) PieceMap() map[Square]*Piece {
 	result := make(map[Square]*Piece)
-	for s := range b.occupied.ScanReversed() {
-		p := b.PieceAt(Square(s))
+	for occupied := b.occupied; occupied != BBVoid; {
+		s := occupied.PopMsb()
+		p := b.PieceAt(s)
 		cp := NewPiece(p.Type, p.Color)
-		result[Square(s)] = &cp
+		result[s] = &cp
 	}
 	return result
 }
@@ -480,9 +460,11 @@ func (b *BaseBoard) SetPieceMap(pm map[Square]*Piece) {
 	}
 }
 
+// SetChess960Pos sets up the Chess960 starting position of a Scharnagl
+// number from 0 to 959, and panics on other numbers. See NewChess960Board.
 func (b *BaseBoard) SetChess960Pos(sharnagl int) {
-	if sharnagl < 0 || sharnagl > 960 {
-		panic("invalid position")
+	if err := checkChess960Pos(sharnagl); err != nil {
+		panic(err)
 	}
 
 	n, bw := sharnagl/4, sharnagl%4
@@ -491,7 +473,7 @@ func (b *BaseBoard) SetChess960Pos(sharnagl int) {
 
 	n1, n2 := 0, 0
 	for n1 = 0; n1 < 4; n1++ {
-		n2 := n + (3-n1)*(4-n1)
+		n2 = n + (3-n1)*(4-n1)/2 - 5
 		if n1 < n2 && 1 <= n2 && n2 <= 4 {
 			break
 		}
@@ -545,7 +527,7 @@ func (b *BaseBoard) SetChess960Pos(sharnagl int) {
 	}
 	for i := 0; i < 8; i++ {
 		if !used[i] {
-			b.rooks = NewBitboardFromFile(File(i)) & BBBackRanks
+			b.rooks |= NewBitboardFromFile(File(i)) & BBBackRanks
 			used[i] = true
 			break
 		}
@@ -755,6 +737,9 @@ type BoardState struct {
 	epSquare       Square
 	halfMoveClock  uint
 	fullMoveNumber uint
+	zobristHash    uint64
+	pockets        [2]Pocket
+	checks         [2]int
 }
 
 func NewBoardStateFromBoard(b *Board) BoardState {
@@ -779,6 +764,9 @@ func NewBoardStateFromBoard(b *Board) BoardState {
 	bs.epSquare = b.epSquare
 	bs.halfMoveClock = b.halfMoveClock
 	bs.fullMoveNumber = b.fullMoveNumber
+	bs.zobristHash = b.zobristHash
+	bs.pockets = b.pockets
+	bs.checks = b.checks
 
 	return bs
 }
@@ -786,15 +774,22 @@ func NewBoardStateFromBoard(b *Board) BoardState {
 type Board struct {
 	baseBoard BaseBoard
 
+	rules       rules
 	aliases     []string
 	uciVariant  string
 	startingFen string
 
-	// TODO: tbw/tbz
+	// Syzygy tablebase files of the variant
+	tbwSuffix string
+	tbzSuffix string
+	tbwMagic  [4]byte
+	tbzMagic  [4]byte
 
 	connectedKings     bool
 	oneKing            bool
 	capturesCompulsory bool
+	hasPockets         bool
+	checkLimit         int
 
 	chess960 bool
 
@@ -806,37 +801,41 @@ type Board struct {
 	epSquare       Square
 	halfMoveClock  uint
 	fullMoveNumber uint
+
+	// Updated incrementally by Push and Pop
+	zobristHash uint64
+
+	// Pieces in hand of black and white, for variants with drops
+	pockets [2]Pocket
+
+	// Checks given by black and white, for variants with a check limit
+	checks [2]int
 }
 
 func NewBoard(chess960 bool) Board {
 	return NewBoardFromFEN("8/8/8/8/8/8/8/8 w - - 0 1", chess960)
 }
 
+// NewBoardFromFEN creates a board from a FEN. Invalid FENs result in an
+// empty board, use ParseFEN for FENs that are not known to be valid.
 func NewBoardFromFEN(fen string, chess960 bool) Board {
-	board := Board{}
-
-	board.aliases = []string{"Standard", "Chess", "Classical", "Normal"}
-	board.uciVariant = "chess"
-	board.startingFen = StartingFEN
-	board.connectedKings = false
-	board.oneKing = true
-	board.capturesCompulsory = false
-
-	board.chess960 = chess960
+	board := newStandardBoard(chess960)
+	board.setUp(fen)
+	return board
+}
 
-	board.moveStack = []Move{}
-	board.stack = []BoardState{}
+// setUp sets up the position of a FEN on a new board, or an empty board if
+// the FEN is invalid.
+func (b *Board) setUp(fen string) {
+	b.moveStack = []Move{}
+	b.stack = []BoardState{}
 
-	board.baseBoard = NewBaseBoard(strings.Fields(fen)[0])
-	if fen == "" {
-		board.Clear()
-	} else if fen == StartingFEN {
-		board.Reset()
-	} else {
-		board.SetFEN(fen)
+	b.baseBoard = NewBaseBoard("")
+	if fen == b.startingFen {
+		b.Reset()
+	} else if fen == "" || b.SetFEN(fen) != nil {
+		b.Clear()
 	}
-
-	return board
 }
 
 func NewDefaultBoard() Board {
@@ -846,17 +845,24 @@ func NewDefaultBoard() Board {
 func NewBoardFromBoard(b *Board) Board {
 	board := Board{}
 
-	copy(board.aliases, b.aliases)
+	board.rules = b.rules
+	board.aliases = append([]string{}, b.aliases...)
 	board.uciVariant = b.uciVariant
 	board.startingFen = b.startingFen
+	board.tbwSuffix = b.tbwSuffix
+	board.tbzSuffix = b.tbzSuffix
+	board.tbwMagic = b.tbwMagic
+	board.tbzMagic = b.tbzMagic
 	board.connectedKings = b.connectedKings
 	board.oneKing = b.oneKing
 	board.capturesCompulsory = b.capturesCompulsory
+	board.hasPockets = b.hasPockets
+	board.checkLimit = b.checkLimit
 
 	board.chess960 = b.chess960
 
-	copy(board.moveStack, b.moveStack)
-	copy(board.stack, b.stack)
+	board.moveStack = append([]Move{}, b.moveStack...)
+	board.stack = append([]BoardState{}, b.stack...)
 
 	board.baseBoard = NewBaseBoardFromBaseBoard(&b.baseBoard)
 
@@ -865,6 +871,9 @@ func NewBoardFromBoard(b *Board) Board {
 	board.epSquare = b.epSquare
 	board.halfMoveClock = b.halfMoveClock
 	board.fullMoveNumber = b.fullMoveNumber
+	board.zobristHash = b.zobristHash
+	board.pockets = b.pockets
+	board.checks = b.checks
 
 	return board
 }
@@ -882,12 +891,53 @@ func (b *Board) CastlingRights() Bitboard {
 	return b.castlingRights
 }
 
+func (b *Board) EpSquare() Square {
+	return b.epSquare
+}
+
+func (b *Board) IsChess960() bool {
+	return b.chess960
+}
+
+// TablebaseSuffixes returns the file suffixes of the Syzygy WDL and DTZ
+// tables of the variant.
+func (b *Board) TablebaseSuffixes() (string, string) {
+	return b.tbwSuffix, b.tbzSuffix
+}
+
+// TablebaseMagics returns the first bytes of the Syzygy WDL and DTZ tables
+// of the variant.
+func (b *Board) TablebaseMagics() ([4]byte, [4]byte) {
+	return b.tbwMagic, b.tbzMagic
+}
+
+func (b *Board) FullMoveNumber() uint {
+	return b.fullMoveNumber
+}
+
+func (b *Board) HalfMoveClock() uint {
+	return b.halfMoveClock
+}
+
+func (b *Board) MoveStack() []Move {
+	return append([]Move{}, b.moveStack...)
+}
+
+// Reset sets up the starting position of the variant and clears the move
+// stack.
 func (b *Board) Reset() {
+	if b.startingFen != StartingFEN {
+		b.SetFEN(b.startingFen)
+		return
+	}
+
 	b.turn = White
 	b.castlingRights = BBCorners
 	b.epSquare = SquareNone
 	b.halfMoveClock = 0
 	b.fullMoveNumber = 1
+	b.pockets = [2]Pocket{}
+	b.checks = [2]int{}
 
 	b.baseBoard.Reset()
 	b.clearStack()
@@ -899,14 +949,19 @@ func (b *Board) Clear() {
 	b.epSquare = SquareNone
 	b.halfMoveClock = 0
 	b.fullMoveNumber = 1
+	b.pockets = [2]Pocket{}
+	b.checks = [2]int{}
 
 	b.baseBoard.Clear()
 	b.clearStack()
 }
 
+// clearStack is called whenever a position is set up, which is when the
+// hash is computed from scratch.
 func (b *Board) clearStack() {
 	b.moveStack = []Move{}
 	b.stack = []BoardState{}
+	b.zobristHash = b.computeZobristHash()
 }
 
 func (b *Board) RemovePieceAt(s Square) Piece {
@@ -920,178 +975,175 @@ func (b *Board) SetPieceAt(s Square, p *Piece, promoted bool) {
 	b.clearStack()
 }
 
-func (b *Board) generatePseudoLegalMoves(fromMask, toMask Bitboard) chan Move {
-	ch := make(chan Move)
+// Upper bound of the number of moves in a position, for buffers on the stack
+const maxMoves = 256
 
-	go func(b Board) {
-		defer close(ch)
+func appendPromotions(moves []Move, fromSquare, toSquare Square) []Move {
+	return append(moves,
+		Move{fromSquare, toSquare, Queen, NoPiece},
+		Move{fromSquare, toSquare, Rook, NoPiece},
+		Move{fromSquare, toSquare, Bishop, NoPiece},
+		Move{fromSquare, toSquare, Knight, NoPiece})
+}
 
-		ourPieces := b.baseBoard.occupiedColor[b.turn]
+func (b *Board) generatePseudoLegalMoves(moves []Move, fromMask, toMask Bitboard) []Move {
+	return b.rules.generatePseudoLegalMoves(b, moves, fromMask, toMask)
+}
 
-		// Generate piece moves
-		nonPawns := ourPieces & ^b.baseBoard.pawns & fromMask
-		for fromSquare := range nonPawns.ScanReversed() {
-			moves := b.baseBoard.Attacks(Square(fromSquare)) & ^ourPieces & toMask
-			for toSquare := range moves.ScanReversed() {
-				m, _ := NewNormalMove(Square(fromSquare), Square(toSquare))
-				ch <- *m
-			}
-		}
+func (standardRules) generatePseudoLegalMoves(b *Board, moves []Move, fromMask, toMask Bitboard) []Move {
+	ourPieces := b.baseBoard.occupiedColor[b.turn]
 
-		// Generate castling moves
-		if fromMask.IsMaskingBB(b.baseBoard.kings) {
-			for move := range b.generateCastlingMoves(fromMask, toMask) {
-				ch <- move
-			}
+	// Generate piece moves
+	nonPawns := ourPieces & ^b.baseBoard.pawns & fromMask
+	for nonPawns != BBVoid {
+		fromSquare := nonPawns.PopMsb()
+		targets := b.baseBoard.Attacks(fromSquare) & ^ourPieces & toMask
+		for targets != BBVoid {
+			moves = append(moves, Move{fromSquare, targets.PopMsb(), NoPiece, NoPiece})
 		}
+	}
 
-		// The remaining moves are pawn moves
-		pawns := b.baseBoard.pawns & b.baseBoard.occupiedColor[b.turn] & fromMask
-		if pawns == BBVoid {
-			return
-		}
-
-		// Generate captures
-		capturers := pawns
-		for fromSquare := range capturers.ScanReversed() {
-			targets := pawnAttacks[b.turn][fromSquare] & b.baseBoard.occupiedColor[b.turn.Swap()] & toMask
-			for toSquare := range targets.ScanReversed() {
-				if Square(toSquare).Rank() == 0 || Square(toSquare).Rank() == 7 {
-					var m *Move
-					m, _ = NewPromotionMove(Square(fromSquare), Square(toSquare), Queen)
-					ch <- *m
-					m, _ = NewPromotionMove(Square(fromSquare), Square(toSquare), Rook)
-					ch <- *m
-					m, _ = NewPromotionMove(Square(fromSquare), Square(toSquare), Bishop)
-					ch <- *m
-					m, _ = NewPromotionMove(Square(fromSquare), Square(toSquare), Knight)
-					ch <- *m
-				} else {
-					m, _ := NewNormalMove(Square(fromSquare), Square(toSquare))
-					ch <- *m
-				}
+	// Generate castling moves
+	if fromMask.IsMaskingBB(b.baseBoard.kings) {
+		moves = b.generateCastlingMoves(moves, fromMask, toMask)
+	}
+
+	// The remaining moves are pawn moves
+	pawns := b.baseBoard.pawns & b.baseBoard.occupiedColor[b.turn] & fromMask
+	if pawns == BBVoid {
+		return moves
+	}
+
+	// Generate captures
+	capturers := pawns
+	for capturers != BBVoid {
+		fromSquare := capturers.PopMsb()
+		targets := pawnAttacks[b.turn][fromSquare] & b.baseBoard.occupiedColor[b.turn.Swap()] & toMask
+		for targets != BBVoid {
+			toSquare := targets.PopMsb()
+			if toSquare.Rank() == 0 || toSquare.Rank() == 7 {
+				moves = appendPromotions(moves, fromSquare, toSquare)
+			} else {
+				moves = append(moves, Move{fromSquare, toSquare, NoPiece, NoPiece})
 			}
 		}
+	}
 
-		// Prepare pawn advance generation
-		singleMoves, doubleMoves := BBVoid, BBVoid
-		if b.turn == White {
-			singleMoves = (pawns << 8) & ^b.baseBoard.occupied
-			doubleMoves = (singleMoves << 8) & ^b.baseBoard.occupied & (BBRank3 | BBRank4)
+	// Prepare pawn advance generation
+	singleMoves, doubleMoves := BBVoid, BBVoid
+	if b.turn == White {
+		singleMoves = (pawns << 8) & ^b.baseBoard.occupied
+		doubleMoves = (singleMoves << 8) & ^b.baseBoard.occupied & (BBRank3 | BBRank4)
+	} else {
+		singleMoves = (pawns >> 8) & ^b.baseBoard.occupied
+		doubleMoves = (singleMoves >> 8) & ^b.baseBoard.occupied & (BBRank6 | BBRank5)
+	}
+	singleMoves &= toMask
+	doubleMoves &= toMask
+
+	// Generate single pawn moves
+	for singleMoves != BBVoid {
+		toSquare := singleMoves.PopMsb()
+		fromSquare := toSquare
+		if b.turn == Black {
+			fromSquare += 8
 		} else {
-			singleMoves = (pawns >> 8) & ^b.baseBoard.occupied
-			doubleMoves = (singleMoves >> 8) & ^b.baseBoard.occupied & (BBRank6 | BBRank5)
+			fromSquare -= 8
 		}
-		singleMoves &= toMask
-		doubleMoves &= toMask
 
-		// Generate single pawn moves
-		for toSquare := range singleMoves.ScanReversed() {
-			fromSquare := Square(toSquare)
-			if b.turn == Black {
-				fromSquare += 8
-			} else {
-				fromSquare -= 8
-			}
+		if toSquare.Rank() == 0 || toSquare.Rank() == 7 {
+			moves = appendPromotions(moves, fromSquare, toSquare)
+		} else {
+			moves = append(moves, Move{fromSquare, toSquare, NoPiece, NoPiece})
+		}
+	}
 
-			if Square(toSquare).Rank() == 0 || Square(toSquare).Rank() == 7 {
-				var m *Move
-				m, _ = NewPromotionMove(fromSquare, Square(toSquare), Queen)
-				ch <- *m
-				m, _ = NewPromotionMove(fromSquare, Square(toSquare), Rook)
-				ch <- *m
-				m, _ = NewPromotionMove(fromSquare, Square(toSquare), Bishop)
-				ch <- *m
-				m, _ = NewPromotionMove(fromSquare, Square(toSquare), Knight)
-				ch <- *m
-			} else {
-				m, _ := NewNormalMove(fromSquare, Square(toSquare))
-				ch <- *m
-			}
+	// Generate double pawn moves
+	for doubleMoves != BBVoid {
+		toSquare := doubleMoves.PopMsb()
+		fromSquare := toSquare
+		if b.turn == Black {
+			fromSquare += 16
+		} else {
+			fromSquare -= 16
 		}
 
-		// Generate double pawn moves
-		for toSquare := range doubleMoves.ScanReversed() {
-			fromSquare := Square(toSquare)
-			if b.turn == Black {
-				fromSquare += 16
-			} else {
-				fromSquare -= 16
-			}
+		moves = append(moves, Move{fromSquare, toSquare, NoPiece, NoPiece})
+	}
 
-			m, _ := NewNormalMove(fromSquare, Square(toSquare))
-			ch <- *m
-		}
+	// Generate enpassant captures
+	if b.epSquare != SquareNone {
+		moves = b.generatePseudoLegalEp(moves, fromMask, toMask)
+	}
 
-		// Generate enpassant captures
-		if b.epSquare != SquareNone {
-			for move := range b.generatePseudoLegalEp(fromMask, toMask) {
-				ch <- move
-			}
-		}
-	}(NewBoardFromBoard(b))
+	return moves
+}
+
+// PseudoLegalMoves appends the pseudo legal moves to buf and returns the
+// extended slice. Reusing a buffer, like moves = b.PseudoLegalMoves(moves[:0]),
+// avoids allocations.
+func (b *Board) PseudoLegalMoves(buf []Move) []Move {
+	return b.generatePseudoLegalMoves(buf, BBAll, BBAll)
+}
 
+// moveChan returns moves as a closed, buffered channel for the channel based
+// API, which can be left early.
+func moveChan(moves []Move) chan Move {
+	ch := make(chan Move, len(moves))
+	for _, m := range moves {
+		ch <- m
+	}
+
+	close(ch)
 	return ch
 }
 
 func (b *Board) GeneratePseudoLegalMoves() chan Move {
-	return b.generatePseudoLegalMoves(BBAll, BBAll)
+	return moveChan(b.PseudoLegalMoves(nil))
 }
 
-func (b *Board) generatePseudoLegalEp(fromMask, toMask Bitboard) chan Move {
-	ch := make(chan Move)
-
-	go func(b Board) {
-		defer close(ch)
-
-		if (b.epSquare == SquareNone) || !NewBitboardFromSquare(b.epSquare).IsMaskingBB(toMask) {
-			return
-		}
+func (b *Board) generatePseudoLegalEp(moves []Move, fromMask, toMask Bitboard) []Move {
+	if (b.epSquare == SquareNone) || !NewBitboardFromSquare(b.epSquare).IsMaskingBB(toMask) {
+		return moves
+	}
 
-		if NewBitboardFromSquare(b.epSquare).IsMaskingBB(b.baseBoard.occupied) {
-			return
-		}
+	if NewBitboardFromSquare(b.epSquare).IsMaskingBB(b.baseBoard.occupied) {
+		return moves
+	}
 
-		capturers := b.baseBoard.pawns & b.baseBoard.occupiedColor[b.turn] & fromMask & PawnAttacks(b.epSquare, b.turn.Swap())
-		if b.turn == White {
-			capturers &= BBRank5
-		} else {
-			capturers &= BBRank4
-		}
+	capturers := b.baseBoard.pawns & b.baseBoard.occupiedColor[b.turn] & fromMask & PawnAttacks(b.epSquare, b.turn.Swap())
+	if b.turn == White {
+		capturers &= BBRank5
+	} else {
+		capturers &= BBRank4
+	}
 
-		for capturer := range capturers.ScanReversed() {
-			m, _ := NewNormalMove(Square(capturer), b.epSquare)
-			ch <- *m
-		}
-	}(NewBoardFromBoard(b))
+	for capturers != BBVoid {
+		moves = append(moves, Move{capturers.PopMsb(), b.epSquare, NoPiece, NoPiece})
+	}
 
-	return ch
+	return moves
 }
 
-func (b *Board) generatePseudoLegalCaptures(fromMask, toMask Bitboard) chan Move {
-	ch := make(chan Move)
-
-	go func(b Board) {
-		defer close(ch)
-
-		for m := range b.generatePseudoLegalMoves(fromMask, (toMask & b.baseBoard.occupiedColor[b.turn.Swap()])) {
-			ch <- m
-		}
-
-		for m := range b.generatePseudoLegalEp(fromMask, toMask) {
-			ch <- m
-		}
-	}(NewBoardFromBoard(b))
-
-	return ch
+func (b *Board) generatePseudoLegalCaptures(moves []Move, fromMask, toMask Bitboard) []Move {
+	moves = b.generatePseudoLegalMoves(moves, fromMask, toMask&b.baseBoard.occupiedColor[b.turn.Swap()])
+	return b.generatePseudoLegalEp(moves, fromMask, toMask)
 }
 
 func (b *Board) IsCheck() bool {
+	return b.rules.isCheck(b)
+}
+
+func (standardRules) isCheck(b *Board) bool {
 	kingSquare := b.baseBoard.King(b.turn)
 	return kingSquare != SquareNone && b.baseBoard.IsAttackedBy(b.turn.Swap(), kingSquare)
 }
 
 func (b *Board) IsIntoCheck(m *Move) bool {
+	return b.rules.isIntoCheck(b, m)
+}
+
+func (standardRules) isIntoCheck(b *Board, m *Move) bool {
 	kingSquare := b.baseBoard.King(b.turn)
 	if kingSquare == SquareNone {
 		return false
@@ -1099,15 +1151,8 @@ func (b *Board) IsIntoCheck(m *Move) bool {
 
 	checkers := b.baseBoard.AttackersMask(b.turn.Swap(), kingSquare)
 	if checkers != BBVoid {
-		isIn := false
-		for move := range b.generateEvasions(kingSquare, checkers, NewBitboardFromSquare(m.FromSquare), NewBitboardFromSquare(m.ToSquare)) {
-			if move == *m {
-				isIn = true
-				break
-			}
-		}
-
-		if !isIn {
+		var buf [8]Move
+		if !containsMove(b.generateEvasions(buf[:0], kingSquare, checkers, NewBitboardFromSquare(m.FromSquare), NewBitboardFromSquare(m.ToSquare)), m) {
 			return true
 		}
 	}
@@ -1116,11 +1161,19 @@ func (b *Board) IsIntoCheck(m *Move) bool {
 }
 
 func (b *Board) WasIntoCheck() bool {
+	return b.rules.wasIntoCheck(b)
+}
+
+func (standardRules) wasIntoCheck(b *Board) bool {
 	kingSquare := b.baseBoard.King(b.turn.Swap())
 	return kingSquare != SquareNone && b.baseBoard.IsAttackedBy(b.turn, kingSquare)
 }
 
 func (b *Board) IsPseudoLegal(m *Move) bool {
+	return b.rules.isPseudoLegal(b, m)
+}
+
+func (standardRules) isPseudoLegal(b *Board, m *Move) bool {
 	// Null moves are not pseudo legal
 	if !m.IsNotNull() {
 		return false
@@ -1161,10 +1214,9 @@ func (b *Board) IsPseudoLegal(m *Move) bool {
 
 	// Handle castling
 	if pieceType == King {
-		for move := range b.generateCastlingMoves(BBAll, BBAll) {
-			if move == *m {
-				return true
-			}
+		var buf [4]Move
+		if containsMove(b.generateCastlingMoves(buf[:0], BBAll, BBAll), m) {
+			return true
 		}
 	}
 
@@ -1175,39 +1227,64 @@ func (b *Board) IsPseudoLegal(m *Move) bool {
 
 	// Handle pawn moves
 	if pieceType == Pawn {
-		for move := range b.generatePseudoLegalMoves(fromMask, toMask) {
-			if move == *m {
-				return true
-			}
-		}
-
-		return false
+		var buf [8]Move
+		return containsMove(b.generatePseudoLegalMoves(buf[:0], fromMask, toMask), m)
 	}
 
 	return b.baseBoard.Attacks(m.FromSquare)&toMask != BBVoid
 }
 
 func (b *Board) IsLegal(m *Move) bool {
+	return b.rules.isLegal(b, m)
+}
+
+func (standardRules) isLegal(b *Board, m *Move) bool {
 	return !b.IsVariantEnd() && b.IsPseudoLegal(m) && !b.IsIntoCheck(m)
 }
 
+// IsVariantEnd checks if the game is over due to a special rule of the
+// variant, like an exploded king in Atomic.
 func (b *Board) IsVariantEnd() bool {
-	return false
+	return b.rules.isVariantEnd(b)
 }
 
+// IsVariantLoss checks if the side to move lost by a rule of the variant.
 func (b *Board) IsVariantLoss() bool {
-	return false
+	return b.rules.isVariantLoss(b)
 }
 
+// IsVariantWin checks if the side to move won by a rule of the variant.
 func (b *Board) IsVariantWin() bool {
-	return false
+	return b.rules.isVariantWin(b)
 }
 
+// IsVariantDraw checks if the game is drawn by a rule of the variant.
 func (b *Board) IsVariantDraw() bool {
+	return b.rules.isVariantDraw(b)
+}
+
+func (standardRules) isVariantEnd(b *Board) bool {
+	return false
+}
+
+func (standardRules) isVariantLoss(b *Board) bool {
+	return false
+}
+
+func (standardRules) isVariantWin(b *Board) bool {
+	return false
+}
+
+func (standardRules) isVariantDraw(b *Board) bool {
 	return false
 }
 
 func (b *Board) IsGameOver(claimDraw bool) bool {
+	// Chess variant support
+	if b.IsVariantLoss() || b.IsVariantWin() || b.IsVariantDraw() {
+		return true
+	}
+
 	// 75 move rule
 	if b.IsSeventyFiveMoves() {
 		return true
@@ -1219,13 +1296,7 @@ func (b *Board) IsGameOver(claimDraw bool) bool {
 	}
 
 	// stalemate or checkmate
-	hasLegalMoves := 0
-	for range b.GenerateLegalMoves(BBAll, BBAll) {
-		hasLegalMoves++
-		break
-	}
-
-	if hasLegalMoves == 0 {
+	if !b.hasLegalMoves() {
 		return true
 	}
 
@@ -1282,13 +1353,7 @@ func (b *Board) Result(claimDraw bool) string {
 	}
 
 	// Stalemate
-	hasLegalMoves := 0
-	for range b.GenerateLegalMoves(BBAll, BBAll) {
-		hasLegalMoves++
-		break
-	}
-
-	if hasLegalMoves == 0 {
+	if !b.hasLegalMoves() {
 		return "1/2-1/2"
 	}
 
@@ -1301,13 +1366,7 @@ func (b *Board) IsCheckmate() bool {
 		return false
 	}
 
-	legalMovesCount := 0
-	for range b.GenerateLegalMoves(BBAll, BBAll) {
-		legalMovesCount++
-		break
-	}
-
-	return legalMovesCount == 0
+	return !b.hasLegalMoves()
 }
 
 func (b *Board) IsStalemate() bool {
@@ -1319,16 +1378,14 @@ func (b *Board) IsStalemate() bool {
 		return false
 	}
 
-	hasLegalMoves := 0
-	for range b.GenerateLegalMoves(BBAll, BBAll) {
-		hasLegalMoves++
-		break
-	}
-
-	return hasLegalMoves == 0
+	return !b.hasLegalMoves()
 }
 
 func (b *Board) IsInsufficientMaterial() bool {
+	return b.rules.isInsufficientMaterial(b)
+}
+
+func (standardRules) isInsufficientMaterial(b *Board) bool {
 	// Enough material to mate.
 	if b.baseBoard.pawns != BBVoid || b.baseBoard.rooks != BBVoid || b.baseBoard.queens != BBVoid {
 		return false
@@ -1356,13 +1413,7 @@ func (b *Board) IsInsufficientMaterial() bool {
 
 func (b *Board) IsSeventyFiveMoves() bool {
 	if b.halfMoveClock >= 150 {
-		legalMoves := 0
-		for range b.GenerateLegalMoves(BBAll, BBAll) {
-			legalMoves++
-			break
-		}
-
-		if legalMoves != 0 {
+		if b.hasLegalMoves() {
 			return true
 		}
 	}
@@ -1371,7 +1422,7 @@ func (b *Board) IsSeventyFiveMoves() bool {
 }
 
 func (b *Board) IsFiveFoldRepetition() bool {
-	transpositionKey := b.transpositionKey()
+	hash := b.zobristHash
 
 	if len(b.moveStack) < 16 {
 		return false
@@ -1384,7 +1435,7 @@ func (b *Board) IsFiveFoldRepetition() bool {
 			switchYard = append(switchYard, b.Pop())
 		}
 
-		if b.transpositionKey() != transpositionKey {
+		if b.zobristHash != hash {
 			for i := range switchYard {
 				b.Push(switchYard[len(switchYard)-i-1])
 			}
@@ -1406,13 +1457,7 @@ func (b *Board) CanClaimDraw() bool {
 
 func (b *Board) CanClaimFiftyMoves() bool {
 	if b.halfMoveClock >= 100 {
-		legalMoves := 0
-		for range b.GenerateLegalMoves(BBAll, BBAll) {
-			legalMoves++
-			break
-		}
-
-		if legalMoves != 0 {
+		if b.hasLegalMoves() {
 			return true
 		}
 	}
@@ -1421,8 +1466,8 @@ func (b *Board) CanClaimFiftyMoves() bool {
 }
 
 func (b *Board) CanClaimThreefoldRepetition() bool {
-	transpositionKey := b.transpositionKey()
-	transpositions := map[string]int{transpositionKey: 1}
+	hash := b.zobristHash
+	transpositions := map[uint64]int{hash: 1}
 
 	// Count positions.
 	switchyard := []*Move{}
@@ -1434,7 +1479,7 @@ func (b *Board) CanClaimThreefoldRepetition() bool {
 			break
 		}
 
-		transpositions[b.transpositionKey()]++
+		transpositions[b.zobristHash]++
 	}
 
 	for i := range switchyard {
@@ -1442,15 +1487,16 @@ func (b *Board) CanClaimThreefoldRepetition() bool {
 	}
 
 	// Threefold repetition occured.
-	if transpositions[transpositionKey] >= 3 {
+	if transpositions[hash] >= 3 {
 		return true
 	}
 
 	// The next legal move is a threefold repetition.
-	for move := range b.GenerateLegalMoves(BBAll, BBAll) {
+	var buf [maxMoves]Move
+	for _, move := range b.LegalMoves(buf[:0]) {
 		b.Push(&move)
 
-		if transpositions[b.transpositionKey()] >= 2 {
+		if transpositions[b.zobristHash] >= 2 {
 			b.Pop()
 			return true
 		}
@@ -1461,14 +1507,31 @@ func (b *Board) CanClaimThreefoldRepetition() bool {
 	return false
 }
 
-func (b *Board) pushCapture(m *Move, captureSquare Square, pt PieceType, wasPromoted bool) {
+func (standardRules) pushCapture(b *Board, m *Move, captureSquare Square, pt PieceType, wasPromoted bool) {
 	// Noop
 }
 
+func (standardRules) pushDrop(b *Board, m *Move) {
+	b.setPieceAt(m.ToSquare, m.Drop, b.turn, false)
+}
+
 func (b *Board) Push(move *Move) {
+	b.rules.push(b, move)
+}
+
+func (standardRules) push(b *Board, move *Move) {
+	stateKey := b.zobristStateKey()
+
 	b.stack = append(b.stack, NewBoardStateFromBoard(b)) // Capture the board state
 	b.moveStack = append(b.moveStack, *move)             // TODO: Make a defensive copy
 
+	// Castling rights, en passant and turn are hashed again once the move is
+	// done, pieces are hashed as they move
+	b.zobristHash ^= stateKey
+	defer func() {
+		b.zobristHash ^= b.zobristStateKey()
+	}()
+
 	m := b.toChess960(move)
 
 	// Reset en passant square
@@ -1489,8 +1552,7 @@ func (b *Board) Push(move *Move) {
 
 	// Drops
 	if m.Drop != NoPiece {
-		p := NewPiece(m.Drop, b.turn)
-		b.baseBoard.SetPieceAt(m.ToSquare, &p, false)
+		b.rules.pushDrop(b, m)
 		b.turn = b.turn.Swap()
 		return
 	}
@@ -1504,7 +1566,7 @@ func (b *Board) Push(move *Move) {
 	toMask := NewBitboardFromSquare(m.ToSquare)
 
 	promoted := b.baseBoard.promoted.IsMaskingBB(fromMask)
-	piece := b.baseBoard.RemovePieceAt(m.FromSquare)
+	piece := b.removePieceAt(m.FromSquare)
 	captureSquare := m.ToSquare
 	capturedPieceType := b.baseBoard.PieceTypeAt(m.ToSquare)
 
@@ -1526,20 +1588,20 @@ func (b *Board) Push(move *Move) {
 
 	// Handle special pawn moves
 	if piece.Type == Pawn {
-		diff := int(m.ToSquare - m.FromSquare)
+		diff := int(m.ToSquare) - int(m.FromSquare)
 
 		if diff == 16 && m.FromSquare.Rank() == 1 {
 			b.epSquare = m.FromSquare + 8
-		} else if diff == -16 && m.FromSquare == 6 {
+		} else if diff == -16 && m.FromSquare.Rank() == 6 {
 			b.epSquare = m.FromSquare - 8
 		} else if m.ToSquare == epSquare && (util.AbsInt(diff) == 7 || util.AbsInt(diff) == 9) && capturedPieceType == NoPiece {
 			// Remove pawns captured en passant
 			if b.turn == White {
 				captureSquare := Square(epSquare - 8)
-				capturedPieceType = b.baseBoard.RemovePieceAt(captureSquare).Type
+				capturedPieceType = b.removePieceAt(captureSquare).Type
 			} else {
 				captureSquare := Square(epSquare + 8)
-				capturedPieceType = b.baseBoard.RemovePieceAt(captureSquare).Type
+				capturedPieceType = b.removePieceAt(captureSquare).Type
 			}
 		}
 	}
@@ -1559,24 +1621,24 @@ func (b *Board) Push(move *Move) {
 	if castling != BBVoid {
 		aSide := m.ToSquare.File() < m.FromSquare.File()
 
-		b.baseBoard.RemovePieceAt(m.FromSquare)
-		b.baseBoard.RemovePieceAt(m.ToSquare)
+		b.removePieceAt(m.FromSquare)
+		b.removePieceAt(m.ToSquare)
 
 		if aSide {
 			if b.turn == White {
-				b.baseBoard.setPieceAt(C1, King, b.turn, false)
-				b.baseBoard.setPieceAt(D1, Rook, b.turn, false)
+				b.setPieceAt(C1, King, b.turn, false)
+				b.setPieceAt(D1, Rook, b.turn, false)
 			} else {
-				b.baseBoard.setPieceAt(C8, King, b.turn, false)
-				b.baseBoard.setPieceAt(D8, Rook, b.turn, false)
+				b.setPieceAt(C8, King, b.turn, false)
+				b.setPieceAt(D8, Rook, b.turn, false)
 			}
 		} else {
 			if b.turn == White {
-				b.baseBoard.setPieceAt(G1, King, b.turn, false)
-				b.baseBoard.setPieceAt(F1, Rook, b.turn, false)
+				b.setPieceAt(G1, King, b.turn, false)
+				b.setPieceAt(F1, Rook, b.turn, false)
 			} else {
-				b.baseBoard.setPieceAt(G8, King, b.turn, false)
-				b.baseBoard.setPieceAt(F8, Rook, b.turn, false)
+				b.setPieceAt(G8, King, b.turn, false)
+				b.setPieceAt(F8, Rook, b.turn, false)
 			}
 		}
 	}
@@ -1584,10 +1646,10 @@ func (b *Board) Push(move *Move) {
 	// Put the piece on the target square
 	if castling == BBVoid && piece.Type != NoPiece {
 		wasPromoted := b.baseBoard.promoted.IsMaskingBB(toMask)
-		b.baseBoard.setPieceAt(m.ToSquare, piece.Type, b.turn, wasPromoted)
+		b.setPieceAt(m.ToSquare, piece.Type, b.turn, promoted)
 
 		if capturedPieceType != NoPiece {
-			b.pushCapture(m, captureSquare, capturedPieceType, wasPromoted)
+			b.rules.pushCapture(b, m, captureSquare, capturedPieceType, wasPromoted)
 		}
 	}
 
@@ -1619,6 +1681,9 @@ func (b *Board) Pop() *Move {
 	b.epSquare = state.epSquare
 	b.halfMoveClock = state.halfMoveClock
 	b.fullMoveNumber = state.fullMoveNumber
+	b.zobristHash = state.zobristHash
+	b.pockets = state.pockets
+	b.checks = state.checks
 
 	return &move
 }
@@ -1635,12 +1700,12 @@ func (b *Board) CastlingShredderFen() string {
 
 	builder := []string{}
 
-	for s := range (castlingRights & BBRank1).ScanReversed() {
-		builder = append(builder, strings.ToUpper(Square(s).File().Name()))
+	for white := castlingRights & BBRank1; white != BBVoid; {
+		builder = append(builder, strings.ToUpper(white.PopMsb().File().Name()))
 	}
 
-	for s := range (castlingRights & BBRank8).ScanReversed() {
-		builder = append(builder, Square(s).File().Name())
+	for black := castlingRights & BBRank8; black != BBVoid; {
+		builder = append(builder, black.PopMsb().File().Name())
 	}
 
 	return strings.Join(builder, "")
@@ -1661,19 +1726,20 @@ func (b *Board) CastlingXFEN() string {
 			backRank = BBRank1
 		}
 
-		for rookSquare := range (b.CleanCastlingRights() & backRank).ScanReversed() {
-			rookFile := Square(rookSquare).File()
+		for rooks := b.CleanCastlingRights() & backRank; rooks != BBVoid; {
+			rookSquare := rooks.PopMsb()
+			rookFile := rookSquare.File()
 			aSide := rookFile < kingFile
 
-			otherRooks := b.baseBoard.occupiedColor[color] & b.baseBoard.rooks & backRank & ^NewBitboardFromSquare(Square(rookSquare))
+			otherRooks := b.baseBoard.occupiedColor[color] & b.baseBoard.rooks & backRank & ^NewBitboardFromSquare(rookSquare)
 
 			ch := "k"
 			if aSide {
 				ch = "q"
 			}
 
-			for other := range otherRooks.ScanReversed() {
-				if (Square(other).File() < rookFile) == aSide {
+			for otherRooks != BBVoid {
+				if (otherRooks.PopMsb().File() < rookFile) == aSide {
 					ch = rookFile.Name()
 					break
 				}
@@ -1695,81 +1761,49 @@ func (b *Board) CastlingXFEN() string {
 }
 
 func (b *Board) hasPseudoLegalEnPassant() bool {
-	if b.epSquare != SquareNone {
-		for range b.generatePseudoLegalEp(BBAll, BBAll) {
-			return true
-		}
-	}
-
-	return false
+	var buf [2]Move
+	return b.epSquare != SquareNone && len(b.generatePseudoLegalEp(buf[:0], BBAll, BBAll)) > 0
 }
 
 func (b *Board) hasLegalEnPassant() bool {
-	if b.epSquare != SquareNone {
-		for range b.generateLegalEp(BBAll, BBAll) {
-			return true
-		}
-	}
-
-	return false
+	var buf [2]Move
+	return b.epSquare != SquareNone && len(b.generateLegalEp(buf[:0], BBAll, BBAll)) > 0
 }
 
 func (b *Board) FEN(shredder bool, enPassant string, promoted PieceType) string {
-	return fmt.Sprintf("%s %d %d", b.epd(shredder, enPassant, promoted), b.halfMoveClock, b.fullMoveNumber)
+	fen := fmt.Sprintf("%s %d %d", b.epd(shredder, enPassant, promoted), b.halfMoveClock, b.fullMoveNumber)
+	if b.checkLimit > 0 {
+		// Checks given so far, like "+2+0"
+		fen += fmt.Sprintf(" +%d+%d", b.checks[White], b.checks[Black])
+	}
+
+	return fen
 }
 
 func (b *Board) ShredderFEN(enPassant string, promoted PieceType) string {
-	return fmt.Sprintf("%s %d %d", b.epd(true, enPassant, promoted), b.halfMoveClock, b.fullMoveNumber)
+	return b.FEN(true, enPassant, promoted)
 }
 
-func (b *Board) SetFEN(fen string) {
-	parts := strings.Fields(fen)
-	if len(parts) != 6 {
-		panic("FEN string should consist of 6 parts")
-	}
-
-	if !(parts[1] == "w" || parts[1] == "b") {
-		panic("Expected 'w' or 'b' for turn part of fen")
-	}
-
-	if parts[3] != "-" {
-		sq := NewSquareFromName(parts[3])
-		if sq == SquareNone {
-			panic("Invalid enpassant square name")
-		}
-	}
-
-	halfMoveClock, err := strconv.Atoi(parts[4])
-	if err != nil || halfMoveClock < 0 {
-		panic("Halfmove clock invalid or cannot be negative")
-	}
-
-	fullMoveNumber, err := strconv.Atoi(parts[5])
-	if err != nil || fullMoveNumber < 0 {
-		panic("fullmove number invalid or cannot be negative")
-	}
-
-	b.baseBoard.SetFEN(parts[0])
-
-	// set turn
-	if parts[1] == "w" {
-		b.turn = White
-	} else {
-		b.turn = Black
-	}
-
-	b.setCastlingFEN(parts[2])
-
-	if parts[3] == "-" {
-		b.epSquare = SquareNone
-	} else {
-		b.epSquare = NewSquareFromName(parts[3])
+// SetFEN sets up the position of a FEN and clears the move stack. The
+// board is left unchanged if the FEN is invalid and the *FENError is
+// returned.
+func (b *Board) SetFEN(fen string) error {
+	parsed, err := parseFEN(fen, b.hasPockets, b.checkLimit)
+	if err != nil {
+		return err
 	}
 
-	b.halfMoveClock = uint(halfMoveClock)
-	b.fullMoveNumber = uint(fullMoveNumber)
+	b.baseBoard.setBoardFEN(parsed.board)
+	b.turn = parsed.turn
+	b.setCastlingFEN(parsed.castling)
+	b.epSquare = parsed.epSquare
+	b.halfMoveClock = uint(parsed.halfMoveClock)
+	b.fullMoveNumber = uint(parsed.fullMoveNumber)
+	b.pockets = parsed.pockets
+	b.checks = parsed.checks
 
 	b.clearStack()
+	return nil
 }
 
 func (b *Board) setCastlingFEN(castlingFen string) {
@@ -1778,10 +1812,6 @@ func (b *Board) setCastlingFEN(castlingFen string) {
 		return
 	}
 
-	if matches, _ := regexp.MatchString(FENCastlingRegexString, castlingFen); !matches {
-		panic("Invalid castling fen")
-	}
-
 	b.castlingRights = BBVoid
 
 	for _, flag := range strings.Split(castlingFen, "") {
@@ -1820,9 +1850,13 @@ func (b *Board) setCastlingFEN(castlingFen string) {
 	b.clearStack()
 }
 
-func (b *Board) SetBoardFEN(fen string) {
-	b.baseBoard.SetFEN(fen)
+func (b *Board) SetBoardFEN(fen string) error {
+	if err := b.baseBoard.SetFEN(fen); err != nil {
+		return err
+	}
+
 	b.clearStack()
+	return nil
 }
 
 func (b *Board) SetPieceMap(pm map[Square]*Piece) {
@@ -1830,6 +1864,40 @@ func (b *Board) SetPieceMap(pm map[Square]*Piece) {
 	b.clearStack()
 }
 
+func checkChess960Pos(sharnagl int) error {
+	if sharnagl < 0 || sharnagl >= 960 {
+		return &ValueError{description: "invalid chess960 position: " + strconv.Itoa(sharnagl), Value: strconv.Itoa(sharnagl)}
+	}
+
+	return nil
+}
+
+// NewChess960BaseBoard returns the Chess960 starting position of a
+// Scharnagl number from 0 to 959. Errors are *ValueError.
+func NewChess960BaseBoard(sharnagl int) (BaseBoard, error) {
+	b := NewBaseBoard("")
+	if err := checkChess960Pos(sharnagl); err != nil {
+		return b, err
+	}
+
+	b.SetChess960Pos(sharnagl)
+	return b, nil
+}
+
+// NewChess960Board returns the Chess960 starting position of a Scharnagl
+// number from 0 to 959, with white to move. Errors are *ValueError.
+func NewChess960Board(sharnagl int) (Board, error) {
+	b := NewBoard(true)
+	if err := checkChess960Pos(sharnagl); err != nil {
+		return b, err
+	}
+
+	b.SetChess960Pos(sharnagl)
+	return b, nil
+}
+
+// SetChess960Pos sets up the Chess960 starting position of a Scharnagl
+// number from 0 to 959, and panics on other numbers. See NewChess960Board.
 func (b *Board) SetChess960Pos(sharnagl int) {
 	b.baseBoard.SetChess960Pos(sharnagl)
 	b.chess960 = true
@@ -1973,7 +2041,12 @@ func (b *Board) Chess960Pos(ignoreTurn, ignoreCastling, ignoreCounters bool) int
 func (b *Board) epd(shredder bool, enPassant string, promoted PieceType, epdOperations ...interface{}) string {
 	epd := []string{}
 
-	epd = append(epd, b.baseBoard.FEN(promoted != NoPiece))
+	if b.hasPockets {
+		// Promoted pieces are always marked, as they are demoted when captured
+		epd = append(epd, b.baseBoard.FEN(true)+"["+strings.ToUpper(b.pockets[White].String())+b.pockets[Black].String()+"]")
+	} else {
+		epd = append(epd, b.baseBoard.FEN(promoted != NoPiece))
+	}
 	if b.turn == White {
 		epd = append(epd, "w")
 	} else {
@@ -2107,7 +2180,8 @@ func (b *Board) algebraic(move *Move, long bool) string {
 		fromMask := b.baseBoard.PieceMask(piece, b.turn)
 		fromMask &= ^NewBitboardFromSquare(move.FromSquare)
 		toMask := NewBitboardFromSquare(move.ToSquare)
-		for candidate := range b.GenerateLegalMoves(fromMask, toMask) {
+		var buf [maxMoves]Move
+		for _, candidate := range b.generateLegalMoves(buf[:0], fromMask, toMask) {
 			others |= NewBitboardFromSquare(candidate.FromSquare)
 		}
 
@@ -2162,10 +2236,12 @@ func (b *Board) algebraic(move *Move, long bool) string {
 }
 
 var sanRegexp *regexp.Regexp
+var sanDropRegexp *regexp.Regexp
 var fenCastlingRegexp *regexp.Regexp
 
 func init() {
 	sanRegexp = regexp.MustCompile("^([NBKRQ])?([a-h])?([1-8])?[\\-x]?([a-h][1-8])(=?[nbrqkNBRQK])?(\\+|#)?\\z")
+	sanDropRegexp = regexp.MustCompile("^([PNBRQ])?@([a-h][1-8])(\\+|#)?\\z")
 	fenCastlingRegexp = regexp.MustCompile("^(?:-|[KQABCDEFGH]{0,2}[kqabcdefgh]{0,2})\\z")
 }
 
@@ -2179,7 +2255,7 @@ func (e SanParseError) Error() string { return e.description }
 func (b *Board) parseSan(san string) (*Move, error) {
 	// Castling
 	if _, ok := (map[string]bool{"O-O": true, "O-O+": true, "O-O#": true})[san]; ok {
-		for m := range b.generateCastlingMoves(BBAll, BBAll) {
+		for _, m := range b.generateCastlingMoves(nil, BBAll, BBAll) {
 			if b.IsKingsideCastling(&m) {
 				return &m, nil
 			}
@@ -2187,7 +2263,7 @@ func (b *Board) parseSan(san string) (*Move, error) {
 
 		return nil, SanParseError{description: "Invalid kingside castling expression"}
 	} else if _, ok := (map[string]bool{"O-O-O": true, "O-O-O+": true, "O-O-O#": true})[san]; ok {
-		for m := range b.generateCastlingMoves(BBAll, BBAll) {
+		for _, m := range b.generateCastlingMoves(nil, BBAll, BBAll) {
 			if b.IsQueensideCastling(&m) {
 				return &m, nil
 			}
@@ -2196,6 +2272,21 @@ func (b *Board) parseSan(san string) (*Move, error) {
 		return nil, SanParseError{description: "Invalid queenside castling expression"}
 	}
 
+	// Drops
+	if matches := sanDropRegexp.FindStringSubmatch(san); matches != nil {
+		drop := Pawn
+		if len(matches[1]) > 0 {
+			drop = NewPieceFromSymbol(strings.ToLower(matches[1])).Type
+		}
+
+		m, _ := NewDropMove(NewSquareFromName(matches[2]), drop)
+		if !b.IsLegal(m) {
+			return nil, SanParseError{description: "Illegal SAN " + san + " " + b.FEN(false, "legal", NoPiece)}
+		}
+
+		return m, nil
+	}
+
 	// Match normal moves
 	match := sanRegexp.MatchString(san)
 	if !match {
@@ -2240,8 +2331,8 @@ func (b *Board) parseSan(san string) (*Move, error) {
 	// Match legal moves
 	m, _ := NewNullMove()
 	matchedMove := *m
-	for move := range b.GenerateLegalMoves(fromMask, toMask) {
-
+	var buf [maxMoves]Move
+	for _, move := range b.generateLegalMoves(buf[:0], fromMask, toMask) {
 		if move.Promotion != promotion {
 			continue
 		}
@@ -2260,6 +2351,10 @@ func (b *Board) parseSan(san string) (*Move, error) {
 	return &matchedMove, nil
 }
 
+func (b *Board) ParseSan(san string) (*Move, error) {
+	return b.parseSan(san)
+}
+
 func (b *Board) PushSan(san string) (*Move, error) {
 	move, err := b.parseSan(san)
 	if err != nil {
@@ -2286,6 +2381,12 @@ func (b *Board) parseUci(uci string) (*Move, error) {
 	return move, nil
 }
 
+// ParseUci parses a legal move in UCI notation. Castling is accepted as the
+// king moving two squares or onto its rook.
+func (b *Board) ParseUci(uci string) (*Move, error) {
+	return b.parseUci(uci)
+}
+
 func (b *Board) PushUci(uci string) (*Move, error) {
 	move, err := b.parseUci(uci)
 	if err != nil {
@@ -2310,14 +2411,14 @@ func (b *Board) sliderBlockers(kingSquare Square) Bitboard {
 	rooks_and_queens := b.baseBoard.rooks | b.baseBoard.queens
 	bishops_and_queens := b.baseBoard.bishops | b.baseBoard.queens
 
-	snipers := ((rankAttacks[kingSquare][0] & rooks_and_queens) |
-		(fileAttacks[kingSquare][0] & rooks_and_queens) |
-		(diagAttacks[kingSquare][0] & bishops_and_queens))
+	snipers := ((rankSliderAttacks(kingSquare, BBVoid) & rooks_and_queens) |
+		(fileSliderAttacks(kingSquare, BBVoid) & rooks_and_queens) |
+		(BishopAttacks(kingSquare, BBVoid) & bishops_and_queens))
 
 	blockers := BBVoid
 
-	for sniper := range (snipers & b.baseBoard.occupiedColor[b.turn.Swap()]).ScanReversed() {
-		b := bbBetween[kingSquare][sniper] & b.baseBoard.occupied
+	for snipers &= b.baseBoard.occupiedColor[b.turn.Swap()]; snipers != BBVoid; {
+		b := bbBetween[kingSquare][snipers.PopMsb()] & b.baseBoard.occupied
 
 		// Add to blockers if exactly one piece in-between.
 		if (b & NewBitboardFromSquare(Square(b.Msb()))) == b {
@@ -2473,6 +2574,10 @@ func (b *Board) HasChess960CastlingRights() bool {
 }
 
 func (b *Board) Status() uint {
+	return b.rules.status(b)
+}
+
+func (standardRules) status(b *Board) uint {
 	errors := StatusValid
 
 	// There must be at least one piece
@@ -2481,14 +2586,16 @@ func (b *Board) Status() uint {
 	}
 
 	// There must be exactly one king of each color
-	if !b.baseBoard.occupiedColor[White].IsMaskingBB(b.baseBoard.kings) {
-		errors |= StatusNoWhiteKing
-	}
-	if !b.baseBoard.occupiedColor[Black].IsMaskingBB(b.baseBoard.kings) {
-		errors |= StatusNoBlackKing
-	}
-	if (b.baseBoard.occupied & b.baseBoard.kings).PopCount() > 2 {
-		errors |= StatusTooManyKings
+	if b.oneKing {
+		if !b.baseBoard.occupiedColor[White].IsMaskingBB(b.baseBoard.kings) {
+			errors |= StatusNoWhiteKing
+		}
+		if !b.baseBoard.occupiedColor[Black].IsMaskingBB(b.baseBoard.kings) {
+			errors |= StatusNoBlackKing
+		}
+		if (b.baseBoard.occupied & b.baseBoard.kings).PopCount() > 2 {
+			errors |= StatusTooManyKings
+		}
 	}
 
 	// There can not be more than 16 pieces of any color.
@@ -2593,146 +2700,159 @@ func (b *Board) epSkewered(kingSquare, capturer Square) bool {
 	occupancy := (b.baseBoard.occupied & ^NewBitboardFromSquare(lastDouble) & ^NewBitboardFromSquare(capturer) | NewBitboardFromSquare(b.epSquare))
 
 	horizontalAttackers := b.baseBoard.occupiedColor[b.turn.Swap()] & (b.baseBoard.rooks | b.baseBoard.queens)
-	if rankAttacks[kingSquare][(rankMasks[kingSquare] & occupancy)].IsMaskingBB(horizontalAttackers) {
+	if rankSliderAttacks(kingSquare, occupancy).IsMaskingBB(horizontalAttackers) {
 		return true
 	}
 
-	diagonalAttackers := b.baseBoard.occupiedColor[b.turn.Swap()] & (b.baseBoard.bishops & b.baseBoard.queens)
-	if diagAttacks[kingSquare][(diagMasks[kingSquare] & occupancy)].IsMaskingBB(diagonalAttackers) {
+	diagonalAttackers := b.baseBoard.occupiedColor[b.turn.Swap()] & (b.baseBoard.bishops | b.baseBoard.queens)
+	if BishopAttacks(kingSquare, occupancy).IsMaskingBB(diagonalAttackers) {
 		return true
 	}
 
 	return false
 }
 
-func (b *Board) generateEvasions(kingSquare Square, checkers, fromMask, toMask Bitboard) chan Move {
-	ch := make(chan Move)
-
-	go func(b Board) {
-		defer close(ch)
+func (b *Board) generateEvasions(moves []Move, kingSquare Square, checkers, fromMask, toMask Bitboard) []Move {
+	sliders := checkers & (b.baseBoard.bishops | b.baseBoard.rooks | b.baseBoard.queens)
 
-		sliders := checkers & (b.baseBoard.bishops | b.baseBoard.rooks | b.baseBoard.queens)
+	attacked := BBVoid
+	for sliders != BBVoid {
+		checker := sliders.PopMsb()
+		attacked |= bbRays[kingSquare][checker] & ^NewBitboardFromSquare(checker)
+	}
 
-		attacked := BBVoid
-		for checker := range sliders.ScanReversed() {
-			attacked |= bbRays[kingSquare][checker] & ^NewBitboardFromSquare(Square(checker))
+	if NewBitboardFromSquare(kingSquare).IsMaskingBB(fromMask) {
+		targets := kingAttacks[kingSquare] & ^b.baseBoard.occupiedColor[b.turn] & ^attacked & toMask
+		for targets != BBVoid {
+			moves = append(moves, Move{kingSquare, targets.PopMsb(), NoPiece, NoPiece})
 		}
+	}
 
-		if NewBitboardFromSquare(kingSquare).IsMaskingBB(fromMask) {
-			for toSquare := range (kingAttacks[kingSquare] & ^b.baseBoard.occupiedColor[b.turn] & ^attacked & toMask).ScanReversed() {
-				m, _ := NewNormalMove(kingSquare, Square(toSquare))
-				ch <- *m
-			}
-		}
+	checker := Square(checkers.Msb())
+	if NewBitboardFromSquare(checker) == checkers {
+		// capture or block a single checker
+		target := bbBetween[kingSquare][checker] | checkers
 
-		checker := Square(checkers.Msb())
-		if NewBitboardFromSquare(checker) == checkers {
-			// capture or block a single checker
-			target := bbBetween[kingSquare][checker] | checkers
+		moves = b.generatePseudoLegalMoves(moves, ^b.baseBoard.kings&fromMask, target&toMask)
 
-			for move := range b.generatePseudoLegalMoves(^b.baseBoard.kings&fromMask, target&toMask) {
-				ch <- move
+		// Capture the checking pawn en passant (avoid duplicate)
+		if b.epSquare != SquareNone && !NewBitboardFromSquare(b.epSquare).IsMaskingBB(target) {
+			lastDouble := b.epSquare
+			if b.turn == White {
+				lastDouble -= 8
+			} else {
+				lastDouble += 8
 			}
 
-			// Capture the checking pawn en passant (avoid duplicate)
-			if b.epSquare != SquareNone && !NewBitboardFromSquare(b.epSquare).IsMaskingBB(target) {
-				lastDouble := b.epSquare
-				if b.turn == White {
-					lastDouble -= 8
-				} else {
-					lastDouble += 8
-				}
-
-				if lastDouble == checker {
-					for move := range b.generatePseudoLegalEp(fromMask, toMask) {
-						ch <- move
-					}
-				}
+			if lastDouble == checker {
+				moves = b.generatePseudoLegalEp(moves, fromMask, toMask)
 			}
 		}
-	}(NewBoardFromBoard(b))
+	}
 
-	return ch
+	return moves
 }
 
-func (b *Board) GenerateLegalMoves(fromMask, toMask Bitboard) chan Move {
-	ch := make(chan Move)
+func (b *Board) generateLegalMoves(moves []Move, fromMask, toMask Bitboard) []Move {
+	return b.rules.generateLegalMoves(b, moves, fromMask, toMask)
+}
 
-	go func(b Board) {
-		defer close(ch)
+func (standardRules) generateLegalMoves(b *Board, moves []Move, fromMask, toMask Bitboard) []Move {
+	if b.IsVariantEnd() {
+		return moves
+	}
 
-		if b.IsVariantEnd() {
-			return
-		}
+	kingMask := b.baseBoard.kings & b.baseBoard.occupiedColor[b.turn]
+	if kingMask == BBVoid {
+		return b.generatePseudoLegalMoves(moves, fromMask, toMask)
+	}
 
-		kingMask := b.baseBoard.kings & b.baseBoard.occupiedColor[b.turn]
-		if kingMask != BBVoid {
-			king := Square(kingMask.Msb())
-			blockers := b.sliderBlockers(king)
-			checkers := b.baseBoard.AttackersMask(b.turn.Swap(), king)
+	king := Square(kingMask.Msb())
+	blockers := b.sliderBlockers(king)
+	checkers := b.baseBoard.AttackersMask(b.turn.Swap(), king)
 
-			if checkers != BBVoid {
-				for move := range b.generateEvasions(king, checkers, fromMask, toMask) {
-					if b.isSafe(king, blockers, &move) {
-						ch <- move
-					}
-				}
-			} else {
-				for move := range b.generatePseudoLegalMoves(fromMask, toMask) {
-					if b.isSafe(king, blockers, &move) {
-						ch <- move
-					}
-				}
-			}
-		} else {
-			for move := range b.generatePseudoLegalMoves(fromMask, toMask) {
-				ch <- move
-			}
+	start := len(moves)
+	if checkers != BBVoid {
+		moves = b.generateEvasions(moves, king, checkers, fromMask, toMask)
+	} else {
+		moves = b.generatePseudoLegalMoves(moves, fromMask, toMask)
+	}
+
+	// Keep the safe moves in place
+	n := start
+	for i := start; i < len(moves); i++ {
+		if b.isSafe(king, blockers, &moves[i]) {
+			moves[n] = moves[i]
+			n++
 		}
-	}(NewBoardFromBoard(b))
+	}
 
-	return ch
+	return moves[:n]
 }
 
-func (b *Board) generateLegalEp(fromMask, toMask Bitboard) chan Move {
-	ch := make(chan Move)
+// LegalMoves appends the legal moves to buf and returns the extended slice.
+// Reusing a buffer, like moves = b.LegalMoves(moves[:0]), avoids
+// allocations.
+func (b *Board) LegalMoves(buf []Move) []Move {
+	return b.generateLegalMoves(buf, BBAll, BBAll)
+}
 
-	go func(b Board) {
-		defer close(ch)
+// MaskedLegalMoves appends the legal moves from squares of fromMask to
+// squares of toMask.
+func (b *Board) MaskedLegalMoves(buf []Move, fromMask, toMask Bitboard) []Move {
+	return b.generateLegalMoves(buf, fromMask, toMask)
+}
 
-		if b.IsVariantEnd() {
-			return
-		}
+func (b *Board) GenerateLegalMoves(fromMask, toMask Bitboard) chan Move {
+	return moveChan(b.generateLegalMoves(nil, fromMask, toMask))
+}
 
-		for move := range b.generatePseudoLegalEp(fromMask, toMask) {
-			if !b.IsIntoCheck(&move) {
-				ch <- move
-			}
+func (b *Board) hasLegalMoves() bool {
+	var buf [maxMoves]Move
+	return len(b.generateLegalMoves(buf[:0], BBAll, BBAll)) > 0
+}
+
+func containsMove(moves []Move, m *Move) bool {
+	for _, move := range moves {
+		if move == *m {
+			return true
 		}
-	}(NewBoardFromBoard(b))
+	}
 
-	return ch
+	return false
 }
 
-func (b *Board) generateLegalCaptures(fromMask, toMask Bitboard) chan Move {
-	ch := make(chan Move)
+func (b *Board) generateLegalEp(moves []Move, fromMask, toMask Bitboard) []Move {
+	if b.IsVariantEnd() {
+		return moves
+	}
+
+	start := len(moves)
+	moves = b.generatePseudoLegalEp(moves, fromMask, toMask)
 
-	go func() {
-		defer close(ch)
-		for move := range b.GenerateLegalMoves(fromMask, toMask&b.baseBoard.occupiedColor[b.turn.Swap()]) {
-			ch <- move
-		}
-		for move := range b.generateLegalEp(fromMask, toMask) {
-			ch <- move
+	n := start
+	for i := start; i < len(moves); i++ {
+		if !b.IsIntoCheck(&moves[i]) {
+			moves[n] = moves[i]
+			n++
 		}
-	}()
+	}
 
-	return ch
+	return moves[:n]
+}
+
+func (b *Board) generateLegalCaptures(moves []Move, fromMask, toMask Bitboard) []Move {
+	moves = b.generateLegalMoves(moves, fromMask, toMask&b.baseBoard.occupiedColor[b.turn.Swap()])
+	return b.generateLegalEp(moves, fromMask, toMask)
 }
 
 func (b *Board) attackedForKing(path, occupied Bitboard) bool {
-	for sq := range path.ScanReversed() {
-		if b.baseBoard.attackersMask(b.turn.Swap(), Square(sq), occupied) != BBVoid {
+	return b.rules.attackedForKing(b, path, occupied)
+}
+
+func (standardRules) attackedForKing(b *Board, path, occupied Bitboard) bool {
+	for path != BBVoid {
+		if b.baseBoard.attackersMask(b.turn.Swap(), path.PopMsb(), occupied) != BBVoid {
 			return true
 		}
 	}
@@ -2745,74 +2865,67 @@ func (b *Board) castlingUncoversRankAttack(rookMask Bitboard, kingTo Square) Bit
 		return BBVoid
 	}
 
-	rankPieces := rankMasks[kingTo] & (b.baseBoard.occupied ^ rookMask)
 	sliders := (b.baseBoard.queens | b.baseBoard.rooks) & b.baseBoard.occupiedColor[b.turn.Swap()]
-	return rankAttacks[kingTo][rankPieces] & sliders
+	return rankSliderAttacks(kingTo, b.baseBoard.occupied^rookMask) & sliders
 }
 
-func (b *Board) generateCastlingMoves(fromMask, toMask Bitboard) chan Move {
-	ch := make(chan Move)
-
-	go func(b Board) {
-		defer close(ch)
-
-		if b.IsVariantEnd() {
-			return
-		}
+func (b *Board) generateCastlingMoves(moves []Move, fromMask, toMask Bitboard) []Move {
+	if b.IsVariantEnd() {
+		return moves
+	}
 
-		backRank := BBRank8
-		if b.turn == White {
-			backRank = BBRank1
-		}
+	backRank := BBRank8
+	if b.turn == White {
+		backRank = BBRank1
+	}
 
-		kingMask := b.baseBoard.occupiedColor[b.turn] & b.baseBoard.kings & ^b.baseBoard.promoted & backRank & fromMask
-		kingMask = kingMask & -kingMask
-		if kingMask == BBVoid || b.attackedForKing(kingMask, b.baseBoard.occupied) {
-			return
-		}
+	kingMask := b.baseBoard.occupiedColor[b.turn] & b.baseBoard.kings & ^b.baseBoard.promoted & backRank & fromMask
+	kingMask = kingMask & -kingMask
+	if kingMask == BBVoid || b.attackedForKing(kingMask, b.baseBoard.occupied) {
+		return moves
+	}
 
-		bbC := BBFileC & backRank
-		bbD := BBFileD & backRank
-		bbF := BBFileF & backRank
-		bbG := BBFileG & backRank
+	bbC := BBFileC & backRank
+	bbD := BBFileD & backRank
+	bbF := BBFileF & backRank
+	bbG := BBFileG & backRank
 
-		for candidate := range (b.CleanCastlingRights() & backRank & toMask).ScanReversed() {
-			rookMask := NewBitboardFromSquare(Square(candidate))
-			aSide := rookMask < kingMask
+	for candidates := b.CleanCastlingRights() & backRank & toMask; candidates != BBVoid; {
+		candidate := candidates.PopMsb()
+		rookMask := NewBitboardFromSquare(candidate)
+		aSide := rookMask < kingMask
 
-			emptyForRook := BBVoid
-			emptyForKing := BBVoid
+		emptyForRook := BBVoid
+		emptyForKing := BBVoid
 
-			kingTo := SquareNone
+		kingTo := SquareNone
 
-			if aSide {
-				kingTo := Square(bbC.Msb())
-				if !rookMask.IsMaskingBB(bbD) {
-					emptyForRook = bbBetween[candidate][bbD.Msb()] | bbD
-				}
-				if !kingMask.IsMaskingBB(bbC) {
-					emptyForKing = bbBetween[kingMask.Msb()][kingTo] | bbC
-				}
-			} else {
-				kingTo := Square(bbG.Msb())
-				if !rookMask.IsMaskingBB(bbF) {
-					emptyForRook = bbBetween[candidate][bbF.Msb()] | bbF
-				}
-				if !kingMask.IsMaskingBB(bbG) {
-					emptyForKing = bbBetween[kingMask.Msb()][kingTo] | bbG
-				}
+		if aSide {
+			kingTo = Square(bbC.Msb())
+			if !rookMask.IsMaskingBB(bbD) {
+				emptyForRook = bbBetween[candidate][bbD.Msb()] | bbD
 			}
-
-			if !((((b.baseBoard.occupied ^ kingMask ^ rookMask) & (emptyForKing | emptyForRook)) != BBVoid) ||
-				b.attackedForKing(emptyForKing, (b.baseBoard.occupied^kingMask)) ||
-				b.castlingUncoversRankAttack(rookMask, kingTo) != BBVoid) {
-				m := b.fromChess960(b.chess960, Square(kingMask.Msb()), Square(candidate), NoPiece, NoPiece)
-				ch <- *m
+			if !kingMask.IsMaskingBB(bbC) {
+				emptyForKing = bbBetween[kingMask.Msb()][kingTo] | bbC
+			}
+		} else {
+			kingTo = Square(bbG.Msb())
+			if !rookMask.IsMaskingBB(bbF) {
+				emptyForRook = bbBetween[candidate][bbF.Msb()] | bbF
+			}
+			if !kingMask.IsMaskingBB(bbG) {
+				emptyForKing = bbBetween[kingMask.Msb()][kingTo] | bbG
 			}
 		}
-	}(NewBoardFromBoard(b))
 
-	return ch
+		if !((((b.baseBoard.occupied ^ kingMask ^ rookMask) & (emptyForKing | emptyForRook)) != BBVoid) ||
+			b.attackedForKing(emptyForKing, (b.baseBoard.occupied^kingMask)) ||
+			b.castlingUncoversRankAttack(rookMask, kingTo) != BBVoid) {
+			moves = append(moves, b.fromChess960Move(b.chess960, Square(kingMask.Msb()), candidate, NoPiece, NoPiece))
+		}
+	}
+
+	return moves
 }
 
 func (b *Board) IsEnPassant(move *Move) bool {
@@ -2832,14 +2945,21 @@ func (b *Board) isZeroing(move *Move) bool {
 }
 
 func (b *Board) isIrreversible(move *Move) bool {
+	return b.rules.isIrreversible(b, move)
+}
+
+func (standardRules) isIrreversible(b *Board, move *Move) bool {
+	return b.isZeroing(move) || b.reducesCastlingRights(move)
+}
+
+func (b *Board) reducesCastlingRights(move *Move) bool {
 	backrank := BBRank1
 	if b.turn == Black {
 		backrank = BBRank8
 	}
 	cr := b.CleanCastlingRights() & backrank
 
-	return b.isZeroing(move) ||
-		(cr != BBVoid && (NewBitboardFromSquare(move.FromSquare)&b.baseBoard.kings & ^b.baseBoard.promoted) != BBVoid) ||
+	return (cr != BBVoid && (NewBitboardFromSquare(move.FromSquare)&b.baseBoard.kings & ^b.baseBoard.promoted) != BBVoid) ||
 		cr.IsMaskingBB(NewBitboardFromSquare(move.FromSquare)) ||
 		cr.IsMaskingBB(NewBitboardFromSquare(move.ToSquare))
 }
@@ -2862,28 +2982,29 @@ func (b *Board) IsQueensideCastling(move *Move) bool {
 }
 
 func (b *Board) fromChess960(chess960 bool, fromSquare, toSquare Square, promotion, drop PieceType) *Move {
+	m := b.fromChess960Move(chess960, fromSquare, toSquare, promotion, drop)
+	return &m
+}
+
+// fromChess960Move is fromChess960 without allocating, for move generation.
+func (b *Board) fromChess960Move(chess960 bool, fromSquare, toSquare Square, promotion, drop PieceType) Move {
 	if !chess960 && drop == NoPiece {
 		if fromSquare == E1 && b.baseBoard.kings.IsMaskingBB(BBE1) {
 			if toSquare == H1 {
-				m, _ := NewNormalMove(E1, G1)
-				return m
+				return Move{E1, G1, NoPiece, NoPiece}
 			} else if toSquare == A1 {
-				m, _ := NewNormalMove(E1, C1)
-				return m
+				return Move{E1, C1, NoPiece, NoPiece}
 			}
 		} else if fromSquare == E8 && b.baseBoard.kings.IsMaskingBB(BBE8) {
 			if toSquare == H8 {
-				m, _ := NewNormalMove(E8, G8)
-				return m
+				return Move{E8, G8, NoPiece, NoPiece}
 			} else if toSquare == A8 {
-				m, _ := NewNormalMove(E8, C8)
-				return m
+				return Move{E8, C8, NoPiece, NoPiece}
 			}
 		}
 	}
 
-	m, _ := NewMove(fromSquare, toSquare, promotion, drop)
-	return m
+	return Move{fromSquare, toSquare, promotion, drop}
 }
 
 func (b *Board) toChess960(m *Move) *Move {
@@ -2926,19 +3047,13 @@ func (b *Board) transpositionKey() string {
 }
 
 func (b *Board) LegalMovesCount() int {
-	count := 0
-	for range b.GenerateLegalMoves(BBAll, BBAll) {
-		count++
-	}
-	return count
+	var buf [maxMoves]Move
+	return len(b.LegalMoves(buf[:0]))
 }
 
 func (b *Board) PseudoLegalMovesCount() int {
-	count := 0
-	for range b.GeneratePseudoLegalMoves() {
-		count++
-	}
-	return count
+	var buf [maxMoves]Move
+	return len(b.PseudoLegalMoves(buf[:0]))
 }
 
 func (b Board) String() string {
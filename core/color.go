@@ -7,14 +7,27 @@ const (
 	White Color = 1
 )
 
+// MakeColor returns Black for 0 and White for any other value, like the
+// boolean colors of python-chess.
 func MakeColor(i uint8) Color {
 	if i == 0 {
 		return Black
 	}
-	if i == 1 {
-		return White
+
+	return White
+}
+
+// ParseColor parses a color name, "w" or "white" and "b" or "black".
+// Errors are *ValueError.
+func ParseColor(name string) (Color, error) {
+	switch name {
+	case "w", "white":
+		return White, nil
+	case "b", "black":
+		return Black, nil
 	}
-	panic("Cannot make color for given value")
+
+	return Black, &ValueError{description: "invalid color name: " + name, Value: name}
 }
 
 func (c Color) Name() string {
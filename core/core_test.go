@@ -1,6 +1,8 @@
 package core
 
 import (
+	"math/rand"
+	"runtime"
 	"testing"
 )
 
@@ -424,6 +426,22 @@ func TestBoard(t *testing.T) {
 		}
 	})
 
+	t.Run("Black enpassant", func(t *testing.T) {
+		b := NewBoardFromFEN("4k3/8/8/8/3p4/8/4P3/4K3 w - - 0 1", false)
+		b.PushSan("e4")
+		b.PushSan("dxe3")
+
+		if b.FEN(false, "fen", NoPiece) != "4k3/8/8/8/8/4p3/8/4K3 w - - 0 2" {
+			t.Errorf("FEN not matching, actual: %v", b.FEN(false, "fen", NoPiece))
+		}
+
+		b = NewBoardFromFEN("4k3/3p4/8/4P3/8/8/8/4K3 b - - 0 1", false)
+		b.PushSan("d5")
+		if b.FEN(false, "fen", NoPiece) != "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2" {
+			t.Errorf("FEN not matching, actual: %v", b.FEN(false, "fen", NoPiece))
+		}
+	})
+
 	t.Run("Get Set", func(t *testing.T) {
 		b := NewDefaultBoard()
 
@@ -623,7 +641,7 @@ func TestBoard(t *testing.T) {
 		}
 
 		// Black can not castle queenside or kingside
-		if len(b.generateCastlingMoves(BBAll, BBAll)) != 0 {
+		if len(b.generateCastlingMoves(nil, BBAll, BBAll)) != 0 {
 			t.Error("960 black should not have any castling moves")
 		}
 
@@ -638,12 +656,12 @@ func TestBoard(t *testing.T) {
 		b := NewBoardFromFEN("r3k2r/pppppppp/8/8/8/8/PPPPPPPP/R3K2R w KQkq - 0 1", false)
 
 		// King not selected
-		if lenMoveChan(b.generateCastlingMoves(BBAll & ^b.baseBoard.kings, BBAll)) != 0 {
+		if len(b.generateCastlingMoves(nil, BBAll & ^b.baseBoard.kings, BBAll)) != 0 {
 			t.Error("King not selected failed")
 		}
 
 		// Rook on h1 not selected
-		if lenMoveChan(b.generateCastlingMoves(BBAll, BBAll & ^BBH1)) != 1 {
+		if len(b.generateCastlingMoves(nil, BBAll, BBAll & ^BBH1)) != 1 {
 			t.Error("Rook on h1 selected failed")
 		}
 	})
@@ -1081,7 +1099,86 @@ func TestBoard(t *testing.T) {
 
 	// TODO: Polyglot not yet ready (or may be this test shouldn't live here)
 	t.Run("polyglot", func(t *testing.T) {
-		t.Skip("Polyglot not yet implemented")
+		b := NewDefaultBoard()
+		if b.ZobristHash() != 0x463b96181691fc9c {
+			t.Errorf("starting position hash not matching: %x", b.ZobristHash())
+		}
+
+		for _, uci := range []string{"e2e4", "d7d5", "e4e5", "f7f5"} {
+			b.PushUci(uci)
+		}
+		if b.ZobristHash() != 0x22a48b5a8e47ff78 {
+			t.Errorf("en passant hash not matching: %x", b.ZobristHash())
+		}
+
+		b = NewBoardFromFEN("rnbqkbnr/p1pppppp/8/8/PpP4P/8/1P1PPPP1/RNBQKBNR b KQkq c3 0 3", false)
+		if b.ZobristHash() != 0x3c8123ea7b067637 {
+			t.Errorf("hash from FEN not matching: %x", b.ZobristHash())
+		}
+	})
+
+	t.Run("incremental zobrist hash", func(t *testing.T) {
+		b := NewBoardFromFEN("r3k2r/8/8/3pP3/8/8/6p1/R3K2R w KQkq d6 0 1", false)
+		hashes := []uint64{b.ZobristHash()}
+
+		for _, san := range []string{"exd6", "O-O-O", "O-O-O", "gxh1=Q", "Rxh1", "Rxd6", "Rxh8+", "Kd7"} {
+			if _, err := b.PushSan(san); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if b.ZobristHash() != b.computeZobristHash() {
+				t.Errorf("hash after %s not matching", san)
+			}
+			hashes = append(hashes, b.ZobristHash())
+		}
+
+		for i := len(hashes) - 2; i >= 0; i-- {
+			b.Pop()
+			if b.ZobristHash() != hashes[i] {
+				t.Errorf("hash not restored at ply %d", i)
+			}
+		}
+
+		b.PushSan("--")
+		if b.ZobristHash() != b.computeZobristHash() {
+			t.Errorf("hash after null move not matching")
+		}
+	})
+
+	t.Run("repetition", func(t *testing.T) {
+		b := NewDefaultBoard()
+		shuffle := []string{"Nf3", "Nf6", "Ng1", "Ng8"}
+
+		for i := 0; i < 2; i++ {
+			for _, san := range shuffle {
+				b.PushSan(san)
+			}
+		}
+		if !b.CanClaimThreefoldRepetition() || b.IsFiveFoldRepetition() {
+			t.Errorf("expected threefold repetition only")
+		}
+
+		for i := 0; i < 2; i++ {
+			for _, san := range shuffle {
+				b.PushSan(san)
+			}
+		}
+		if !b.IsFiveFoldRepetition() {
+			t.Errorf("expected fivefold repetition")
+		}
+
+		b = NewDefaultBoard()
+		for _, san := range []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1"} {
+			b.PushSan(san)
+		}
+		if !b.CanClaimThreefoldRepetition() {
+			t.Errorf("expected next move to claim threefold repetition")
+		}
+
+		b.PushSan("Nc6")
+		if b.CanClaimThreefoldRepetition() {
+			t.Errorf("expected no threefold repetition")
+		}
 	})
 
 	t.Run("castling move generation", func(t *testing.T) {
@@ -1275,3 +1372,820 @@ func TestBoard(t *testing.T) {
 		}
 	})
 }
+
+func TestParseFEN(t *testing.T) {
+	b, err := ParseFEN(StartingFEN)
+	if err != nil || b.FEN(false, "legal", NoPiece) != StartingFEN {
+		t.Fatalf("expected the starting position, got %v", err)
+	}
+
+	invalid := []struct {
+		fen      string
+		field    FENField
+		position int
+	}{
+		{"", FENFieldFEN, 0},
+		{"8/8/8/8/8/8/8/8 w - - 0", FENFieldFEN, 23},
+		{"8/8/8/8/8/8/8/8 w - - 0 1 x", FENFieldFEN, 26},
+		{"8/8/8/8/8/8/8 w - - 0 1", FENFieldBoard, 13},
+		{"8/8/8/8/8/8/8/8/8 w - - 0 1", FENFieldBoard, 15},
+		{"rnbqkbnr/ppppXppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", FENFieldBoard, 13},
+		{"rnbqkbnr/pppppppp/44/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", FENFieldBoard, 19},
+		{"rnbqkbnr/ppppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", FENFieldBoard, 17},
+		{"rnbqkbnr/ppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", FENFieldBoard, 16},
+		{"~nbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", FENFieldBoard, 0},
+		{"8/8/8/8/8/8/8/8 white - - 0 1", FENFieldTurn, 16},
+		{"8/8/8/8/8/8/8/8 w KQkqK - 0 1", FENFieldCastling, 22},
+		{"8/8/8/8/8/8/8/8 w kK - 0 1", FENFieldCastling, 19},
+		{"8/8/8/8/8/8/8/8 w - e9 0 1", FENFieldEnPassant, 20},
+		{"8/8/8/8/8/8/8/8 w - - -1 1", FENFieldHalfMoveClock, 22},
+		{"8/8/8/8/8/8/8/8 w - - 0 1x", FENFieldFullMoveNumber, 25},
+	}
+
+	empty := NewDefaultBoard()
+	if err := empty.SetFEN(""); err == nil || err.Error() != "invalid fen at 0: empty fen" {
+		t.Errorf("error message not matching: %v", err)
+	}
+
+	for _, tc := range invalid {
+		b := NewDefaultBoard()
+		err := b.SetFEN(tc.fen)
+
+		e, ok := err.(*FENError)
+		if !ok || e.Field != tc.field || e.Position != tc.position || e.FEN != tc.fen {
+			t.Errorf("%q: expected %s error at %d, got %v", tc.fen, tc.field, tc.position, err)
+		}
+
+		if b.FEN(false, "legal", NoPiece) != StartingFEN {
+			t.Errorf("%q: expected board to be unchanged", tc.fen)
+		}
+
+		if _, err := ParseFEN(tc.fen); err == nil {
+			t.Errorf("%q: expected an error", tc.fen)
+		}
+
+		if b := NewBoardFromFEN(tc.fen, false); b.FEN(false, "legal", NoPiece) != "8/8/8/8/8/8/8/8 w - - 0 1" {
+			t.Errorf("%q: expected an empty board", tc.fen)
+		}
+	}
+
+	t.Run("board fen", func(t *testing.T) {
+		if b, err := ParseBoardFEN("4k3/8/8/8/8/8/8/4K2R"); err != nil || b.PieceAt(H1) == nil {
+			t.Errorf("expected the position, got %v", err)
+		}
+
+		if _, err := ParseBoardFEN("4k3/8/8/8/8/8/8/4K2R w"); err == nil {
+			t.Errorf("expected an error for a full fen")
+		}
+
+		b := NewDefaultBoard()
+		if err := b.SetBoardFEN("4k3/8/8/8/8/8/8/4K2"); err == nil || b.FEN(false, "legal", NoPiece) != StartingFEN {
+			t.Errorf("expected an error and board to be unchanged")
+		}
+	})
+
+	t.Run("constructors", func(t *testing.T) {
+		if s, err := ParseSquare("e4"); err != nil || s != E4 {
+			t.Errorf("expected e4, got %v", err)
+		}
+
+		for _, name := range []string{"", "e", "e44", "i1", "a9"} {
+			if _, err := ParseSquare(name); err == nil || NewSquareFromName(name) != SquareNone {
+				t.Errorf("%q: expected an invalid square", name)
+			}
+		}
+
+		if p, err := ParsePiece("N"); err != nil || p != NewPiece(Knight, White) {
+			t.Errorf("expected a white knight, got %v", err)
+		}
+
+		if _, err := ParsePiece("x"); err == nil || NewPieceFromSymbol("").Type != NoPiece {
+			t.Errorf("expected an invalid piece")
+		}
+
+		if pt, err := ParsePieceType("Q"); err != nil || pt != Queen || NewPieceType(7) != NoPiece {
+			t.Errorf("piece types not matching")
+		}
+
+		// Invalid pieces have no symbol or name instead of panicking
+		if p := NewPieceFromSymbol("x"); p.Symbol() != "" || p.Type.Name() != "" {
+			t.Errorf("expected no symbol and name of an invalid piece")
+		}
+
+		if b, err := NewChess960Board(518); err != nil || b.FEN(false, "legal", NoPiece) != StartingFEN || !b.IsChess960() {
+			t.Errorf("expected the standard starting position, got %v", err)
+		}
+
+		positions := map[string]bool{}
+		for n := 0; n < 960; n++ {
+			b, err := NewChess960Board(n)
+			if err != nil || !b.IsValid() {
+				t.Fatalf("%d: expected a valid position, got %v", n, err)
+			}
+			positions[b.FEN(false, "legal", NoPiece)] = true
+		}
+
+		if len(positions) != 960 {
+			t.Errorf("expected 960 distinct positions, got %d", len(positions))
+		}
+
+		for n, fen := range map[int]string{0: "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR", 959: "rkrnnqbb/pppppppp/8/8/8/8/PPPPPPPP/RKRNNQBB"} {
+			if b, _ := NewChess960BaseBoard(n); b.FEN(false) != fen {
+				t.Errorf("%d: expected %s, got %s", n, fen, b.FEN(false))
+			}
+		}
+
+		for _, n := range []int{-1, 960} {
+			if _, err := NewChess960Board(n); err == nil {
+				t.Errorf("%d: expected an invalid chess960 position", n)
+			}
+			if _, err := NewChess960BaseBoard(n); err == nil {
+				t.Errorf("%d: expected an invalid chess960 position", n)
+			}
+		}
+
+		if c, err := ParseColor("b"); err != nil || c != Black || MakeColor(2) != White {
+			t.Errorf("colors not matching")
+		}
+
+		if _, err := ParseColor("red"); err == nil {
+			t.Errorf("expected an invalid color")
+		}
+
+		for _, uci := range []string{"e2e9", "x@e4", "e7e8x", "e2", "e2e4e5"} {
+			if _, err := NewMoveFromUci(uci); err == nil {
+				t.Errorf("%q: expected an invalid move", uci)
+			}
+		}
+	})
+}
+
+func TestLegalMoves(t *testing.T) {
+	fens := []string{
+		StartingFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		"4k3/8/8/2pP4/8/8/8/4K2R w K c6 0 1",
+		"4k3/8/8/8/8/8/4r3/R3K3 w Q - 0 1",
+	}
+
+	var buf [maxMoves]Move
+	for _, fen := range fens {
+		b := NewBoardFromFEN(fen, false)
+		moves := b.LegalMoves(buf[:0])
+
+		if len(moves) != lenMoveChan(b.GenerateLegalMoves(BBAll, BBAll)) || len(moves) != b.LegalMovesCount() {
+			t.Errorf("%s: legal moves count not matching", fen)
+		}
+
+		for i := range moves {
+			if !b.IsLegal(&moves[i]) {
+				t.Errorf("%s: generated illegal move %s", fen, moves[i].Uci())
+			}
+		}
+
+		pseudo := b.PseudoLegalMoves(nil)
+		if len(pseudo) != lenMoveChan(b.GeneratePseudoLegalMoves()) || len(pseudo) < len(moves) {
+			t.Errorf("%s: pseudo legal moves count not matching", fen)
+		}
+
+		if allocs := testing.AllocsPerRun(10, func() { b.LegalMoves(buf[:0]) }); allocs != 0 {
+			t.Errorf("%s: expected no allocations, got %v", fen, allocs)
+		}
+	}
+
+	t.Run("appends to buffer", func(t *testing.T) {
+		b := NewDefaultBoard()
+		null, _ := NewNullMove()
+		moves := b.LegalMoves([]Move{*null})
+		if len(moves) != 21 || moves[0] != *null {
+			t.Errorf("expected the moves to be appended")
+		}
+
+		if moves := b.MaskedLegalMoves(nil, BBRank2, BBRank4); len(moves) != 8 {
+			t.Errorf("expected the double pawn pushes, got %v", moves)
+		}
+	})
+
+	t.Run("no goroutines", func(t *testing.T) {
+		before := runtime.NumGoroutine()
+
+		b := NewDefaultBoard()
+		for i := 0; i < 100; i++ {
+			b.IsGameOver(false)
+			b.IsCheckmate()
+			for range b.GenerateLegalMoves(BBAll, BBAll) {
+				break
+			}
+			for range BBAll.ScanForward() {
+				break
+			}
+		}
+
+		if after := runtime.NumGoroutine(); after > before {
+			t.Errorf("expected no goroutines to be left, got %d more", after-before)
+		}
+	})
+}
+
+func TestPerft(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fen      string
+		chess960 bool
+		nodes    []uint64
+	}{
+		{"initial", StartingFEN, false, []uint64{20, 400, 8902, 197281}},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", false, []uint64{48, 2039, 97862}},
+		{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", false, []uint64{14, 191, 2812, 43238}},
+		{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", false, []uint64{6, 264, 9467}},
+		{"position 4 mirrored", "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1", false, []uint64{6, 264, 9467}},
+		{"position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", false, []uint64{44, 1486, 62379}},
+		{"position 6", "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", false, []uint64{46, 2079, 89890}},
+		{"en passant skewer", "8/8/1k6/8/3Pp3/8/5B2/4K3 b - d3 0 1", false, []uint64{8}},
+		{"chess960 1", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", true, []uint64{21, 528, 12189}},
+		{"chess960 2", "2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9", true, []uint64{21, 807, 18002}},
+		{"chess960 3", "b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w GE - 1 9", true, []uint64{20, 479, 10471}},
+		{"chess960 4", "qbbnnrkr/2pp2pp/p7/1p2pp2/8/P3PP2/1PPP1KPP/QBBNNR1R w hf - 0 9", true, []uint64{22, 593, 13440}},
+		{"chess960 5", "1nbbnrkr/p1p1ppp1/3p4/1p3P1p/3Pq2P/8/PPP1P1P1/QNBBNRKR w HFhf - 0 9", true, []uint64{28, 1120, 31058}},
+	}
+
+	for _, tc := range testCases {
+		b := NewBoardFromFEN(tc.fen, tc.chess960)
+		hash := b.ZobristHash()
+		for depth, nodes := range tc.nodes {
+			if perft := b.Perft(depth + 1); perft != nodes {
+				t.Errorf("%s: expected %d nodes at depth %d, got %d", tc.name, nodes, depth+1, perft)
+			}
+		}
+
+		if b.ZobristHash() != hash || len(b.MoveStack()) != 0 {
+			t.Errorf("%s: board not restored", tc.name)
+		}
+	}
+
+	t.Run("detailed", func(t *testing.T) {
+		detailed := []struct {
+			fen   string
+			depth int
+			stats PerftStats
+		}{
+			{StartingFEN, 4, PerftStats{197281, 1576, 0, 0, 0, 469, 8}},
+			{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 3, PerftStats{97862, 17102, 45, 3162, 0, 993, 1}},
+			{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 4, PerftStats{43238, 3348, 123, 0, 0, 1680, 17}},
+			{"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 3, PerftStats{9467, 1021, 4, 0, 120, 38, 22}},
+		}
+
+		for _, tc := range detailed {
+			b := NewBoardFromFEN(tc.fen, false)
+			if stats := b.DetailedPerft(tc.depth); stats != tc.stats {
+				t.Errorf("%s: expected %+v, got %+v", tc.fen, tc.stats, stats)
+			}
+		}
+	})
+
+	t.Run("divide", func(t *testing.T) {
+		b := NewBoardFromFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", false)
+		divisions := b.PerftDivide(2, true)
+		if len(divisions) != 48 {
+			t.Fatalf("expected a division per root move, got %d", len(divisions))
+		}
+
+		total := PerftStats{}
+		for _, d := range divisions {
+			total.Add(d.Stats)
+			if d.Move.Uci() == "e1g1" && d.Stats.Nodes != 43 {
+				t.Errorf("expected 43 nodes after e1g1, got %d", d.Stats.Nodes)
+			}
+		}
+
+		if total != b.DetailedPerft(2) {
+			t.Errorf("divisions do not add up: %+v", total)
+		}
+
+		if divisions := b.PerftDivide(1, true); divisions[0].Stats.Nodes != 1 || b.PerftDivide(0, false) == nil {
+			t.Errorf("expected leaf divisions")
+		}
+	})
+}
+
+func TestMagicAttacks(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for sq := Square(0); sq < 64; sq++ {
+		for i := 0; i < 200; i++ {
+			occupied := Bitboard(r.Uint64() & r.Uint64())
+
+			if RookAttacks(sq, occupied) != SlidingAttacks(sq, occupied, []int{-8, 8, -1, 1}) {
+				t.Fatalf("rook attacks from %v not matching for %x", sq, uint64(occupied))
+			}
+
+			if BishopAttacks(sq, occupied) != SlidingAttacks(sq, occupied, []int{-9, -7, 7, 9}) ||
+				DiagAttacks(sq)[DiagMasks(sq)&occupied] != BishopAttacks(sq, occupied) {
+				t.Fatalf("diagonal attacks from %v not matching for %x", sq, uint64(occupied))
+			}
+
+			if fileSliderAttacks(sq, occupied) != SlidingAttacks(sq, occupied, []int{-8, 8}) ||
+				rankSliderAttacks(sq, occupied) != SlidingAttacks(sq, occupied, []int{-1, 1}) ||
+				FileAttacks(sq)[FileMasks(sq)&occupied] != fileSliderAttacks(sq, occupied) ||
+				RankAttacks(sq)[RankMasks(sq)&occupied] != rankSliderAttacks(sq, occupied) {
+				t.Fatalf("line attacks from %v not matching for %x", sq, uint64(occupied))
+			}
+		}
+	}
+}
+
+// Occupancies for the attack benchmarks
+func benchmarkOccupancies() []Bitboard {
+	r := rand.New(rand.NewSource(1))
+	occupancies := make([]Bitboard, 1024)
+	for i := range occupancies {
+		occupancies[i] = Bitboard(r.Uint64() & r.Uint64())
+	}
+	return occupancies
+}
+
+func BenchmarkAttackTableLookup(b *testing.B) {
+	diagMasks, diagAttacks := AttackTable([]int{-9, -7, 7, 9})
+	fileMasks, fileAttacks := AttackTable([]int{-8, 8})
+	rankMasks, rankAttacks := AttackTable([]int{-1, 1})
+	occupancies := benchmarkOccupancies()
+
+	b.ResetTimer()
+	attacks := BBVoid
+	for i := 0; i < b.N; i++ {
+		sq := Square(i & 63)
+		occupied := occupancies[i&1023]
+		attacks ^= diagAttacks[sq][diagMasks[sq]&occupied] |
+			fileAttacks[sq][fileMasks[sq]&occupied] |
+			rankAttacks[sq][rankMasks[sq]&occupied]
+	}
+	_ = attacks
+}
+
+func BenchmarkMagicLookup(b *testing.B) {
+	occupancies := benchmarkOccupancies()
+
+	b.ResetTimer()
+	attacks := BBVoid
+	for i := 0; i < b.N; i++ {
+		attacks ^= QueenAttacks(Square(i&63), occupancies[i&1023])
+	}
+	_ = attacks
+}
+
+func BenchmarkAttackersMask(b *testing.B) {
+	board := NewBoardFromFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", false)
+
+	for i := 0; i < b.N; i++ {
+		board.baseBoard.AttackersMask(Black, Square(i&63))
+	}
+}
+
+func BenchmarkLegalMoves(b *testing.B) {
+	board := NewBoardFromFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", false)
+	moves := make([]Move, 0, maxMoves)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		moves = board.LegalMoves(moves[:0])
+	}
+}
+
+func BenchmarkPerft(b *testing.B) {
+	board := NewBoardFromFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", false)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		board.Perft(3)
+	}
+}
+
+func TestCrazyhouse(t *testing.T) {
+	b := NewCrazyhouseBoard(CrazyhouseStartingFEN, false)
+	if fen := b.FEN(false, "legal", NoPiece); fen != CrazyhouseStartingFEN {
+		t.Errorf("expected the starting position, got %s", fen)
+	}
+	if b.Variant() != "Crazyhouse" || b.UCIVariant() != "crazyhouse" {
+		t.Errorf("unexpected variant %s", b.Variant())
+	}
+
+	// Captured pieces go to the pocket of the capturer
+	hash := b.ZobristHash()
+	for _, san := range []string{"e4", "d5", "exd5", "Qxd5", "Nc3", "Qa5"} {
+		if _, err := b.PushSan(san); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fen := b.FEN(false, "legal", NoPiece); fen != "rnb1kbnr/ppp1pppp/8/q7/8/2N5/PPPP1PPP/R1BQKBNR[Pp] w KQkq - 2 4" {
+		t.Errorf("unexpected fen %s", fen)
+	}
+
+	m, err := b.ParseSan("@e7")
+	if err == nil || m != nil {
+		t.Error("expected drop on an occupied square to be illegal")
+	}
+	if m, err = b.ParseSan("P@e6"); err != nil || m.Drop != Pawn || m.ToSquare != E6 || b.San(m) != "@e6" {
+		t.Errorf("unexpected pawn drop %v: %v", m, err)
+	}
+	b.Push(m)
+	if b.Pocket(White).Len() != 0 || b.Pocket(Black).Count(Pawn) != 1 {
+		t.Errorf("expected pawn to leave the pocket, got %v", b.Pocket(White))
+	}
+	if b.ZobristHash() != b.computeZobristHash() {
+		t.Error("hash not updated incrementally")
+	}
+
+	for len(b.MoveStack()) > 0 {
+		b.Pop()
+	}
+	if b.FEN(false, "legal", NoPiece) != CrazyhouseStartingFEN || b.ZobristHash() != hash {
+		t.Errorf("expected pockets to be restored, got %s", b.FEN(false, "legal", NoPiece))
+	}
+
+	// Drops of every kind, pawns not on the back ranks
+	b = NewCrazyhouseBoard("2k5/8/8/8/8/8/8/4K3[QRBNPqrbnp] w - - 0 1", false)
+	if n := b.LegalMovesCount(); n != 301 {
+		t.Errorf("expected 301 legal moves, got %d", n)
+	}
+	if _, err := b.ParseSan("P@e8"); err == nil {
+		t.Error("expected pawn drop on the back rank to be illegal")
+	}
+	if m, err := b.PushSan("N@b6+"); err != nil || m.Uci() != "N@b6" {
+		t.Errorf("unexpected knight drop %v: %v", m, err)
+	}
+	if perft := b.Perft(1); perft != 4 {
+		t.Errorf("unexpected perft %d", perft)
+	}
+
+	// Only blocking drops in check
+	b = NewCrazyhouseBoard("k7/8/8/8/8/8/8/R3K3[np] b - - 0 1", false)
+	if n := b.LegalMovesCount(); n != 14 {
+		t.Errorf("expected 14 legal moves in check, got %d", n)
+	}
+	if m, _ := NewMoveFromUci("N@b2"); b.IsLegal(m) {
+		t.Error("expected drop not blocking the check to be illegal")
+	}
+	if b.IsInsufficientMaterial() {
+		t.Error("expected pockets to be sufficient material")
+	}
+
+	// Promoted pieces are demoted when captured and stay promoted when moving
+	b = NewCrazyhouseBoard("k7/8/8/8/8/8/8/rQ~2K3[] b - - 0 1", false)
+	if _, err := b.PushSan("Rxb1"); err != nil {
+		t.Fatal(err)
+	}
+	if fen := b.FEN(false, "legal", NoPiece); fen != "k7/8/8/8/8/8/8/1r2K3[p] w - - 0 2" {
+		t.Errorf("expected a pawn in the pocket, got %s", fen)
+	}
+	b.Pop()
+	if _, err := b.PushSan("Ka7"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.PushSan("Qxa1"); err != nil {
+		t.Fatal(err)
+	}
+	if fen := b.FEN(false, "legal", NoPiece); fen != "8/k7/8/8/8/8/8/Q~3K3[R] b - - 0 2" {
+		t.Errorf("expected the queen to stay promoted, got %s", fen)
+	}
+
+	// Pockets as a ninth row, invalid pockets
+	b = NewCrazyhouseBoard("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR/Qnn w KQkq - 0 1", false)
+	if b.Pocket(White).String() != "q" || b.Pocket(Black).String() != "nn" {
+		t.Errorf("unexpected pockets %s %s", b.Pocket(White), b.Pocket(Black))
+	}
+	if err := b.SetFEN("8/8/8/8/8/8/8/8[Qk] w - - 0 1"); err == nil || err.(*FENError).Position != 17 {
+		t.Errorf("expected king in pocket to be invalid, got %v", err)
+	}
+	if err := b.SetFEN("8/8/8/8/8/8/8/8Q] w - - 0 1"); err == nil {
+		t.Error("expected unopened pocket to be invalid")
+	}
+	if _, err := ParseFEN("8/8/8/8/8/8/8/8[] w - - 0 1"); err == nil {
+		t.Error("expected pockets to be invalid in standard chess")
+	}
+
+	// Captured material counts against the pieces on the board
+	b = NewCrazyhouseBoard("4k3/pppppppp/8/8/8/8/PPPPPPPP/4K3[PPPPPPPP] w - - 0 1", false)
+	if status := b.Status(); status&StatusTooManyWhitePawns != 0 || !b.IsValid() {
+		t.Errorf("unexpected status %d", status)
+	}
+
+	if b, err := NewVariantBoard("zh", "", false); err != nil || b.UCIVariant() != "crazyhouse" {
+		t.Errorf("expected crazyhouse board, got %v", err)
+	}
+	if _, err := NewVariantBoard("Fairy", "", false); err == nil {
+		t.Error("expected unknown variant")
+	}
+}
+
+func TestAtomic(t *testing.T) {
+	for _, tc := range []struct {
+		fen   string
+		nodes []uint64
+	}{
+		{StartingFEN, []uint64{20, 400, 8902}},
+		{"rn2kb1r/1pp1p2p/p2q1pp1/3P4/2P3b1/4PN2/PP3PPP/R2QKB1R b KQkq - 0 1", []uint64{40, 1238, 45237}},
+		{"rn1qkb1r/p5pp/2p5/3p4/N3P3/5P2/PPP4P/R1BQK3 w Qkq - 0 1", []uint64{28, 833, 23353}},
+	} {
+		b := NewAtomicBoard(tc.fen, false)
+		for depth, nodes := range tc.nodes {
+			if perft := b.Perft(depth + 1); perft != nodes {
+				t.Errorf("%s: expected %d nodes at depth %d, got %d", tc.fen, nodes, depth+1, perft)
+			}
+		}
+	}
+
+	// Pieces next to the capture explode, pawns survive
+	b := NewAtomicBoard("4k3/8/8/3p1n2/3Pn3/8/8/4K2Q w - - 0 1", false)
+	if _, err := b.PushSan("Qxe4"); err != nil {
+		t.Fatal(err)
+	}
+	if fen := b.FEN(false, "legal", NoPiece); fen != "4k3/8/8/3p4/3P4/8/8/4K3 b - - 0 1" {
+		t.Errorf("unexpected explosion %s", fen)
+	}
+	if b.IsInsufficientMaterial() || b.IsGameOver(false) {
+		t.Error("expected pawns to be sufficient material")
+	}
+
+	// Exploded rooks lose their castling rights
+	b = NewAtomicBoard("r3k3/8/8/8/8/8/8/R3K3 w Qq - 0 1", false)
+	if _, err := b.PushSan("Rxa8"); err != nil {
+		t.Fatal(err)
+	}
+	if fen := b.FEN(false, "legal", NoPiece); fen != "4k3/8/8/8/8/8/8/4K3 b - - 0 1" || !b.IsInsufficientMaterial() {
+		t.Errorf("unexpected castling rights %s", fen)
+	}
+
+	// Exploding the king wins
+	b = NewAtomicBoard("4k3/3p4/8/4N3/8/8/8/4K3 w - - 0 1", false)
+	m, _ := b.ParseSan("Nxd7")
+	if san := b.San(m); san != "Nxd7#" {
+		t.Errorf("expected explosion to be shown as mate, got %s", san)
+	}
+	b.Push(m)
+	if !b.IsVariantEnd() || !b.IsVariantLoss() || b.IsVariantWin() || !b.IsGameOver(false) || b.Result(false) != "1-0" {
+		t.Errorf("expected white to win, got %s", b.Result(false))
+	}
+	if b.LegalMovesCount() != 0 || b.IsStalemate() {
+		t.Error("expected no moves after the game ended")
+	}
+
+	// Kings can not capture
+	b = NewAtomicBoard("4k3/8/8/8/8/8/4p3/4K3 w - - 0 1", false)
+	if m, _ := NewMoveFromUci("e1e2"); b.IsLegal(m) || b.LegalMovesCount() != 2 {
+		t.Errorf("expected king capture to be illegal, got %v", b.LegalMoves(nil))
+	}
+
+	// Connected kings can not be checked
+	b = NewAtomicBoard("8/8/8/8/8/8/3k4/r3K3 w - - 0 1", false)
+	if b.IsCheck() {
+		t.Error("expected no check next to the enemy king")
+	}
+	if _, err := b.ParseSan("Kf1"); err == nil {
+		t.Error("expected king to stay out of check")
+	}
+	if _, err := b.ParseSan("Ke2"); err != nil {
+		t.Errorf("expected king to stay connected: %v", err)
+	}
+}
+
+func TestAntichess(t *testing.T) {
+	b := NewAntichessBoard(AntichessStartingFEN, false)
+	for depth, nodes := range []uint64{20, 400, 8067} {
+		if perft := b.Perft(depth + 1); perft != nodes {
+			t.Errorf("expected %d nodes at depth %d, got %d", nodes, depth+1, perft)
+		}
+	}
+
+	// Captures are compulsory
+	for _, san := range []string{"e3", "b5"} {
+		if _, err := b.PushSan(san); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if moves := b.LegalMoves(nil); len(moves) != 1 || b.San(&moves[0]) != "Bxb5" {
+		t.Errorf("expected the capture to be the only move, got %v", moves)
+	}
+	if _, err := b.ParseSan("a3"); err == nil {
+		t.Error("expected quiet move to be illegal")
+	}
+
+	// Kings are ordinary pieces
+	b = NewAntichessBoard("4k3/8/8/8/8/8/8/4R2K b - - 0 1", false)
+	if b.IsCheck() || b.IsCheckmate() || b.LegalMovesCount() != 5 {
+		t.Errorf("expected no check, got %v", b.LegalMoves(nil))
+	}
+	if !b.IsValid() {
+		t.Errorf("unexpected status %d", b.Status())
+	}
+
+	b = NewAntichessBoard("8/4P3/8/8/8/8/8/k7 w - - 0 1", false)
+	if m, err := b.PushSan("e8=K"); err != nil || b.LegalMovesCount() != 3 {
+		t.Errorf("expected promotion to king, got %v: %v", m, err)
+	}
+
+	// No castling
+	b = NewAntichessBoard("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", false)
+	if m, _ := NewMoveFromUci("e1g1"); b.IsLegal(m) || b.Status()&StatusBadCastlingRights == 0 {
+		t.Error("expected castling to be illegal")
+	}
+
+	// Losing all pieces or having no moves wins
+	b = NewAntichessBoard("8/8/8/8/8/8/1p6/B7 w - - 0 1", false)
+	if _, err := b.PushSan("Bxb2"); err != nil {
+		t.Fatal(err)
+	}
+	if !b.IsVariantEnd() || !b.IsVariantWin() || !b.IsGameOver(false) || b.Result(false) != "0-1" {
+		t.Errorf("expected black to win without pieces, got %s", b.Result(false))
+	}
+
+	b = NewAntichessBoard("8/8/8/8/8/p7/P7/8 w - - 0 1", false)
+	if !b.IsStalemate() || !b.IsVariantWin() || b.Result(false) != "1-0" {
+		t.Errorf("expected white to win without moves, got %s", b.Result(false))
+	}
+
+	// Bishops that can not capture each other
+	b = NewAntichessBoard("8/8/8/8/8/8/b7/B7 w - - 0 1", false)
+	if !b.IsInsufficientMaterial() || b.Result(false) != "1/2-1/2" {
+		t.Errorf("expected a draw, got %s", b.Result(false))
+	}
+}
+
+func TestKingOfTheHill(t *testing.T) {
+	b := NewKingOfTheHillBoard("8/8/8/8/8/3K4/8/7k w - - 0 1", false)
+	if b.IsInsufficientMaterial() || b.IsVariantEnd() {
+		t.Error("expected the king to be able to reach the center")
+	}
+
+	if _, err := b.PushSan("Kd4"); err != nil {
+		t.Fatal(err)
+	}
+	if !b.IsVariantEnd() || !b.IsVariantLoss() || !b.IsGameOver(false) || b.Result(false) != "1-0" {
+		t.Errorf("expected white to win in the center, got %s", b.Result(false))
+	}
+
+	if b.Variant() != "King of the Hill" || b.UCIVariant() != "kingofthehill" {
+		t.Errorf("unexpected variant %s", b.Variant())
+	}
+}
+
+func TestThreeCheck(t *testing.T) {
+	b := NewThreeCheckBoard(ThreeCheckStartingFEN, false)
+	for depth, nodes := range []uint64{20, 400, 8902} {
+		if perft := b.Perft(depth + 1); perft != nodes {
+			t.Errorf("expected %d nodes at depth %d, got %d", nodes, depth+1, perft)
+		}
+	}
+
+	for _, san := range []string{"e4", "f5"} {
+		if _, err := b.PushSan(san); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hash := b.ZobristHash()
+	if _, err := b.PushSan("Qh5+"); err != nil {
+		t.Fatal(err)
+	}
+	if b.Checks(White) != 1 || b.Checks(Black) != 0 || b.ZobristHash() != b.computeZobristHash() {
+		t.Errorf("expected a check by white, got %d and %d", b.Checks(White), b.Checks(Black))
+	}
+	if fen := b.FEN(false, "legal", NoPiece); fen != "rnbqkbnr/ppppp1pp/8/5p1Q/4P3/8/PPPP1PPP/RNB1KBNR b KQkq - 1 2 +1+0" {
+		t.Errorf("unexpected fen %s", fen)
+	}
+
+	b.Pop()
+	if b.Checks(White) != 0 || b.ZobristHash() != hash {
+		t.Error("expected pop to restore the checks")
+	}
+
+	// The checks given, or the checks remaining after the en passant square
+	for _, fen := range []string{"7k/8/8/8/8/8/8/K5R1 w - - 0 1 +2+0", "7k/8/8/8/8/8/8/K5R1 w - - 1+3 0 1"} {
+		b = NewThreeCheckBoard(fen, false)
+		if b.Checks(White) != 2 || b.Checks(Black) != 0 {
+			t.Errorf("%s: expected 2 checks by white, got %d and %d", fen, b.Checks(White), b.Checks(Black))
+		}
+	}
+
+	if _, err := b.PushSan("Rh1+"); err != nil {
+		t.Fatal(err)
+	}
+	if !b.IsVariantEnd() || !b.IsVariantLoss() || b.Result(false) != "1-0" {
+		t.Errorf("expected white to win by the third check, got %s", b.Result(false))
+	}
+	if fen := b.FEN(false, "legal", NoPiece); fen != "7k/8/8/8/8/8/8/K6R b - - 1 1 +3+0" {
+		t.Errorf("unexpected fen %s", fen)
+	}
+
+	for _, fen := range []string{"7k/8/8/8/8/8/8/K5R1 w - - 0 1 +4+0", "7k/8/8/8/8/8/8/K5R1 w - - 0 1 +2"} {
+		if err := b.SetFEN(fen); err == nil {
+			t.Errorf("%s: expected invalid checks", fen)
+		} else if e, ok := err.(*FENError); !ok || e.Field != FENFieldChecks {
+			t.Errorf("%s: unexpected error %v", fen, err)
+		}
+	}
+
+	b = NewThreeCheckBoard("8/8/8/3k4/8/3K4/8/8 w - - 0 1 +2+2", false)
+	if !b.IsInsufficientMaterial() {
+		t.Error("expected bare kings to be insufficient material")
+	}
+}
+
+func TestRacingKings(t *testing.T) {
+	b := NewRacingKingsBoard(RacingKingsStartingFEN, false)
+	for depth, nodes := range []uint64{21, 421, 11264} {
+		if perft := b.Perft(depth + 1); perft != nodes {
+			t.Errorf("expected %d nodes at depth %d, got %d", nodes, depth+1, perft)
+		}
+	}
+	if !b.IsValid() {
+		t.Errorf("unexpected status %d", b.Status())
+	}
+
+	// Giving check is not allowed
+	b = NewRacingKingsBoard("8/8/8/8/8/8/k7/6RK w - - 0 1", false)
+	if _, err := b.ParseSan("Rg2"); err == nil {
+		t.Error("expected check to be illegal")
+	}
+
+	// Black can still draw by reaching the eighth rank too
+	b = NewRacingKingsBoard("8/k5K1/8/8/8/8/8/8 w - - 0 1", false)
+	if _, err := b.PushSan("Kg8"); err != nil {
+		t.Fatal(err)
+	}
+	if b.IsVariantEnd() {
+		t.Error("expected black to be able to draw")
+	}
+	if _, err := b.PushSan("Ka8"); err != nil {
+		t.Fatal(err)
+	}
+	if !b.IsVariantDraw() || b.Result(false) != "1/2-1/2" {
+		t.Errorf("expected a draw, got %s", b.Result(false))
+	}
+
+	b = NewRacingKingsBoard("8/3K4/8/k7/8/8/8/8 w - - 0 1", false)
+	if _, err := b.PushSan("Kc8"); err != nil {
+		t.Fatal(err)
+	}
+	if !b.IsVariantEnd() || !b.IsVariantLoss() || b.Result(false) != "1-0" {
+		t.Errorf("expected white to win the race, got %s", b.Result(false))
+	}
+
+	for fen, status := range map[string]uint{
+		"8/8/8/8/8/8/k5R1/7K b - - 0 1":           StatusRaceCheck,
+		"K1k5/8/8/8/8/8/8/8 b - - 0 1":            StatusRaceOver,
+		"8/8/8/8/8/8/kP6/7K w - - 0 1":            StatusRaceMaterial,
+		"8/8/8/8/8/8/kQ6/Q6K w - - 0 1":           StatusRaceMaterial,
+		"8/8/8/8/8/8/krbnNBRK/qrbnNBRQ b - - 0 1": StatusValid,
+	} {
+		b := NewRacingKingsBoard(fen, false)
+		if s := b.Status(); s != status {
+			t.Errorf("%s: expected status %d, got %d", fen, status, s)
+		}
+	}
+}
+
+func TestHorde(t *testing.T) {
+	b := NewHordeBoard(HordeStartingFEN, false)
+	for depth, nodes := range []uint64{8, 128, 1274} {
+		if perft := b.Perft(depth + 1); perft != nodes {
+			t.Errorf("expected %d nodes at depth %d, got %d", nodes, depth+1, perft)
+		}
+	}
+	if !b.IsValid() {
+		t.Errorf("unexpected status %d", b.Status())
+	}
+
+	// Pawns on the first rank can move two squares
+	b = NewHordeBoard("4k3/8/8/8/8/8/8/P7 w - - 0 1", false)
+	if _, err := b.PushSan("a3"); err != nil {
+		t.Fatal(err)
+	}
+	if b.IsInsufficientMaterial() {
+		t.Error("expected black to be able to capture the horde")
+	}
+
+	// Losing all pieces loses
+	b = NewHordeBoard("4k3/8/8/8/8/8/1q6/P7 b - - 0 1", false)
+	if _, err := b.PushSan("Qxa1"); err != nil {
+		t.Fatal(err)
+	}
+	if !b.IsVariantEnd() || !b.IsVariantLoss() || !b.IsGameOver(false) || b.Result(false) != "0-1" {
+		t.Errorf("expected black to win, got %s", b.Result(false))
+	}
+
+	for fen, status := range map[string]uint{
+		"4k3/8/8/8/8/8/8/4K3 w - - 0 1":      StatusTooManyKings,
+		"8/8/8/8/8/8/8/P7 w - - 0 1":         StatusNoBlackKing,
+		"4k3/8/8/8/8/8/8/p7 w - - 0 1":       StatusPawnsOnBackRank,
+		"P3k3/8/8/8/8/8/8/8 b - - 0 1":       StatusPawnsOnBackRank,
+		"4k3/8/8/8/8/8/8/PPPPPPPP w - - 0 1": StatusValid,
+	} {
+		b := NewHordeBoard(fen, false)
+		if s := b.Status(); s != status {
+			t.Errorf("%s: expected status %d, got %d", fen, status, s)
+		}
+	}
+}
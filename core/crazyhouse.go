@@ -0,0 +1,206 @@
+package core
+
+import (
+	"strings"
+)
+
+// CrazyhouseStartingFEN is the starting position of Crazyhouse, with empty
+// pockets.
+const CrazyhouseStartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1"
+
+// Pocket counts the pieces in hand of a color in Crazyhouse, which can be
+// dropped on the board instead of making a move.
+type Pocket struct {
+	counts [King + 1]int
+}
+
+// Add puts a piece in the pocket.
+func (p *Pocket) Add(pt PieceType) {
+	p.counts[pt]++
+}
+
+// Remove takes a piece out of the pocket. Removing a piece that is not in
+// the pocket has no effect.
+func (p *Pocket) Remove(pt PieceType) {
+	if p.counts[pt] > 0 {
+		p.counts[pt]--
+	}
+}
+
+// Count returns the number of pieces of a type in the pocket.
+func (p Pocket) Count(pt PieceType) int {
+	return p.counts[pt]
+}
+
+// Len returns the number of pieces in the pocket.
+func (p Pocket) Len() int {
+	n := 0
+	for pt := Pawn; pt <= King; pt++ {
+		n += p.counts[pt]
+	}
+
+	return n
+}
+
+// String returns the symbols of the pieces in the pocket, strongest first,
+// like "qnp".
+func (p Pocket) String() string {
+	symbols := ""
+	for pt := King; pt >= Pawn; pt-- {
+		symbols += strings.Repeat(pt.Symbol(), p.counts[pt])
+	}
+
+	return symbols
+}
+
+// crazyhouseRules put captured pieces in the pocket of the capturer, who
+// can drop them on an empty square instead of moving. Promoted pieces are
+// demoted to pawns when captured.
+type crazyhouseRules struct {
+	standardRules
+}
+
+// NewCrazyhouseBoard creates a Crazyhouse board from a FEN, with the pieces
+// in hand in brackets after the position, like "...[Qn]". Invalid FENs
+// result in an empty board.
+func NewCrazyhouseBoard(fen string, chess960 bool) Board {
+	board := newStandardBoard(chess960)
+
+	board.rules = crazyhouseRules{}
+	board.aliases = []string{"Crazyhouse", "Crazy House", "House", "ZH"}
+	board.uciVariant = "crazyhouse"
+	board.startingFen = CrazyhouseStartingFEN
+	board.tbwSuffix = ""
+	board.tbzSuffix = ""
+	board.tbwMagic = [4]byte{}
+	board.tbzMagic = [4]byte{}
+	board.hasPockets = true
+
+	board.setUp(fen)
+	return board
+}
+
+// Pocket returns the pieces in hand of a color.
+func (b *Board) Pocket(c Color) Pocket {
+	return b.pockets[c]
+}
+
+// SetPocket sets the pieces in hand of a color and clears the move stack.
+func (b *Board) SetPocket(c Color, p Pocket) {
+	b.pockets[c] = p
+	b.clearStack()
+}
+
+// legalDropSquares returns the empty squares where a drop does not leave
+// the king in check, which are the squares between the king and a single
+// checking slider when in check.
+func (b *Board) legalDropSquares() Bitboard {
+	empty := ^b.baseBoard.occupied
+
+	king := b.baseBoard.King(b.turn)
+	if king == SquareNone {
+		return empty
+	}
+
+	checkers := b.baseBoard.AttackersMask(b.turn.Swap(), king)
+	switch checkers.PopCount() {
+	case 0:
+		return empty
+	case 1:
+		return bbBetween[king][checkers.Msb()] & empty
+	}
+
+	return BBVoid
+}
+
+func (b *Board) generateLegalDrops(moves []Move, toMask Bitboard) []Move {
+	squares := b.legalDropSquares() & toMask
+	if squares == BBVoid {
+		return moves
+	}
+
+	for pt := Queen; pt >= Pawn; pt-- {
+		if b.pockets[b.turn].Count(pt) == 0 {
+			continue
+		}
+
+		// Pawns can not be dropped on the back ranks
+		targets := squares
+		if pt == Pawn {
+			targets &= ^BBBackRanks
+		}
+
+		for targets != BBVoid {
+			s := targets.PopMsb()
+			moves = append(moves, Move{s, s, NoPiece, pt})
+		}
+	}
+
+	return moves
+}
+
+func (r crazyhouseRules) generateLegalMoves(b *Board, moves []Move, fromMask, toMask Bitboard) []Move {
+	moves = r.standardRules.generateLegalMoves(b, moves, fromMask, toMask)
+	return b.generateLegalDrops(moves, fromMask&toMask)
+}
+
+func (r crazyhouseRules) isPseudoLegal(b *Board, m *Move) bool {
+	if m.Drop == NoPiece {
+		return r.standardRules.isPseudoLegal(b, m)
+	}
+
+	toMask := NewBitboardFromSquare(m.ToSquare)
+	return m.FromSquare == m.ToSquare && m.Promotion == NoPiece && m.Drop != King &&
+		!b.baseBoard.occupied.IsMaskingBB(toMask) &&
+		!(m.Drop == Pawn && BBBackRanks.IsMaskingBB(toMask)) &&
+		b.pockets[b.turn].Count(m.Drop) > 0
+}
+
+func (r crazyhouseRules) isLegal(b *Board, m *Move) bool {
+	if m.Drop == NoPiece {
+		return r.standardRules.isLegal(b, m)
+	}
+
+	return b.IsPseudoLegal(m) && b.legalDropSquares().IsMaskingBB(NewBitboardFromSquare(m.ToSquare))
+}
+
+func (r crazyhouseRules) pushDrop(b *Board, m *Move) {
+	b.removeFromPocket(b.turn, m.Drop)
+	r.standardRules.pushDrop(b, m)
+}
+
+func (crazyhouseRules) pushCapture(b *Board, m *Move, captureSquare Square, pt PieceType, wasPromoted bool) {
+	if wasPromoted {
+		pt = Pawn
+	}
+
+	b.addToPocket(b.turn, pt)
+}
+
+// Captured material stays in the game, so only castling can not be undone.
+func (crazyhouseRules) isIrreversible(b *Board, m *Move) bool {
+	return b.reducesCastlingRights(m)
+}
+
+// Pieces in hand are enough to mate.
+func (r crazyhouseRules) isInsufficientMaterial(b *Board) bool {
+	return b.pockets[White].Len() == 0 && b.pockets[Black].Len() == 0 && r.standardRules.isInsufficientMaterial(b)
+}
+
+// Pieces change sides, so only the totals on the board and in the pockets
+// are limited.
+func (r crazyhouseRules) status(b *Board) uint {
+	status := r.standardRules.status(b)
+
+	pawns := b.baseBoard.pawns.PopCount() + b.pockets[White].Count(Pawn) + b.pockets[Black].Count(Pawn)
+	if pawns <= 16 {
+		status &= ^(StatusTooManyWhitePawns | StatusTooManyBlackPawns)
+	}
+
+	pieces := b.baseBoard.occupied.PopCount() + b.pockets[White].Len() + b.pockets[Black].Len()
+	if pieces <= 32 {
+		status &= ^(StatusTooManyWhitePieces | StatusTooManyBlackPieces)
+	}
+
+	return status
+}
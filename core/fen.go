@@ -0,0 +1,341 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FENField names a space separated part of a FEN.
+type FENField string
+
+const (
+	FENFieldFEN            FENField = "fen"
+	FENFieldBoard          FENField = "board"
+	FENFieldTurn           FENField = "turn"
+	FENFieldCastling       FENField = "castling"
+	FENFieldEnPassant      FENField = "en passant"
+	FENFieldHalfMoveClock  FENField = "halfmove clock"
+	FENFieldFullMoveNumber FENField = "fullmove number"
+	FENFieldChecks         FENField = "checks"
+)
+
+// FENError describes an invalid FEN. Position is the index of the
+// offending character in the FEN, or its length when a part is missing.
+type FENError struct {
+	error
+	description string
+
+	FEN      string
+	Field    FENField
+	Position int
+}
+
+func (e *FENError) Error() string {
+	if e.Field == FENFieldFEN {
+		return fmt.Sprintf("invalid fen at %d: %s", e.Position, e.description)
+	}
+
+	return fmt.Sprintf("invalid %s part of fen at %d: %s", e.Field, e.Position, e.description)
+}
+
+// fenPart is a space separated part of a FEN with its offset.
+type fenPart struct {
+	text   string
+	offset int
+}
+
+func splitFEN(fen string) []fenPart {
+	parts := []fenPart{}
+	start := -1
+	for i, c := range fen + " " {
+		isSpace := c == ' ' || c == '\t' || c == '\n' || c == '\r'
+		if isSpace && start >= 0 {
+			parts = append(parts, fenPart{fen[start:i], start})
+			start = -1
+		} else if !isSpace && start < 0 {
+			start = i
+		}
+	}
+
+	return parts
+}
+
+func fenError(fen string, field FENField, position int, description string) *FENError {
+	return &FENError{description: description, FEN: fen, Field: field, Position: position}
+}
+
+// validateBoardFEN checks the position part of a FEN, which starts at the
+// offset of the full FEN.
+func validateBoardFEN(fen string, part fenPart) error {
+	rows := 1
+	columns := 0
+	previousWasDigit := false
+	previousWasPiece := false
+
+	for i, c := range part.text {
+		position := part.offset + i
+
+		switch {
+		case c == '/':
+			if columns != 8 {
+				return fenError(fen, FENFieldBoard, position, "expected 8 columns per row")
+			}
+
+			rows++
+			if rows > 8 {
+				return fenError(fen, FENFieldBoard, position, "expected 8 rows")
+			}
+
+			columns = 0
+			previousWasDigit = false
+			previousWasPiece = false
+			continue
+		case c >= '1' && c <= '8':
+			if previousWasDigit {
+				return fenError(fen, FENFieldBoard, position, "two subsequent digits")
+			}
+
+			columns += int(c - '0')
+			previousWasDigit = true
+			previousWasPiece = false
+		case c == '~':
+			if !previousWasPiece {
+				return fenError(fen, FENFieldBoard, position, "~ not after piece")
+			}
+
+			previousWasDigit = false
+			previousWasPiece = false
+		case strings.ContainsRune("pnbrqkPNBRQK", c):
+			columns++
+			previousWasDigit = false
+			previousWasPiece = true
+		default:
+			return fenError(fen, FENFieldBoard, position, fmt.Sprintf("invalid character %q", c))
+		}
+
+		if columns > 8 {
+			return fenError(fen, FENFieldBoard, position, "expected 8 columns per row")
+		}
+	}
+
+	end := part.offset + len(part.text)
+	if columns != 8 {
+		return fenError(fen, FENFieldBoard, end, "expected 8 columns per row")
+	}
+
+	if rows != 8 {
+		return fenError(fen, FENFieldBoard, end, "expected 8 rows")
+	}
+
+	return nil
+}
+
+func validateCastlingFEN(fen string, part fenPart) error {
+	if part.text == "-" {
+		return nil
+	}
+
+	whites, blacks := 0, 0
+	for i, c := range part.text {
+		switch {
+		case strings.ContainsRune("KQABCDEFGH", c) && blacks == 0 && whites < 2:
+			whites++
+		case strings.ContainsRune("kqabcdefgh", c) && blacks < 2:
+			blacks++
+		default:
+			return fenError(fen, FENFieldCastling, part.offset+i, fmt.Sprintf("unexpected castling flag %q", c))
+		}
+	}
+
+	return nil
+}
+
+func parseFENNumber(fen string, part fenPart, field FENField) (int, error) {
+	for i, c := range part.text {
+		if c < '0' || c > '9' {
+			return 0, fenError(fen, field, part.offset+i, "expected a non-negative number")
+		}
+	}
+
+	n, err := strconv.Atoi(part.text)
+	if err != nil {
+		return 0, fenError(fen, field, part.offset, "number out of range")
+	}
+
+	return n, nil
+}
+
+// parsedFEN holds the validated parts of a FEN, so boards are only changed
+// once a FEN is known to be valid.
+type parsedFEN struct {
+	board          string
+	turn           Color
+	castling       string
+	epSquare       Square
+	halfMoveClock  int
+	fullMoveNumber int
+	pockets        [2]Pocket
+	checks         [2]int
+}
+
+// parsePockets splits the pieces in hand off the position part of a
+// Crazyhouse FEN, where they follow in brackets, like "...[Qn]", or as a
+// ninth row, like ".../Qn". Both pockets are empty if there is no such part.
+func parsePockets(fen string, part fenPart) (fenPart, [2]Pocket, error) {
+	var pockets [2]Pocket
+
+	board, start, end := part.text, 0, 0
+	if strings.HasSuffix(part.text, "]") {
+		i := strings.Index(part.text, "[")
+		if i < 0 {
+			return part, pockets, fenError(fen, FENFieldBoard, part.offset+len(part.text)-1, "] without [")
+		}
+		board, start, end = part.text[:i], i+1, len(part.text)-1
+	} else if strings.Count(part.text, "/") == 8 {
+		i := strings.LastIndex(part.text, "/")
+		board, start, end = part.text[:i], i+1, len(part.text)
+	}
+
+	for i, c := range part.text[start:end] {
+		if !strings.ContainsRune("pnbrqPNBRQ", c) {
+			return part, pockets, fenError(fen, FENFieldBoard, part.offset+start+i, fmt.Sprintf("invalid pocket piece %q", c))
+		}
+
+		p := NewPieceFromSymbol(string(c))
+		pockets[p.Color].Add(p.Type)
+	}
+
+	return fenPart{board, part.offset}, pockets, nil
+}
+
+// parseChecks parses the check counters of a Three-check FEN, either the
+// checks given by white and black, like "+2+0", or the checks they have
+// left to give, like "1+3".
+func parseChecks(fen string, part fenPart, limit int) ([2]int, error) {
+	var checks [2]int
+
+	text := part.text
+	given := strings.HasPrefix(text, "+")
+	if given {
+		text = text[1:]
+	}
+
+	counts := strings.Split(text, "+")
+	if len(counts) != 2 {
+		return checks, fenError(fen, FENFieldChecks, part.offset, "expected checks like +2+0 or 1+3")
+	}
+
+	for i, c := range []Color{White, Black} {
+		n, err := strconv.Atoi(counts[i])
+		if err != nil || counts[i][0] < '0' || counts[i][0] > '9' || n > limit {
+			return checks, fenError(fen, FENFieldChecks, part.offset, fmt.Sprintf("expected 0 to %d checks", limit))
+		}
+
+		if given {
+			checks[c] = n
+		} else {
+			checks[c] = limit - n
+		}
+	}
+
+	return checks, nil
+}
+
+// parseFEN parses a FEN, with the pieces in hand of Crazyhouse after the
+// position if withPockets is set, and the check counters of Three-check
+// if there is a checkLimit.
+func parseFEN(fen string, withPockets bool, checkLimit int) (*parsedFEN, error) {
+	parts := splitFEN(fen)
+	if len(parts) == 0 {
+		return nil, fenError(fen, FENFieldFEN, len(fen), "empty fen")
+	}
+
+	// The check counters follow the move number, like "+2+0", or the en
+	// passant square, like "1+3"
+	var checks [2]int
+	if checkLimit > 0 && len(parts) == 7 {
+		i := 6
+		if strings.Contains(parts[4].text, "+") {
+			i = 4
+		}
+
+		var err error
+		if checks, err = parseChecks(fen, parts[i], checkLimit); err != nil {
+			return nil, err
+		}
+		parts = append(parts[:i], parts[i+1:]...)
+	}
+
+	if len(parts) < 6 {
+		return nil, fenError(fen, FENFieldFEN, len(fen), fmt.Sprintf("expected 6 parts, got %d", len(parts)))
+	}
+
+	if len(parts) > 6 {
+		return nil, fenError(fen, FENFieldFEN, parts[6].offset, fmt.Sprintf("expected 6 parts, got %d", len(parts)))
+	}
+
+	var pockets [2]Pocket
+	if withPockets {
+		var err error
+		if parts[0], pockets, err = parsePockets(fen, parts[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateBoardFEN(fen, parts[0]); err != nil {
+		return nil, err
+	}
+
+	turn, err := ParseColor(parts[1].text)
+	if err != nil || len(parts[1].text) != 1 {
+		return nil, fenError(fen, FENFieldTurn, parts[1].offset, "expected 'w' or 'b'")
+	}
+
+	if err := validateCastlingFEN(fen, parts[2]); err != nil {
+		return nil, err
+	}
+
+	epSquare := SquareNone
+	if parts[3].text != "-" {
+		if epSquare, err = ParseSquare(parts[3].text); err != nil {
+			return nil, fenError(fen, FENFieldEnPassant, parts[3].offset, "invalid en passant square")
+		}
+	}
+
+	halfMoveClock, err := parseFENNumber(fen, parts[4], FENFieldHalfMoveClock)
+	if err != nil {
+		return nil, err
+	}
+
+	fullMoveNumber, err := parseFENNumber(fen, parts[5], FENFieldFullMoveNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsedFEN{
+		board:          parts[0].text,
+		turn:           turn,
+		castling:       parts[2].text,
+		epSquare:       epSquare,
+		halfMoveClock:  halfMoveClock,
+		fullMoveNumber: fullMoveNumber,
+		pockets:        pockets,
+		checks:         checks,
+	}, nil
+}
+
+// ParseBoardFEN parses the position part of a FEN. Errors are *FENError.
+func ParseBoardFEN(fen string) (BaseBoard, error) {
+	b := NewBaseBoard("")
+	err := b.SetFEN(fen)
+	return b, err
+}
+
+// ParseFEN parses a FEN of a standard chess board. Errors are *FENError.
+// For Chess960 set the FEN on NewBoard(true).
+func ParseFEN(fen string) (Board, error) {
+	b := NewBoard(false)
+	err := b.SetFEN(fen)
+	return b, err
+}
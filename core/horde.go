@@ -0,0 +1,77 @@
+package core
+
+// HordeStartingFEN is the starting position of Horde, with 36 white pawns
+// against the black army.
+const HordeStartingFEN = "rnbqkbnr/pppppppp/8/1PP2PP1/PPPPPPPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 1"
+
+// hordeRules give white a horde of pawns and no king. Black wins by
+// capturing all of them, white by mating the black king. Pawns on the
+// first rank can move two squares.
+type hordeRules struct {
+	standardRules
+}
+
+// NewHordeBoard creates a Horde board from a FEN. Invalid FENs result in
+// an empty board.
+func NewHordeBoard(fen string, chess960 bool) Board {
+	board := newStandardBoard(chess960)
+
+	board.rules = hordeRules{}
+	board.aliases = []string{"Horde", "Horde chess"}
+	board.uciVariant = "horde"
+	board.startingFen = HordeStartingFEN
+	board.tbwSuffix = ""
+	board.tbzSuffix = ""
+	board.tbwMagic = [4]byte{}
+	board.tbzMagic = [4]byte{}
+	board.oneKing = false
+
+	board.setUp(fen)
+	return board
+}
+
+func (hordeRules) isVariantEnd(b *Board) bool {
+	return b.baseBoard.occupiedColor[White] == BBVoid || b.baseBoard.occupiedColor[Black] == BBVoid
+}
+
+func (hordeRules) isVariantDraw(b *Board) bool {
+	return b.baseBoard.occupied == BBVoid
+}
+
+func (hordeRules) isVariantLoss(b *Board) bool {
+	return b.baseBoard.occupied != BBVoid && b.baseBoard.occupiedColor[b.turn] == BBVoid
+}
+
+func (hordeRules) isVariantWin(b *Board) bool {
+	return b.baseBoard.occupied != BBVoid && b.baseBoard.occupiedColor[b.turn.Swap()] == BBVoid
+}
+
+// The side with the king can always win by capturing the horde.
+func (hordeRules) isInsufficientMaterial(b *Board) bool {
+	return false
+}
+
+// Black has exactly one king and white none. White can have up to 36
+// pieces, any of them pawns, and pawns on the first rank.
+func (r hordeRules) status(b *Board) uint {
+	status := r.standardRules.status(b)
+	bb := &b.baseBoard
+
+	blackKings := bb.kings & bb.occupiedColor[Black]
+	if blackKings == BBVoid {
+		status |= StatusNoBlackKing
+	}
+	if blackKings.PopCount() > 1 || bb.kings&bb.occupiedColor[White] != BBVoid {
+		status |= StatusTooManyKings
+	}
+
+	if bb.occupiedColor[White].PopCount() <= 36 {
+		status &= ^(StatusTooManyWhitePieces | StatusTooManyWhitePawns)
+	}
+
+	if bb.pawns&BBRank8 == BBVoid && bb.pawns&bb.occupiedColor[Black]&BBRank1 == BBVoid {
+		status &= ^StatusPawnsOnBackRank
+	}
+
+	return status
+}
@@ -0,0 +1,41 @@
+package core
+
+// kingOfTheHillRules add a way to win: bringing the king to one of the
+// four center squares.
+type kingOfTheHillRules struct {
+	standardRules
+}
+
+// NewKingOfTheHillBoard creates a King of the Hill board from a FEN.
+// Invalid FENs result in an empty board.
+func NewKingOfTheHillBoard(fen string, chess960 bool) Board {
+	board := newStandardBoard(chess960)
+
+	board.rules = kingOfTheHillRules{}
+	board.aliases = []string{"King of the Hill", "KOTH", "kingOfTheHill"}
+	board.uciVariant = "kingofthehill"
+	board.tbwSuffix = ""
+	board.tbzSuffix = ""
+	board.tbwMagic = [4]byte{}
+	board.tbzMagic = [4]byte{}
+
+	board.setUp(fen)
+	return board
+}
+
+func (kingOfTheHillRules) isVariantEnd(b *Board) bool {
+	return b.baseBoard.kings&BBCenter != BBVoid
+}
+
+func (kingOfTheHillRules) isVariantWin(b *Board) bool {
+	return b.baseBoard.kings&b.baseBoard.occupiedColor[b.turn]&BBCenter != BBVoid
+}
+
+func (kingOfTheHillRules) isVariantLoss(b *Board) bool {
+	return b.baseBoard.kings&b.baseBoard.occupiedColor[b.turn.Swap()]&BBCenter != BBVoid
+}
+
+// A bare king can still walk to the center.
+func (kingOfTheHillRules) isInsufficientMaterial(b *Board) bool {
+	return false
+}
@@ -0,0 +1,108 @@
+package core
+
+// Slider attacks are looked up in fixed tables. The occupancy of the
+// relevant squares is multiplied with a precomputed magic number, which maps
+// every occupancy to a unique index (or to an index with the same attacks).
+
+type magic struct {
+	mask   Bitboard
+	number uint64
+	shift  uint
+	offset int
+}
+
+func (m *magic) index(occupied Bitboard) int {
+	return m.offset + int((uint64(occupied&m.mask)*m.number)>>m.shift)
+}
+
+var rookMagicNumbers = [64]uint64{
+	0x0080008024514002, 0x024000A001403000, 0x2080200008801000, 0x3600100820044200,
+	0x1080040002800800, 0x1280020001040080, 0x0280008002000100, 0x0300088041000022,
+	0x0640800080400020, 0x0501004001002080, 0xA001002000110048, 0x0010801002810800,
+	0x0000800800800400, 0x7405000249000400, 0x8002000200010408, 0x6002000203B40041,
+	0x800028800C844000, 0x0001010040002080, 0x2002020010804021, 0x2028808008001000,
+	0x00C4008004800800, 0x1005010004000802, 0x1060440048425001, 0x2000520000810054,
+	0x0600400080008028, 0x0000C00280200180, 0x0000200080100088, 0x1900100080080080,
+	0x0100080080040080, 0x0002000200100408, 0x8009004100440200, 0x20980C0200006383,
+	0x0092401222800080, 0x0210002001400140, 0x8020200080801001, 0x0410010009002112,
+	0x0008040080800800, 0x000A000280800400, 0x20C0800200800100, 0x404A802040800100,
+	0x2000A08140018000, 0x4010002000404000, 0x0320004100110024, 0x0800081001010022,
+	0x0008002040040400, 0x1003040002008080, 0x0440010208040010, 0x0817000080410002,
+	0x6006804200310200, 0x0041AB0482004200, 0x00A0802000100080, 0x0200081000210100,
+	0x0000040008008280, 0x0C02040002008080, 0x2000410822100400, 0x4000010044288200,
+	0x20038000C0210013, 0x704000F445048021, 0x0000412001908903, 0x1002342009500101,
+	0x0903000800040211, 0x2047000802040001, 0x0002000841040082, 0x4000002844008102,
+}
+
+var bishopMagicNumbers = [64]uint64{
+	0x04A0140C08405200, 0x2224041828410008, 0x0612008413020014, 0x0028484300100006,
+	0x8104104440C02200, 0x0801100804000000, 0x002A080203104005, 0x006586080A051C00,
+	0x000060A004110062, 0x8031A0010A409102, 0x8000086805082000, 0x002011240084040C,
+	0x4000020210000000, 0x00800A0884240012, 0x00104082082084A0, 0x00800C8643101022,
+	0x1088200408500411, 0x0103002004110602, 0x0804100808001110, 0x0A84008804101101,
+	0x00260004220101A1, 0x0000800808012810, 0x0100480208024800, 0x500441508208410C,
+	0x0204221404600410, 0x1001041460840408, 0x6000980030012021, 0x0A4008200A020140,
+	0x1001001001004010, 0x0098008806100C00, 0x2882008800441000, 0x0800420040410400,
+	0x00082208014008A1, 0x1000900808100200, 0x000040300E080840, 0x1083020080080081,
+	0x0140002020020080, 0x0004008200040920, 0x8001180902008404, 0x0254464045020100,
+	0x1004100804300802, 0x01020202A0010206, 0x00844200410E1008, 0xA100884010400202,
+	0x4002380104040041, 0x0008101010810648, 0x0402440104220200, 0x0448488D00400202,
+	0x2002088208423004, 0x4221008084200000, 0x0080010088D00000, 0x0920005042020080,
+	0x6040C01020220006, 0x10002420480E4104, 0x0205A004010A1040, 0x00240808530C2049,
+	0x0008209050101000, 0x2081042082301040, 0x00A0010444040400, 0x0504000000840400,
+	0x1103288040082229, 0x0100024010022091, 0xC0C0048408422400, 0x000408900A428100,
+}
+
+// One entry per occupancy subset of each square: 2^popcount(mask)
+var rookTable [102400]Bitboard
+var bishopTable [5248]Bitboard
+
+func initMagics(numbers *[64]uint64, table []Bitboard, deltas []int) [64]magic {
+	magics := [64]magic{}
+	offset := 0
+
+	for sq := Square(0); sq < 64; sq++ {
+		mask := SlidingAttacks(sq, BBVoid, deltas) & ^Edges(sq)
+		m := magic{mask: mask, number: numbers[sq], shift: uint(64 - mask.PopCount()), offset: offset}
+
+		// Enumerate all subsets of the mask (Carry-Rippler)
+		subset := BBVoid
+		for {
+			table[m.index(subset)] = SlidingAttacks(sq, subset, deltas)
+			subset = (subset - mask) & mask
+			if subset == BBVoid {
+				break
+			}
+		}
+
+		magics[sq] = m
+		offset += 1 << uint(mask.PopCount())
+	}
+
+	return magics
+}
+
+var rookMagics = initMagics(&rookMagicNumbers, rookTable[:], []int{-8, 8, -1, 1})
+var bishopMagics = initMagics(&bishopMagicNumbers, bishopTable[:], []int{-9, -7, 7, 9})
+
+func RookAttacks(s Square, occupied Bitboard) Bitboard {
+	return rookTable[rookMagics[s].index(occupied)]
+}
+
+func BishopAttacks(s Square, occupied Bitboard) Bitboard {
+	return bishopTable[bishopMagics[s].index(occupied)]
+}
+
+// fileSliderAttacks returns the attacks along the file from the square.
+func fileSliderAttacks(s Square, occupied Bitboard) Bitboard {
+	return RookAttacks(s, occupied) & NewBitboardFromFile(s.File())
+}
+
+// rankSliderAttacks returns the attacks along the rank from the square.
+func rankSliderAttacks(s Square, occupied Bitboard) Bitboard {
+	return RookAttacks(s, occupied) & NewBitboardFromRank(s.Rank())
+}
+
+func QueenAttacks(s Square, occupied Bitboard) Bitboard {
+	return RookAttacks(s, occupied) | BishopAttacks(s, occupied)
+}
@@ -14,6 +14,19 @@ func (e *MoveError) Error() string {
 	return e.description
 }
 
+// ValueError is returned for invalid square names, piece symbols and color
+// names.
+type ValueError struct {
+	error
+	description string
+
+	Value string
+}
+
+func (e *ValueError) Error() string {
+	return e.description
+}
+
 type Move struct {
 	FromSquare Square
 	ToSquare   Square
@@ -46,22 +59,38 @@ func NewMoveFromUci(uci string) (*Move, error) {
 		return NewNullMove()
 	}
 
+	invalid := &MoveError{description: "Invalid uci string:" + uci}
+
 	if len(uci) == 4 && uci[1] == '@' {
-		drop := NewPieceFromSymbol(string(uci[0])).Type
+		drop, err := ParsePieceType(uci[0:1])
 		square := NewSquareFromName(uci[2:])
+		if err != nil || square == SquareNone {
+			return nil, invalid
+		}
+
 		return NewDropMove(square, drop)
 	}
 
-	if len(uci) == 4 {
-		return NewNormalMove(NewSquareFromName(uci[0:2]), NewSquareFromName(uci[2:4]))
+	if len(uci) != 4 && len(uci) != 5 {
+		return nil, invalid
+	}
+
+	fromSquare := NewSquareFromName(uci[0:2])
+	toSquare := NewSquareFromName(uci[2:4])
+	if fromSquare == SquareNone || toSquare == SquareNone {
+		return nil, invalid
 	}
 
 	if len(uci) == 5 {
-		promotion := NewPieceFromSymbol(string(uci[4])).Type
-		return NewPromotionMove(NewSquareFromName(uci[0:2]), NewSquareFromName(uci[2:4]), promotion)
+		promotion, err := ParsePieceType(uci[4:5])
+		if err != nil {
+			return nil, invalid
+		}
+
+		return NewPromotionMove(fromSquare, toSquare, promotion)
 	}
 
-	return nil, &MoveError{description: "Invalid uci string:" + uci}
+	return NewNormalMove(fromSquare, toSquare)
 }
 
 func (m *Move) Uci() string {
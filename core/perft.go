@@ -0,0 +1,151 @@
+package core
+
+// PerftStats breaks down the leaf nodes of a perft by the kind of the last
+// move. Captures include en passant captures.
+type PerftStats struct {
+	Nodes      uint64
+	Captures   uint64
+	EnPassants uint64
+	Castles    uint64
+	Promotions uint64
+	Checks     uint64
+	Mates      uint64
+}
+
+// Add sums the counts of other into s.
+func (s *PerftStats) Add(other PerftStats) {
+	s.Nodes += other.Nodes
+	s.Captures += other.Captures
+	s.EnPassants += other.EnPassants
+	s.Castles += other.Castles
+	s.Promotions += other.Promotions
+	s.Checks += other.Checks
+	s.Mates += other.Mates
+}
+
+// PerftDivision is the perft of the position after a root move.
+type PerftDivision struct {
+	Move  Move
+	Stats PerftStats
+}
+
+// perftBuffer returns a buffer with room for the moves of every ply, so
+// that the search does not allocate for move lists.
+func perftBuffer(depth int) []Move {
+	return make([]Move, 0, maxMoves*(depth+1))
+}
+
+// Perft counts the leaf nodes of the legal move tree to the given depth.
+// Moves are pushed and popped on the board, which is left unchanged.
+func (b *Board) Perft(depth int) uint64 {
+	if depth < 1 {
+		return 1
+	}
+
+	return b.perft(depth, perftBuffer(depth))
+}
+
+func (b *Board) perft(depth int, buf []Move) uint64 {
+	moves := b.LegalMoves(buf[:0])
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+
+	nodes := uint64(0)
+	for i := range moves {
+		b.Push(&moves[i])
+		nodes += b.perft(depth-1, moves[len(moves):])
+		b.Pop()
+	}
+
+	return nodes
+}
+
+// DetailedPerft is Perft with the leaf nodes broken down, see PerftStats.
+// It is considerably slower, since every leaf is visited.
+func (b *Board) DetailedPerft(depth int) PerftStats {
+	if depth < 1 {
+		return PerftStats{Nodes: 1}
+	}
+
+	return b.detailedPerft(depth, perftBuffer(depth))
+}
+
+func (b *Board) detailedPerft(depth int, buf []Move) PerftStats {
+	stats := PerftStats{}
+	moves := b.LegalMoves(buf[:0])
+
+	for i := range moves {
+		if depth == 1 {
+			stats.Add(b.leafStats(&moves[i]))
+			continue
+		}
+
+		b.Push(&moves[i])
+		stats.Add(b.detailedPerft(depth-1, moves[len(moves):]))
+		b.Pop()
+	}
+
+	return stats
+}
+
+// leafStats classifies a legal move as a leaf node.
+func (b *Board) leafStats(m *Move) PerftStats {
+	stats := PerftStats{Nodes: 1}
+	if b.IsCapture(m) {
+		stats.Captures++
+	}
+	if b.IsEnPassant(m) {
+		stats.EnPassants++
+	}
+	if b.IsCastling(m) {
+		stats.Castles++
+	}
+	if m.Promotion != NoPiece {
+		stats.Promotions++
+	}
+
+	b.Push(m)
+	if b.IsCheck() {
+		stats.Checks++
+		if !b.hasLegalMoves() {
+			stats.Mates++
+		}
+	}
+	b.Pop()
+
+	return stats
+}
+
+// PerftDivide returns the perft of each root move, in move generation
+// order. Only the node counts are filled in unless detailed is set.
+func (b *Board) PerftDivide(depth int, detailed bool) []PerftDivision {
+	if depth < 1 {
+		return []PerftDivision{}
+	}
+
+	buf := perftBuffer(depth)
+	moves := b.LegalMoves(nil)
+	divisions := make([]PerftDivision, len(moves))
+
+	for i := range moves {
+		divisions[i].Move = moves[i]
+
+		switch {
+		case detailed && depth == 1:
+			divisions[i].Stats = b.leafStats(&moves[i])
+		case depth == 1:
+			divisions[i].Stats.Nodes = 1
+		default:
+			b.Push(&moves[i])
+			if detailed {
+				divisions[i].Stats = b.detailedPerft(depth-1, buf)
+			} else {
+				divisions[i].Stats.Nodes = b.perft(depth-1, buf)
+			}
+			b.Pop()
+		}
+	}
+
+	return divisions
+}
@@ -16,6 +16,8 @@ const (
 	King    PieceType = 6
 )
 
+// NewPieceType returns the piece type of a code, or NoPiece for invalid
+// codes.
 func NewPieceType(i uint8) PieceType {
 	switch i {
 	case 1:
@@ -32,7 +34,17 @@ func NewPieceType(i uint8) PieceType {
 		return King
 	}
 
-	panic("Invalid piece code")
+	return NoPiece
+}
+
+// ParsePieceType parses a piece type symbol of either case, like "n" or "N".
+func ParsePieceType(symbol string) (PieceType, error) {
+	p, err := ParsePiece(symbol)
+	if err != nil {
+		return NoPiece, err
+	}
+
+	return p.Type, nil
 }
 
 func (p PieceType) Symbol() string {
@@ -51,7 +63,7 @@ func (p PieceType) Symbol() string {
 		return "k"
 	}
 
-	panic("Invalid piece")
+	return ""
 }
 
 func (p PieceType) Name() string {
@@ -70,7 +82,7 @@ func (p PieceType) Name() string {
 		return "king"
 	}
 
-	panic("Invalid piece")
+	return ""
 }
 
 func UnicodePieceSymbol(fenPiece string) string {
@@ -113,6 +125,8 @@ func NewPiece(t PieceType, c Color) Piece {
 	return Piece{Type: t, Color: c}
 }
 
+// NewPieceFromSymbol returns the piece of a symbol, like "N" for a white
+// knight. Invalid symbols result in a piece of type NoPiece, see ParsePiece.
 func NewPieceFromSymbol(s string) Piece {
 	switch s {
 	case "R":
@@ -141,7 +155,18 @@ func NewPieceFromSymbol(s string) Piece {
 		return Piece{Pawn, Black}
 	}
 
-	panic("Invalid piece symbol")
+	return Piece{NoPiece, Black}
+}
+
+// ParsePiece parses a piece symbol, like "N" for a white knight. Errors are
+// *ValueError.
+func ParsePiece(symbol string) (Piece, error) {
+	p := NewPieceFromSymbol(symbol)
+	if p.Type == NoPiece {
+		return p, &ValueError{description: "invalid piece symbol: " + symbol, Value: symbol}
+	}
+
+	return p, nil
 }
 
 func (p *Piece) Symbol() string {
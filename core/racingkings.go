@@ -0,0 +1,128 @@
+package core
+
+// RacingKingsStartingFEN is the starting position of Racing Kings, with
+// both sides on the first two ranks.
+const RacingKingsStartingFEN = "8/8/8/8/8/8/krbnNBRK/qrbnNBRQ w - - 0 1"
+
+// racingKingsRules forbid giving check. The first king to reach the eighth
+// rank wins, but if white gets there first black still has one move to
+// reach it too and draw.
+type racingKingsRules struct {
+	standardRules
+}
+
+// NewRacingKingsBoard creates a Racing Kings board from a FEN. Invalid FENs
+// result in an empty board.
+func NewRacingKingsBoard(fen string, chess960 bool) Board {
+	board := newStandardBoard(chess960)
+
+	board.rules = racingKingsRules{}
+	board.aliases = []string{"Racing Kings", "Racing", "Race", "racingkings"}
+	board.uciVariant = "racingkings"
+	board.startingFen = RacingKingsStartingFEN
+	board.tbwSuffix = ""
+	board.tbzSuffix = ""
+	board.tbwMagic = [4]byte{}
+	board.tbzMagic = [4]byte{}
+
+	board.setUp(fen)
+	return board
+}
+
+// givesCheck checks if a move puts the enemy king in check.
+func (b *Board) givesCheck(m *Move) bool {
+	b.Push(m)
+	defer b.Pop()
+	return b.IsCheck()
+}
+
+func (r racingKingsRules) isLegal(b *Board, m *Move) bool {
+	return r.standardRules.isLegal(b, m) && !b.givesCheck(m)
+}
+
+func (r racingKingsRules) generateLegalMoves(b *Board, moves []Move, fromMask, toMask Bitboard) []Move {
+	start := len(moves)
+	moves = r.standardRules.generateLegalMoves(b, moves, fromMask, toMask)
+
+	n := start
+	for i := start; i < len(moves); i++ {
+		if !b.givesCheck(&moves[i]) {
+			moves[n] = moves[i]
+			n++
+		}
+	}
+
+	return moves[:n]
+}
+
+func (racingKingsRules) isVariantEnd(b *Board) bool {
+	bb := &b.baseBoard
+
+	if bb.kings&BBRank8 == BBVoid {
+		return false
+	}
+
+	blackKings := bb.kings & bb.occupiedColor[Black]
+	if b.turn == White || blackKings&BBRank8 != BBVoid || blackKings == BBVoid {
+		return true
+	}
+
+	// White reached the eighth rank, the game goes on if the black king
+	// can follow with its next move
+	targets := kingAttacks[blackKings.Msb()] & BBRank8 & ^bb.occupiedColor[Black]
+	for targets != BBVoid {
+		if !bb.IsAttackedBy(White, targets.PopMsb()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (racingKingsRules) isVariantDraw(b *Board) bool {
+	inGoal := b.baseBoard.kings & BBRank8
+	return inGoal&b.baseBoard.occupiedColor[White] != BBVoid && inGoal&b.baseBoard.occupiedColor[Black] != BBVoid
+}
+
+func (racingKingsRules) isVariantLoss(b *Board) bool {
+	return b.IsVariantEnd() && b.baseBoard.kings&b.baseBoard.occupiedColor[b.turn]&BBRank8 == BBVoid
+}
+
+func (racingKingsRules) isVariantWin(b *Board) bool {
+	inGoal := b.baseBoard.kings & BBRank8
+	return b.IsVariantEnd() && inGoal&b.baseBoard.occupiedColor[b.turn] != BBVoid &&
+		inGoal&b.baseBoard.occupiedColor[b.turn.Swap()] == BBVoid
+}
+
+// A bare king can still race.
+func (racingKingsRules) isInsufficientMaterial(b *Board) bool {
+	return false
+}
+
+// Kings can never be in check, there are no pawns and no more pieces than
+// in the starting position. A race is over once both kings reached the
+// eighth rank with black to move.
+func (r racingKingsRules) status(b *Board) uint {
+	status := r.standardRules.status(b)
+	bb := &b.baseBoard
+
+	if b.IsCheck() {
+		status |= StatusRaceCheck
+	}
+
+	if b.turn == Black && b.IsVariantDraw() {
+		status |= StatusRaceOver
+	}
+
+	if bb.pawns != BBVoid {
+		status |= StatusRaceMaterial
+	}
+	for _, c := range []Color{White, Black} {
+		if (bb.knights&bb.occupiedColor[c]).PopCount() > 2 || (bb.bishops&bb.occupiedColor[c]).PopCount() > 2 ||
+			(bb.rooks&bb.occupiedColor[c]).PopCount() > 2 || (bb.queens&bb.occupiedColor[c]).PopCount() > 1 {
+			status |= StatusRaceMaterial
+		}
+	}
+
+	return status
+}
@@ -221,8 +221,14 @@ func RankFromName(name string) Rank {
 	return RankNone
 }
 
+// NewSquareFromName returns the square of a name, like "e4", or SquareNone
+// for invalid names, see ParseSquare.
 // TODO: Optimize with ASCII value computation
 func NewSquareFromName(name string) Square {
+	if len(name) != 2 {
+		return SquareNone
+	}
+
 	file := FileFromName(name[0:1])
 	if file == FileNone {
 		return SquareNone
@@ -236,6 +242,16 @@ func NewSquareFromName(name string) Square {
 	return NewSquare(file, rank)
 }
 
+// ParseSquare parses a square name, like "e4". Errors are *ValueError.
+func ParseSquare(name string) (Square, error) {
+	s := NewSquareFromName(name)
+	if s == SquareNone {
+		return s, &ValueError{description: "invalid square name: " + name, Value: name}
+	}
+
+	return s, nil
+}
+
 func (s Square) File() File {
 	return File(uint8(s) & 7)
 }
@@ -0,0 +1,72 @@
+package core
+
+// ThreeCheckStartingFEN is the starting position of Three-check, with the
+// checks given by white and black after the move number.
+const ThreeCheckStartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 +0+0"
+
+// threeCheckRules add a way to win: giving check three times.
+type threeCheckRules struct {
+	standardRules
+}
+
+// NewThreeCheckBoard creates a Three-check board from a FEN, with the
+// checks given by white and black after the move number, like "+2+0".
+// FENs without them start with no checks given. Invalid FENs result in an
+// empty board.
+func NewThreeCheckBoard(fen string, chess960 bool) Board {
+	board := newStandardBoard(chess960)
+
+	board.rules = threeCheckRules{}
+	board.aliases = []string{"Three-check", "Three check", "Threecheck", "Three check chess", "3-check", "3 check", "3check"}
+	board.uciVariant = "3check"
+	board.startingFen = ThreeCheckStartingFEN
+	board.tbwSuffix = ""
+	board.tbzSuffix = ""
+	board.tbwMagic = [4]byte{}
+	board.tbzMagic = [4]byte{}
+	board.checkLimit = 3
+
+	board.setUp(fen)
+	return board
+}
+
+// Checks returns the number of checks given by a color.
+func (b *Board) Checks(c Color) int {
+	return b.checks[c]
+}
+
+// SetChecks sets the number of checks given by a color and clears the move
+// stack.
+func (b *Board) SetChecks(c Color, checks int) {
+	b.checks[c] = checks
+	b.clearStack()
+}
+
+func (r threeCheckRules) push(b *Board, m *Move) {
+	r.standardRules.push(b, m)
+
+	if b.IsCheck() {
+		b.addCheck(b.turn.Swap())
+	}
+}
+
+func (threeCheckRules) isVariantEnd(b *Board) bool {
+	return b.checks[White] >= b.checkLimit || b.checks[Black] >= b.checkLimit
+}
+
+func (threeCheckRules) isVariantWin(b *Board) bool {
+	return b.checks[b.turn] >= b.checkLimit && b.checks[b.turn.Swap()] < b.checkLimit
+}
+
+func (threeCheckRules) isVariantLoss(b *Board) bool {
+	return b.checks[b.turn.Swap()] >= b.checkLimit && b.checks[b.turn] < b.checkLimit
+}
+
+func (threeCheckRules) isVariantDraw(b *Board) bool {
+	return b.checks[White] >= b.checkLimit && b.checks[Black] >= b.checkLimit
+}
+
+// Any piece besides the king can give check.
+func (threeCheckRules) isInsufficientMaterial(b *Board) bool {
+	return b.baseBoard.occupied == b.baseBoard.kings
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"strings"
+)
+
+// rules are the parts of the rules of chess that differ between variants.
+// Boards call them through their rules, variants embed standardRules and
+// override what they change.
+type rules interface {
+	generatePseudoLegalMoves(b *Board, moves []Move, fromMask, toMask Bitboard) []Move
+	generateLegalMoves(b *Board, moves []Move, fromMask, toMask Bitboard) []Move
+	isPseudoLegal(b *Board, m *Move) bool
+	isLegal(b *Board, m *Move) bool
+	isCheck(b *Board) bool
+	isIntoCheck(b *Board, m *Move) bool
+	wasIntoCheck(b *Board) bool
+	attackedForKing(b *Board, path, occupied Bitboard) bool
+	push(b *Board, m *Move)
+	pushDrop(b *Board, m *Move)
+	pushCapture(b *Board, m *Move, captureSquare Square, pt PieceType, wasPromoted bool)
+	isIrreversible(b *Board, m *Move) bool
+	isInsufficientMaterial(b *Board) bool
+	status(b *Board) uint
+	isVariantEnd(b *Board) bool
+	isVariantLoss(b *Board) bool
+	isVariantWin(b *Board) bool
+	isVariantDraw(b *Board) bool
+}
+
+// standardRules are the rules of standard chess and Chess960.
+type standardRules struct{}
+
+// newStandardBoard creates a board with the attributes of standard chess,
+// without a position.
+func newStandardBoard(chess960 bool) Board {
+	board := Board{}
+
+	board.rules = standardRules{}
+	board.aliases = []string{"Standard", "Chess", "Classical", "Normal"}
+	board.uciVariant = "chess"
+	board.startingFen = StartingFEN
+	board.tbwSuffix = ".rtbw"
+	board.tbzSuffix = ".rtbz"
+	board.tbwMagic = [4]byte{0x71, 0xe8, 0x23, 0x5d}
+	board.tbzMagic = [4]byte{0xd7, 0x66, 0x0c, 0xa5}
+	board.connectedKings = false
+	board.oneKing = true
+	board.capturesCompulsory = false
+	board.hasPockets = false
+	board.checkLimit = 0
+
+	board.chess960 = chess960
+
+	return board
+}
+
+// variants are the board constructors of the supported variants.
+var variants = []func(fen string, chess960 bool) Board{
+	NewBoardFromFEN,
+	NewCrazyhouseBoard,
+	NewAtomicBoard,
+	NewAntichessBoard,
+	NewKingOfTheHillBoard,
+	NewThreeCheckBoard,
+	NewRacingKingsBoard,
+	NewHordeBoard,
+}
+
+// NewVariantBoard creates a board of the variant with a name or alias, like
+// "Crazyhouse" or "zh", ignoring case. The FEN is set up as by
+// NewBoardFromFEN. Unknown variants result in an empty standard board and a
+// *ValueError.
+func NewVariantBoard(variant, fen string, chess960 bool) (Board, error) {
+	for _, newBoard := range variants {
+		board := newBoard("", chess960)
+		for _, alias := range board.aliases {
+			if strings.EqualFold(alias, variant) {
+				board.setUp(fen)
+				return board, nil
+			}
+		}
+	}
+
+	return NewBoard(chess960), &ValueError{description: "unknown variant: " + variant, Value: variant}
+}
+
+// Variant returns the name of the variant of the board, like "Standard".
+func (b *Board) Variant() string {
+	return b.aliases[0]
+}
+
+// Aliases returns the names of the variant of the board.
+func (b *Board) Aliases() []string {
+	return append([]string{}, b.aliases...)
+}
+
+// UCIVariant returns the name of the variant for the UCI_Variant option of
+// engines.
+func (b *Board) UCIVariant() string {
+	return b.uciVariant
+}
@@ -0,0 +1,234 @@
+package engine
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// Analysis is a running search, see UCIEngine.Analyse. Its methods may be
+// called concurrently.
+type Analysis struct {
+	// Info lines as they arrive, closed when the search has ended. Updates
+	// are dropped while the channel is full, Lines always has the latest.
+	Infos <-chan Info
+	infos chan Info
+
+	e *UCIEngine
+
+	mu    sync.Mutex
+	board core.Board
+	lines []Info
+
+	// Searches stopped by SetPosition whose bestmove is still to come
+	stale int
+
+	// Set once the output has been read up to the end of the search
+	ended  bool
+	done   chan struct{}
+	result *PlayResult
+	err    error
+}
+
+// Analyse starts searching the position of the board with the given number
+// of principal variations. The search runs until stopped, unless a limit is
+// given. Other calls to the engine fail until the search has ended.
+func (e *UCIEngine) Analyse(board *core.Board, multiPV int, limit Limit) (*Analysis, error) {
+	if err := e.checkIdle(); err != nil {
+		return nil, err
+	}
+
+	if multiPV < 1 {
+		multiPV = 1
+	}
+
+	if err := e.setMultiPV(multiPV); err != nil {
+		return nil, err
+	}
+
+	if err := e.setPosition(board); err != nil {
+		return nil, err
+	}
+
+	if err := e.p.send(analysisGoCommand(&limit)); err != nil {
+		return nil, err
+	}
+
+	infos := make(chan Info, 256)
+	a := &Analysis{
+		Infos: infos,
+		infos: infos,
+		e:     e,
+		board: core.NewBoardFromBoard(board),
+		done:  make(chan struct{}),
+	}
+
+	e.mu.Lock()
+	e.analysis = a
+	e.mu.Unlock()
+
+	go a.run()
+
+	return a, nil
+}
+
+func analysisGoCommand(limit *Limit) string {
+	if limit.isZero() {
+		return "go infinite"
+	}
+
+	return goCommand(limit)
+}
+
+// setMultiPV sets the number of lines to search, which needs the MultiPV
+// option unless it is 1.
+func (e *UCIEngine) setMultiPV(multiPV int) error {
+	o, ok := e.Options["multipv"]
+	if !ok {
+		if multiPV > 1 {
+			return &EngineError{description: "engine does not support multipv"}
+		}
+
+		return nil
+	}
+
+	value := strconv.Itoa(multiPV)
+	if e.multiPV == "" {
+		e.multiPV = o.Default
+	}
+
+	if value == e.multiPV {
+		return nil
+	}
+
+	if err := o.check(value); err != nil {
+		return err
+	}
+
+	e.multiPV = value
+	return e.setOption(&o, value)
+}
+
+// run reads the output of the engine until the search has ended.
+func (a *Analysis) run() {
+	defer func() {
+		a.e.mu.Lock()
+		a.e.analysis = nil
+		a.e.mu.Unlock()
+
+		close(a.infos)
+		close(a.done)
+	}()
+
+	for {
+		line, err := a.e.p.recv(nil)
+		if err != nil {
+			a.mu.Lock()
+			a.err = err
+			a.ended = true
+			a.mu.Unlock()
+			return
+		}
+
+		command, args := splitCommand(line)
+
+		a.mu.Lock()
+		switch {
+		case a.stale > 0:
+			// Output of a search stopped by SetPosition
+			if command == "bestmove" {
+				a.stale--
+			}
+		case command == "info":
+			info := parseInfo(&a.board, args)
+			a.addLine(&info)
+
+			select {
+			case a.infos <- info:
+			default:
+			}
+		case command == "bestmove":
+			a.result = &PlayResult{}
+			a.result.Move, a.result.Ponder = parseBestMove(&a.board, args)
+			if len(a.lines) > 0 {
+				a.result.Info = a.lines[0]
+			}
+			a.ended = true
+
+			a.mu.Unlock()
+			return
+		}
+		a.mu.Unlock()
+	}
+}
+
+// addLine merges an info into the line of its rank.
+func (a *Analysis) addLine(info *Info) {
+	rank := info.MultiPV
+	if rank < 1 {
+		rank = 1
+	}
+
+	for len(a.lines) < rank {
+		a.lines = append(a.lines, Info{MultiPV: len(a.lines) + 1})
+	}
+
+	a.lines[rank-1].merge(info)
+}
+
+// Lines returns the latest info of each principal variation, sorted by rank
+// with the best line first.
+func (a *Analysis) Lines() []Info {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]Info{}, a.lines...)
+}
+
+// SetPosition restarts the search from the position of the board, keeping
+// the number of lines and searching until stopped. Lines of the previous
+// position are discarded, though Infos may still hold some of its updates.
+func (a *Analysis) SetPosition(board *core.Board) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.ended {
+		return &EngineError{description: "analysis has ended"}
+	}
+
+	if err := a.e.p.send("stop"); err != nil {
+		return err
+	}
+
+	a.stale++
+	a.board = core.NewBoardFromBoard(board)
+	a.lines = nil
+
+	if err := a.e.setPosition(board); err != nil {
+		return err
+	}
+
+	return a.e.p.send("go infinite")
+}
+
+// Stop ends the search and returns its result.
+func (a *Analysis) Stop() (*PlayResult, error) {
+	a.mu.Lock()
+	if !a.ended {
+		a.e.p.send("stop")
+	}
+	a.mu.Unlock()
+
+	return a.Wait()
+}
+
+// Wait waits for the search to end and returns its result.
+func (a *Analysis) Wait() (*PlayResult, error) {
+	<-a.done
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.result, a.err
+}
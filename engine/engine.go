@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"bufio"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+type EngineError struct {
+	error
+	description string
+}
+
+func (e *EngineError) Error() string {
+	return e.description
+}
+
+// Engines are given this long to answer the handshake and to quit.
+var handshakeTimeout = 10 * time.Second
+
+// CECP engines not finishing their features with done=1 are assumed to be
+// done after this long, as the protocol says.
+var featureTimeout = 2 * time.Second
+
+var errTimeout = &EngineError{description: "engine timed out"}
+
+// Limit bounds a search. Zero values are not sent to the engine.
+type Limit struct {
+	Depth int
+	Nodes uint64
+	Mate  int
+
+	// Time to search for, exactly
+	MoveTime time.Duration
+
+	// Clock times
+	WhiteTime time.Duration
+	BlackTime time.Duration
+	WhiteInc  time.Duration
+	BlackInc  time.Duration
+	MovesToGo int
+}
+
+func (l *Limit) isZero() bool {
+	return *l == Limit{}
+}
+
+// Score is an evaluation from the point of view of the side to move.
+type Score struct {
+	// Centipawns, unless a mate is found
+	CP int
+
+	// Moves to mate if IsMate, negative when getting mated. A mate score of
+	// 0 means the side to move is mated.
+	Mate   int
+	IsMate bool
+
+	// Set when the engine only reported a bound
+	LowerBound bool
+	UpperBound bool
+}
+
+// Info holds the values of info lines sent by an engine during a search.
+// Values not sent are left at their zero value.
+type Info struct {
+	Depth    int
+	SelDepth int
+
+	// 1 for the best line, counting up with MultiPV
+	MultiPV int
+
+	Score *Score
+	PV    []core.Move
+
+	Nodes    uint64
+	NPS      uint64
+	Time     time.Duration
+	HashFull int
+	TBHits   uint64
+
+	CurrMove       *core.Move
+	CurrMoveNumber int
+
+	// Free text of the engine, "info string"
+	String string
+}
+
+// merge overwrites the values that are set in other.
+func (i *Info) merge(other *Info) {
+	if other.Depth != 0 {
+		i.Depth = other.Depth
+	}
+	if other.SelDepth != 0 {
+		i.SelDepth = other.SelDepth
+	}
+	if other.MultiPV != 0 {
+		i.MultiPV = other.MultiPV
+	}
+	if other.Score != nil {
+		i.Score = other.Score
+	}
+	if other.PV != nil {
+		i.PV = other.PV
+	}
+	if other.Nodes != 0 {
+		i.Nodes = other.Nodes
+	}
+	if other.NPS != 0 {
+		i.NPS = other.NPS
+	}
+	if other.Time != 0 {
+		i.Time = other.Time
+	}
+	if other.HashFull != 0 {
+		i.HashFull = other.HashFull
+	}
+	if other.TBHits != 0 {
+		i.TBHits = other.TBHits
+	}
+	if other.CurrMove != nil {
+		i.CurrMove = other.CurrMove
+	}
+	if other.CurrMoveNumber != 0 {
+		i.CurrMoveNumber = other.CurrMoveNumber
+	}
+	if other.String != "" {
+		i.String = other.String
+	}
+}
+
+// PlayResult is the outcome of a search for the move to play.
+type PlayResult struct {
+	// Nil if the engine had no move to play
+	Move *core.Move
+
+	// The move the engine expects in reply, if any
+	Ponder *core.Move
+
+	// Info of the best line, merged from the info lines of the search
+	Info Info
+
+	// CECP engines may resign instead of moving, or offer a draw
+	Resigned    bool
+	DrawOffered bool
+}
+
+// process exchanges lines with an engine. Lines are read in the background
+// so that reads can time out and searches can be stopped.
+type process struct {
+	cmd *exec.Cmd
+
+	w      io.WriteCloser
+	writeL sync.Mutex
+
+	lines chan string
+}
+
+func newProcess(r io.Reader, w io.WriteCloser) *process {
+	p := &process{w: w, lines: make(chan string, 64)}
+
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 4096), 1<<20)
+		for scanner.Scan() {
+			p.lines <- strings.TrimSpace(scanner.Text())
+		}
+
+		close(p.lines)
+	}()
+
+	return p
+}
+
+func startProcess(command string, args []string) (*process, error) {
+	cmd := exec.Command(command, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := newProcess(stdout, stdin)
+	p.cmd = cmd
+	return p, nil
+}
+
+func (p *process) send(line string) error {
+	p.writeL.Lock()
+	defer p.writeL.Unlock()
+
+	if _, err := io.WriteString(p.w, line+"\n"); err != nil {
+		return &EngineError{description: "engine terminated: " + err.Error()}
+	}
+
+	return nil
+}
+
+// recv returns the next non-empty line. A nil timeout waits forever.
+func (p *process) recv(timeout <-chan time.Time) (string, error) {
+	for {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				return "", &EngineError{description: "engine terminated"}
+			}
+
+			if line != "" {
+				return line, nil
+			}
+		case <-timeout:
+			return "", errTimeout
+		}
+	}
+}
+
+// close closes the input of the engine and waits for it to exit, killing
+// it when it does not.
+func (p *process) close() error {
+	p.w.Close()
+
+	if p.cmd == nil {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(handshakeTimeout):
+		p.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+// splitCommand splits a line into its command and the remaining text.
+func splitCommand(line string) (string, string) {
+	parts := strings.SplitN(line, " ", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,956 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// The test binary runs as a fake engine when started with this variable set
+// to the protocol to speak.
+const fakeEngineEnv = "GOLANG_CHESS_FAKE_ENGINE"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(fakeEngineEnv) {
+	case "uci":
+		fakeUCIEngine(os.Stdin, os.Stdout)
+		os.Exit(0)
+	case "xboard":
+		NewXBoardServer("golang-chess", MaterialSearcher{}).Serve(os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// fakeMoves returns the legal moves sorted by their UCI notation, reversed
+// for the risky style.
+func fakeMoves(board *core.Board, style string) []core.Move {
+	moves := board.LegalMoves(nil)
+	sort.Slice(moves, func(i, j int) bool {
+		if style == "Risky" {
+			return moves[i].Uci() > moves[j].Uci()
+		}
+		return moves[i].Uci() < moves[j].Uci()
+	})
+	return moves
+}
+
+func fakePosition(args string, chess960 bool) core.Board {
+	tokens := strings.Fields(args)
+	board := core.NewBoard(chess960)
+
+	i := 0
+	if len(tokens) > 0 && tokens[0] == "startpos" {
+		board.SetFEN(core.StartingFEN)
+		i = 1
+	} else if len(tokens) >= 7 && tokens[0] == "fen" {
+		board.SetFEN(strings.Join(tokens[1:7], " "))
+		i = 7
+	}
+
+	if i < len(tokens) && tokens[i] == "moves" {
+		for _, uci := range tokens[i+1:] {
+			board.PushUci(uci)
+		}
+	}
+
+	return board
+}
+
+// fakeUCIEngine plays the first legal move of a position, searching to
+// depth 2 unless told otherwise. With MultiPV the following moves make up
+// the other lines.
+func fakeUCIEngine(r io.Reader, w io.Writer) {
+	out := bufio.NewWriter(w)
+	send := func(format string, args ...interface{}) {
+		fmt.Fprintf(out, format+"\n", args...)
+		out.Flush()
+	}
+
+	options := map[string]string{"Style": "Solid", "UCI_Chess960": "false", "MultiPV": "1"}
+	position := ""
+
+	// Sent when an infinite search is stopped
+	bestMove := ""
+	board := core.NewDefaultBoard()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		command, args := splitCommand(strings.TrimSpace(scanner.Text()))
+		switch command {
+		case "uci":
+			send("id name Fake Engine")
+			send("id author golang-chess")
+			send("option name Hash type spin default 16 min 1 max 1024")
+			send("option name Style type combo default Solid var Solid var Risky")
+			send("option name Clear Hash type button")
+			send("option name UCI_Chess960 type check default false")
+			send("option name Book File type string default <empty>")
+			send("option name MultiPV type spin default 1 min 1 max 500")
+			send("uciok")
+		case "isready":
+			send("readyok")
+		case "setoption":
+			parts := strings.SplitN(strings.TrimPrefix(args, "name "), " value ", 2)
+			if len(parts) == 2 {
+				options[parts[0]] = parts[1]
+			}
+		case "position":
+			position = args
+			board = fakePosition(args, options["UCI_Chess960"] == "true")
+		case "go":
+			tokens := strings.Fields(args)
+			depth := 2
+			if len(tokens) == 2 && tokens[0] == "depth" {
+				depth, _ = strconv.Atoi(tokens[1])
+			}
+
+			send("info string position %s", position)
+
+			moves := fakeMoves(&board, options["Style"])
+			if len(moves) == 0 {
+				if board.IsCheck() {
+					send("info depth 0 score mate 0")
+				} else {
+					send("info depth 0 score cp 0")
+				}
+				send("bestmove (none)")
+				continue
+			}
+
+			multiPV, _ := strconv.Atoi(options["MultiPV"])
+			if multiPV > len(moves) {
+				multiPV = len(moves)
+			}
+
+			// The line of each rank starts with the next move
+			pvs := []string{}
+			for k := 0; k < multiPV; k++ {
+				board.Push(&moves[k])
+				replies := fakeMoves(&board, options["Style"])
+				board.Pop()
+
+				pv := moves[k].Uci()
+				if len(replies) > 0 {
+					pv += " " + replies[0].Uci()
+				}
+				pvs = append(pvs, pv)
+			}
+
+			for d := 1; d <= depth; d++ {
+				for k, pv := range pvs {
+					send("info depth %d seldepth %d multipv %d score cp %d nodes %d nps 1000 time %d pv %s", d, d, k+1, 10*d-k, 100*d, d, pv)
+				}
+			}
+
+			bestMove = "bestmove " + strings.Replace(pvs[0], " ", " ponder ", 1)
+			if len(tokens) == 1 && tokens[0] == "infinite" {
+				continue
+			}
+
+			send(bestMove)
+			bestMove = ""
+		case "stop":
+			if bestMove != "" {
+				send(bestMove)
+				bestMove = ""
+			}
+		case "quit":
+			return
+		}
+	}
+}
+
+func startFakeUCI(t *testing.T) *UCIEngine {
+	os.Setenv(fakeEngineEnv, "uci")
+	defer os.Unsetenv(fakeEngineEnv)
+
+	e, err := StartUCI(os.Args[0])
+	if err != nil {
+		t.Fatalf("failed to start the fake engine: %v", err)
+	}
+
+	return e
+}
+
+func TestUCIEngine(t *testing.T) {
+	e := startFakeUCI(t)
+	defer e.Quit()
+
+	t.Run("handshake", func(t *testing.T) {
+		if e.Name() != "Fake Engine" || e.ID["author"] != "golang-chess" {
+			t.Errorf("id not matching: %v", e.ID)
+		}
+
+		hash, ok := e.Options["hash"]
+		if !ok || hash.Type != "spin" || hash.Default != "16" || hash.Min != 1 || hash.Max != 1024 {
+			t.Errorf("hash option not matching: %+v", hash)
+		}
+
+		style := e.Options["style"]
+		if len(style.Vars) != 2 || style.Vars[1] != "Risky" {
+			t.Errorf("combo option not matching: %+v", style)
+		}
+
+		if o := e.Options["book file"]; o.Name != "Book File" || o.Type != "string" || o.Default != "" {
+			t.Errorf("string option not matching: %+v", o)
+		}
+
+		if e.Options["clear hash"].Type != "button" {
+			t.Errorf("button option not matching")
+		}
+	})
+
+	t.Run("play", func(t *testing.T) {
+		board := core.NewDefaultBoard()
+		board.PushUci("e2e4")
+
+		result, err := e.Play(&board, Limit{Depth: 3})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.Move == nil || result.Move.Uci() != "a7a5" || result.Ponder == nil || result.Ponder.Uci() != "a2a3" {
+			t.Errorf("best move not matching: %v %v", result.Move, result.Ponder)
+		}
+
+		info := result.Info
+		if info.Depth != 3 || info.Score == nil || info.Score.CP != 30 || info.Nodes != 300 || info.Time != 3*time.Millisecond {
+			t.Errorf("info not matching: %+v", info)
+		}
+
+		if len(info.PV) != 2 || info.PV[1].Uci() != "a2a3" {
+			t.Errorf("pv not matching: %v", info.PV)
+		}
+
+		if info.String != "position startpos moves e2e4" {
+			t.Errorf("position not matching: %q", info.String)
+		}
+
+		if len(board.MoveStack()) != 1 {
+			t.Errorf("expected board to be unchanged")
+		}
+
+		if _, err := e.Play(&board, Limit{}); err == nil {
+			t.Errorf("expected a limit to be required")
+		}
+	})
+
+	t.Run("no moves", func(t *testing.T) {
+		board := core.NewBoardFromFEN("7k/6Q1/6K1/8/8/8/8/8 b - - 0 1", false)
+		result, err := e.Play(&board, Limit{MoveTime: time.Second})
+		if err != nil || result.Move != nil || !result.Info.Score.IsMate || result.Info.Score.Mate != 0 {
+			t.Errorf("expected no move, got %v %v", result, err)
+		}
+	})
+
+	t.Run("configure", func(t *testing.T) {
+		if err := e.Configure(map[string]string{"Hash": "2048"}); err == nil {
+			t.Errorf("expected spin to be out of range")
+		}
+
+		if err := e.Configure(map[string]string{"Threads": "2"}); err == nil {
+			t.Errorf("expected unknown option to fail")
+		}
+
+		if err := e.Configure(map[string]string{"style": "Risky", "Clear Hash": ""}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		board := core.NewDefaultBoard()
+		if result, err := e.Play(&board, Limit{Depth: 1}); err != nil || result.Move.Uci() != "h2h4" {
+			t.Errorf("expected the risky style, got %v %v", result, err)
+		}
+
+		e.Configure(map[string]string{"Style": "Solid"})
+	})
+
+	t.Run("chess960", func(t *testing.T) {
+		board := core.NewBoardFromFEN("4k3/8/8/8/8/8/8/R3K1R1 w GA - 0 1", true)
+		board.PushUci("e1g1")
+		board.PushUci("e8d8")
+
+		result, err := e.Play(&board, Limit{Depth: 1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.Info.String != "position fen 4k3/8/8/8/8/8/8/R3K1R1 w GA - 0 1 moves e1g1 e8d8" {
+			t.Errorf("position not matching: %q", result.Info.String)
+		}
+
+		if result.Move == nil || !board.IsLegal(result.Move) {
+			t.Errorf("expected a legal move, got %v", result.Move)
+		}
+	})
+}
+
+func TestNewUCIEngine(t *testing.T) {
+	engineIn, w := io.Pipe()
+	r, engineOut := io.Pipe()
+	go func() {
+		fakeUCIEngine(engineIn, engineOut)
+		engineOut.Close()
+	}()
+
+	e, err := NewUCIEngine(r, w)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	board := core.NewDefaultBoard()
+	if result, err := e.Play(&board, Limit{Nodes: 1000}); err != nil || result.Move.Uci() != "a2a3" {
+		t.Errorf("best move not matching: %v %v", result, err)
+	}
+
+	if err := e.Quit(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := e.Ping(); err == nil {
+		t.Errorf("expected engine to be terminated")
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	board := core.NewDefaultBoard()
+
+	info := parseInfo(&board, "depth 12 seldepth 18 multipv 2 score cp -35 upperbound nodes 123456 nps 654321 hashfull 12 tbhits 3 time 250 pv e2e4 e7e5 g1f3 x1x2 d2d4")
+	if info.Depth != 12 || info.SelDepth != 18 || info.MultiPV != 2 || info.Nodes != 123456 || info.NPS != 654321 ||
+		info.HashFull != 12 || info.TBHits != 3 || info.Time != 250*time.Millisecond {
+		t.Errorf("info not matching: %+v", info)
+	}
+
+	if info.Score == nil || info.Score.CP != -35 || !info.Score.UpperBound || info.Score.IsMate {
+		t.Errorf("score not matching: %+v", info.Score)
+	}
+
+	if len(info.PV) != 3 || info.PV[2].Uci() != "g1f3" {
+		t.Errorf("expected the pv up to the invalid move, got %v", info.PV)
+	}
+
+	info = parseInfo(&board, "score mate -3 lowerbound currmove g1f3 currmovenumber 4")
+	if !info.Score.IsMate || info.Score.Mate != -3 || !info.Score.LowerBound || info.CurrMove.Uci() != "g1f3" || info.CurrMoveNumber != 4 {
+		t.Errorf("info not matching: %+v", info)
+	}
+
+	info = parseInfo(&board, "depth 1 string depth  2 pv e2e4")
+	if info.Depth != 1 || info.String != "depth  2 pv e2e4" || info.PV != nil {
+		t.Errorf("expected the rest to be text, got %+v", info)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	limit := Limit{WhiteTime: time.Minute, BlackTime: 30 * time.Second, WhiteInc: time.Second, MovesToGo: 20, Depth: 10}
+	if command := goCommand(&limit); command != "go wtime 60000 btime 30000 winc 1000 movestogo 20 depth 10" {
+		t.Errorf("go command not matching: %s", command)
+	}
+
+	board := core.NewDefaultBoard()
+	if command := positionCommand(&board); command != "position startpos" {
+		t.Errorf("position command not matching: %s", command)
+	}
+
+	board = core.NewBoardFromFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", false)
+	board.PushUci("e1g1")
+	if command := positionCommand(&board); command != "position fen r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1 moves e1g1" {
+		t.Errorf("position command not matching: %s", command)
+	}
+}
+
+// nextInfo waits for an info of the analysis, failing on timeout.
+func nextInfo(t *testing.T, a *Analysis) Info {
+	select {
+	case info, ok := <-a.Infos:
+		if !ok {
+			t.Fatalf("analysis ended unexpectedly")
+		}
+		return info
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for info")
+	}
+
+	return Info{}
+}
+
+func TestAnalysis(t *testing.T) {
+	e := startFakeUCI(t)
+	defer e.Quit()
+
+	board := core.NewDefaultBoard()
+
+	t.Run("multipv", func(t *testing.T) {
+		a, err := e.Analyse(&board, 3, Limit{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := e.Play(&board, Limit{Depth: 1}); err == nil {
+			t.Errorf("expected engine to be busy")
+		}
+
+		// String, then 3 lines for each of 2 depths
+		for i := 0; i < 7; i++ {
+			nextInfo(t, a)
+		}
+
+		lines := a.Lines()
+		if len(lines) != 3 {
+			t.Fatalf("expected 3 lines, got %v", lines)
+		}
+
+		for i, uci := range []string{"a2a3", "a2a4", "b1a3"} {
+			if lines[i].MultiPV != i+1 || lines[i].Depth != 2 || lines[i].PV[0].Uci() != uci || lines[i].Score.CP != 20-i {
+				t.Errorf("line %d not matching: %+v", i+1, lines[i])
+			}
+		}
+
+		result, err := a.Stop()
+		if err != nil || result.Move.Uci() != "a2a3" || result.Ponder.Uci() != "a7a5" || result.Info.Depth != 2 {
+			t.Fatalf("result not matching: %v %v", result, err)
+		}
+
+		if _, ok := <-a.Infos; ok {
+			t.Errorf("expected infos to be closed")
+		}
+
+		if err := a.SetPosition(&board); err == nil {
+			t.Errorf("expected analysis to have ended")
+		}
+
+		// Playing searches a single line again
+		if result, err := e.Play(&board, Limit{Depth: 1}); err != nil || result.Move.Uci() != "a2a3" || e.multiPV != "1" {
+			t.Errorf("expected engine to be idle and search one line, got %v %v with multipv %s", result, err, e.multiPV)
+		}
+	})
+
+	t.Run("set position", func(t *testing.T) {
+		a, err := e.Analyse(&board, 1, Limit{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		nextInfo(t, a)
+
+		after := core.NewDefaultBoard()
+		after.PushUci("e2e4")
+		if err := a.SetPosition(&after); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Skip updates queued before the position was set
+		for {
+			info := nextInfo(t, a)
+			if info.Depth == 2 && info.PV[0].Uci() == "a7a5" {
+				break
+			}
+		}
+
+		if lines := a.Lines(); len(lines) != 1 || lines[0].PV[0].Uci() != "a7a5" || lines[0].String != "position startpos moves e2e4" {
+			t.Errorf("expected lines of the new position, got %v", lines)
+		}
+
+		if result, err := a.Stop(); err != nil || result.Move.Uci() != "a7a5" {
+			t.Errorf("result not matching: %v %v", result, err)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		a, err := e.Analyse(&board, 2, Limit{Depth: 3})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		result, err := a.Wait()
+		if err != nil || result.Move.Uci() != "a2a3" || len(a.Lines()) != 2 || a.Lines()[1].Depth != 3 {
+			t.Errorf("result not matching: %v %v", result, err)
+		}
+
+		if _, err := e.Analyse(&board, 501, Limit{}); err == nil {
+			t.Errorf("expected multipv to be out of range")
+		}
+	})
+}
+
+// startUCIServer serves the material search to a client over pipes.
+func startUCIServer(t *testing.T) *UCIEngine {
+	serverIn, w := io.Pipe()
+	r, serverOut := io.Pipe()
+	go func() {
+		server := NewUCIServer("golang-chess", "test", MaterialSearcher{})
+		server.Serve(serverIn, serverOut)
+		serverOut.Close()
+	}()
+
+	e, err := NewUCIEngine(r, w)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return e
+}
+
+func TestUCIServer(t *testing.T) {
+	e := startUCIServer(t)
+	defer e.Quit()
+
+	if e.Name() != "golang-chess" || e.ID["author"] != "test" {
+		t.Errorf("id not matching: %v", e.ID)
+	}
+
+	if _, ok := e.Options["uci_chess960"]; !ok {
+		t.Errorf("expected UCI_Chess960 option, got %v", e.Options)
+	}
+
+	tests := []struct {
+		name  string
+		fen   string
+		limit Limit
+		move  string
+		mate  int
+	}{
+		{"capture", "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1", Limit{Depth: 2}, "d2d5", 0},
+		{"mate in 1", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", Limit{Depth: 5}, "a1a8", 1},
+		{"mate in 2", "k7/8/2K5/8/8/8/8/7R w - - 0 1", Limit{Mate: 2}, "", 2},
+		{"mated", "7k/4Q3/6K1/8/8/8/8/8 b - - 0 1", Limit{Depth: 3}, "h8g8", -1},
+		{"no moves", "7k/6Q1/6K1/8/8/8/8/8 b - - 0 1", Limit{Depth: 3}, "(none)", 0},
+		{"nodes", core.StartingFEN, Limit{Nodes: 1}, "", 0},
+		{"movetime", core.StartingFEN, Limit{MoveTime: 50 * time.Millisecond}, "", 0},
+		{"clock", core.StartingFEN, Limit{WhiteTime: time.Second, BlackTime: time.Second}, "", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			board := core.NewBoardFromFEN(test.fen, false)
+
+			start := time.Now()
+			result, err := e.Play(&board, test.limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if time.Since(start) > 5*time.Second {
+				t.Errorf("search took %v", time.Since(start))
+			}
+
+			switch {
+			case test.move == "(none)":
+				if result.Move != nil || !result.Info.Score.IsMate || result.Info.Score.Mate != 0 {
+					t.Errorf("expected no move, got %v %+v", result.Move, result.Info.Score)
+				}
+				return
+			case result.Move == nil || !board.IsLegal(result.Move):
+				t.Fatalf("expected a legal move, got %v", result.Move)
+			case test.move != "" && result.Move.Uci() != test.move:
+				t.Errorf("expected %s, got %s", test.move, result.Move.Uci())
+			}
+
+			if test.mate != 0 && (result.Info.Score == nil || !result.Info.Score.IsMate || result.Info.Score.Mate != test.mate) {
+				t.Errorf("expected mate in %d, got %+v", test.mate, result.Info.Score)
+			}
+		})
+	}
+
+	t.Run("analysis", func(t *testing.T) {
+		board := core.NewDefaultBoard()
+		board.PushUci("e2e4")
+
+		a, err := e.Analyse(&board, 1, Limit{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		info := nextInfo(t, a)
+		if info.Depth != 1 || len(info.PV) == 0 {
+			t.Errorf("info not matching: %+v", info)
+		}
+
+		result, err := a.Stop()
+		if err != nil || result.Move == nil || !board.IsLegal(result.Move) {
+			t.Errorf("expected a legal move, got %v %v", result, err)
+		}
+	})
+
+	t.Run("chess960", func(t *testing.T) {
+		board := core.NewBoardFromFEN("1r2k3/8/8/8/8/8/8/1R2K1R1 w GBb - 0 1", true)
+		board.PushUci("e1g1")
+		board.PushUci("e8b8")
+
+		result, err := e.Play(&board, Limit{Depth: 1})
+		if err != nil || result.Move == nil || !board.IsLegal(result.Move) {
+			t.Errorf("expected a legal move, got %v %v", result, err)
+		}
+	})
+
+	t.Run("invalid position", func(t *testing.T) {
+		serverIn, w := io.Pipe()
+		r, serverOut := io.Pipe()
+		go func() {
+			NewUCIServer("golang-chess", "test", MaterialSearcher{}).Serve(serverIn, serverOut)
+			serverOut.Close()
+		}()
+		defer w.Close()
+
+		scanner := bufio.NewScanner(r)
+		search := func(position string) (info, bestMove string) {
+			io.WriteString(w, position+"\ngo depth 1\n")
+			for scanner.Scan() {
+				line := scanner.Text()
+				if strings.HasPrefix(line, "info string ") {
+					info = line
+				} else if strings.HasPrefix(line, "bestmove ") {
+					return info, strings.Fields(line)[1]
+				}
+			}
+			return info, ""
+		}
+
+		if _, bestMove := search("position startpos moves e2e4"); bestMove == "" {
+			t.Fatal("expected a move")
+		}
+
+		// The moves up to the illegal one are played, instead of keeping the
+		// previous position with black to move
+		info, bestMove := search("position startpos moves e2e4 e7e5 e1e3 d2d4")
+		board := core.NewDefaultBoard()
+		board.PushUci("e2e4")
+		board.PushUci("e7e5")
+		if _, err := board.ParseUci(bestMove); info == "" || err != nil {
+			t.Errorf("expected a legal move after 1. e4 e5, got %q %q", info, bestMove)
+		}
+
+		if info, bestMove := search("position fen 8/8 w - - 0 1"); info == "" || bestMove != "(none)" {
+			t.Errorf("expected no move for an invalid position, got %q %q", info, bestMove)
+		}
+	})
+}
+
+func TestParsePosition(t *testing.T) {
+	board, err := parsePosition("startpos moves e2e4 e7e5", false)
+	if err != nil || board.FEN(false, "legal", core.NoPiece) != "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2" {
+		t.Errorf("position not matching: %v %v", board.FEN(false, "legal", core.NoPiece), err)
+	}
+
+	board, err = parsePosition("fen 1r2k3/8/8/8/8/8/8/1R2K1R1 w GBb - 0 1 moves e1g1 e8b8", true)
+	if err != nil || board.FEN(true, "legal", core.NoPiece) != "2kr4/8/8/8/8/8/8/1R3RK1 w - - 2 2" {
+		t.Errorf("chess960 position not matching: %v %v", board.FEN(true, "legal", core.NoPiece), err)
+	}
+
+	for _, args := range []string{"", "fen 8/8 w - - 0 1", "startpos moves e2e5", "moves e2e4"} {
+		if _, err := parsePosition(args, false); err == nil {
+			t.Errorf("expected error for %q", args)
+		}
+	}
+
+	limit := parseGo("wtime 60000 btime 30000 winc 1000 movestogo 20 depth 10 nodes 5000 mate 3 movetime 100")
+	if limit != (Limit{Depth: 10, Nodes: 5000, Mate: 3, MoveTime: 100 * time.Millisecond, WhiteTime: time.Minute,
+		BlackTime: 30 * time.Second, WhiteInc: time.Second, MovesToGo: 20}) {
+		t.Errorf("limit not matching: %+v", limit)
+	}
+
+	if limit := parseGo("depth 3 infinite"); !limit.isZero() {
+		t.Errorf("expected infinite search, got %+v", limit)
+	}
+
+	start := core.NewDefaultBoard()
+	text := "depth 3 seldepth 5 multipv 2 score mate -2 lowerbound nodes 100 nps 2000 time 50 pv e2e4 e7e5 string a b"
+	info := parseInfo(&start, text)
+	if formatted := formatInfo(&info); formatted != text {
+		t.Errorf("formatted info not matching: %s", formatted)
+	}
+}
+
+func startXBoard(t *testing.T) *XBoardEngine {
+	os.Setenv(fakeEngineEnv, "xboard")
+	defer os.Unsetenv(fakeEngineEnv)
+
+	e, err := StartXBoard(os.Args[0])
+	if err != nil {
+		t.Fatalf("failed to start the engine: %v", err)
+	}
+
+	return e
+}
+
+// scriptedXBoard performs the handshake with an engine answering commands
+// with the lines of a script, and pings with pongs.
+func scriptedXBoard(t *testing.T, script map[string][]string) *XBoardEngine {
+	engineIn, w := io.Pipe()
+	r, engineOut := io.Pipe()
+	go func() {
+		scanner := bufio.NewScanner(engineIn)
+		for scanner.Scan() {
+			command, args := splitCommand(scanner.Text())
+			if command == "ping" {
+				fmt.Fprintln(engineOut, "pong "+args)
+			}
+
+			for _, line := range script[command] {
+				fmt.Fprintln(engineOut, line)
+			}
+		}
+		engineOut.Close()
+	}()
+
+	e, err := NewXBoardEngine(r, w)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return e
+}
+
+func TestXBoardEngine(t *testing.T) {
+	e := startXBoard(t)
+	defer e.Quit()
+
+	if e.Name() != "golang-chess" || e.Features["setboard"] != "1" || e.Features["usermove"] != "1" {
+		t.Errorf("features not matching: %v", e.Features)
+	}
+
+	castled := core.NewBoardFromFEN("1r2k3/8/8/8/8/8/8/1R2K1R1 w GBb - 0 1", true)
+	castled.PushUci("e1g1")
+	castled.PushUci("e8b8")
+
+	moved := core.NewDefaultBoard()
+	moved.PushUci("e2e4")
+	moved.PushUci("e7e5")
+
+	tests := []struct {
+		name  string
+		board core.Board
+		limit Limit
+		move  string
+		mate  int
+	}{
+		{"start", core.NewDefaultBoard(), Limit{Depth: 2}, "", 0},
+		{"moves", moved, Limit{Depth: 2}, "", 0},
+		{"mate in 1", core.NewBoardFromFEN("6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", false), Limit{Depth: 3}, "a1a8", 1},
+		{"mated", core.NewBoardFromFEN("7k/4Q3/6K1/8/8/8/8/8 b - - 0 1", false), Limit{Depth: 3}, "h8g8", -1},
+		{"chess960", castled, Limit{Depth: 1}, "", 0},
+		{"clock", core.NewDefaultBoard(), Limit{WhiteTime: 2 * time.Second, BlackTime: 2 * time.Second, MovesToGo: 40}, "", 0},
+		{"movetime", core.NewDefaultBoard(), Limit{MoveTime: 10 * time.Millisecond}, "", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := e.Play(&test.board, test.limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Move == nil || !test.board.IsLegal(result.Move) {
+				t.Fatalf("expected a legal move, got %v", result.Move)
+			}
+
+			if test.move != "" && result.Move.Uci() != test.move {
+				t.Errorf("expected %s, got %s", test.move, result.Move.Uci())
+			}
+
+			// Searches end early once a mate is found
+			if test.limit.Depth != 0 && test.mate == 0 && result.Info.Depth != test.limit.Depth {
+				t.Errorf("expected thinking output up to depth %d, got %+v", test.limit.Depth, result.Info)
+			}
+
+			if test.mate != 0 && (result.Info.Score == nil || !result.Info.Score.IsMate || result.Info.Score.Mate != test.mate) {
+				t.Errorf("expected mate in %d, got %+v", test.mate, result.Info.Score)
+			}
+		})
+	}
+
+	t.Run("game over", func(t *testing.T) {
+		board := core.NewBoardFromFEN("7k/6Q1/6K1/8/8/8/8/8 b - - 0 1", false)
+		result, err := e.Play(&board, Limit{Depth: 1})
+		if err != nil || result.Move != nil {
+			t.Errorf("expected no move, got %v %v", result, err)
+		}
+
+		if err := e.GameOver(&board); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if err := e.Ping(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unsupported limit", func(t *testing.T) {
+		board := core.NewDefaultBoard()
+		if _, err := e.Play(&board, Limit{Nodes: 1000}); err == nil {
+			t.Errorf("expected node limit to be unsupported")
+		}
+	})
+
+	t.Run("features without done", func(t *testing.T) {
+		defer func(timeout time.Duration) { featureTimeout = timeout }(featureTimeout)
+		featureTimeout = 100 * time.Millisecond
+
+		e := scriptedXBoard(t, map[string][]string{"protover": {`feature ping=1 setboard=1 myname="Scripted"`}})
+		defer e.Quit()
+
+		if e.Name() != "Scripted" || e.Features["setboard"] != "1" {
+			t.Errorf("features not matching: %v", e.Features)
+		}
+	})
+
+	t.Run("results", func(t *testing.T) {
+		features := []string{"feature ping=1 setboard=1 usermove=1 done=1"}
+		board := core.NewDefaultBoard()
+
+		e := scriptedXBoard(t, map[string][]string{"protover": features, "go": {"0-1 {White resigns}"}})
+		result, err := e.Play(&board, Limit{Depth: 1})
+		if err != nil || result.Move != nil || !result.Resigned {
+			t.Errorf("expected resignation, got %+v (%v)", result, err)
+		}
+		e.Quit()
+
+		e = scriptedXBoard(t, map[string][]string{"protover": features, "go": {"1/2-1/2 {Draw}"}})
+		if result, err := e.Play(&board, Limit{Depth: 1}); err == nil {
+			t.Errorf("expected error for a claimed draw, got %+v", result)
+		}
+		e.Quit()
+	})
+}
+
+func TestXBoardServer(t *testing.T) {
+	serverIn, w := io.Pipe()
+	r, serverOut := io.Pipe()
+	go func() {
+		NewXBoardServer("golang-chess", MaterialSearcher{}).Serve(serverIn, serverOut)
+		serverOut.Close()
+	}()
+	defer w.Close()
+
+	lines := make(chan string, 1024)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	// expect sends commands and waits for a line starting with prefix
+	expect := func(prefix string, commands ...string) string {
+		for _, command := range commands {
+			fmt.Fprintln(w, command)
+		}
+
+		for {
+			select {
+			case line := <-lines:
+				if strings.HasPrefix(line, prefix) {
+					return line
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for %q", prefix)
+			}
+		}
+	}
+
+	expect("feature ping=1", "xboard", "protover 2")
+	expect("pong 1", "new", "sd 1", "ping 1")
+
+	// The engine plays black and answers moves
+	if line := expect("move ", "usermove e2e4"); !strings.HasPrefix(line, "move ") {
+		t.Errorf("expected a move, got %s", line)
+	}
+
+	expect("Illegal move: e2e5", "usermove e2e5")
+	expect("Error (unknown command): foo", "foo")
+
+	// Mate is answered with the result
+	expect("1-0 {White mates}", "new", "force", "setboard 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "usermove a1a8")
+
+	// Move now ends the search early
+	expect("pong 2", "new", "force", "setboard 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "st 30", "ping 2")
+	start := time.Now()
+	expect("move ", "go", "?")
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("expected move now to end the search, took %v", time.Since(start))
+	}
+
+	// Searches stopped by force are not played
+	expect("pong 3", "new", "st 30", "post", "go", "force", "undo", "ping 3")
+	expect("move ", "sd 2", "go")
+
+	expect("", "quit")
+}
+
+func TestCECPParsing(t *testing.T) {
+	features := parseFeatures(`ping=1 myname="Foo Bar 1.0" variants="normal,fischerandom" done=0`)
+	if len(features) != 4 || features[1] != [2]string{"myname", "Foo Bar 1.0"} || features[3] != [2]string{"done", "0"} {
+		t.Errorf("features not matching: %v", features)
+	}
+
+	options := []struct {
+		feature string
+		option  Option
+	}{
+		{"Hash -spin 64 1 1024", Option{Name: "Hash", Type: "spin", Default: "64", Min: 1, Max: 1024}},
+		{"Use Book -check 1", Option{Name: "Use Book", Type: "check", Default: "true"}},
+		{"Clear Hash -button", Option{Name: "Clear Hash", Type: "button"}},
+		{"Book File -file book.bin", Option{Name: "Book File", Type: "string", Default: "book.bin"}},
+	}
+	for _, test := range options {
+		if o := parseXBoardOption(test.feature); fmt.Sprint(o) != fmt.Sprint(test.option) {
+			t.Errorf("option %q not matching: %+v", test.feature, o)
+		}
+	}
+
+	combo := parseXBoardOption("Style -combo Solid /// *Risky Play /// Normal")
+	if combo.Default != "Risky Play" || len(combo.Vars) != 3 || combo.check("normal") != nil || combo.check("Wild") == nil {
+		t.Errorf("combo not matching: %+v", combo)
+	}
+
+	board := core.NewDefaultBoard()
+	info, ok := parseThinking(&board, "9 -156 1084 48000 1. e4 e5 2. Nf3 xx")
+	if !ok || info.Depth != 9 || info.Score.CP != -156 || info.Time != 10840*time.Millisecond || info.Nodes != 48000 ||
+		len(info.PV) != 3 || info.PV[2].Uci() != "g1f3" {
+		t.Errorf("thinking not matching: %+v", info)
+	}
+
+	info, ok = parseThinking(&board, "5 100003 10 500 7 4400 2\te2e4 e7e5")
+	if !ok || !info.Score.IsMate || info.Score.Mate != 3 || info.SelDepth != 7 || info.NPS != 4400 || info.TBHits != 2 || len(info.PV) != 2 {
+		t.Errorf("thinking not matching: %+v", info)
+	}
+
+	if _, ok := parseThinking(&board, "1-0 {White mates}"); ok {
+		t.Errorf("expected result not to be thinking output")
+	}
+
+	info = Info{Depth: 3, Score: &Score{Mate: -2, IsMate: true}, Time: time.Second, Nodes: 42, PV: info.PV}
+	if text := formatThinking(&board, &info); text != "3 -100002 100 42\te4 e5" {
+		t.Errorf("formatted thinking not matching: %q", text)
+	}
+
+	limit := Limit{WhiteTime: 90 * time.Second, BlackTime: time.Minute, WhiteInc: 1500 * time.Millisecond, MovesToGo: 20, Depth: 8}
+	if commands, err := limitCommands(&board, &limit); err != nil || strings.Join(commands, ", ") != "sd 8, level 20 1:30 1.5, time 9000, otim 6000" {
+		t.Errorf("limit commands not matching: %v %v", commands, err)
+	}
+
+	stalemate := core.NewBoardFromFEN("7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", false)
+	mate := core.NewBoardFromFEN("7k/6Q1/6K1/8/8/8/8/8 b - - 0 1", false)
+	for _, test := range []struct {
+		board  core.Board
+		result string
+	}{
+		{stalemate, "1/2-1/2 {Stalemate}"},
+		{mate, "1-0 {White mates}"},
+		{core.NewBoardFromFEN("7k/8/6K1/8/8/8/8/8 w - - 0 1", false), "1/2-1/2 {Insufficient material}"},
+		{core.NewDefaultBoard(), "* {Unfinished}"},
+	} {
+		if text := resultText(&test.board); text != test.result {
+			t.Errorf("expected %s, got %s", test.result, text)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// Searcher chooses moves for a UCIServer. Search must return soon after stop
+// is closed and may report its progress with info. The board belongs to the
+// search. A zero limit searches until stopped.
+type Searcher interface {
+	Search(board *core.Board, limit Limit, stop <-chan struct{}, info func(*Info)) PlayResult
+}
+
+// SearchFunc adapts a function to a Searcher.
+type SearchFunc func(board *core.Board, limit Limit, stop <-chan struct{}, info func(*Info)) PlayResult
+
+func (f SearchFunc) Search(board *core.Board, limit Limit, stop <-chan struct{}, info func(*Info)) PlayResult {
+	return f(board, limit, stop, info)
+}
+
+// budget returns the time to spend on a move of the given side, or 0 when
+// the search is not timed.
+func (l *Limit) budget(turn core.Color) time.Duration {
+	if l.MoveTime != 0 {
+		return l.MoveTime
+	}
+
+	clock, inc := l.BlackTime, l.BlackInc
+	if turn == core.White {
+		clock, inc = l.WhiteTime, l.WhiteInc
+	}
+
+	if clock == 0 {
+		return 0
+	}
+
+	movesToGo := l.MovesToGo
+	if movesToGo == 0 {
+		movesToGo = 30
+	}
+
+	budget := clock/time.Duration(movesToGo) + inc/2
+	if budget > clock/2 {
+		budget = clock / 2
+	}
+
+	return budget
+}
+
+const (
+	maxSearchDepth = 64
+	mateScore      = 100000
+	infiniteScore  = mateScore + 1
+)
+
+var pieceValues = [...]int{core.Pawn: 100, core.Knight: 300, core.Bishop: 300, core.Rook: 500, core.Queen: 900}
+
+// MaterialSearcher is an alpha-beta search counting material only. It knows
+// the rules but not much more, which is enough to test GUIs and tools.
+type MaterialSearcher struct{}
+
+type materialSearch struct {
+	board *core.Board
+	start time.Time
+
+	stop     <-chan struct{}
+	deadline time.Time
+	maxNodes uint64
+
+	nodes   uint64
+	aborted bool
+}
+
+func (MaterialSearcher) Search(board *core.Board, limit Limit, stop <-chan struct{}, info func(*Info)) PlayResult {
+	s := &materialSearch{board: board, start: time.Now(), stop: stop, maxNodes: limit.Nodes}
+	if budget := limit.budget(board.Turn()); budget != 0 {
+		s.deadline = s.start.Add(budget)
+	}
+
+	maxDepth := maxSearchDepth
+	if limit.Depth != 0 {
+		maxDepth = limit.Depth
+	}
+	if limit.Mate != 0 && 2*limit.Mate-1 < maxDepth {
+		maxDepth = 2*limit.Mate - 1
+	}
+
+	result := PlayResult{}
+	for depth := 1; depth <= maxDepth; depth++ {
+		score, pv := s.negamax(depth, 0, -infiniteScore, infiniteScore, result.Info.PV)
+
+		// Moves of an unfinished iteration are only better than nothing
+		if s.aborted && result.Move != nil {
+			break
+		}
+
+		if s.aborted && len(pv) == 0 {
+			pv = board.LegalMoves(nil)
+			if len(pv) > 1 {
+				pv = pv[:1]
+			}
+		}
+
+		result.Info = s.info(depth, score, pv)
+		result.Move, result.Ponder = nil, nil
+		if len(pv) > 0 {
+			result.Move = &pv[0]
+		}
+		if len(pv) > 1 {
+			result.Ponder = &pv[1]
+		}
+
+		if s.aborted {
+			break
+		}
+
+		info(&result.Info)
+
+		// The first mate found is the shortest
+		if len(pv) == 0 || result.Info.Score.IsMate {
+			break
+		}
+	}
+
+	return result
+}
+
+func (s *materialSearch) info(depth int, score int, pv []core.Move) Info {
+	elapsed := time.Since(s.start)
+
+	info := Info{Depth: depth, Score: &Score{CP: score}, PV: pv, Nodes: s.nodes, Time: elapsed}
+	if elapsed > 0 {
+		info.NPS = uint64(float64(s.nodes) / elapsed.Seconds())
+	}
+
+	if score > mateScore-maxSearchDepth {
+		info.Score = &Score{Mate: (mateScore - score + 1) / 2, IsMate: true}
+	} else if score < -mateScore+maxSearchDepth {
+		info.Score = &Score{Mate: -(mateScore + score) / 2, IsMate: true}
+	}
+
+	return info
+}
+
+// checkAbort sets aborted when the search is out of time or nodes, or is
+// stopped.
+func (s *materialSearch) checkAbort() {
+	if s.maxNodes != 0 && s.nodes >= s.maxNodes {
+		s.aborted = true
+	}
+
+	if s.nodes%1024 != 0 {
+		return
+	}
+
+	if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		s.aborted = true
+	}
+
+	select {
+	case <-s.stop:
+		s.aborted = true
+	default:
+	}
+}
+
+func (s *materialSearch) evaluate() int {
+	score := 0
+	for pt := core.Pawn; pt < core.King; pt++ {
+		score += pieceValues[pt] * s.board.Pieces(pt, core.White).PopCount()
+		score -= pieceValues[pt] * s.board.Pieces(pt, core.Black).PopCount()
+	}
+
+	if s.board.Turn() == core.Black {
+		return -score
+	}
+
+	return score
+}
+
+// orderMoves sorts the move of the previous line first, then captures of
+// the most valuable pieces.
+func (s *materialSearch) orderMoves(moves []core.Move, first []core.Move) {
+	value := func(m *core.Move) int {
+		if len(first) > 0 && *m == first[0] {
+			return 2 * infiniteScore
+		}
+
+		if s.board.IsEnPassant(m) {
+			return pieceValues[core.Pawn]
+		}
+
+		if s.board.IsCapture(m) {
+			return pieceValues[s.board.PieceTypeAt(m.ToSquare)] + pieceValues[m.Promotion]
+		}
+
+		return pieceValues[m.Promotion]
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		return value(&moves[i]) > value(&moves[j])
+	})
+}
+
+// negamax returns the score of the position for the side to move and the
+// line leading to it, trying the moves of the previous line first.
+func (s *materialSearch) negamax(depth, ply, alpha, beta int, previous []core.Move) (int, []core.Move) {
+	s.nodes++
+	s.checkAbort()
+	if s.aborted && ply > 0 {
+		return 0, nil
+	}
+
+	moves := s.board.LegalMoves(nil)
+	if len(moves) == 0 {
+		if s.board.IsCheck() {
+			return -mateScore + ply, nil
+		}
+		return 0, nil
+	}
+
+	if ply > 0 && (s.board.IsInsufficientMaterial() || s.board.HalfMoveClock() >= 100) {
+		return 0, nil
+	}
+
+	if depth == 0 {
+		return s.quiesce(alpha, beta), nil
+	}
+
+	s.orderMoves(moves, previous)
+
+	var pv []core.Move
+	for i := range moves {
+		var next []core.Move
+		if len(previous) > 0 && moves[i] == previous[0] {
+			next = previous[1:]
+		}
+
+		s.board.Push(&moves[i])
+		score, line := s.negamax(depth-1, ply+1, -beta, -alpha, next)
+		score = -score
+		s.board.Pop()
+
+		if s.aborted {
+			break
+		}
+
+		if score > alpha || pv == nil {
+			pv = append([]core.Move{moves[i]}, line...)
+		}
+
+		if score > alpha {
+			alpha = score
+			if alpha >= beta {
+				break
+			}
+		}
+	}
+
+	return alpha, pv
+}
+
+// quiesce searches captures until the position is quiet, so that the
+// material count is not taken in the middle of an exchange.
+func (s *materialSearch) quiesce(alpha, beta int) int {
+	standPat := s.evaluate()
+	if standPat >= beta {
+		return standPat
+	}
+	if standPat > alpha {
+		alpha = standPat
+	}
+
+	moves := s.board.LegalMoves(nil)
+	captures := moves[:0]
+	for _, m := range moves {
+		if s.board.IsCapture(&m) {
+			captures = append(captures, m)
+		}
+	}
+
+	s.orderMoves(captures, nil)
+
+	for i := range captures {
+		s.nodes++
+		s.checkAbort()
+		if s.aborted {
+			return alpha
+		}
+
+		s.board.Push(&captures[i])
+		score := -s.quiesce(-beta, -alpha)
+		s.board.Pop()
+
+		if score >= beta {
+			return score
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+
+	return alpha
+}
@@ -0,0 +1,531 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// Option is an option declared by a UCI engine.
+type Option struct {
+	Name string
+
+	// One of check, spin, combo, button and string
+	Type string
+
+	Default string
+	Min     int
+	Max     int
+
+	// Values of a combo option
+	Vars []string
+}
+
+// parseOption parses the arguments of an option line, like
+// "name Hash type spin default 16 min 1 max 1024".
+func parseOption(args string) Option {
+	o := Option{}
+
+	key := ""
+	values := map[string][]string{}
+	for _, token := range strings.Fields(args) {
+		switch token {
+		case "name", "type", "default", "min", "max":
+			key = token
+			values[key] = []string{}
+		case "var":
+			key = token
+			o.Vars = append(o.Vars, "")
+		default:
+			if key == "var" {
+				v := &o.Vars[len(o.Vars)-1]
+				*v = strings.TrimSpace(*v + " " + token)
+			} else if key != "" {
+				values[key] = append(values[key], token)
+			}
+		}
+	}
+
+	o.Name = strings.Join(values["name"], " ")
+	o.Type = strings.Join(values["type"], " ")
+	o.Default = strings.Join(values["default"], " ")
+	if o.Default == "<empty>" {
+		o.Default = ""
+	}
+	o.Min, _ = strconv.Atoi(strings.Join(values["min"], ""))
+	o.Max, _ = strconv.Atoi(strings.Join(values["max"], ""))
+
+	return o
+}
+
+// check returns an error if the value is not valid for the option.
+func (o *Option) check(value string) error {
+	invalid := &EngineError{description: fmt.Sprintf("invalid value for option %s: %q", o.Name, value)}
+
+	switch o.Type {
+	case "check":
+		if value != "true" && value != "false" {
+			return invalid
+		}
+	case "spin":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < o.Min || n > o.Max {
+			return invalid
+		}
+	case "combo":
+		for _, v := range o.Vars {
+			if strings.EqualFold(v, value) {
+				return nil
+			}
+		}
+		return invalid
+	}
+
+	return nil
+}
+
+// UCIEngine is an engine speaking the Universal Chess Interface. Calls must
+// not be made concurrently.
+type UCIEngine struct {
+	p *process
+
+	// Sent with "id", usually name and author
+	ID map[string]string
+
+	// Declared options, by lower case name as UCI names are case insensitive
+	Options map[string]Option
+
+	chess960 bool
+
+	// Value of the MultiPV option last sent, empty before the first search
+	multiPV string
+
+	// The running analysis, guarded by mu
+	mu       sync.Mutex
+	analysis *Analysis
+}
+
+// StartUCI launches an engine and performs the UCI handshake.
+func StartUCI(command string, args ...string) (*UCIEngine, error) {
+	p, err := startProcess(command, args)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &UCIEngine{p: p}
+	if err := e.handshake(); err != nil {
+		p.close()
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// NewUCIEngine performs the UCI handshake with an engine that reads commands
+// from w and answers on r, like an engine reached over the network.
+func NewUCIEngine(r io.Reader, w io.WriteCloser) (*UCIEngine, error) {
+	e := &UCIEngine{p: newProcess(r, w)}
+	if err := e.handshake(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *UCIEngine) handshake() error {
+	e.ID = map[string]string{}
+	e.Options = map[string]Option{}
+
+	if err := e.p.send("uci"); err != nil {
+		return err
+	}
+
+	timeout := time.After(handshakeTimeout)
+	for {
+		line, err := e.p.recv(timeout)
+		if err != nil {
+			return err
+		}
+
+		command, args := splitCommand(line)
+		switch command {
+		case "id":
+			key, value := splitCommand(args)
+			e.ID[key] = value
+		case "option":
+			o := parseOption(args)
+			e.Options[strings.ToLower(o.Name)] = o
+		case "uciok":
+			return e.Ping()
+		}
+	}
+}
+
+// checkIdle returns an error while an analysis is running, as its output
+// would be mixed up with the answers of other commands.
+func (e *UCIEngine) checkIdle() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.analysis != nil {
+		return &EngineError{description: "engine is busy analysing"}
+	}
+
+	return nil
+}
+
+// Ping waits for the engine to be ready for commands.
+func (e *UCIEngine) Ping() error {
+	if err := e.checkIdle(); err != nil {
+		return err
+	}
+
+	if err := e.p.send("isready"); err != nil {
+		return err
+	}
+
+	for {
+		line, err := e.p.recv(nil)
+		if err != nil {
+			return err
+		}
+
+		if line == "readyok" {
+			return nil
+		}
+	}
+}
+
+// Name returns the name the engine identified with.
+func (e *UCIEngine) Name() string {
+	return e.ID["name"]
+}
+
+func (e *UCIEngine) setOption(o *Option, value string) error {
+	if o.Type == "button" {
+		return e.p.send("setoption name " + o.Name)
+	}
+
+	return e.p.send("setoption name " + o.Name + " value " + value)
+}
+
+// Configure sets options of the engine. Values are checked against the
+// declarations, the value of a button is ignored.
+func (e *UCIEngine) Configure(options map[string]string) error {
+	if err := e.checkIdle(); err != nil {
+		return err
+	}
+
+	for name, value := range options {
+		o, ok := e.Options[strings.ToLower(name)]
+		if !ok {
+			return &EngineError{description: "engine does not support option " + name}
+		}
+
+		if err := o.check(value); err != nil {
+			return err
+		}
+
+		if err := e.setOption(&o, value); err != nil {
+			return err
+		}
+	}
+
+	return e.Ping()
+}
+
+// NewGame tells the engine that the next search is from a different game.
+func (e *UCIEngine) NewGame() error {
+	if err := e.checkIdle(); err != nil {
+		return err
+	}
+
+	if err := e.p.send("ucinewgame"); err != nil {
+		return err
+	}
+
+	return e.Ping()
+}
+
+// positionCommand returns the position command for the starting position of
+// the board and its move stack.
+func positionCommand(board *core.Board) string {
+	root := core.NewBoardFromBoard(board)
+	moves := root.MoveStack()
+	for range moves {
+		root.Pop()
+	}
+
+	command := "position"
+	if fen := root.FEN(root.IsChess960(), "fen", core.NoPiece); fen == core.StartingFEN {
+		command += " startpos"
+	} else {
+		command += " fen " + fen
+	}
+
+	if len(moves) > 0 {
+		command += " moves"
+		for _, m := range moves {
+			command += " " + m.Uci()
+		}
+	}
+
+	return command
+}
+
+// goCommand returns the go command for a limit.
+func goCommand(limit *Limit) string {
+	command := "go"
+
+	ms := func(d time.Duration) string {
+		return strconv.FormatInt(int64(d/time.Millisecond), 10)
+	}
+
+	if limit.WhiteTime != 0 {
+		command += " wtime " + ms(limit.WhiteTime)
+	}
+	if limit.BlackTime != 0 {
+		command += " btime " + ms(limit.BlackTime)
+	}
+	if limit.WhiteInc != 0 {
+		command += " winc " + ms(limit.WhiteInc)
+	}
+	if limit.BlackInc != 0 {
+		command += " binc " + ms(limit.BlackInc)
+	}
+	if limit.MovesToGo != 0 {
+		command += " movestogo " + strconv.Itoa(limit.MovesToGo)
+	}
+	if limit.Depth != 0 {
+		command += " depth " + strconv.Itoa(limit.Depth)
+	}
+	if limit.Nodes != 0 {
+		command += " nodes " + strconv.FormatUint(limit.Nodes, 10)
+	}
+	if limit.Mate != 0 {
+		command += " mate " + strconv.Itoa(limit.Mate)
+	}
+	if limit.MoveTime != 0 {
+		command += " movetime " + ms(limit.MoveTime)
+	}
+
+	return command
+}
+
+// setPosition sends the position of the board, switching the engine in and
+// out of Chess960 mode as needed.
+func (e *UCIEngine) setPosition(board *core.Board) error {
+	if board.IsChess960() != e.chess960 {
+		if o, ok := e.Options["uci_chess960"]; ok {
+			if err := e.setOption(&o, strconv.FormatBool(board.IsChess960())); err != nil {
+				return err
+			}
+		}
+
+		e.chess960 = board.IsChess960()
+	}
+
+	return e.p.send(positionCommand(board))
+}
+
+// Play searches the position of the board for the move to play. The board
+// is not changed.
+func (e *UCIEngine) Play(board *core.Board, limit Limit) (*PlayResult, error) {
+	if limit.isZero() {
+		return nil, &EngineError{description: "search limit required"}
+	}
+
+	if err := e.checkIdle(); err != nil {
+		return nil, err
+	}
+
+	// Analyses may have left more lines to search
+	if err := e.setMultiPV(1); err != nil {
+		return nil, err
+	}
+
+	if err := e.setPosition(board); err != nil {
+		return nil, err
+	}
+
+	if err := e.p.send(goCommand(&limit)); err != nil {
+		return nil, err
+	}
+
+	result := &PlayResult{}
+	for {
+		line, err := e.p.recv(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		command, args := splitCommand(line)
+		switch command {
+		case "info":
+			info := parseInfo(board, args)
+			if info.MultiPV <= 1 {
+				result.Info.merge(&info)
+			}
+		case "bestmove":
+			result.Move, result.Ponder = parseBestMove(board, args)
+			return result, nil
+		}
+	}
+}
+
+// Quit asks the engine to exit and waits for it. A running analysis ends
+// with an error.
+func (e *UCIEngine) Quit() error {
+	e.p.send("quit")
+	return e.p.close()
+}
+
+// parseBestMove parses the arguments of a bestmove line. Illegal moves are
+// returned as nil.
+func parseBestMove(board *core.Board, args string) (*core.Move, *core.Move) {
+	tokens := strings.Fields(args)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	b := core.NewBoardFromBoard(board)
+	move, err := b.ParseUci(tokens[0])
+	if err != nil {
+		return nil, nil
+	}
+
+	if len(tokens) < 3 || tokens[1] != "ponder" {
+		return move, nil
+	}
+
+	b.Push(move)
+	ponder, err := b.ParseUci(tokens[2])
+	if err != nil {
+		return move, nil
+	}
+
+	return move, ponder
+}
+
+// parseMoves parses a line of moves from the board, stopping at the first
+// token that is not a legal move. Returns the moves and the number of
+// tokens used.
+func parseMoves(board *core.Board, tokens []string) ([]core.Move, int) {
+	b := core.NewBoardFromBoard(board)
+	moves := []core.Move{}
+	for _, token := range tokens {
+		m, err := b.ParseUci(token)
+		if err != nil {
+			break
+		}
+
+		moves = append(moves, *m)
+		b.Push(m)
+	}
+
+	return moves, len(moves)
+}
+
+// parseInfo parses the arguments of an info line. Moves are relative to the
+// searched board.
+func parseInfo(board *core.Board, args string) Info {
+	info := Info{}
+
+	// The rest of the line is free text
+	if i := strings.Index(" "+args+" ", " string "); i >= 0 {
+		info.String = strings.TrimSpace(args[i+len("string"):])
+		args = args[:i]
+	}
+
+	tokens := strings.Fields(args)
+
+	integer := func(i int) int {
+		if i < len(tokens) {
+			n, _ := strconv.Atoi(tokens[i])
+			return n
+		}
+		return 0
+	}
+
+	unsigned := func(i int) uint64 {
+		if i < len(tokens) {
+			n, _ := strconv.ParseUint(tokens[i], 10, 64)
+			return n
+		}
+		return 0
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "depth":
+			i++
+			info.Depth = integer(i)
+		case "seldepth":
+			i++
+			info.SelDepth = integer(i)
+		case "multipv":
+			i++
+			info.MultiPV = integer(i)
+		case "nodes":
+			i++
+			info.Nodes = unsigned(i)
+		case "nps":
+			i++
+			info.NPS = unsigned(i)
+		case "time":
+			i++
+			info.Time = time.Duration(integer(i)) * time.Millisecond
+		case "hashfull":
+			i++
+			info.HashFull = integer(i)
+		case "tbhits":
+			i++
+			info.TBHits = unsigned(i)
+		case "currmovenumber":
+			i++
+			info.CurrMoveNumber = integer(i)
+		case "currmove":
+			i++
+			if i < len(tokens) {
+				b := core.NewBoardFromBoard(board)
+				if m, err := b.ParseUci(tokens[i]); err == nil {
+					info.CurrMove = m
+				}
+			}
+		case "score":
+			score := &Score{}
+		scoreTokens:
+			for i+1 < len(tokens) {
+				switch tokens[i+1] {
+				case "cp":
+					score.CP = integer(i + 2)
+					i += 2
+				case "mate":
+					score.Mate = integer(i + 2)
+					score.IsMate = true
+					i += 2
+				case "lowerbound":
+					score.LowerBound = true
+					i++
+				case "upperbound":
+					score.UpperBound = true
+					i++
+				default:
+					break scoreTokens
+				}
+			}
+			info.Score = score
+		case "pv":
+			pv, n := parseMoves(board, tokens[i+1:])
+			info.PV = pv
+			i += n
+		}
+	}
+
+	return info
+}
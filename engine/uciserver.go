@@ -0,0 +1,323 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// UCIServer is the engine side of the Universal Chess Interface, so that
+// GUIs can play against a Searcher.
+type UCIServer struct {
+	Name   string
+	Author string
+
+	Searcher Searcher
+
+	board    core.Board
+	chess960 bool
+
+	w      io.Writer
+	writeL sync.Mutex
+	err    error
+
+	// Set while searching, stop is closed to end the search and done once
+	// the best move is sent
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewUCIServer(name, author string, searcher Searcher) *UCIServer {
+	return &UCIServer{Name: name, Author: author, Searcher: searcher, board: core.NewDefaultBoard()}
+}
+
+// send writes a line to the GUI. Only the first error is kept.
+func (s *UCIServer) send(format string, a ...interface{}) {
+	s.writeL.Lock()
+	defer s.writeL.Unlock()
+
+	if s.err != nil {
+		return
+	}
+
+	if _, err := fmt.Fprintf(s.w, format+"\n", a...); err != nil {
+		s.err = err
+	}
+}
+
+// Serve answers the commands read from r until quit or the end of input.
+// Unknown commands are ignored, as the protocol asks.
+func (s *UCIServer) Serve(r io.Reader, w io.Writer) error {
+	s.w = w
+	defer s.stopSearch()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		command, args := splitCommand(strings.TrimSpace(scanner.Text()))
+
+		switch command {
+		case "uci":
+			s.send("id name %s", s.Name)
+			s.send("id author %s", s.Author)
+			s.send("option name UCI_Chess960 type check default false")
+			s.send("uciok")
+		case "isready":
+			s.send("readyok")
+		case "setoption":
+			s.setOption(args)
+		case "ucinewgame":
+			s.stopSearch()
+			s.board = core.NewBoardFromFEN(core.StartingFEN, s.chess960)
+		case "position":
+			s.stopSearch()
+			// Like most engines, go on from the last legal position rather
+			// than searching a stale one
+			board, err := parsePosition(args, s.chess960)
+			if err != nil {
+				s.send("info string %s", err)
+			}
+			s.board = board
+		case "go":
+			s.stopSearch()
+			s.startSearch(parseGo(args))
+		case "stop":
+			s.stopSearch()
+		case "quit":
+			return s.writeErr()
+		}
+
+		if err := s.writeErr(); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return s.writeErr()
+}
+
+func (s *UCIServer) writeErr() error {
+	s.writeL.Lock()
+	defer s.writeL.Unlock()
+
+	return s.err
+}
+
+// setOption handles "setoption name <name> value <value>".
+func (s *UCIServer) setOption(args string) {
+	args = strings.TrimPrefix(args, "name ")
+	name, value := args, ""
+	if i := strings.Index(args, " value "); i >= 0 {
+		name, value = args[:i], strings.TrimSpace(args[i+len(" value "):])
+	}
+
+	if strings.EqualFold(strings.TrimSpace(name), "UCI_Chess960") {
+		s.chess960 = value == "true"
+	}
+}
+
+// parsePosition parses the arguments of a position command, like
+// "startpos moves e2e4 e7e5" or "fen <fen> moves ...". On errors the board
+// is returned with the moves before the illegal one, or empty if the
+// position itself is invalid.
+func parsePosition(args string, chess960 bool) (core.Board, error) {
+	tokens := strings.Fields(args)
+
+	moves := len(tokens)
+	for i, token := range tokens {
+		if token == "moves" {
+			moves = i
+			break
+		}
+	}
+
+	board := core.NewBoardFromFEN(core.StartingFEN, chess960)
+	switch {
+	case len(tokens) > 0 && tokens[0] == "startpos":
+	case len(tokens) > 0 && tokens[0] == "fen":
+		board = core.NewBoard(chess960)
+		if err := board.SetFEN(strings.Join(tokens[1:moves], " ")); err != nil {
+			return board, err
+		}
+	default:
+		return core.NewBoard(chess960), &EngineError{description: "expected startpos or fen: " + args}
+	}
+
+	for i := moves + 1; i < len(tokens); i++ {
+		if _, err := board.PushUci(tokens[i]); err != nil {
+			return board, err
+		}
+	}
+
+	return board, nil
+}
+
+// parseGo parses the arguments of a go command. Infinite searches have a
+// zero limit.
+func parseGo(args string) Limit {
+	limit := Limit{}
+
+	tokens := strings.Fields(args)
+	value := func(i int) int {
+		if i < len(tokens) {
+			n, _ := strconv.Atoi(tokens[i])
+			return n
+		}
+		return 0
+	}
+
+	ms := func(i int) time.Duration {
+		return time.Duration(value(i)) * time.Millisecond
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "wtime":
+			i++
+			limit.WhiteTime = ms(i)
+		case "btime":
+			i++
+			limit.BlackTime = ms(i)
+		case "winc":
+			i++
+			limit.WhiteInc = ms(i)
+		case "binc":
+			i++
+			limit.BlackInc = ms(i)
+		case "movestogo":
+			i++
+			limit.MovesToGo = value(i)
+		case "depth":
+			i++
+			limit.Depth = value(i)
+		case "nodes":
+			i++
+			limit.Nodes = uint64(value(i))
+		case "mate":
+			i++
+			limit.Mate = value(i)
+		case "movetime":
+			i++
+			limit.MoveTime = ms(i)
+		case "infinite":
+			return Limit{}
+		}
+	}
+
+	return limit
+}
+
+func (s *UCIServer) startSearch(limit Limit) {
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.stop, s.done = stop, done
+
+	board := core.NewBoardFromBoard(&s.board)
+
+	go func() {
+		defer close(done)
+
+		result := s.Searcher.Search(&board, limit, stop, func(info *Info) {
+			s.send("info %s", formatInfo(info))
+		})
+
+		// Infinite searches only answer once stopped
+		if limit.isZero() {
+			<-stop
+		}
+
+		switch {
+		case result.Move == nil:
+			s.send("bestmove (none)")
+		case result.Ponder == nil:
+			s.send("bestmove %s", result.Move.Uci())
+		default:
+			s.send("bestmove %s ponder %s", result.Move.Uci(), result.Ponder.Uci())
+		}
+	}()
+}
+
+// stopSearch ends a running search and waits for its best move to be sent.
+func (s *UCIServer) stopSearch() {
+	if s.stop == nil {
+		return
+	}
+
+	close(s.stop)
+	<-s.done
+	s.stop, s.done = nil, nil
+}
+
+// formatInfo returns the arguments of an info line, the reverse of
+// parseInfo.
+func formatInfo(info *Info) string {
+	parts := []string{}
+	add := func(name string, value interface{}) {
+		parts = append(parts, fmt.Sprintf("%s %v", name, value))
+	}
+
+	if info.Depth != 0 {
+		add("depth", info.Depth)
+	}
+	if info.SelDepth != 0 {
+		add("seldepth", info.SelDepth)
+	}
+	if info.MultiPV != 0 {
+		add("multipv", info.MultiPV)
+	}
+	if info.Score != nil {
+		score := "cp " + strconv.Itoa(info.Score.CP)
+		if info.Score.IsMate {
+			score = "mate " + strconv.Itoa(info.Score.Mate)
+		}
+		if info.Score.LowerBound {
+			score += " lowerbound"
+		}
+		if info.Score.UpperBound {
+			score += " upperbound"
+		}
+		add("score", score)
+	}
+	if info.Nodes != 0 {
+		add("nodes", info.Nodes)
+	}
+	if info.NPS != 0 {
+		add("nps", info.NPS)
+	}
+	if info.Time != 0 {
+		add("time", int64(info.Time/time.Millisecond))
+	}
+	if info.HashFull != 0 {
+		add("hashfull", info.HashFull)
+	}
+	if info.TBHits != 0 {
+		add("tbhits", info.TBHits)
+	}
+	if info.CurrMove != nil {
+		add("currmove", info.CurrMove.Uci())
+	}
+	if info.CurrMoveNumber != 0 {
+		add("currmovenumber", info.CurrMoveNumber)
+	}
+	if len(info.PV) > 0 {
+		pv := []string{}
+		for _, m := range info.PV {
+			pv = append(pv, m.Uci())
+		}
+		add("pv", strings.Join(pv, " "))
+	}
+
+	// The rest of the line is free text
+	if info.String != "" {
+		add("string", info.String)
+	}
+
+	return strings.Join(parts, " ")
+}
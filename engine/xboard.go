@@ -0,0 +1,581 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/captainsano/golang-chess/core"
+	"github.com/captainsano/golang-chess/util"
+)
+
+// XBoardEngine is an engine speaking the Chess Engine Communication Protocol
+// of XBoard and WinBoard, version 2. Calls must not be made concurrently.
+type XBoardEngine struct {
+	p *process
+
+	// Features sent during the handshake, like "myname" or "setboard"
+	Features map[string]string
+
+	// Options declared with "feature option", by lower case name
+	Options map[string]Option
+
+	pings int
+}
+
+// StartXBoard launches an engine and performs the CECP handshake.
+func StartXBoard(command string, args ...string) (*XBoardEngine, error) {
+	p, err := startProcess(command, args)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &XBoardEngine{p: p}
+	if err := e.handshake(); err != nil {
+		p.close()
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// NewXBoardEngine performs the CECP handshake with an engine that reads
+// commands from w and answers on r.
+func NewXBoardEngine(r io.Reader, w io.WriteCloser) (*XBoardEngine, error) {
+	e := &XBoardEngine{p: newProcess(r, w)}
+	if err := e.handshake(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// handshake accepts all features of the engine. Engines not sending done=1
+// are assumed to be done once the handshake times out, unless they asked
+// for more time with done=0.
+func (e *XBoardEngine) handshake() error {
+	e.Features = map[string]string{}
+	e.Options = map[string]Option{}
+
+	if err := e.p.send("xboard"); err != nil {
+		return err
+	}
+
+	if err := e.p.send("protover 2"); err != nil {
+		return err
+	}
+
+	timeout := time.After(featureTimeout)
+	for done := false; !done; {
+		line, err := e.p.recv(timeout)
+		if err == errTimeout {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		command, args := splitCommand(line)
+		if command != "feature" {
+			continue
+		}
+
+		for _, f := range parseFeatures(args) {
+			switch f[0] {
+			case "option":
+				o := parseXBoardOption(f[1])
+				e.Options[strings.ToLower(o.Name)] = o
+			case "done":
+				// The engine asks for more time with done=0
+				done = f[1] == "1"
+				if !done {
+					timeout = nil
+				}
+			default:
+				e.Features[f[0]] = f[1]
+			}
+
+			if err := e.p.send("accepted " + f[0]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return e.Ping()
+}
+
+// parseFeatures parses the arguments of a feature line into name and value
+// pairs. Values may be quoted.
+func parseFeatures(args string) [][2]string {
+	features := [][2]string{}
+	for args = strings.TrimSpace(args); args != ""; args = strings.TrimSpace(args) {
+		eq := strings.Index(args, "=")
+		if eq < 0 {
+			break
+		}
+
+		name := strings.TrimSpace(args[:eq])
+		args = args[eq+1:]
+
+		value := ""
+		if strings.HasPrefix(args, "\"") {
+			end := strings.Index(args[1:], "\"")
+			if end < 0 {
+				end = len(args) - 1
+			}
+			value, args = args[1:end+1], args[util.MinInt(end+2, len(args)):]
+		} else {
+			end := strings.Index(args, " ")
+			if end < 0 {
+				end = len(args)
+			}
+			value, args = args[:end], args[end:]
+		}
+
+		features = append(features, [2]string{name, value})
+	}
+
+	return features
+}
+
+// parseXBoardOption parses the value of an option feature, like
+// "Hash -spin 64 1 1024". Options are described the UCI way: check options
+// default to "true" or "false", sliders are spins and files and paths are
+// strings.
+func parseXBoardOption(value string) Option {
+	tokens := strings.Fields(value)
+
+	o := Option{Name: value}
+	for i, token := range tokens {
+		if !strings.HasPrefix(token, "-") {
+			continue
+		}
+
+		rest := tokens[i+1:]
+		switch token[1:] {
+		case "check":
+			o.Type = "check"
+			o.Default = strconv.FormatBool(len(rest) > 0 && rest[0] == "1")
+		case "spin", "slider":
+			o.Type = "spin"
+			if len(rest) == 3 {
+				o.Default = rest[0]
+				o.Min, _ = strconv.Atoi(rest[1])
+				o.Max, _ = strconv.Atoi(rest[2])
+			}
+		case "combo":
+			o.Type = "combo"
+			for _, v := range strings.Split(strings.Join(rest, " "), "///") {
+				v = strings.TrimSpace(v)
+				if strings.HasPrefix(v, "*") {
+					v = v[1:]
+					o.Default = v
+				}
+				o.Vars = append(o.Vars, v)
+			}
+		case "string", "file", "path":
+			o.Type = "string"
+			o.Default = strings.Join(rest, " ")
+		case "button", "save", "reset":
+			o.Type = "button"
+		default:
+			continue
+		}
+
+		o.Name = strings.Join(tokens[:i], " ")
+		break
+	}
+
+	return o
+}
+
+// Ping waits for the engine to process the commands sent so far, if it
+// supports the ping feature.
+func (e *XBoardEngine) Ping() error {
+	if e.Features["ping"] != "1" {
+		return nil
+	}
+
+	e.pings++
+	if err := e.p.send("ping " + strconv.Itoa(e.pings)); err != nil {
+		return err
+	}
+
+	pong := "pong " + strconv.Itoa(e.pings)
+	for {
+		line, err := e.p.recv(nil)
+		if err != nil {
+			return err
+		}
+
+		if line == pong {
+			return nil
+		}
+	}
+}
+
+// Name returns the name the engine identified with.
+func (e *XBoardEngine) Name() string {
+	return e.Features["myname"]
+}
+
+// Configure sets options of the engine. Values are checked against the
+// declarations, the value of a button is ignored.
+func (e *XBoardEngine) Configure(options map[string]string) error {
+	for name, value := range options {
+		o, ok := e.Options[strings.ToLower(name)]
+		if !ok {
+			return &EngineError{description: "engine does not support option " + name}
+		}
+
+		if err := o.check(value); err != nil {
+			return err
+		}
+
+		command := "option " + o.Name
+		switch o.Type {
+		case "button":
+		case "check":
+			if value == "true" {
+				command += "=1"
+			} else {
+				command += "=0"
+			}
+		default:
+			command += "=" + value
+		}
+
+		if err := e.p.send(command); err != nil {
+			return err
+		}
+	}
+
+	return e.Ping()
+}
+
+// moveText returns a move the way the engine wants it, in SAN or in
+// coordinate notation with Chess960 castling as O-O and O-O-O.
+func (e *XBoardEngine) moveText(board *core.Board, m *core.Move) string {
+	if e.Features["san"] == "1" {
+		return board.San(m)
+	}
+
+	if board.IsChess960() && board.IsKingsideCastling(m) {
+		return "O-O"
+	} else if board.IsChess960() && board.IsQueensideCastling(m) {
+		return "O-O-O"
+	}
+
+	return m.Uci()
+}
+
+// setPosition starts a new game in force mode from the starting position of
+// the board and plays its move stack.
+func (e *XBoardEngine) setPosition(board *core.Board) error {
+	commands := []string{"new"}
+	if board.IsChess960() {
+		if !strings.Contains(","+e.Features["variants"]+",", ",fischerandom,") {
+			return &EngineError{description: "engine does not support chess960"}
+		}
+		commands = append(commands, "variant fischerandom")
+	}
+	commands = append(commands, "force", "post")
+
+	root := core.NewBoardFromBoard(board)
+	moves := root.MoveStack()
+	for range moves {
+		root.Pop()
+	}
+
+	if fen := root.FEN(false, "legal", core.NoPiece); fen != core.StartingFEN {
+		if e.Features["setboard"] != "1" {
+			return &EngineError{description: "engine does not support setboard"}
+		}
+		commands = append(commands, "setboard "+fen)
+	}
+
+	for i := range moves {
+		text := e.moveText(&root, &moves[i])
+		if e.Features["usermove"] == "1" {
+			text = "usermove " + text
+		}
+		commands = append(commands, text)
+		root.Push(&moves[i])
+	}
+
+	for _, command := range commands {
+		if err := e.p.send(command); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// limitCommands returns the commands setting a limit for the side to move.
+// Node and mate limits have no CECP equivalent.
+func limitCommands(board *core.Board, limit *Limit) ([]string, error) {
+	commands := []string{}
+
+	if limit.Depth != 0 {
+		commands = append(commands, "sd "+strconv.Itoa(limit.Depth))
+	}
+
+	if limit.MoveTime != 0 {
+		seconds := int((limit.MoveTime + time.Second - 1) / time.Second)
+		commands = append(commands, "st "+strconv.Itoa(seconds))
+	}
+
+	own, other, inc := limit.BlackTime, limit.WhiteTime, limit.BlackInc
+	if board.Turn() == core.White {
+		own, other, inc = limit.WhiteTime, limit.BlackTime, limit.WhiteInc
+	}
+
+	if own != 0 {
+		seconds := int(own / time.Second)
+		commands = append(commands,
+			fmt.Sprintf("level %d %d:%02d %s", limit.MovesToGo, seconds/60, seconds%60, strconv.FormatFloat(inc.Seconds(), 'f', -1, 64)),
+			"time "+strconv.FormatInt(int64(own/(10*time.Millisecond)), 10),
+			"otim "+strconv.FormatInt(int64(other/(10*time.Millisecond)), 10))
+	}
+
+	if len(commands) == 0 {
+		return nil, &EngineError{description: "search limit not supported by cecp"}
+	}
+
+	return commands, nil
+}
+
+// Play searches the position of the board for the move to play. The board
+// is not changed.
+func (e *XBoardEngine) Play(board *core.Board, limit Limit) (*PlayResult, error) {
+	commands, err := limitCommands(board, &limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.setPosition(board); err != nil {
+		return nil, err
+	}
+
+	for _, command := range commands {
+		if err := e.p.send(command); err != nil {
+			return nil, err
+		}
+	}
+
+	// Skip output of earlier games, like results sent after a move
+	if err := e.Ping(); err != nil {
+		return nil, err
+	}
+
+	if err := e.p.send("go"); err != nil {
+		return nil, err
+	}
+
+	result := &PlayResult{}
+	for {
+		line, err := e.p.recv(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		command, args := splitCommand(line)
+		switch {
+		case command == "move":
+			result.Move = parseXBoardMove(board, args)
+			if result.Move == nil {
+				return nil, &EngineError{description: "engine played illegal move " + args}
+			}
+
+			if pv := result.Info.PV; len(pv) > 1 && pv[0] == *result.Move {
+				result.Ponder = &pv[1]
+			}
+
+			// Keep the engine from thinking on
+			return result, e.p.send("force")
+		case command == "resign":
+			result.Resigned = true
+			return result, e.p.send("force")
+		case line == "offer draw":
+			result.DrawOffered = true
+		case command == "1-0" || command == "0-1" || command == "1/2-1/2":
+			// Games that are over are only announced, a loss of the engine
+			// in any other position is a resignation
+			loss := "0-1"
+			if board.Turn() == core.Black {
+				loss = "1-0"
+			}
+
+			if board.IsGameOver(true) && command == board.Result(true) {
+				return result, nil
+			} else if command == loss {
+				result.Resigned = true
+				return result, e.p.send("force")
+			}
+
+			return nil, &EngineError{description: "engine claimed " + line}
+		case strings.HasPrefix(line, "Illegal move") || strings.HasPrefix(line, "Error"):
+			return nil, &EngineError{description: line}
+		case command != "" && command[0] >= '0' && command[0] <= '9':
+			if info, ok := parseThinking(board, line); ok {
+				result.Info.merge(&info)
+			}
+		}
+	}
+}
+
+// GameOver tells the engine the result of the game on the board.
+func (e *XBoardEngine) GameOver(board *core.Board) error {
+	return e.p.send("result " + resultText(board))
+}
+
+// Quit asks the engine to exit and waits for it.
+func (e *XBoardEngine) Quit() error {
+	e.p.send("quit")
+	return e.p.close()
+}
+
+// resultText returns the result of the board with the reason in braces, as
+// sent by CECP engines and interfaces.
+func resultText(board *core.Board) string {
+	result := board.Result(true)
+
+	winner := "White"
+	if result == "0-1" {
+		winner = "Black"
+	}
+
+	reason := "Unfinished"
+	switch {
+	case board.IsVariantEnd():
+		reason = "Variant end"
+	case board.IsCheckmate():
+		reason = winner + " mates"
+	case board.CanClaimFiftyMoves():
+		reason = "Fifty move rule"
+	case board.CanClaimThreefoldRepetition():
+		reason = "Threefold repetition"
+	case board.IsSeventyFiveMoves():
+		reason = "Seventy-five move rule"
+	case board.IsFiveFoldRepetition():
+		reason = "Fivefold repetition"
+	case board.IsInsufficientMaterial():
+		reason = "Insufficient material"
+	case result == "1/2-1/2":
+		reason = "Stalemate"
+	}
+
+	return result + " {" + reason + "}"
+}
+
+// parseXBoardMove parses a legal move in coordinate notation or SAN, or
+// returns nil.
+func parseXBoardMove(board *core.Board, text string) *core.Move {
+	b := core.NewBoardFromBoard(board)
+	if m, err := b.ParseUci(text); err == nil {
+		return m
+	}
+
+	if m, err := b.ParseSan(text); err == nil {
+		return m
+	}
+
+	return nil
+}
+
+// parseThinking parses a line of thinking output, like
+// "9 156 1084 48000 Nf3 Nc6 Nc3", where the score is in centipawns and the
+// time in centiseconds. Extra numbers before the pv are separated by a tab:
+// "9 156 1084 48000 11 4400 0\tNf3 Nc6 Nc3".
+func parseThinking(board *core.Board, line string) (Info, bool) {
+	info := Info{}
+
+	pv := ""
+	if i := strings.Index(line, "\t"); i >= 0 {
+		line, pv = line[:i], line[i+1:]
+	}
+
+	tokens := strings.Fields(line)
+	if len(tokens) < 4 {
+		return info, false
+	}
+
+	numbers := []int64{}
+	for i, token := range tokens {
+		n, err := strconv.ParseInt(token, 10, 64)
+		if err != nil {
+			if pv == "" {
+				pv = strings.Join(tokens[i:], " ")
+			}
+			break
+		}
+		numbers = append(numbers, n)
+	}
+
+	if len(numbers) < 4 {
+		return info, false
+	}
+
+	info.Depth = int(numbers[0])
+	info.Score = parseXBoardScore(numbers[1])
+	info.Time = time.Duration(numbers[2]) * 10 * time.Millisecond
+	info.Nodes = uint64(numbers[3])
+
+	if len(numbers) > 4 {
+		info.SelDepth = int(numbers[4])
+	}
+	if len(numbers) > 5 {
+		info.NPS = uint64(numbers[5])
+	}
+	if len(numbers) > 6 {
+		info.TBHits = uint64(numbers[6])
+	}
+
+	// The pv usually is in SAN, with move numbers
+	b := core.NewBoardFromBoard(board)
+	for _, token := range strings.Fields(pv) {
+		if strings.HasSuffix(token, ".") {
+			continue
+		}
+
+		m := parseXBoardMove(&b, token)
+		if m == nil {
+			break
+		}
+
+		info.PV = append(info.PV, *m)
+		b.Push(m)
+	}
+
+	return info, true
+}
+
+// xboardMate is added to the moves to mate of mate scores, and subtracted
+// when getting mated.
+const xboardMate = 100000
+
+func parseXBoardScore(score int64) *Score {
+	if score > xboardMate {
+		return &Score{Mate: int(score - xboardMate), IsMate: true}
+	} else if score <= -xboardMate {
+		return &Score{Mate: int(score + xboardMate), IsMate: true}
+	}
+
+	return &Score{CP: int(score)}
+}
+
+func formatXBoardScore(score *Score) int {
+	if score == nil {
+		return 0
+	}
+
+	if !score.IsMate {
+		return score.CP
+	} else if score.Mate <= 0 {
+		return score.Mate - xboardMate
+	}
+
+	return score.Mate + xboardMate
+}
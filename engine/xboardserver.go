@@ -0,0 +1,326 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// Searches without a time control are given this long.
+var xboardDefaultMoveTime = 5 * time.Second
+
+// XBoardServer is the engine side of the Chess Engine Communication
+// Protocol, so that XBoard and tournament tools can play against a Searcher.
+type XBoardServer struct {
+	Name string
+
+	Searcher Searcher
+
+	board    core.Board
+	chess960 bool
+
+	// In force mode moves are only recorded, otherwise the engine answers
+	// moves of the other side
+	force bool
+	post  bool
+
+	// Time control from level, st and sd
+	movesPerSession int
+	increment       time.Duration
+	moveTime        time.Duration
+	depth           int
+
+	// Clocks from time and otim
+	engineTime   time.Duration
+	opponentTime time.Duration
+
+	w      io.Writer
+	writeL sync.Mutex
+	err    error
+
+	// Set while thinking, see UCIServer. The move is not played if discard
+	// is set when the search ends.
+	stop    chan struct{}
+	done    chan struct{}
+	mu      sync.Mutex
+	discard bool
+}
+
+func NewXBoardServer(name string, searcher Searcher) *XBoardServer {
+	return &XBoardServer{Name: name, Searcher: searcher, board: core.NewDefaultBoard()}
+}
+
+func (s *XBoardServer) send(format string, a ...interface{}) {
+	s.writeL.Lock()
+	defer s.writeL.Unlock()
+
+	if s.err != nil {
+		return
+	}
+
+	if _, err := fmt.Fprintf(s.w, format+"\n", a...); err != nil {
+		s.err = err
+	}
+}
+
+func (s *XBoardServer) writeErr() error {
+	s.writeL.Lock()
+	defer s.writeL.Unlock()
+
+	return s.err
+}
+
+// Serve answers the commands read from r until quit or the end of input.
+func (s *XBoardServer) Serve(r io.Reader, w io.Writer) error {
+	s.w = w
+	defer s.abortSearch()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		command, args := splitCommand(line)
+
+		switch command {
+		case "", "xboard", "accepted", "rejected", "random", "hard", "easy", "computer", "name", "rating", "ics", "draw", "hint", "bk", "white", "black":
+		case "protover":
+			s.send("feature ping=1 setboard=1 usermove=1 playother=1 san=0 time=1 draw=0 sigint=0 sigterm=0 reuse=1 analyze=0 colors=0 variants=\"normal,fischerandom\" myname=\"%s\" done=1", s.Name)
+		case "new":
+			s.abortSearch()
+			s.chess960 = false
+			s.board = core.NewDefaultBoard()
+			s.force = false
+			s.depth = 0
+		case "variant":
+			s.abortSearch()
+			if args != "normal" && args != "fischerandom" {
+				s.send("Error (unsupported variant): %s", args)
+				continue
+			}
+			s.chess960 = args == "fischerandom"
+			s.board = core.NewBoardFromFEN(core.StartingFEN, s.chess960)
+		case "force":
+			s.abortSearch()
+			s.force = true
+		case "go":
+			s.abortSearch()
+			s.force = false
+			s.think()
+		case "playother":
+			s.abortSearch()
+			s.force = false
+		case "usermove":
+			s.abortSearch()
+			s.userMove(args)
+		case "setboard":
+			s.abortSearch()
+			board := core.NewBoard(s.chess960)
+			if err := board.SetFEN(args); err != nil {
+				s.send("tellusererror Illegal position: %s", err)
+				continue
+			}
+			s.board = board
+		case "undo", "remove":
+			s.abortSearch()
+			n := 1
+			if command == "remove" {
+				n = 2
+			}
+			for ; n > 0 && len(s.board.MoveStack()) > 0; n-- {
+				s.board.Pop()
+			}
+		case "result":
+			s.abortSearch()
+			s.force = true
+		case "level":
+			s.setLevel(args)
+		case "st":
+			seconds, _ := strconv.Atoi(args)
+			s.moveTime = time.Duration(seconds) * time.Second
+		case "sd":
+			s.depth, _ = strconv.Atoi(args)
+		case "time", "otim":
+			cs, _ := strconv.Atoi(args)
+			if command == "time" {
+				s.engineTime = time.Duration(cs) * 10 * time.Millisecond
+			} else {
+				s.opponentTime = time.Duration(cs) * 10 * time.Millisecond
+			}
+		case "?":
+			s.moveNow()
+		case "ping":
+			s.send("pong %s", args)
+		case "post", "nopost":
+			s.post = command == "post"
+		case "quit":
+			return s.writeErr()
+		default:
+			s.send("Error (unknown command): %s", command)
+		}
+
+		if err := s.writeErr(); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return s.writeErr()
+}
+
+// setLevel handles "level <moves per session> <base> <increment>", where
+// the base is in minutes or minutes:seconds. The clock itself is sent with
+// time and otim.
+func (s *XBoardServer) setLevel(args string) {
+	tokens := strings.Fields(args)
+	if len(tokens) != 3 {
+		s.send("Error (invalid level): %s", args)
+		return
+	}
+
+	s.movesPerSession, _ = strconv.Atoi(tokens[0])
+	increment, _ := strconv.ParseFloat(tokens[2], 64)
+	s.increment = time.Duration(increment * float64(time.Second))
+	s.moveTime = 0
+}
+
+// userMove plays a move of the opponent, in coordinate notation or SAN, and
+// answers it unless in force mode.
+func (s *XBoardServer) userMove(text string) {
+	m := parseXBoardMove(&s.board, text)
+	if m == nil {
+		s.send("Illegal move: %s", text)
+		return
+	}
+
+	s.board.Push(m)
+	if s.board.IsGameOver(true) {
+		s.send(resultText(&s.board))
+		return
+	}
+
+	if !s.force {
+		s.think()
+	}
+}
+
+// limit returns the limit of a search for the side to move.
+func (s *XBoardServer) limit() Limit {
+	limit := Limit{Depth: s.depth, MoveTime: s.moveTime}
+	if limit.MoveTime == 0 && s.engineTime != 0 {
+		limit.WhiteTime, limit.BlackTime = s.engineTime, s.opponentTime
+		limit.WhiteInc, limit.BlackInc = s.increment, s.increment
+		if s.board.Turn() == core.Black {
+			limit.WhiteTime, limit.BlackTime = limit.BlackTime, limit.WhiteTime
+		}
+
+		if s.movesPerSession != 0 {
+			played := int(s.board.FullMoveNumber()) - 1
+			limit.MovesToGo = s.movesPerSession - played%s.movesPerSession
+		}
+	}
+
+	if limit.isZero() {
+		limit.MoveTime = xboardDefaultMoveTime
+	}
+
+	return limit
+}
+
+// think searches for a move of the side to move and plays it once found.
+func (s *XBoardServer) think() {
+	if s.board.IsGameOver(true) {
+		s.send(resultText(&s.board))
+		return
+	}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.stop, s.done = stop, done
+	s.discard = false
+
+	board := core.NewBoardFromBoard(&s.board)
+	limit := s.limit()
+	post := s.post
+
+	go func() {
+		defer close(done)
+
+		result := s.Searcher.Search(&board, limit, stop, func(info *Info) {
+			if post {
+				s.send("%s", formatThinking(&board, info))
+			}
+		})
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.discard || result.Move == nil {
+			return
+		}
+
+		s.send("move %s", s.moveText(result.Move))
+		s.board.Push(result.Move)
+		if s.board.IsGameOver(true) {
+			s.send(resultText(&s.board))
+		}
+	}()
+}
+
+// moveText returns a move of the board in coordinate notation, with
+// Chess960 castling as O-O and O-O-O.
+func (s *XBoardServer) moveText(m *core.Move) string {
+	if s.board.IsChess960() && s.board.IsKingsideCastling(m) {
+		return "O-O"
+	} else if s.board.IsChess960() && s.board.IsQueensideCastling(m) {
+		return "O-O-O"
+	}
+
+	return m.Uci()
+}
+
+func (s *XBoardServer) waitSearch(discard bool) {
+	if s.stop == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.discard = discard
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.done
+	s.stop, s.done = nil, nil
+}
+
+// moveNow ends the search, playing the best move found so far.
+func (s *XBoardServer) moveNow() {
+	s.waitSearch(false)
+}
+
+// abortSearch ends the search without playing a move. A move found before
+// is already played.
+func (s *XBoardServer) abortSearch() {
+	s.waitSearch(true)
+}
+
+// formatThinking returns a line of thinking output, the reverse of
+// parseThinking.
+func formatThinking(board *core.Board, info *Info) string {
+	b := core.NewBoardFromBoard(board)
+
+	pv := []string{}
+	for i := range info.PV {
+		pv = append(pv, b.San(&info.PV[i]))
+		b.Push(&info.PV[i])
+	}
+
+	return fmt.Sprintf("%d %d %d %d\t%s", info.Depth, formatXBoardScore(info.Score),
+		int64(info.Time/(10*time.Millisecond)), info.Nodes, strings.Join(pv, " "))
+}
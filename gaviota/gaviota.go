@@ -0,0 +1,461 @@
+// Package gaviota probes Gaviota endgame tablebases for the distance to mate
+// (DTM) of positions with up to 5 pieces, except the endgames of three
+// pawns, KPPPvK and KPPvKP.
+package gaviota
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+type TablebaseError struct {
+	error
+	description string
+}
+
+func (e *TablebaseError) Error() string {
+	return e.description
+}
+
+// MissingTableError is returned when a position needs a table that was not
+// added.
+type MissingTableError struct {
+	error
+	description string
+}
+
+func (e *MissingTableError) Error() string {
+	return e.description
+}
+
+// UnsupportedEndgameError is returned when a position is of an endgame the
+// tables of which cannot be probed.
+type UnsupportedEndgameError struct {
+	error
+	description string
+}
+
+func (e *UnsupportedEndgameError) Error() string {
+	return e.description
+}
+
+// Only LZMA compressed tables are supported.
+const suffix = ".gtb.cp4"
+
+// Values are stored in blocks of this many entries, the blocks of white to
+// move first.
+const entriesPerBlock = 16 * 1024
+
+// Results of stored values
+const (
+	iDraw   = 0
+	iWMate  = 1
+	iBMate  = 2
+	iForbid = 3
+)
+
+// dtmUnpack returns the plies to mate and the result of a stored value. The
+// mating side is stored in the low 2 bits, the moves to mate in the others.
+// Mates in more than 63 moves use the draw and forbidden codes.
+func dtmUnpack(side int, packed byte) (int, int) {
+	if packed == iDraw || packed == iForbid {
+		return 0, int(packed)
+	}
+
+	info := int(packed & 3)
+	store := int(packed >> 2)
+
+	// The side to move and its opponent
+	moverMates, otherMates := iWMate, iBMate
+	if side == 1 {
+		moverMates, otherMates = iBMate, iWMate
+	}
+
+	switch info {
+	case moverMates:
+		return 2*(store+1) - 1, moverMates
+	case otherMates:
+		return 2 * store, otherMates
+	case iDraw:
+		return 2*(store+1+63) - 1, moverMates
+	default:
+		return 2 * (store + 63), otherMates
+	}
+}
+
+// table is an endgame file, read on first use.
+type table struct {
+	endgame *endgame
+	path    string
+
+	initialized bool
+	err         error
+	file        *os.File
+
+	// Offsets of the blocks, and of the end of the last
+	blockIndex []uint32
+
+	// The last block read
+	block      int
+	blockCache []byte
+}
+
+func (t *table) open() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 40)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		f.Close()
+		return &TablebaseError{description: "invalid table header: " + t.path}
+	}
+
+	offset := binary.LittleEndian.Uint32(header[32:])
+	blocksPerSide := (t.endgame.maxIndex + entriesPerBlock - 1) / entriesPerBlock
+	if offset < 40 || (offset-40)%4 != 0 || uint64(offset-40)/4 < 2*blocksPerSide+1 {
+		f.Close()
+		return &TablebaseError{description: "invalid block index: " + t.path}
+	}
+
+	buf := make([]byte, offset-40)
+	if _, err := f.ReadAt(buf, 40); err != nil {
+		f.Close()
+		return err
+	}
+
+	t.blockIndex = make([]uint32, len(buf)/4)
+	for i := range t.blockIndex {
+		t.blockIndex[i] = binary.LittleEndian.Uint32(buf[4*i:])
+	}
+
+	t.file = f
+	t.block = -1
+	return nil
+}
+
+// decodeBlock decompresses a block of n entries. Blocks start with a flag:
+// zero for LZMA with a header, otherwise LZMA86 with a 14 byte header, the
+// properties of which Gaviota always sets the same.
+func decodeBlock(z []byte, n int) ([]byte, error) {
+	if len(z) < 2 {
+		return nil, errCorrupt
+	}
+
+	if z[0] == 0 {
+		return lzmaDecodeAlone(z[2:], n)
+	}
+
+	if len(z) < 15 {
+		return nil, errCorrupt
+	}
+
+	return lzmaDecode(z[15:], 3, 0, 2, n)
+}
+
+// value returns the stored value of an index for a side to move.
+func (t *table) value(side int, idx uint64) (byte, error) {
+	blocksPerSide := (t.endgame.maxIndex + entriesPerBlock - 1) / entriesPerBlock
+	block := int(uint64(side)*blocksPerSide + idx/entriesPerBlock)
+
+	if block != t.block {
+		start, end := t.blockIndex[block], t.blockIndex[block+1]
+		if end < start {
+			return 0, &TablebaseError{description: "invalid block index: " + t.path}
+		}
+
+		z := make([]byte, end-start)
+		if _, err := t.file.ReadAt(z, int64(start)); err != nil {
+			return 0, err
+		}
+
+		n := uint64(entriesPerBlock)
+		if first := idx / entriesPerBlock * entriesPerBlock; first+n > t.endgame.maxIndex {
+			n = t.endgame.maxIndex - first
+		}
+
+		data, err := decodeBlock(z, int(n))
+		if err != nil {
+			return 0, err
+		}
+
+		t.block, t.blockCache = block, data
+	}
+
+	return t.blockCache[idx%entriesPerBlock], nil
+}
+
+// Tablebase is a set of table files. Tables are opened on first use.
+type Tablebase struct {
+	mu sync.Mutex
+
+	// By endgame, like krk
+	paths  map[string]string
+	tables map[string]*table
+}
+
+func NewTablebase() *Tablebase {
+	return &Tablebase{paths: map[string]string{}, tables: map[string]*table{}}
+}
+
+// Open returns a tablebase of the tables in a directory.
+func Open(directory string) (*Tablebase, error) {
+	tb := NewTablebase()
+	if _, err := tb.AddDirectory(directory); err != nil {
+		return nil, err
+	}
+
+	return tb, nil
+}
+
+// AddDirectory adds the tables of a directory and returns their number.
+// Other files are ignored. Tables of unsupported endgames are added, but
+// probing their positions returns an UnsupportedEndgameError.
+func (tb *Tablebase) AddDirectory(directory string) (int, error) {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return 0, err
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	added := 0
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), suffix)
+		if f.IsDir() || !strings.HasSuffix(f.Name(), suffix) || (endgames[name] == nil && !unsupportedEndgames[name]) {
+			continue
+		}
+
+		if _, ok := tb.paths[name]; !ok {
+			added++
+		}
+		tb.paths[name] = filepath.Join(directory, f.Name())
+	}
+
+	return added, nil
+}
+
+// Close closes the open table files. The tablebase may not be probed
+// afterwards.
+func (tb *Tablebase) Close() error {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	var err error
+	for _, t := range tb.tables {
+		if t.file != nil {
+			if closeErr := t.file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}
+
+	tb.tables = map[string]*table{}
+	return err
+}
+
+// side returns the squares of the pieces of a color, king first, then by
+// descending type, and their names.
+func side(b *core.Board, c core.Color) ([]int, string) {
+	squares := []int{}
+	name := ""
+	for pt := core.King; pt >= core.Pawn; pt-- {
+		for bb := b.Pieces(pt, c); bb != core.BBVoid; {
+			squares = append(squares, int(bb.PopLsb()))
+			name += pt.Symbol()
+		}
+	}
+
+	return squares, name
+}
+
+// probeTable returns the plies to mate of a position ignoring en passant,
+// positive if the side to move mates.
+func (tb *Tablebase) probeTable(b *core.Board) (int, error) {
+	ws, wname := side(b, core.White)
+	bs, bname := side(b, core.Black)
+
+	if len(ws)+len(bs) > 5 {
+		return 0, &MissingTableError{description: "no tables for more than 5 pieces"}
+	}
+
+	// KvK is the only endgame that is not stored
+	if len(ws)+len(bs) == 2 {
+		return 0, nil
+	}
+
+	stm := 0
+	if b.Turn() == core.Black {
+		stm = 1
+	}
+
+	// Tables store one of the colors, with colors swapped the board is
+	// mirrored.
+	name := wname + bname
+	if endgames[name] == nil && !unsupportedEndgames[name] {
+		ws, bs = bs, ws
+		for _, list := range [][]int{ws, bs} {
+			for i := range list {
+				list[i] = flipNS(list[i])
+			}
+		}
+
+		stm ^= 1
+		name = bname + wname
+	}
+
+	if endgames[name] == nil {
+		return 0, &UnsupportedEndgameError{description: "unsupported endgame " + name}
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	path, ok := tb.paths[name]
+	if !ok {
+		return 0, &MissingTableError{description: "missing table " + name + suffix}
+	}
+
+	t, ok := tb.tables[name]
+	if !ok {
+		t = &table{endgame: endgames[name], path: path}
+		tb.tables[name] = t
+	}
+
+	if !t.initialized {
+		t.initialized = true
+		t.err = t.open()
+	}
+	if t.err != nil {
+		return 0, t.err
+	}
+
+	idx, ok := t.endgame.index(ws, bs)
+	if !ok || idx >= t.endgame.maxIndex {
+		return 0, &TablebaseError{description: "position not in table " + name + suffix}
+	}
+
+	packed, err := t.value(stm, idx)
+	if err != nil {
+		return 0, err
+	}
+
+	plies, res := dtmUnpack(stm, packed)
+	switch {
+	case res == iForbid:
+		return 0, &TablebaseError{description: "illegal position in table " + name + suffix}
+	case res == iDraw:
+		return 0, nil
+	case (res == iWMate) == (stm == 0):
+		return plies, nil
+	default:
+		return -plies, nil
+	}
+}
+
+// better tells if a DTM is better than another for the side to move: wins
+// in fewer plies, then draws, then losses in more plies.
+func better(a, b int) bool {
+	rank := func(dtm int) (int, int) {
+		switch {
+		case dtm > 0:
+			return 2, -dtm
+		case dtm < 0:
+			return 0, -dtm
+		}
+		return 1, 0
+	}
+
+	ra, da := rank(a)
+	rb, db := rank(b)
+	return ra > rb || (ra == rb && da > db)
+}
+
+// ProbeDTM returns the plies to mate of a position with best play: positive
+// if the side to move mates, negative if it is mated, and 0 for draws and
+// checkmates.
+func (tb *Tablebase) ProbeDTM(board *core.Board) (int, error) {
+	if board.CleanCastlingRights() != core.BBVoid {
+		return 0, &TablebaseError{description: "tables do not contain positions with castling rights"}
+	}
+
+	b := core.NewBoardFromBoard(board)
+	dtm, err := tb.probeTable(&b)
+	if err != nil {
+		return 0, err
+	}
+
+	// Tables do not know about en passant captures, which may be better
+	// than the other moves
+	moves := b.LegalMoves(nil)
+	for i := range moves {
+		if !b.IsEnPassant(&moves[i]) {
+			continue
+		}
+
+		b.Push(&moves[i])
+		after, err := tb.probeTable(&b)
+		mate := b.IsCheckmate()
+		b.Pop()
+		if err != nil {
+			return 0, err
+		}
+
+		value := 0
+		switch {
+		case mate:
+			value = 1
+		case after > 0:
+			value = -after - 1
+		case after < 0:
+			value = -after + 1
+		}
+
+		if better(value, dtm) {
+			dtm = value
+		}
+	}
+
+	return dtm, nil
+}
+
+// ProbeWDL returns 1 if the side to move wins, -1 if it loses and 0 for
+// draws, without the 50-move rule.
+func (tb *Tablebase) ProbeWDL(board *core.Board) (int, error) {
+	dtm, err := tb.ProbeDTM(board)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case dtm > 0:
+		return 1, nil
+	case dtm < 0 || board.IsCheckmate():
+		return -1, nil
+	}
+
+	return 0, nil
+}
+
+// Endgames returns the names of the endgames of the added tables, like
+// "krk".
+func (tb *Tablebase) Endgames() []string {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	names := make([]string, 0, len(tb.paths))
+	for name := range tb.paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,494 @@
+package gaviota
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/captainsano/golang-chess/core"
+	retrograde "github.com/captainsano/golang-chess/internal/endgame"
+)
+
+// Compressed by liblzma from lzmaTestData
+var (
+	lzmaAloneStream = "" +
+		"5d00008000ffffffffffffffff0036984aeeeb18e251173c573659be899c2f83d395b27d7f4f26858b9278dc00d2fdb5" +
+		"ba55f3a303a812fb42ec7d360c55a6a577f6b7377527d360d75560d3cc69727bd89255b8964eba0280951b4e96a440f0" +
+		"9a9670fa32ec15aae021c3c8c3551249b5b447c4fadcd3433d963f79b0efb9d3ed9215aba01b4ec3066c566d0c26e0aa" +
+		"a0c81903cb09b17a8342034b5af968242f7045dfc7add55e1c7616a9ce398327c21a51447b4bacb2816d665c7d5c6f31" +
+		"14a353bf3946b4a6002a4ea1ab98a9190c78560bc7047d3cdc54b0e2b93dbed69ba6f06628d0dda689c2cff3b0fed3c9" +
+		"b39ba8ad17a9fe7f4217e5c075bb3e2ba26b71beef9c3608b875ae98365ebd83c854718ba497c15c1a753a29962ded59" +
+		"2ecc356fbc92f5f58a52da5e0c48a04262a9f789fe6a081a2b400771ea51bc26b5eac4c64124de5b71293b1bd0485bd8" +
+		"2e2f201e3db3c995d5a56ca16fc5bcac890fb9afc76298b06b28a076fcc5ebce2757d9ff88d3f47137b58d12928bf6f2" +
+		"231a9fda13f152b050a4320ebffaaf815ba37ea48f9a9149573bc14c84e61dc28fa96c8f668959beaabfd2118f93422b" +
+		"af62bbf800e89ff4132f1fe13b8017755b54fd95a64502c550b943257388e025608603c6d59111b11e955fe788fc7b0b" +
+		"3834150c84735d96ab4f5a18adc19c273d0b38a83fc98e2cbade9920430ce12878a810c8679c8b6809bc9b6254c60193" +
+		"3622bc0e9c595301b1bff8effff04d404271fffd16f509"
+
+	// Raw LZMA1 with the properties of Gaviota blocks
+	lzmaRawStream = "" +
+		"0036984aeeeb18e251173c573659be899c2f83d395b27d7f4f26858b9278dc00d2fdb5ba55f3a303a812fb42ec7d360c" +
+		"55a6a577f6b7377527d360d75560d3cc69727bd89255b8964eba0280951b4e96a440f09a9670fa32ec15aae021c3c8c3" +
+		"551249b5b447c4fadcd3433d963f79b0efb9d3ed9215aba01b4ec3066c566d0c26e0aaa0c81903cb09b17a8342034b5a" +
+		"f968242f7045dfc7add55e1c7616a9ce398327c21a51447b4bacb2816d665c7d5c6f3114a353bf3946b4a6002a4ea1ab" +
+		"98a9190c78560bc7047d3cdc54b0e2b93dbed69ba6f06628d0dda689c2cff3b0fed3c9b39ba8ad17a9fe7f4217e5c075" +
+		"bb3e2ba26b71beef9c3608b875ae98365ebd83c854718ba497c15c1a753a29962ded592ecc356fbc92f5f58a52da5e0c" +
+		"48a04262a9f789fe6a081a2b400771ea51bc26b5eac4c64124de5b71293b1bd0485bd82e2f201e3db3c995d5a56ca16f" +
+		"c5bcac890fb9afc76298b06b28a076fcc5ebce2757d9ff88d3f47137b58d12928bf6f2231a9fda13f152b050a4320ebf" +
+		"faaf815ba37ea48f9a9149573bc14c84e61dc28fa96c8f668959beaabfd2118f93422baf62bbf800e89ff4132f1fe13b" +
+		"8017755b54fd95a64502c550b943257388e025608603c6d59111b11e955fe788fc7b0b3834150c84735d96ab4f5a18ad" +
+		"c19c273d0b38a83fc98e2cbade9920430ce12878a810c8679c8b6809bc9b6254c601933622bc0e9c595301b1bff8efff" +
+		"f04d404271fffd16f509"
+)
+
+// lzmaTestData returns words chosen by a linear congruential generator, so
+// that the data has matches at all distances.
+func lzmaTestData() []byte {
+	words := []string{"pawn ", "knight ", "bishop ", "rook ", "queen ", "king ", "mate ", "draw "}
+
+	data := []byte{}
+	x := uint32(1)
+	for i := 0; i < 600; i++ {
+		x = (x*1103515245 + 12345) & 0x7fffffff
+		data = append(data, words[(x>>16)%8]...)
+	}
+	return data
+}
+
+func TestLZMA(t *testing.T) {
+	expected := lzmaTestData()
+
+	alone, _ := hex.DecodeString(lzmaAloneStream)
+	data, err := lzmaDecodeAlone(alone, len(expected))
+	if err != nil || !bytes.Equal(data, expected) {
+		t.Errorf("unexpected .lzma decoding: %q (%v)", data, err)
+	}
+
+	data, err = decodeBlock(append([]byte{0, 0}, alone...), len(expected))
+	if err != nil || !bytes.Equal(data, expected) {
+		t.Errorf("unexpected decoding of .lzma blocks: %q (%v)", data, err)
+	}
+
+	raw, _ := hex.DecodeString(lzmaRawStream)
+	data, err = decodeBlock(append(make([]byte, 15), raw...), len(expected))
+	if err == nil {
+		t.Error("expected error decoding with the flag of .lzma blocks")
+	}
+
+	block := append([]byte{1}, make([]byte, 14)...)
+	data, err = decodeBlock(append(block, raw...), len(expected))
+	if err != nil || !bytes.Equal(data, expected) {
+		t.Errorf("unexpected block decoding: %q (%v)", data, err)
+	}
+
+	if _, err := lzmaDecode(raw[:len(raw)/2], 3, 0, 2, len(expected)); err == nil {
+		t.Error("expected error decoding truncated stream")
+	}
+
+	if _, err := lzmaDecode(raw, 3, 0, 2, len(expected)+1); err == nil {
+		t.Error("expected error decoding beyond the end marker")
+	}
+}
+
+func TestIndexTables(t *testing.T) {
+	count := func(table [][]int) int {
+		seen := map[int]bool{}
+		for _, row := range table {
+			for _, idx := range row {
+				if idx != noIndex {
+					seen[idx] = true
+				}
+			}
+		}
+		return len(seen)
+	}
+
+	kk := [][]int{}
+	for i := range kkidx {
+		kk = append(kk, kkidx[i][:])
+	}
+	if n := count(kk); n != maxKKIndex {
+		t.Errorf("expected %d king placements, got %d", maxKKIndex, n)
+	}
+
+	pp := [][]int{}
+	for i := range ppidx {
+		pp = append(pp, ppidx[i][:])
+	}
+	if n := count(pp); n != maxPPIndex {
+		t.Errorf("expected %d pawn pairs, got %d", maxPPIndex, n)
+	}
+
+	if idx, _ := aaaIndex(63, 61, 62); idx != maxAAAIndex-1 {
+		t.Errorf("expected last index of three pieces, got %d", idx)
+	}
+
+	// Indices of random positions are in range
+	r := rand.New(rand.NewSource(1))
+	for name, eg := range endgames {
+		for i := 0; i < 100; i++ {
+			used := map[int]bool{}
+			place := func(pawn bool) int {
+				for {
+					s := r.Intn(64)
+					if !used[s] && (!pawn || validPawn(s)) {
+						used[s] = true
+						return s
+					}
+				}
+			}
+
+			var ws, bs []int
+			for j, c := range name {
+				list := &ws
+				if j > 0 && strings.LastIndex(name, "k") <= j {
+					list = &bs
+				}
+				*list = append(*list, place(c == 'p'))
+			}
+
+			idx, ok := eg.index(ws, bs)
+			if ok && idx >= eg.maxIndex {
+				t.Fatalf("%s: index %d of %v %v is not below %d", name, idx, ws, bs, eg.maxIndex)
+			}
+		}
+	}
+}
+
+// Retrograde analysis of KRvK, by the squares of the white king, white rook
+// and black king: plies to mate for white to move, plies to be mated for
+
+var update = flag.Bool("update", false, "rewrite the tables of testdata, compressed by xz")
+
+// refNormalize returns the flips moving the black king to the a1-d1-d4
+// triangle, and the white king below the a1-h8 diagonal if the black king
+// is on it, like the reference implementation.
+func refNormalize(bk, wk int) func(s int) int {
+	we, ns, nwse := bk&7 > 3, bk>>3 > 3, false
+	flips := func(s int) int {
+		if we {
+			s ^= 007
+		}
+		if ns {
+			s ^= 070
+		}
+		if nwse {
+			s = s&7<<3 | s>>3
+		}
+		return s
+	}
+
+	x, y := flips(bk), flips(wk)
+	nwse = x>>3 > x&7 || (x>>3 == x&7 && y>>3 > y&7)
+	return flips
+}
+
+// refKK numbers the placements of the black and white king in the order of
+// the reference implementation, equivalent placements sharing the number of
+// the first.
+var refKK = func() [64][64]int {
+	kk := [64][64]int{}
+	for x := range kk {
+		for y := range kk[x] {
+			kk[x][y] = -1
+		}
+	}
+
+	n := 0
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			if adjacent(x, y) {
+				continue
+			}
+
+			flips := refNormalize(x, y)
+			i, j := flips(x), flips(y)
+			if kk[i][j] == -1 {
+				kk[i][j] = n
+				n++
+			}
+			kk[x][y] = kk[i][j]
+		}
+	}
+
+	return kk
+}()
+
+// refIndex returns the index of the reference implementation of the white
+// king and a piece against the black king. Pawns are mirrored to the a-d
+// files and sliced from the 7th rank down.
+func refIndex(pt core.PieceType, wk, x, bk int) int {
+	if pt == core.Pawn {
+		if x&7 > 3 {
+			wk, x, bk = wk^007, x^007, bk^007
+		}
+		sq := (x ^ 070) - 8
+		return ((sq+sq&3)>>1)*64*64 + wk*64 + bk
+	}
+
+	flips := refNormalize(bk, wk)
+	return refKK[flips(bk)][flips(wk)]*64 + flips(x)
+}
+
+// compressBlock compresses a block with xz: as a .lzma file behind a flag
+// of zero, or as raw LZMA1 behind the flag and LZMA86 header of Gaviota.
+func compressBlock(data []byte, raw bool) ([]byte, error) {
+	args := []string{"--format=lzma", "--lzma1=preset=9,dict=64KiB,lc=1,lp=1,pb=1"}
+	if raw {
+		args = []string{"--format=raw", "--lzma1=preset=9,dict=64KiB,lc=3,lp=0,pb=2"}
+	}
+
+	cmd := exec.Command("xz", append(args, "--stdout")...)
+	cmd.Stdin = bytes.NewReader(data)
+	z, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	if !raw {
+		return append([]byte{0, 0}, z...), nil
+	}
+
+	header := make([]byte, 15)
+	header[0], header[2] = 1, 0x5d
+	binary.LittleEndian.PutUint32(header[3:], 64*1024)
+	binary.LittleEndian.PutUint64(header[7:], uint64(len(data)))
+	return append(header, z...), nil
+}
+
+// writeTable writes the table of an analysis, checking that positions with
+// the same index have the same value.
+func writeTable(t *testing.T, e *retrograde.Endgame, name, path string) {
+	maxIndex := endgames[name].maxIndex
+
+	values := [2][]byte{make([]byte, maxIndex), make([]byte, maxIndex)}
+	known := [2][]bool{make([]bool, maxIndex), make([]bool, maxIndex)}
+	for i := range values {
+		for j := range values[i] {
+			values[i][j] = iForbid
+		}
+	}
+
+	for wk := 0; wk < 64; wk++ {
+		for x := 0; x < 64; x++ {
+			for bk := 0; bk < 64; bk++ {
+				for stm, whiteToMove := range []bool{true, false} {
+					if !e.Legal(wk, x, bk, whiteToMove) {
+						continue
+					}
+
+					// Only white mates: in odd plies with white to move and
+					// even plies with black to move
+					var packed byte
+					if plies := e.DTM(wk, x, bk, whiteToMove); plies > 0 {
+						packed = byte((plies+1)/2-1)<<2 | iWMate
+					} else if e.WDL(wk, x, bk, whiteToMove) == -1 {
+						packed = byte(-plies/2)<<2 | iWMate
+					}
+
+					idx := refIndex(e.Piece, wk, x, bk)
+					if uint64(idx) >= maxIndex {
+						t.Fatalf("index %d of %d %d %d out of range", idx, wk, x, bk)
+					} else if known[stm][idx] && values[stm][idx] != packed {
+						t.Fatalf("index %d of %d %d %d is not unique", idx, wk, x, bk)
+					}
+					known[stm][idx] = true
+					values[stm][idx] = packed
+				}
+			}
+		}
+	}
+
+	blocks := [][]byte{}
+	for _, side := range values {
+		for start := 0; start < len(side); start += entriesPerBlock {
+			end := start + entriesPerBlock
+			if end > len(side) {
+				end = len(side)
+			}
+
+			block, err := compressBlock(side[start:end], len(blocks)%2 == 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			blocks = append(blocks, block)
+		}
+	}
+
+	var buf bytes.Buffer
+	offset := 40 + 4*(len(blocks)+1)
+	header := make([]uint32, 10)
+	header[8] = uint32(offset)
+	binary.Write(&buf, binary.LittleEndian, header)
+	for _, block := range blocks {
+		binary.Write(&buf, binary.LittleEndian, uint32(offset))
+		offset += len(block)
+	}
+	binary.Write(&buf, binary.LittleEndian, uint32(offset))
+	for _, block := range blocks {
+		buf.Write(block)
+	}
+
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTables(t *testing.T) {
+	pieces := map[string]core.PieceType{"krk": core.Rook, "kpk": core.Pawn}
+	if *update {
+		for name, pt := range pieces {
+			writeTable(t, retrograde.Solve(pt), name, filepath.Join("testdata", name+suffix))
+		}
+	}
+
+	tb, err := Open("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tb.Close()
+
+	if names := tb.Endgames(); len(names) != 2 || names[0] != "kpk" || names[1] != "krk" {
+		t.Errorf("expected kpk and krk, got %v", names)
+	}
+
+	// The longest mates are in 16 and 28 moves
+	for name, longest := range map[string]int{"krk": 31, "kpk": 55} {
+		table := &table{endgame: endgames[name], path: filepath.Join("testdata", name+suffix)}
+		if err := table.open(); err != nil {
+			t.Fatal(err)
+		}
+
+		plies := 0
+		for idx := uint64(0); idx < table.endgame.maxIndex; idx++ {
+			packed, err := table.value(0, idx)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if n, info := dtmUnpack(0, packed); info == iWMate && n > plies {
+				plies = n
+			}
+		}
+		table.file.Close()
+
+		if plies != longest {
+			t.Errorf("%s: expected longest mate in %d plies, got %d", name, longest, plies)
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, pt := range pieces {
+		e := retrograde.Solve(pt)
+		for checked := 0; checked < 1000; {
+			wk, x, bk, whiteToMove := r.Intn(64), r.Intn(64), r.Intn(64), r.Intn(2) == 0
+			if !e.Legal(wk, x, bk, whiteToMove) {
+				continue
+			}
+			checked++
+
+			// Also look up the position with colors swapped
+			b := e.Board(wk, x, bk, whiteToMove, r.Intn(2) == 0)
+
+			dtm, err := tb.ProbeDTM(&b)
+			if expected := e.DTM(wk, x, bk, whiteToMove); err != nil || dtm != expected {
+				t.Errorf("%s: expected dtm %d, got %d (%v)", b.FEN(false, "legal", core.NoPiece), expected, dtm, err)
+			}
+
+			wdl, err := tb.ProbeWDL(&b)
+			if expected := e.WDL(wk, x, bk, whiteToMove); err != nil || wdl != expected {
+				t.Errorf("%s: expected wdl %d, got %d (%v)", b.FEN(false, "legal", core.NoPiece), expected, wdl, err)
+			}
+		}
+	}
+
+	for _, c := range []struct {
+		fen      string
+		dtm, wdl int
+	}{
+		// Mated and stalemated
+		{"k7/2K5/8/8/8/8/8/R7 b - - 0 1", 0, -1},
+		{"4k3/4P3/4K3/8/8/8/8/8 b - - 0 1", 0, 0},
+
+		// Promoting with check, then mating
+		{"k7/2K1P3/8/8/8/8/8/8 w - - 0 1", 3, 1},
+		{"8/8/8/8/8/8/2k1p3/K7 b - - 0 1", 3, 1},
+
+		// Only with the opposition
+		{"8/8/4k3/8/4K3/4P3/8/8 w - - 0 1", 0, 0},
+		{"8/8/4k3/8/4K3/4P3/8/8 b - - 0 1", -36, -1},
+	} {
+		b := core.NewBoardFromFEN(c.fen, false)
+		if dtm, err := tb.ProbeDTM(&b); err != nil || dtm != c.dtm {
+			t.Errorf("%s: expected dtm %d, got %d (%v)", c.fen, c.dtm, dtm, err)
+		}
+		if wdl, err := tb.ProbeWDL(&b); err != nil || wdl != c.wdl {
+			t.Errorf("%s: expected wdl %d, got %d (%v)", c.fen, c.wdl, wdl, err)
+		}
+	}
+}
+
+func TestTablebaseErrors(t *testing.T) {
+	directory, err := ioutil.TempDir("", "gaviota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	ioutil.WriteFile(filepath.Join(directory, "kqk.gtb.cp4"), []byte("not a table"), 0644)
+	ioutil.WriteFile(filepath.Join(directory, "krk.gtb.cp2"), nil, 0644)
+	ioutil.WriteFile(filepath.Join(directory, "kpppk.gtb.cp4"), nil, 0644)
+
+	tb, err := Open(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tb.Close()
+
+	if names := tb.Endgames(); len(names) != 2 || names[0] != "kpppk" || names[1] != "kqk" {
+		t.Errorf("expected kpppk and kqk, got %v", names)
+	}
+
+	b := core.NewBoardFromFEN("8/8/8/4k3/8/8/8/4K3 w - - 0 1", false)
+	if dtm, err := tb.ProbeDTM(&b); err != nil || dtm != 0 {
+		t.Errorf("expected KvK to be a draw, got %d (%v)", dtm, err)
+	}
+
+	b = core.NewBoardFromFEN("8/8/8/4k3/8/8/8/3RK3 w - - 0 1", false)
+	if _, err := tb.ProbeDTM(&b); err == nil {
+		t.Error("expected missing table error")
+	} else if _, ok := err.(*MissingTableError); !ok {
+		t.Errorf("expected missing table error, got %v", err)
+	}
+
+	for _, fen := range []string{"8/8/8/4k3/8/PPP5/8/4K3 w - - 0 1", "8/ppp5/8/4k3/8/8/8/4K3 b - - 0 1", "8/8/8/4k3/p7/1PP5/8/4K3 w - - 0 1"} {
+		b = core.NewBoardFromFEN(fen, false)
+		if _, err := tb.ProbeDTM(&b); err == nil {
+			t.Errorf("%s: expected unsupported endgame error", fen)
+		} else if _, ok := err.(*UnsupportedEndgameError); !ok {
+			t.Errorf("%s: expected unsupported endgame error, got %v", fen, err)
+		}
+	}
+
+	b = core.NewBoardFromFEN("8/8/8/4k3/8/8/8/3QK3 w - - 0 1", false)
+	if _, err := tb.ProbeDTM(&b); err == nil {
+		t.Error("expected invalid table error")
+	}
+
+	b = core.NewBoardFromFEN("8/8/8/4k3/8/8/8/RN2K1NR w - - 0 1", false)
+	if _, err := tb.ProbeDTM(&b); err == nil {
+		t.Error("expected error for 6 pieces")
+	}
+
+	b = core.NewBoardFromFEN("4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", false)
+	if _, err := tb.ProbeDTM(&b); err == nil {
+		t.Error("expected castling rights error")
+	}
+}
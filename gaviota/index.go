@@ -0,0 +1,485 @@
+package gaviota
+
+import (
+	"sort"
+)
+
+// Gaviota indexes positions by endgame with the squares of the pieces of
+// each side, king first, then the other pieces by descending type.
+
+const (
+	maxKKIndex  = 462
+	maxAAIndex  = 64 * 63 / 2
+	maxAAAIndex = 64 * 63 * 62 / 6
+	maxPPIndex  = 576
+
+	// Pawns on the a-d files and ranks 2-7, and on any file
+	pawnSlices   = 24
+	pawnSquares  = 48
+	maxPp48Index = pawnSlices * pawnSquares
+)
+
+const noIndex = -1
+
+var (
+	// Both kings, the black king normalized to the a1-d1-d4 triangle
+	kkidx [64][64]int
+
+	// Two like pieces
+	aaidx [64][64]int
+
+	// Two white pawns, the anchor on the a-d files
+	ppidx [pawnSlices][pawnSquares]int
+)
+
+func flipWE(s int) int {
+	return s ^ 7
+}
+
+func flipNS(s int) int {
+	return s ^ 070
+}
+
+func flipNWSE(s int) int {
+	return (s&7)<<3 | s>>3
+}
+
+func adjacent(a, b int) bool {
+	df, dr := a&7-b&7, a>>3-b>>3
+	return df >= -1 && df <= 1 && dr >= -1 && dr <= 1
+}
+
+// flipType returns the flips that move x to the a1-d1-d4 triangle, and y
+// below the diagonal if x is on it: 1 for west-east, 2 for north-south and
+// 4 for the a1-h8 diagonal.
+func flipType(x, y int) int {
+	ft := 0
+
+	if x&7 > 3 {
+		x, y = flipWE(x), flipWE(y)
+		ft |= 1
+	}
+
+	if x>>3 > 3 {
+		x, y = flipNS(x), flipNS(y)
+		ft |= 2
+	}
+
+	rowx, colx := x>>3, x&7
+	if rowx > colx {
+		x, y = flipNWSE(x), flipNWSE(y)
+		ft |= 4
+	}
+
+	rowy, coly := y>>3, y&7
+	if rowx == colx && rowy > coly {
+		ft |= 4
+	}
+
+	return ft
+}
+
+// flip applies the flips of a flip type to squares.
+func flip(ft int, squares ...[]int) {
+	for _, list := range squares {
+		for i, s := range list {
+			if ft&1 != 0 {
+				s = flipWE(s)
+			}
+			if ft&2 != 0 {
+				s = flipNS(s)
+			}
+			if ft&4 != 0 {
+				s = flipNWSE(s)
+			}
+			list[i] = s
+		}
+	}
+}
+
+func normKKIndex(x, y int) (int, int) {
+	ft := flipType(x, y)
+	squares := []int{x, y}
+	flip(ft, squares)
+	return squares[0], squares[1]
+}
+
+// ppPutAnchorFirst orders two pawns, the anchor being the more advanced,
+// then the one closer to the edge.
+func ppPutAnchorFirst(a, b int) (int, int) {
+	rowA, rowB := a&070, b&070
+	if rowB > rowA {
+		return b, a
+	} else if rowB < rowA {
+		return a, b
+	}
+
+	highest := func(s int) int {
+		col := uint(s & 7)
+		x := 1<<col | 1<<(col^7)
+		return x & (x - 1)
+	}
+
+	hiA, hiB := highest(a), highest(b)
+	if hiB > hiA || (hiB == hiA && b < a) {
+		return b, a
+	}
+	return a, b
+}
+
+// Pawn squares of the a-d files to 0..23 from the 7th rank down, and all
+// pawn squares to 0..47
+func pidx24(pawn int) int {
+	sq := flipNS(pawn) - 8
+	return (sq + sq&3) >> 1
+}
+
+func pidx48(pawn int) int {
+	return flipNS(pawn) - 8
+}
+
+func validPawn(s int) bool {
+	return s >= 8 && s < 56
+}
+
+func init() {
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			kkidx[x][y] = noIndex
+			aaidx[x][y] = noIndex
+		}
+	}
+
+	idx := 0
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			if x == y || adjacent(x, y) {
+				continue
+			}
+
+			i, j := normKKIndex(x, y)
+			if kkidx[i][j] == noIndex {
+				kkidx[i][j] = idx
+				kkidx[x][y] = idx
+				idx++
+			} else {
+				kkidx[x][y] = kkidx[i][j]
+			}
+		}
+	}
+
+	idx = 0
+	for x := 0; x < 64; x++ {
+		for y := x + 1; y < 64; y++ {
+			aaidx[x][y] = idx
+			aaidx[y][x] = idx
+			idx++
+		}
+	}
+
+	for i := range ppidx {
+		for j := range ppidx[i] {
+			ppidx[i][j] = noIndex
+		}
+	}
+
+	idx = 0
+	for a := 55; a >= 8; a-- {
+		if a&7 < 4 {
+			continue
+		}
+
+		for b := a - 1; b >= 8; b-- {
+			anchor, loosen := ppPutAnchorFirst(a, b)
+			if anchor&7 > 3 {
+				anchor, loosen = flipWE(anchor), flipWE(loosen)
+			}
+
+			i, j := pidx24(anchor), pidx48(loosen)
+			if ppidx[i][j] == noIndex {
+				ppidx[i][j] = idx
+				idx++
+			}
+		}
+	}
+}
+
+// aaaIndex returns the index of three like pieces on distinct squares.
+func aaaIndex(a, b, c int) (uint64, bool) {
+	squares := []int{a, b, c}
+	sort.Ints(squares)
+	x, y, z := squares[0], squares[1], squares[2]
+	if x == y || y == z {
+		return 0, false
+	}
+
+	return uint64(x + y*(y-1)/2 + z*(z-1)*(z-2)/6), true
+}
+
+// kingsIndex normalizes the squares by the kings and returns the index of
+// the kings.
+func kingsIndex(ws, bs []int) (uint64, bool) {
+	ft := flipType(bs[0], ws[0])
+	flip(ft, ws, bs)
+
+	ki := kkidx[bs[0]][ws[0]]
+	return uint64(ki), ki != noIndex
+}
+
+// combine returns the index of values with their ranges, most significant
+// first.
+func combine(values ...uint64) uint64 {
+	idx := uint64(0)
+	for i := 0; i < len(values); i += 2 {
+		idx = idx*values[i+1] + values[i]
+	}
+	return idx
+}
+
+// pawnlessIndex returns an index function of endgames without pawns, from
+// the index of the kings and the other pieces.
+func pawnlessIndex(rest func(ws, bs []int) (uint64, uint64, bool)) func(ws, bs []int) (uint64, bool) {
+	return func(ws, bs []int) (uint64, bool) {
+		ki, ok := kingsIndex(ws, bs)
+		if !ok {
+			return 0, false
+		}
+
+		idx, size, ok := rest(ws, bs)
+		return ki*size + idx, ok
+	}
+}
+
+func pairIndex(a, b int) (uint64, bool) {
+	ai := aaidx[a][b]
+	return uint64(ai), ai != noIndex
+}
+
+var (
+	kxkIndex = pawnlessIndex(func(ws, bs []int) (uint64, uint64, bool) {
+		return uint64(ws[1]), 64, true
+	})
+
+	kabkIndex = pawnlessIndex(func(ws, bs []int) (uint64, uint64, bool) {
+		return combine(uint64(ws[1]), 64, uint64(ws[2]), 64), 64 * 64, true
+	})
+
+	kakbIndex = pawnlessIndex(func(ws, bs []int) (uint64, uint64, bool) {
+		return combine(uint64(ws[1]), 64, uint64(bs[1]), 64), 64 * 64, true
+	})
+
+	kaakIndex = pawnlessIndex(func(ws, bs []int) (uint64, uint64, bool) {
+		ai, ok := pairIndex(ws[1], ws[2])
+		return ai, maxAAIndex, ok
+	})
+
+	kaaakIndex = pawnlessIndex(func(ws, bs []int) (uint64, uint64, bool) {
+		ai, ok := aaaIndex(ws[1], ws[2], ws[3])
+		return ai, maxAAAIndex, ok
+	})
+
+	kaabkIndex = pawnlessIndex(func(ws, bs []int) (uint64, uint64, bool) {
+		ai, ok := pairIndex(ws[1], ws[2])
+		return combine(ai, maxAAIndex, uint64(ws[3]), 64), maxAAIndex * 64, ok
+	})
+
+	kabbkIndex = pawnlessIndex(func(ws, bs []int) (uint64, uint64, bool) {
+		ai, ok := pairIndex(ws[2], ws[3])
+		return combine(ai, maxAAIndex, uint64(ws[1]), 64), maxAAIndex * 64, ok
+	})
+
+	kabckIndex = pawnlessIndex(func(ws, bs []int) (uint64, uint64, bool) {
+		return combine(uint64(ws[1]), 64, uint64(ws[2]), 64, uint64(ws[3]), 64), 64 * 64 * 64, true
+	})
+
+	kaakbIndex = pawnlessIndex(func(ws, bs []int) (uint64, uint64, bool) {
+		ai, ok := pairIndex(ws[1], ws[2])
+		return combine(ai, maxAAIndex, uint64(bs[1]), 64), maxAAIndex * 64, ok
+	})
+
+	kabkcIndex = pawnlessIndex(func(ws, bs []int) (uint64, uint64, bool) {
+		return combine(uint64(ws[1]), 64, uint64(ws[2]), 64, uint64(bs[1]), 64), 64 * 64 * 64, true
+	})
+)
+
+// pawnIndex returns an index function of endgames with a leading pawn,
+// which is mirrored to the a-d files. The slice of a white pawn counts from
+// the 7th rank down, that of a black pawn from the 2nd rank up.
+func pawnIndex(pawn func(ws, bs []int) int, whitePawn bool, rest func(ws, bs []int) (uint64, uint64, bool)) func(ws, bs []int) (uint64, bool) {
+	return func(ws, bs []int) (uint64, bool) {
+		p := pawn(ws, bs)
+		if !validPawn(p) {
+			return 0, false
+		}
+
+		if p&7 > 3 {
+			flip(1, ws, bs)
+			p = flipWE(p)
+		}
+
+		slice := pidx24(p)
+		if !whitePawn {
+			sq := p - 8
+			slice = (sq + sq&3) >> 1
+		}
+
+		idx, size, ok := rest(ws, bs)
+		return combine(uint64(slice), pawnSlices, uint64(ws[0]), 64, uint64(bs[0]), 64)*size + idx, ok
+	}
+}
+
+// pawnPairIndex returns an index function of endgames with two pawns, the
+// anchor of which is mirrored to the a-d files.
+func pawnPairIndex(pawns func(ws, bs []int) (int, int), bothWhite bool, rest func(ws, bs []int) (uint64, uint64, bool)) func(ws, bs []int) (uint64, bool) {
+	return func(ws, bs []int) (uint64, bool) {
+		anchor, loosen := pawns(ws, bs)
+		if !validPawn(anchor) || !validPawn(loosen) || anchor == loosen {
+			return 0, false
+		}
+
+		if bothWhite {
+			anchor, loosen = ppPutAnchorFirst(anchor, loosen)
+		}
+
+		if anchor&7 > 3 {
+			flip(1, ws, bs)
+			anchor, loosen = flipWE(anchor), flipWE(loosen)
+		}
+
+		var slice uint64
+		var slices uint64
+		if bothWhite {
+			pp := ppidx[pidx24(anchor)][pidx48(loosen)]
+			if pp == noIndex {
+				return 0, false
+			}
+			slice, slices = uint64(pp), maxPPIndex
+		} else {
+			slice, slices = uint64(pidx24(anchor)*pawnSquares+loosen-8), maxPp48Index
+		}
+
+		idx, size, ok := rest(ws, bs)
+		return combine(slice, slices, uint64(ws[0]), 64, uint64(bs[0]), 64)*size + idx, ok
+	}
+}
+
+func noPieces(ws, bs []int) (uint64, uint64, bool) {
+	return 0, 1, true
+}
+
+// whitePiece indexes the square of a white piece.
+func whitePiece(i int) func(ws, bs []int) (uint64, uint64, bool) {
+	return func(ws, bs []int) (uint64, uint64, bool) {
+		return uint64(ws[i]), 64, true
+	}
+}
+
+// blackPiece indexes the square of a black piece.
+func blackPiece(i int) func(ws, bs []int) (uint64, uint64, bool) {
+	return func(ws, bs []int) (uint64, uint64, bool) {
+		return uint64(bs[i]), 64, true
+	}
+}
+
+var (
+	kpkIndex  = pawnIndex(func(ws, bs []int) int { return ws[1] }, true, noPieces)
+	kapkIndex = pawnIndex(func(ws, bs []int) int { return ws[2] }, true, whitePiece(1))
+	kakpIndex = pawnIndex(func(ws, bs []int) int { return bs[1] }, false, whitePiece(1))
+
+	kabpkIndex = pawnIndex(func(ws, bs []int) int { return ws[3] }, true, func(ws, bs []int) (uint64, uint64, bool) {
+		return combine(uint64(ws[1]), 64, uint64(ws[2]), 64), 64 * 64, true
+	})
+
+	kaapkIndex = pawnIndex(func(ws, bs []int) int { return ws[3] }, true, func(ws, bs []int) (uint64, uint64, bool) {
+		ai, ok := pairIndex(ws[1], ws[2])
+		return ai, maxAAIndex, ok
+	})
+
+	kabkpIndex = pawnIndex(func(ws, bs []int) int { return bs[1] }, false, func(ws, bs []int) (uint64, uint64, bool) {
+		return combine(uint64(ws[1]), 64, uint64(ws[2]), 64), 64 * 64, true
+	})
+
+	kaakpIndex = pawnIndex(func(ws, bs []int) int { return bs[1] }, false, func(ws, bs []int) (uint64, uint64, bool) {
+		ai, ok := pairIndex(ws[1], ws[2])
+		return ai, maxAAIndex, ok
+	})
+
+	kapkbIndex = pawnIndex(func(ws, bs []int) int { return ws[2] }, true, func(ws, bs []int) (uint64, uint64, bool) {
+		return combine(uint64(ws[1]), 64, uint64(bs[1]), 64), 64 * 64, true
+	})
+
+	kppkIndex  = pawnPairIndex(func(ws, bs []int) (int, int) { return ws[1], ws[2] }, true, noPieces)
+	kpkpIndex  = pawnPairIndex(func(ws, bs []int) (int, int) { return ws[1], bs[1] }, false, noPieces)
+	kappkIndex = pawnPairIndex(func(ws, bs []int) (int, int) { return ws[2], ws[3] }, true, whitePiece(1))
+	kapkpIndex = pawnPairIndex(func(ws, bs []int) (int, int) { return ws[2], bs[1] }, false, whitePiece(1))
+	kppkaIndex = pawnPairIndex(func(ws, bs []int) (int, int) { return ws[1], ws[2] }, true, blackPiece(1))
+)
+
+// endgame is a table of Gaviota, like "krk".
+type endgame struct {
+	name     string
+	maxIndex uint64
+	index    func(ws, bs []int) (uint64, bool)
+}
+
+// Endgames by name. Endgames with the same material on the other side are
+// probed with colors swapped.
+var endgames = map[string]*endgame{}
+
+// Endgames of three pawns, the tables of which cannot be probed
+var unsupportedEndgames = map[string]bool{"kpppk": true, "kppkp": true}
+
+func addEndgame(name string, maxIndex uint64, index func(ws, bs []int) (uint64, bool)) {
+	endgames[name] = &endgame{name: name, maxIndex: maxIndex, index: index}
+}
+
+func init() {
+	const kk = maxKKIndex
+	const slices = pawnSlices * 64 * 64
+
+	addEndgame("kpk", slices, kpkIndex)
+	addEndgame("kppk", maxPPIndex*64*64, kppkIndex)
+	addEndgame("kpkp", maxPp48Index*64*64, kpkpIndex)
+
+	pieces := []string{"q", "r", "b", "n"}
+	for i, a := range pieces {
+		addEndgame("k"+a+"k", kk*64, kxkIndex)
+		addEndgame("k"+a+a+"k", kk*maxAAIndex, kaakIndex)
+		addEndgame("k"+a+"pk", slices*64, kapkIndex)
+		addEndgame("k"+a+"kp", slices*64, kakpIndex)
+		addEndgame("k"+a+a+a+"k", kk*maxAAAIndex, kaaakIndex)
+		addEndgame("k"+a+a+"pk", slices*maxAAIndex, kaapkIndex)
+		addEndgame("k"+a+a+"kp", slices*maxAAIndex, kaakpIndex)
+		addEndgame("k"+a+"ppk", maxPPIndex*64*64*64, kappkIndex)
+		addEndgame("k"+a+"pkp", maxPp48Index*64*64*64, kapkpIndex)
+		addEndgame("kppk"+a, maxPPIndex*64*64*64, kppkaIndex)
+
+		for j, b := range pieces {
+			addEndgame("k"+a+"pk"+b, slices*64*64, kapkbIndex)
+			addEndgame("k"+a+a+"k"+b, kk*maxAAIndex*64, kaakbIndex)
+
+			// The stronger piece first
+			if j >= i {
+				addEndgame("k"+a+"k"+b, kk*64*64, kakbIndex)
+			}
+
+			if j <= i {
+				continue
+			}
+
+			addEndgame("k"+a+b+"k", kk*64*64, kabkIndex)
+			addEndgame("k"+a+a+b+"k", kk*maxAAIndex*64, kaabkIndex)
+			addEndgame("k"+a+b+b+"k", kk*maxAAIndex*64, kabbkIndex)
+			addEndgame("k"+a+b+"pk", slices*64*64, kabpkIndex)
+			addEndgame("k"+a+b+"kp", slices*64*64, kabkpIndex)
+
+			for _, c := range pieces {
+				addEndgame("k"+a+b+"k"+c, kk*64*64*64, kabkcIndex)
+			}
+
+			for _, c := range pieces[j+1:] {
+				addEndgame("k"+a+b+c+"k", kk*64*64*64, kabckIndex)
+			}
+		}
+	}
+}
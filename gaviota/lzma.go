@@ -0,0 +1,350 @@
+package gaviota
+
+import (
+	"encoding/binary"
+)
+
+// An LZMA decoder for the compressed blocks of tables, following the
+// specification of the LZMA SDK. The whole output is kept in memory, so the
+// dictionary size does not matter.
+
+const (
+	lzmaNumBitModelTotalBits = 11
+	lzmaBitModelTotal        = 1 << lzmaNumBitModelTotalBits
+	lzmaNumMoveBits          = 5
+	lzmaProbInit             = lzmaBitModelTotal / 2
+
+	lzmaNumStates          = 12
+	lzmaNumPosBitsMax      = 4
+	lzmaNumLenToPosStates  = 4
+	lzmaNumAlignBits       = 4
+	lzmaStartPosModelIndex = 4
+	lzmaEndPosModelIndex   = 14
+	lzmaNumFullDistances   = 1 << (lzmaEndPosModelIndex >> 1)
+	lzmaMatchMinLen        = 2
+)
+
+var errCorrupt = &TablebaseError{description: "corrupt lzma stream"}
+
+type rangeDecoder struct {
+	data []byte
+	pos  int
+
+	rng  uint32
+	code uint32
+
+	err error
+}
+
+func newRangeDecoder(data []byte) *rangeDecoder {
+	rc := &rangeDecoder{data: data, rng: 0xffffffff}
+	if len(data) < 5 || data[0] != 0 {
+		rc.err = errCorrupt
+		return rc
+	}
+
+	rc.code = binary.BigEndian.Uint32(data[1:5])
+	rc.pos = 5
+	if rc.code == rc.rng {
+		rc.err = errCorrupt
+	}
+
+	return rc
+}
+
+func (rc *rangeDecoder) next() uint32 {
+	if rc.pos >= len(rc.data) {
+		rc.err = errCorrupt
+		return 0
+	}
+
+	b := rc.data[rc.pos]
+	rc.pos++
+	return uint32(b)
+}
+
+func (rc *rangeDecoder) normalize() {
+	if rc.rng < 1<<24 {
+		rc.rng <<= 8
+		rc.code = rc.code<<8 | rc.next()
+	}
+}
+
+func (rc *rangeDecoder) directBits(n uint) uint32 {
+	res := uint32(0)
+	for ; n > 0; n-- {
+		rc.rng >>= 1
+		rc.code -= rc.rng
+		t := 0 - (rc.code >> 31)
+		rc.code += rc.rng & t
+
+		if rc.code == rc.rng {
+			rc.err = errCorrupt
+		}
+
+		rc.normalize()
+		res = res<<1 + t + 1
+	}
+
+	return res
+}
+
+func (rc *rangeDecoder) bit(p *uint16) uint32 {
+	v := uint32(*p)
+	bound := (rc.rng >> lzmaNumBitModelTotalBits) * v
+
+	var symbol uint32
+	if rc.code < bound {
+		v += (lzmaBitModelTotal - v) >> lzmaNumMoveBits
+		rc.rng = bound
+	} else {
+		v -= v >> lzmaNumMoveBits
+		rc.code -= bound
+		rc.rng -= bound
+		symbol = 1
+	}
+
+	*p = uint16(v)
+	rc.normalize()
+	return symbol
+}
+
+func newProbs(n int) []uint16 {
+	probs := make([]uint16, n)
+	for i := range probs {
+		probs[i] = lzmaProbInit
+	}
+	return probs
+}
+
+// bitTree decodes numBits bits, most significant first, with the
+// probabilities of a binary tree.
+func (rc *rangeDecoder) bitTree(probs []uint16, numBits uint) uint32 {
+	m := uint32(1)
+	for i := uint(0); i < numBits; i++ {
+		m = m<<1 + rc.bit(&probs[m])
+	}
+	return m - 1<<numBits
+}
+
+func (rc *rangeDecoder) reverseBitTree(probs []uint16, numBits uint) uint32 {
+	m := uint32(1)
+	symbol := uint32(0)
+	for i := uint(0); i < numBits; i++ {
+		bit := rc.bit(&probs[m])
+		m = m<<1 + bit
+		symbol |= bit << i
+	}
+	return symbol
+}
+
+type lenDecoder struct {
+	choice  uint16
+	choice2 uint16
+	low     [1 << lzmaNumPosBitsMax][]uint16
+	mid     [1 << lzmaNumPosBitsMax][]uint16
+	high    []uint16
+}
+
+func newLenDecoder() *lenDecoder {
+	d := &lenDecoder{choice: lzmaProbInit, choice2: lzmaProbInit, high: newProbs(1 << 8)}
+	for i := range d.low {
+		d.low[i] = newProbs(1 << 3)
+		d.mid[i] = newProbs(1 << 3)
+	}
+	return d
+}
+
+func (d *lenDecoder) decode(rc *rangeDecoder, posState uint32) uint32 {
+	if rc.bit(&d.choice) == 0 {
+		return rc.bitTree(d.low[posState], 3)
+	}
+	if rc.bit(&d.choice2) == 0 {
+		return 8 + rc.bitTree(d.mid[posState], 3)
+	}
+	return 16 + rc.bitTree(d.high, 8)
+}
+
+// lzmaDecode decodes a raw LZMA stream of size bytes with the literal
+// context, literal position and position bits of the properties. The
+// stream may end with an end marker.
+func lzmaDecode(data []byte, lc, lp, pb uint, size int) ([]byte, error) {
+	if lc > 8 || lp > 4 || pb > lzmaNumPosBitsMax {
+		return nil, &TablebaseError{description: "invalid lzma properties"}
+	}
+
+	rc := newRangeDecoder(data)
+	out := make([]byte, 0, size)
+
+	literalProbs := newProbs(0x300 << (lc + lp))
+	posSlot := [lzmaNumLenToPosStates][]uint16{}
+	for i := range posSlot {
+		posSlot[i] = newProbs(1 << 6)
+	}
+	posDecoders := newProbs(1 + lzmaNumFullDistances - lzmaEndPosModelIndex)
+	align := newProbs(1 << lzmaNumAlignBits)
+	lenDec, repLenDec := newLenDecoder(), newLenDecoder()
+
+	isMatch := newProbs(lzmaNumStates << lzmaNumPosBitsMax)
+	isRep := newProbs(lzmaNumStates)
+	isRepG0 := newProbs(lzmaNumStates)
+	isRepG1 := newProbs(lzmaNumStates)
+	isRepG2 := newProbs(lzmaNumStates)
+	isRep0Long := newProbs(lzmaNumStates << lzmaNumPosBitsMax)
+
+	decodeDistance := func(length uint32) uint32 {
+		lenState := length
+		if lenState > lzmaNumLenToPosStates-1 {
+			lenState = lzmaNumLenToPosStates - 1
+		}
+
+		slot := rc.bitTree(posSlot[lenState], 6)
+		if slot < 4 {
+			return slot
+		}
+
+		numDirectBits := uint(slot>>1) - 1
+		dist := (2 | slot&1) << numDirectBits
+		if slot < lzmaEndPosModelIndex {
+			return dist + rc.reverseBitTree(posDecoders[dist-slot:], numDirectBits)
+		}
+
+		dist += rc.directBits(numDirectBits-lzmaNumAlignBits) << lzmaNumAlignBits
+		return dist + rc.reverseBitTree(align, lzmaNumAlignBits)
+	}
+
+	state := uint32(0)
+	var rep0, rep1, rep2, rep3 uint32
+	pbMask := uint32(1)<<pb - 1
+	lpMask := uint32(1)<<lp - 1
+
+	for len(out) < size && rc.err == nil {
+		pos := uint32(len(out))
+		posState := pos & pbMask
+
+		if rc.bit(&isMatch[state<<lzmaNumPosBitsMax+posState]) == 0 {
+			prevByte := uint32(0)
+			if pos > 0 {
+				prevByte = uint32(out[pos-1])
+			}
+
+			litState := (pos&lpMask)<<lc + prevByte>>(8-lc)
+			probs := literalProbs[0x300*litState:]
+
+			symbol := uint32(1)
+			if state >= 7 {
+				matchByte := uint32(out[pos-rep0-1])
+				for symbol < 0x100 {
+					matchBit := (matchByte >> 7) & 1
+					matchByte <<= 1
+					bit := rc.bit(&probs[(1+matchBit)<<8+symbol])
+					symbol = symbol<<1 | bit
+					if matchBit != bit {
+						break
+					}
+				}
+			}
+			for symbol < 0x100 {
+				symbol = symbol<<1 | rc.bit(&probs[symbol])
+			}
+			out = append(out, byte(symbol-0x100))
+
+			switch {
+			case state < 4:
+				state = 0
+			case state < 10:
+				state -= 3
+			default:
+				state -= 6
+			}
+			continue
+		}
+
+		var length uint32
+		if rc.bit(&isRep[state]) != 0 {
+			if pos == 0 {
+				return nil, errCorrupt
+			}
+
+			if rc.bit(&isRepG0[state]) == 0 {
+				if rc.bit(&isRep0Long[state<<lzmaNumPosBitsMax+posState]) == 0 {
+					if state < 7 {
+						state = 9
+					} else {
+						state = 11
+					}
+					out = append(out, out[pos-rep0-1])
+					continue
+				}
+			} else {
+				var dist uint32
+				if rc.bit(&isRepG1[state]) == 0 {
+					dist = rep1
+				} else {
+					if rc.bit(&isRepG2[state]) == 0 {
+						dist = rep2
+					} else {
+						dist = rep3
+						rep3 = rep2
+					}
+					rep2 = rep1
+				}
+				rep1 = rep0
+				rep0 = dist
+			}
+
+			length = repLenDec.decode(rc, posState)
+			if state < 7 {
+				state = 8
+			} else {
+				state = 11
+			}
+		} else {
+			rep3, rep2, rep1 = rep2, rep1, rep0
+			length = lenDec.decode(rc, posState)
+			if state < 7 {
+				state = 7
+			} else {
+				state = 10
+			}
+
+			rep0 = decodeDistance(length)
+			if rep0 == 0xffffffff {
+				break
+			}
+			if rep0 >= pos {
+				return nil, errCorrupt
+			}
+		}
+
+		for n := length + lzmaMatchMinLen; n > 0 && len(out) < size; n-- {
+			out = append(out, out[uint32(len(out))-rep0-1])
+		}
+	}
+
+	if rc.err != nil {
+		return nil, rc.err
+	}
+	if len(out) != size {
+		return nil, errCorrupt
+	}
+
+	return out, nil
+}
+
+// lzmaProperties splits the properties byte of a stream.
+func lzmaProperties(d byte) (lc, lp, pb uint) {
+	return uint(d % 9), uint(d / 9 % 5), uint(d / 45)
+}
+
+// lzmaDecodeAlone decodes a stream with the 13 byte header of .lzma files:
+// properties, dictionary size and uncompressed size. The size of the
+// output is given since blocks do not need to store it.
+func lzmaDecodeAlone(data []byte, size int) ([]byte, error) {
+	if len(data) < 13 || data[0] >= 9*5*5 {
+		return nil, errCorrupt
+	}
+
+	lc, lp, pb := lzmaProperties(data[0])
+	return lzmaDecode(data[13:], lc, lp, pb, size)
+}
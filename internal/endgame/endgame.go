@@ -0,0 +1,346 @@
+// Package endgame solves endgames of the white king and one piece against
+// the black king, and against the black king and one piece, by retrograde
+// analysis, so that tablebases can be checked against known values. Moves
+// are generated by the core package.
+package endgame
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// Placements are indexed by the squares of the white king, the white piece
+// and the black king.
+const size = 1 << 18
+
+// Index returns the index of a placement.
+func Index(wk, x, bk int) int {
+	return wk<<12 | x<<6 | bk
+}
+
+// Results for the side to move
+const (
+	loss    = -1
+	draw    = 0
+	win     = 1
+	unknown = 2
+)
+
+// Endgame is the analysis of the white king and a piece against the black
+// king, by side to move, white first, and placement.
+type Endgame struct {
+	Piece core.PieceType
+
+	legal [2][size]bool
+	wdl   [2][size]int8
+
+	// Plies to mate, and to zeroing the halfmove clock with the winning
+	// side zeroing as soon as possible and the losing side as late as
+	// possible. Both are 0 when mated.
+	dtm [2][size]int16
+	dtz [2][size]int16
+}
+
+// Legal tells if a placement is a legal position: a pawn is not on the
+// first or last rank and the side not to move is not in check.
+func (e *Endgame) Legal(wk, x, bk int, whiteToMove bool) bool {
+	return e.legal[side(whiteToMove)][Index(wk, x, bk)]
+}
+
+// WDL returns 1 if the side to move wins, -1 if it loses and 0 for draws.
+func (e *Endgame) WDL(wk, x, bk int, whiteToMove bool) int {
+	return int(e.wdl[side(whiteToMove)][Index(wk, x, bk)])
+}
+
+// DTM returns the plies to mate, positive if the side to move mates,
+// negative if it is mated, and 0 for draws and checkmates.
+func (e *Endgame) DTM(wk, x, bk int, whiteToMove bool) int {
+	return e.signed(&e.dtm, wk, x, bk, whiteToMove)
+}
+
+// DTZ returns the plies to the next capture or pawn move, mates included,
+// like DTM.
+func (e *Endgame) DTZ(wk, x, bk int, whiteToMove bool) int {
+	return e.signed(&e.dtz, wk, x, bk, whiteToMove)
+}
+
+func (e *Endgame) signed(plies *[2][size]int16, wk, x, bk int, whiteToMove bool) int {
+	s, i := side(whiteToMove), Index(wk, x, bk)
+	return int(e.wdl[s][i]) * int(plies[s][i])
+}
+
+// Board returns the position of a placement. With swap set, colors are
+// swapped and the board is mirrored, which does not change the result.
+func (e *Endgame) Board(wk, x, bk int, whiteToMove, swap bool) core.Board {
+	symbols := map[int]string{wk: "K", x: strings.ToUpper(e.Piece.Symbol()), bk: "k"}
+	if swap {
+		symbols = map[int]string{wk ^ 56: "k", x ^ 56: e.Piece.Symbol(), bk ^ 56: "K"}
+		whiteToMove = !whiteToMove
+	}
+
+	return newBoard(symbols, whiteToMove)
+}
+
+// newBoard returns the position of pieces by square.
+func newBoard(symbols map[int]string, whiteToMove bool) core.Board {
+	b := core.NewBoard(false)
+	for s, symbol := range symbols {
+		p := core.NewPieceFromSymbol(symbol)
+		b.SetPieceAt(core.Square(s), &p, false)
+	}
+
+	turn := "w"
+	if !whiteToMove {
+		turn = "b"
+	}
+	return core.NewBoardFromFEN(fmt.Sprintf("%s %s - - 0 1", strings.Fields(b.FEN(false, "legal", core.NoPiece))[0], turn), false)
+}
+
+func side(whiteToMove bool) int {
+	if whiteToMove {
+		return 0
+	}
+	return 1
+}
+
+var (
+	mu     sync.Mutex
+	solved = map[core.PieceType]*Endgame{}
+)
+
+// Solve returns the analysis of the white king and a piece against the
+// black king. Analyses are kept, so that solving again is free.
+func Solve(pt core.PieceType) *Endgame {
+	mu.Lock()
+	e := solved[pt]
+	mu.Unlock()
+	if e != nil {
+		return e
+	}
+
+	// Pawns promote to the other endgames
+	promotions := map[core.PieceType]*Endgame{}
+	if pt == core.Pawn {
+		for _, promotion := range []core.PieceType{core.Queen, core.Rook, core.Bishop, core.Knight} {
+			promotions[promotion] = Solve(promotion)
+		}
+	}
+
+	e = solve(pt, promotions)
+
+	mu.Lock()
+	solved[pt] = e
+	mu.Unlock()
+	return e
+}
+
+// edge is a move of a position: to another placement of the endgame, or
+// to another endgame with a known result.
+type edge struct {
+	to      int
+	zeroing bool
+
+	// For moves leaving the endgame, the result of the opponent
+	external bool
+	wdl      int8
+	dtm      int16
+}
+
+type position struct {
+	side  int
+	idx   int
+	edges []edge
+}
+
+// moves returns the positions of the endgame with their moves, and marks
+// checkmates and stalemates.
+func (e *Endgame) moves(promotions map[core.PieceType]*Endgame) []position {
+	boards := [2]core.Board{
+		core.NewBoardFromFEN("8/8/8/8/8/8/8/8 w - - 0 1", false),
+		core.NewBoardFromFEN("8/8/8/8/8/8/8/8 b - - 0 1", false),
+	}
+	pieces := []core.Piece{core.NewPiece(core.King, core.White), core.NewPiece(e.Piece, core.White), core.NewPiece(core.King, core.Black)}
+
+	var positions []position
+	var buf []core.Move
+	for wk := 0; wk < 64; wk++ {
+		for x := 0; x < 64; x++ {
+			if x == wk || (e.Piece == core.Pawn && (x < 8 || x >= 56)) {
+				continue
+			}
+
+			for bk := 0; bk < 64; bk++ {
+				if bk == wk || bk == x {
+					continue
+				}
+
+				for s := range boards {
+					b := &boards[s]
+					for i, sq := range []int{wk, x, bk} {
+						b.SetPieceAt(core.Square(sq), &pieces[i], false)
+					}
+
+					if b.IsValid() && !b.WasIntoCheck() {
+						idx := Index(wk, x, bk)
+						e.legal[s][idx] = true
+						e.wdl[s][idx] = unknown
+
+						buf = b.LegalMoves(buf[:0])
+						p := position{side: s, idx: idx}
+						for i := range buf {
+							p.edges = append(p.edges, e.edge(b, &buf[i], wk, x, bk, promotions))
+						}
+
+						if len(buf) == 0 && b.IsCheck() {
+							e.wdl[s][idx] = loss
+						} else if len(buf) == 0 {
+							e.wdl[s][idx] = draw
+						} else {
+							positions = append(positions, p)
+						}
+					}
+
+					for _, sq := range []int{wk, x, bk} {
+						b.RemovePieceAt(core.Square(sq))
+					}
+				}
+			}
+		}
+	}
+
+	return positions
+}
+
+func (e *Endgame) edge(b *core.Board, m *core.Move, wk, x, bk int, promotions map[core.PieceType]*Endgame) edge {
+	from, to := int(m.FromSquare), int(m.ToSquare)
+
+	switch {
+	case from == bk && to == x:
+		// Only the kings are left
+		return edge{zeroing: true, external: true, wdl: draw}
+	case m.Promotion != core.NoPiece:
+		p := promotions[m.Promotion]
+		idx := Index(wk, to, bk)
+		return edge{zeroing: true, external: true, wdl: p.wdl[1][idx], dtm: p.dtm[1][idx]}
+	case from == wk:
+		return edge{to: Index(to, x, bk)}
+	case from == x:
+		return edge{to: Index(wk, to, bk), zeroing: e.Piece == core.Pawn}
+	}
+
+	return edge{to: Index(wk, x, to)}
+}
+
+func solve(pt core.PieceType, promotions map[core.PieceType]*Endgame) *Endgame {
+	e := &Endgame{Piece: pt}
+	positions := e.moves(promotions)
+
+	// Checkmates and stalemates are known, and moves to other endgames
+	known := [2][size]bool{}
+	for s := range e.legal {
+		for idx, legal := range e.legal[s] {
+			known[s][idx] = legal && e.wdl[s][idx] != unknown
+		}
+	}
+
+	lastExternal := 0
+	for _, p := range positions {
+		for _, m := range p.edges {
+			if m.external && int(m.dtm) > lastExternal {
+				lastExternal = int(m.dtm)
+			}
+		}
+	}
+
+	// The result of the opponent after a move, and its plies
+	result := func(p *position, m *edge, plies *[2][size]int16) (int8, int, bool) {
+		if m.external {
+			return m.wdl, int(m.dtm), true
+		}
+		return e.wdl[1-p.side][m.to], int(plies[1-p.side][m.to]), known[1-p.side][m.to]
+	}
+
+	// A position is won in n plies if a move leads to a loss in n-1, and
+	// lost in n plies if all moves lead to wins, the longest in n-1
+	for ply, lastChange := 1, 0; ply <= lastChange+2 || ply <= lastExternal+1; ply++ {
+		for i := range positions {
+			p := &positions[i]
+			if known[p.side][p.idx] {
+				continue
+			}
+
+			won, lost, longest := false, true, 0
+			for j := range p.edges {
+				wdl, plies, ok := result(p, &p.edges[j], &e.dtm)
+				won = won || (ok && wdl == loss && plies == ply-1)
+				lost = lost && ok && wdl == win
+				if plies > longest {
+					longest = plies
+				}
+			}
+
+			if won {
+				e.wdl[p.side][p.idx], e.dtm[p.side][p.idx] = win, int16(ply)
+			} else if lost && longest == ply-1 {
+				e.wdl[p.side][p.idx], e.dtm[p.side][p.idx] = loss, int16(ply)
+			} else {
+				continue
+			}
+
+			known[p.side][p.idx] = true
+			lastChange = ply
+		}
+	}
+
+	for _, p := range positions {
+		if !known[p.side][p.idx] {
+			e.wdl[p.side][p.idx] = draw
+		}
+	}
+
+	// The same with zeroing moves counting one ply, among the moves that
+	// keep the result. Only mates are known in advance.
+	for _, p := range positions {
+		known[p.side][p.idx] = false
+	}
+
+	for ply, lastChange := 1, 0; ply <= lastChange+2; ply++ {
+		for i := range positions {
+			p := &positions[i]
+			if known[p.side][p.idx] || e.wdl[p.side][p.idx] == draw {
+				continue
+			}
+
+			won, lost, longest := false, true, 0
+			for j := range p.edges {
+				m := &p.edges[j]
+				wdl, plies, ok := result(p, m, &e.dtz)
+				if m.zeroing {
+					plies, ok = 0, true
+				}
+
+				won = won || (ok && wdl == loss && plies == ply-1)
+				lost = lost && ok
+				if plies+1 > longest {
+					longest = plies + 1
+				}
+			}
+
+			if e.wdl[p.side][p.idx] == win && won {
+				e.dtz[p.side][p.idx] = int16(ply)
+			} else if e.wdl[p.side][p.idx] == loss && lost && longest == ply {
+				e.dtz[p.side][p.idx] = int16(ply)
+			} else {
+				continue
+			}
+
+			known[p.side][p.idx] = true
+			lastChange = ply
+		}
+	}
+
+	return e
+}
@@ -0,0 +1,205 @@
+package endgame
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+func TestSolve(t *testing.T) {
+	for _, c := range []struct {
+		piece core.PieceType
+		wins  int
+		dtm   int
+		dtz   int
+	}{
+		// Mates in 10, 16 and 28 moves. Without pawns the DTZ is the DTM.
+		{core.Queen, 144508, 19, 19},
+		{core.Rook, 175168, 31, 31},
+		{core.Bishop, 0, 0, 0},
+		{core.Knight, 0, 0, 0},
+		{core.Pawn, 124960, 55, 19},
+	} {
+		e := Solve(c.piece)
+
+		wins, dtm, dtz := 0, 0, 0
+		for idx := range e.wdl[0] {
+			if e.legal[0][idx] && e.wdl[0][idx] == win {
+				wins++
+				if int(e.dtm[0][idx]) > dtm {
+					dtm = int(e.dtm[0][idx])
+				}
+				if int(e.dtz[0][idx]) > dtz {
+					dtz = int(e.dtz[0][idx])
+				}
+			}
+		}
+
+		if wins != c.wins || dtm != c.dtm || dtz != c.dtz {
+			t.Errorf("%s: expected %d wins, longest mate %d and dtz %d, got %d, %d and %d", c.piece.Name(), c.wins, c.dtm, c.dtz, wins, dtm, dtz)
+		}
+	}
+
+	e := Solve(core.Pawn)
+	for _, c := range []struct {
+		fen           string
+		wdl, dtm, dtz int
+	}{
+		// The king in front of the pawn on the sixth rank wins, stepping
+		// aside first
+		{"4k3/8/4K3/4P3/8/8/8/8 w - - 0 1", 1, 21, 3},
+		{"4k3/8/4K3/4P3/8/8/8/8 b - - 0 1", -1, -24, -4},
+
+		// Stalemate, and the rook pawn does not win
+		{"4k3/4P3/4K3/8/8/8/8/8 b - - 0 1", 0, 0, 0},
+		{"k7/8/K7/P7/8/8/8/8 w - - 0 1", 0, 0, 0},
+
+		// Only with the opposition
+		{"8/8/4k3/8/4K3/4P3/8/8 w - - 0 1", 0, 0, 0},
+		{"8/8/4k3/8/4K3/4P3/8/8 b - - 0 1", -1, -36, -8},
+
+		// Promoting with check, then mating
+		{"k7/2K1P3/8/8/8/8/8/8 w - - 0 1", 1, 3, 1},
+	} {
+		b := core.NewBoardFromFEN(c.fen, false)
+		wk := int(b.Pieces(core.King, core.White).Lsb())
+		x := int(b.Pieces(core.Pawn, core.White).Lsb())
+		bk := int(b.Pieces(core.King, core.Black).Lsb())
+		whiteToMove := b.Turn() == core.White
+
+		if !e.Legal(wk, x, bk, whiteToMove) {
+			t.Errorf("%s: expected a legal position", c.fen)
+		}
+
+		wdl, dtm, dtz := e.WDL(wk, x, bk, whiteToMove), e.DTM(wk, x, bk, whiteToMove), e.DTZ(wk, x, bk, whiteToMove)
+		if wdl != c.wdl || dtm != c.dtm || dtz != c.dtz {
+			t.Errorf("%s: expected wdl %d, dtm %d and dtz %d, got %d, %d and %d", c.fen, c.wdl, c.dtm, c.dtz, wdl, dtm, dtz)
+		}
+
+		swapped := e.Board(wk, x, bk, whiteToMove, true)
+		if p := swapped.PieceAt(core.Square(x ^ 56)); p == nil || p.Symbol() != "p" || swapped.Turn() == b.Turn() {
+			t.Errorf("%s: unexpected board with colors swapped %s", c.fen, swapped.FEN(false, "legal", core.NoPiece))
+		}
+	}
+
+	// Adjacent kings
+	if e.Legal(int(core.A4), int(core.G2), int(core.A5), true) {
+		t.Error("expected adjacent kings to be illegal")
+	}
+}
+
+// expected returns the result and distance to zeroing of a position from
+// those after each of its moves, which are generated by core.Board.
+func expected(t *testing.T, b *core.Board) (int, int) {
+	moves := b.LegalMoves(nil)
+	if len(moves) == 0 {
+		if b.IsCheck() {
+			return -1, 0
+		}
+		return 0, 0
+	}
+
+	wdl, dtz := -1, 0
+	for i := range moves {
+		m := &moves[i]
+		zeroing := b.IsCapture(m) || b.PieceTypeAt(m.FromSquare) == core.Pawn
+
+		b.Push(m)
+		var squares [2][2]int
+		var types [2]core.PieceType
+		for c, color := range []core.Color{core.White, core.Black} {
+			squares[c] = [2]int{int(b.Pieces(core.King, color).Lsb()), -1}
+			for pt := core.Pawn; pt < core.King; pt++ {
+				if bb := b.Pieces(pt, color); bb != core.BBVoid {
+					squares[c][1], types[c] = int(bb.Lsb()), pt
+				}
+			}
+		}
+
+		whiteToMove := b.Turn() == core.White
+		var after, plies int
+		switch {
+		case squares[0][1] >= 0 && squares[1][1] >= 0:
+			v := SolveVersus(types[0], types[1])
+			if !v.Legal(squares[0][0], squares[0][1], squares[1][0], squares[1][1], whiteToMove) {
+				t.Fatalf("%s: unexpected position after %s", b.FEN(false, "legal", core.NoPiece), m.Uci())
+			}
+			after, plies = v.WDL(squares[0][0], squares[0][1], squares[1][0], squares[1][1], whiteToMove), v.DTZ(squares[0][0], squares[0][1], squares[1][0], squares[1][1], whiteToMove)
+		case squares[0][1] >= 0:
+			e := Solve(types[0])
+			after, plies = e.WDL(squares[0][0], squares[0][1], squares[1][0], whiteToMove), e.DTZ(squares[0][0], squares[0][1], squares[1][0], whiteToMove)
+		default:
+			e := Solve(types[1])
+			after, plies = e.WDL(squares[1][0]^56, squares[1][1]^56, squares[0][0]^56, !whiteToMove), e.DTZ(squares[1][0]^56, squares[1][1]^56, squares[0][0]^56, !whiteToMove)
+		}
+		b.Pop()
+
+		if plies < 0 {
+			plies = -plies
+		}
+		if zeroing {
+			plies = 0
+		}
+
+		switch {
+		case -after > wdl:
+			wdl, dtz = -after, plies+1
+		case -after == wdl && wdl == 1 && plies+1 < dtz:
+			dtz = plies + 1
+		case -after == wdl && wdl == -1 && plies+1 > dtz:
+			dtz = plies + 1
+		}
+	}
+
+	if wdl == 0 {
+		dtz = 0
+	}
+	return wdl, dtz
+}
+
+func TestSolveVersus(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, c := range []struct {
+		white, black core.PieceType
+		fen          string
+		wdl, dtz     int
+	}{
+		// Skewering the king and the rook
+		{core.Rook, core.Rook, "4k2r/8/8/8/8/8/8/R5K1 w - - 0 1", 1, 3},
+
+		// Taking the pawn, with the king in front of the other
+		{core.Pawn, core.Pawn, "8/8/4p3/3KP3/8/8/8/7k w - - 0 1", 1, 1},
+	} {
+		v := SolveVersus(c.white, c.black)
+
+		b := core.NewBoardFromFEN(c.fen, false)
+		wk, x := int(b.Pieces(core.King, core.White).Lsb()), int(b.Pieces(c.white, core.White).Lsb())
+		bk, y := int(b.Pieces(core.King, core.Black).Lsb()), int(b.Pieces(c.black, core.Black).Lsb())
+		whiteToMove := b.Turn() == core.White
+		if wdl, dtz := v.WDL(wk, x, bk, y, whiteToMove), v.DTZ(wk, x, bk, y, whiteToMove); wdl != c.wdl || dtz != c.dtz {
+			t.Errorf("%s: expected wdl %d and dtz %d, got %d and %d", c.fen, c.wdl, c.dtz, wdl, dtz)
+		}
+
+		// The results agree with the moves of core.Board
+		for checked := 0; checked < 2000; {
+			wk, x, bk, y := r.Intn(64), r.Intn(64), r.Intn(64), r.Intn(64)
+			whiteToMove := r.Intn(2) == 0
+			if !v.Legal(wk, x, bk, y, whiteToMove) {
+				continue
+			}
+			checked++
+
+			b := v.Board(wk, x, bk, y, whiteToMove, r.Intn(2) == 0)
+			wdl, dtz := expected(t, &b)
+			if v.WDL(wk, x, bk, y, whiteToMove) != wdl || v.DTZ(wk, x, bk, y, whiteToMove) != wdl*dtz {
+				t.Errorf("%s: expected wdl %d and dtz %d, got %d and %d", b.FEN(false, "legal", core.NoPiece), wdl, wdl*dtz, v.WDL(wk, x, bk, y, whiteToMove), v.DTZ(wk, x, bk, y, whiteToMove))
+			}
+		}
+	}
+
+	// Pawns that may pass each other are not solved
+	if v := SolveVersus(core.Pawn, core.Pawn); v.Legal(int(core.A1), int(core.E4), int(core.H8), int(core.D5), true) {
+		t.Error("expected pawns on different files not to be solved")
+	}
+}
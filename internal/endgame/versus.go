@@ -0,0 +1,414 @@
+package endgame
+
+import (
+	"strings"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// Versus is the analysis of the white king and a piece against the black
+// king and a piece, by side to move and placement. Without pawns all
+// placements are solved. With a pawn on each side only placements with the
+// pawns blocking each other on a file are, since the others promote to
+// endgames that are not solved. Moves come from the attack tables of the
+// core package, without en passant captures, like tablebases store them.
+type Versus struct {
+	White core.PieceType
+	Black core.PieceType
+
+	legal [2][]bool
+	wdl   [2][]int8
+
+	// Plies to zeroing the halfmove clock, like Endgame
+	dtz [2][]int16
+
+	// While solving, the moves that do not zero the halfmove clock and do
+	// not lead to a win of the opponent, or cannotLose
+	count [2][]uint8
+}
+
+const cannotLose = 0xff
+
+// Pawn ranks of the white and black pawn blocking each other, by index
+var (
+	blockedRanks [][2]int
+	blockedIdx   [8][8]int
+)
+
+func init() {
+	for w := 1; w < 7; w++ {
+		for b := w + 1; b < 7; b++ {
+			blockedIdx[w][b] = len(blockedRanks)
+			blockedRanks = append(blockedRanks, [2]int{w, b})
+		}
+	}
+}
+
+// Placements are indexed by the squares of the white king, the white
+// piece, the black king and the black piece, in that order.
+type placement [4]int
+
+// index returns the index of a placement. Without pawns the placement is
+// mirrored so that the white king is on the a1-d4 quadrant, with pawns so
+// that the pawns are on the a-d files.
+func (v *Versus) index(p placement) (int, bool) {
+	if v.White == core.Pawn {
+		wp, bp := p[1], p[3]
+		if wp&7 != bp&7 || wp>>3 < 1 || bp>>3 > 6 || wp>>3 >= bp>>3 {
+			return 0, false
+		}
+
+		f := wp & 7
+		if f > 3 {
+			f ^= 7
+			p[0], p[2] = p[0]^7, p[2]^7
+		}
+		return (blockedIdx[wp>>3][bp>>3]*4+f)<<12 | p[0]<<6 | p[2], true
+	}
+
+	if p[0]&7 > 3 {
+		for i := range p {
+			p[i] ^= 7
+		}
+	}
+	if p[0]>>3 > 3 {
+		for i := range p {
+			p[i] ^= 56
+		}
+	}
+	return (p[0]>>3*4+p[0]&7)<<18 | p[1]<<12 | p[2]<<6 | p[3], true
+}
+
+// placement returns the placement of an index.
+func (v *Versus) placement(idx int) placement {
+	if v.White == core.Pawn {
+		ranks, f := blockedRanks[idx>>14], idx>>12&3
+		return placement{idx >> 6 & 63, ranks[0]*8 + f, idx & 63, ranks[1]*8 + f}
+	}
+
+	wk := idx >> 18
+	return placement{wk>>2*8 + wk&3, idx >> 12 & 63, idx >> 6 & 63, idx & 63}
+}
+
+func (v *Versus) size() int {
+	if v.White == core.Pawn {
+		return len(blockedRanks) * 4 << 12
+	}
+	return 16 << 18
+}
+
+// group returns the placements that are solved together: pawn moves lead
+// to placements with fewer ranks between the pawns, which are solved first.
+func (v *Versus) group(idx int) int {
+	if v.White == core.Pawn {
+		ranks := blockedRanks[idx>>14]
+		return ranks[1] - ranks[0]
+	}
+	return 0
+}
+
+// Legal tells if a placement is a legal position that is solved.
+func (v *Versus) Legal(wk, x, bk, y int, whiteToMove bool) bool {
+	idx, ok := v.index(placement{wk, x, bk, y})
+	return ok && v.legal[side(whiteToMove)][idx]
+}
+
+// WDL returns 1 if the side to move wins, -1 if it loses and 0 for draws.
+func (v *Versus) WDL(wk, x, bk, y int, whiteToMove bool) int {
+	idx, ok := v.index(placement{wk, x, bk, y})
+	if !ok {
+		return 0
+	}
+	return int(v.wdl[side(whiteToMove)][idx])
+}
+
+// DTZ returns the plies to the next capture or pawn move, mates included,
+// positive if the side to move wins and negative if it loses.
+func (v *Versus) DTZ(wk, x, bk, y int, whiteToMove bool) int {
+	idx, ok := v.index(placement{wk, x, bk, y})
+	if !ok {
+		return 0
+	}
+
+	s := side(whiteToMove)
+	return int(v.wdl[s][idx]) * int(v.dtz[s][idx])
+}
+
+// Board returns the position of a placement. With swap set, colors are
+// swapped and the board is mirrored, which does not change the result.
+func (v *Versus) Board(wk, x, bk, y int, whiteToMove, swap bool) core.Board {
+	white, black := v.White.Symbol(), v.Black.Symbol()
+	symbols := map[int]string{wk: "K", x: strings.ToUpper(white), bk: "k", y: black}
+	if swap {
+		symbols = map[int]string{wk ^ 56: "k", x ^ 56: white, bk ^ 56: "K", y ^ 56: strings.ToUpper(black)}
+		whiteToMove = !whiteToMove
+	}
+
+	return newBoard(symbols, whiteToMove)
+}
+
+var solvedVersus = map[[2]core.PieceType]*Versus{}
+
+// SolveVersus returns the analysis of the white king and a piece against
+// the black king and a piece. A pawn on only one side is not supported.
+// Analyses are kept, so that solving again is free.
+func SolveVersus(white, black core.PieceType) *Versus {
+	if (white == core.Pawn) != (black == core.Pawn) {
+		panic("endgame: cannot solve " + white.Name() + " against " + black.Name())
+	}
+
+	key := [2]core.PieceType{white, black}
+	mu.Lock()
+	v := solvedVersus[key]
+	mu.Unlock()
+	if v != nil {
+		return v
+	}
+
+	v = &Versus{White: white, Black: black}
+	v.solve([2]*Endgame{Solve(white), Solve(black)})
+
+	mu.Lock()
+	solvedVersus[key] = v
+	mu.Unlock()
+	return v
+}
+
+// Pieces of a placement by color: the king, then the piece
+var (
+	pieceColors = [4]core.Color{core.White, core.White, core.Black, core.Black}
+	kingOf      = [2]int{0, 2}
+)
+
+func (v *Versus) pieceType(i int) core.PieceType {
+	switch i {
+	case 1:
+		return v.White
+	case 3:
+		return v.Black
+	}
+	return core.King
+}
+
+func occupied(p *placement) core.Bitboard {
+	bb := core.BBVoid
+	for _, s := range p {
+		if s >= 0 {
+			bb |= core.NewBitboardFromSquare(core.Square(s))
+		}
+	}
+	return bb
+}
+
+// attacks returns the squares attacked by piece i of a placement.
+func (v *Versus) attacks(p *placement, i int, occupied core.Bitboard) core.Bitboard {
+	s := core.Square(p[i])
+	switch v.pieceType(i) {
+	case core.King:
+		return core.KingAttacks(s)
+	case core.Queen:
+		return core.RookAttacks(s, occupied) | core.BishopAttacks(s, occupied)
+	case core.Rook:
+		return core.RookAttacks(s, occupied)
+	case core.Bishop:
+		return core.BishopAttacks(s, occupied)
+	case core.Knight:
+		return core.KnightAttacks(s)
+	}
+	return core.PawnAttacks(s, pieceColors[i])
+}
+
+// inCheck tells if the king of a side is attacked. Captured pieces are on
+// square -1.
+func (v *Versus) inCheck(p *placement, s int) bool {
+	king := core.NewBitboardFromSquare(core.Square(p[kingOf[s]]))
+	bb := occupied(p)
+	for i := kingOf[1-s]; i <= kingOf[1-s]+1; i++ {
+		if p[i] >= 0 && v.attacks(p, i, bb).IsMaskingBB(king) {
+			return true
+		}
+	}
+	return false
+}
+
+// moves calls visit with the placement after each legal move of side s,
+// telling if the move zeroes the halfmove clock. Captures leave the
+// captured piece on square -1.
+func (v *Versus) moves(p *placement, s int, visit func(to placement, zeroing bool)) int {
+	bb := occupied(p)
+	capturable := core.NewBitboardFromSquare(core.Square(p[kingOf[1-s]+1]))
+	n := 0
+	for i := kingOf[s]; i <= kingOf[s]+1; i++ {
+		var targets core.Bitboard
+		pawn := v.pieceType(i) == core.Pawn
+		if pawn {
+			forward, start := 8, 1
+			if s == 1 {
+				forward, start = -8, 6
+			}
+
+			if one := p[i] + forward; !bb.IsMaskingBB(core.NewBitboardFromSquare(core.Square(one))) {
+				targets |= core.NewBitboardFromSquare(core.Square(one))
+				if two := one + forward; p[i]>>3 == start && !bb.IsMaskingBB(core.NewBitboardFromSquare(core.Square(two))) {
+					targets |= core.NewBitboardFromSquare(core.Square(two))
+				}
+			}
+			targets |= v.attacks(p, i, bb) & capturable
+		} else {
+			targets = v.attacks(p, i, bb) &^ (bb &^ capturable)
+		}
+
+		for targets != core.BBVoid {
+			to := int(targets.PopLsb())
+			if pawn && (to>>3 == 0 || to>>3 == 7) {
+				panic("endgame: promotions are not solved")
+			}
+
+			after := *p
+			after[i] = to
+			capture := to == p[kingOf[1-s]+1]
+			if capture {
+				after[kingOf[1-s]+1] = -1
+			}
+
+			if v.inCheck(&after, s) {
+				continue
+			}
+
+			n++
+			visit(after, capture || pawn)
+		}
+	}
+
+	return n
+}
+
+// result returns the result of the side to move after a zeroing move of
+// side s.
+func (v *Versus) result(after placement, s int, threes [2]*Endgame) int8 {
+	opponent := 1 - s
+	if after[kingOf[opponent]+1] >= 0 {
+		idx, _ := v.index(after)
+		return v.wdl[opponent][idx]
+	}
+
+	// Only the pieces of side s are left, with colors swapped for black
+	flip := 0
+	if s == 1 {
+		flip = 56
+	}
+	k, x, other := after[kingOf[s]]^flip, after[kingOf[s]+1]^flip, after[kingOf[opponent]]^flip
+	return threes[s].wdl[1][Index(k, x, other)]
+}
+
+func (v *Versus) solve(threes [2]*Endgame) {
+	size := v.size()
+	for s := range v.legal {
+		v.legal[s] = make([]bool, size)
+		v.wdl[s] = make([]int8, size)
+		v.dtz[s] = make([]int16, size)
+		v.count[s] = make([]uint8, size)
+	}
+
+	for group := 0; group < 7; group++ {
+		// Positions decided in each number of plies, as side and index
+		var queue [][]int
+		decide := func(s, idx int, wdl int8, plies int) {
+			v.wdl[s][idx], v.dtz[s][idx] = wdl, int16(plies)
+			for len(queue) <= plies {
+				queue = append(queue, nil)
+			}
+			queue[plies] = append(queue[plies], idx<<1|s)
+		}
+
+		for idx := 0; idx < size; idx++ {
+			if v.group(idx) != group {
+				continue
+			}
+
+			p := v.placement(idx)
+			if occupied(&p).PopCount() != 4 {
+				continue
+			}
+
+			for s := range v.legal {
+				if v.inCheck(&p, 1-s) {
+					continue
+				}
+				v.legal[s][idx] = true
+
+				// Only zeroing moves are known yet
+				internal, winning, losing := 0, false, true
+				n := v.moves(&p, s, func(after placement, zeroing bool) {
+					if !zeroing {
+						internal++
+						return
+					}
+
+					wdl := v.result(after, s, threes)
+					winning = winning || wdl == loss
+					losing = losing && wdl == win
+				})
+
+				switch {
+				case n == 0 && v.inCheck(&p, s):
+					decide(s, idx, loss, 0)
+				case n == 0:
+					v.wdl[s][idx] = draw
+				case winning:
+					decide(s, idx, win, 1)
+				case losing && internal == 0:
+					decide(s, idx, loss, 1)
+				case losing:
+					v.wdl[s][idx], v.count[s][idx] = unknown, uint8(internal)
+				default:
+					v.wdl[s][idx], v.count[s][idx] = unknown, cannotLose
+				}
+			}
+		}
+
+		// A position is won in n plies if a move leads to a loss in n-1,
+		// and lost in n plies if all moves lead to wins, the last one
+		// found in n-1. The positions leading to one are found by moving
+		// the pieces back.
+		for plies := 0; plies < len(queue); plies++ {
+			for _, entry := range queue[plies] {
+				s, idx := entry&1, entry>>1
+				p := v.placement(idx)
+				bb := occupied(&p)
+				mover := 1 - s
+
+				for i := kingOf[mover]; i <= kingOf[mover]+1; i++ {
+					if v.pieceType(i) == core.Pawn {
+						continue
+					}
+
+					for from := v.attacks(&p, i, bb) &^ bb; from != core.BBVoid; {
+						before := p
+						before[i] = int(from.PopLsb())
+						pidx, ok := v.index(before)
+						if !ok || !v.legal[mover][pidx] || v.wdl[mover][pidx] != unknown {
+							continue
+						}
+
+						if v.wdl[s][idx] == loss {
+							decide(mover, pidx, win, plies+1)
+						} else if v.count[mover][pidx]--; v.count[mover][pidx] == 0 {
+							decide(mover, pidx, loss, plies+1)
+						}
+					}
+				}
+			}
+		}
+
+		for idx := 0; idx < size; idx++ {
+			for s := range v.wdl {
+				if v.group(idx) == group && v.wdl[s][idx] == unknown {
+					v.wdl[s][idx] = draw
+				}
+			}
+		}
+	}
+
+	v.count = [2][]uint8{}
+}
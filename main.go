@@ -1,9 +1,17 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	. "github.com/captainsano/golang-chess/core"
+	"github.com/captainsano/golang-chess/engine"
 )
 
 func oldMain() {
@@ -43,21 +51,121 @@ func printStatus(b *Board) {
 	fmt.Println(b.Unicode(false, false))
 
 	fmt.Print("Legal Moves: ")
-	for m := range b.GenerateLegalMoves(BBAll, BBAll) {
+	for _, m := range b.LegalMoves(nil) {
 		fmt.Print(b.San(&m), " ")
 	}
 	fmt.Println()
 }
 
+const usage = `usage: golang-chess                  speak UCI or CECP on stdin and stdout
+       golang-chess perft [-divide] [-stats] [-chess960] depth [fen]`
+
+// searcher chooses the moves played over UCI and CECP
+var searcher engine.Searcher = engine.MaterialSearcher{}
+
+// serve speaks the protocol of the first command, "uci" or "xboard".
+func serve() error {
+	r := bufio.NewReader(os.Stdin)
+	first, err := r.ReadString('\n')
+	if err != nil && first == "" {
+		return nil
+	}
+
+	input := io.MultiReader(strings.NewReader(first), r)
+	if strings.TrimSpace(first) == "xboard" {
+		return engine.NewXBoardServer("golang-chess", searcher).Serve(input, os.Stdout)
+	}
+
+	return engine.NewUCIServer("golang-chess", "captainsano", searcher).Serve(input, os.Stdout)
+}
+
+// perft counts the leaf nodes from a position, see Board.Perft
+func perft(args []string) error {
+	flags := flag.NewFlagSet("perft", flag.ContinueOnError)
+	divide := flags.Bool("divide", false, "print the node counts per root move")
+	stats := flags.Bool("stats", false, "break down the leaf nodes by kind of move")
+	chess960 := flags.Bool("chess960", false, "read castling rights of the fen as chess960")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() < 1 {
+		return fmt.Errorf(usage)
+	}
+
+	depth, err := strconv.Atoi(flags.Arg(0))
+	if err != nil || depth < 0 {
+		return fmt.Errorf("invalid depth %q", flags.Arg(0))
+	}
+
+	// The fen may be passed as a single argument or unquoted
+	fen := StartingFEN
+	if flags.NArg() > 1 {
+		fen = strings.Join(flags.Args()[1:], " ")
+	}
+
+	board := NewBoard(*chess960)
+	if err := board.SetFEN(fen); err != nil {
+		return err
+	}
+
+	start := time.Now()
+
+	total := PerftStats{Nodes: 1}
+	if *divide {
+		total = PerftStats{}
+		for _, d := range board.PerftDivide(depth, *stats) {
+			fmt.Printf("%s: %d", d.Move.Uci(), d.Stats.Nodes)
+			if *stats {
+				printPerftStats(d.Stats)
+			}
+			fmt.Println()
+
+			total.Add(d.Stats)
+		}
+		fmt.Println()
+	} else if *stats {
+		total = board.DetailedPerft(depth)
+	} else {
+		total.Nodes = board.Perft(depth)
+	}
+
+	elapsed := time.Since(start)
+
+	fmt.Printf("nodes: %d", total.Nodes)
+	if *stats {
+		printPerftStats(total)
+	}
+	fmt.Println()
+	fmt.Printf("time: %v (%.0f nodes/s)\n", elapsed, float64(total.Nodes)/elapsed.Seconds())
+
+	return nil
+}
+
+func printPerftStats(s PerftStats) {
+	fmt.Printf(" captures: %d ep: %d castles: %d promotions: %d checks: %d mates: %d",
+		s.Captures, s.EnPassants, s.Castles, s.Promotions, s.Checks, s.Mates)
+}
+
 func main() {
-	fmt.Println("--> ", StatusValid)
-	fmt.Println("--> ", StatusNoWhiteKing)
-	fmt.Println("--> ", StatusNoBlackKing)
-	fmt.Println("--> ", StatusTooManyKings)
-	fmt.Println("--> ", StatusTooManyWhitePawns)
-	fmt.Println("--> ", StatusTooManyBlackPawns)
-	fmt.Println("--> ", StatusPawnsOnBackRank)
-	fmt.Println("--> ", StatusTooManyWhitePieces)
-	fmt.Println("--> ", StatusRaceMaterial)
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "perft":
+			err = perft(os.Args[2:])
+		default:
+			err = fmt.Errorf(usage)
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
 
+	if err := serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
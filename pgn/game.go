@@ -0,0 +1,401 @@
+package pgn
+
+import (
+	"strings"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// Numeric annotation glyphs
+const (
+	NagNull                      = 0
+	NagGoodMove                  = 1
+	NagMistake                   = 2
+	NagBrilliantMove             = 3
+	NagBlunder                   = 4
+	NagSpeculativeMove           = 5
+	NagDubiousMove               = 6
+	NagForcedMove                = 7
+	NagSingularMove              = 8
+	NagWorstMove                 = 9
+	NagDrawishPosition           = 10
+	NagQuietPosition             = 11
+	NagActivePosition            = 12
+	NagUnclearPosition           = 13
+	NagWhiteSlightAdvantage      = 14
+	NagBlackSlightAdvantage      = 15
+	NagWhiteModerateAdvantage    = 16
+	NagBlackModerateAdvantage    = 17
+	NagWhiteDecisiveAdvantage    = 18
+	NagBlackDecisiveAdvantage    = 19
+	NagWhiteZugzwang             = 22
+	NagBlackZugzwang             = 23
+	NagWhiteModerateCounterplay  = 132
+	NagBlackModerateCounterplay  = 133
+	NagWhiteDecisiveCounterplay  = 134
+	NagBlackDecisiveCounterplay  = 135
+	NagWhiteModerateTimePressure = 136
+	NagBlackModerateTimePressure = 137
+	NagWhiteSevereTimePressure   = 138
+	NagBlackSevereTimePressure   = 139
+	NagNovelty                   = 146
+)
+
+// Seven Tag Roster, in the order they are exported
+var TagRoster = [...]string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// Headers are the tag pairs of a game. Tags of the Seven Tag Roster are
+// always listed first, all other tags in the order they were set.
+type Headers struct {
+	names  []string
+	values map[string]string
+}
+
+func NewHeaders() Headers {
+	h := Headers{values: map[string]string{}}
+	h.Set("Event", "?")
+	h.Set("Site", "?")
+	h.Set("Date", "????.??.??")
+	h.Set("Round", "?")
+	h.Set("White", "?")
+	h.Set("Black", "?")
+	h.Set("Result", "*")
+	return h
+}
+
+func NewEmptyHeaders() Headers {
+	return Headers{values: map[string]string{}}
+}
+
+func (h *Headers) Get(name string) (string, bool) {
+	v, ok := h.values[name]
+	return v, ok
+}
+
+func (h *Headers) Set(name, value string) {
+	if h.values == nil {
+		h.values = map[string]string{}
+	}
+
+	if _, ok := h.values[name]; !ok {
+		h.names = append(h.names, name)
+	}
+
+	h.values[name] = value
+}
+
+func (h *Headers) Delete(name string) {
+	if _, ok := h.values[name]; !ok {
+		return
+	}
+
+	delete(h.values, name)
+	for i, n := range h.names {
+		if n == name {
+			h.names = append(h.names[:i], h.names[i+1:]...)
+			break
+		}
+	}
+}
+
+func (h *Headers) Len() int {
+	return len(h.names)
+}
+
+func (h *Headers) Names() []string {
+	names := []string{}
+
+	for _, n := range TagRoster {
+		if _, ok := h.values[n]; ok {
+			names = append(names, n)
+		}
+	}
+
+	for _, n := range h.names {
+		if !isRosterTag(n) {
+			names = append(names, n)
+		}
+	}
+
+	return names
+}
+
+func isRosterTag(name string) bool {
+	for _, n := range TagRoster {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *Headers) IsChess960() bool {
+	variant, _ := h.Get("Variant")
+	switch strings.ToLower(variant) {
+	case "chess960", "chess 960", "fischerandom", "fischerrandom", "fischer random":
+		return true
+	}
+
+	return false
+}
+
+// Board returns the starting position described by the FEN and Variant tags.
+// An invalid FEN tag results in an empty board, see ParseBoard.
+func (h *Headers) Board() core.Board {
+	board, _ := h.ParseBoard()
+	return board
+}
+
+// ParseBoard is Board, returning the *core.FENError of an invalid FEN tag.
+func (h *Headers) ParseBoard() (core.Board, error) {
+	board := core.NewBoard(h.IsChess960())
+
+	fen, ok := h.Get("FEN")
+	if !ok {
+		fen = core.StartingFEN
+	}
+
+	err := board.SetFEN(fen)
+	return board, err
+}
+
+// GameNode is a position in the game tree, reached by Move from its Parent.
+// The first of the Variations is the main continuation.
+type GameNode struct {
+	Parent          *GameNode
+	Move            core.Move
+	Nags            []int
+	Comment         string
+	StartingComment string
+	Variations      []*GameNode
+
+	game *Game
+}
+
+func (n *GameNode) Root() *GameNode {
+	node := n
+	for node.Parent != nil {
+		node = node.Parent
+	}
+
+	return node
+}
+
+// Game returns the game the node belongs to, or nil for detached nodes.
+func (n *GameNode) Game() *Game {
+	return n.Root().game
+}
+
+// Moves returns the moves leading from the root to this node.
+func (n *GameNode) Moves() []core.Move {
+	moves := []core.Move{}
+	for node := n; node.Parent != nil; node = node.Parent {
+		moves = append(moves, node.Move)
+	}
+
+	for i, j := 0, len(moves)-1; i < j; i, j = i+1, j-1 {
+		moves[i], moves[j] = moves[j], moves[i]
+	}
+
+	return moves
+}
+
+// Board returns the position after the move of this node.
+func (n *GameNode) Board() core.Board {
+	board := core.NewDefaultBoard()
+	if g := n.Game(); g != nil {
+		board = g.Headers.Board()
+	}
+
+	for _, m := range n.Moves() {
+		board.Push(&m)
+	}
+
+	return board
+}
+
+// San returns the move of this node in standard algebraic notation.
+func (n *GameNode) San() string {
+	if n.Parent == nil {
+		return ""
+	}
+
+	board := n.Parent.Board()
+	return board.San(&n.Move)
+}
+
+func (n *GameNode) Ply() int {
+	ply := 0
+	for node := n; node.Parent != nil; node = node.Parent {
+		ply++
+	}
+
+	return ply
+}
+
+// Next returns the main continuation, or nil at the end of a line.
+func (n *GameNode) Next() *GameNode {
+	if len(n.Variations) == 0 {
+		return nil
+	}
+
+	return n.Variations[0]
+}
+
+// End follows the main continuation to the end of the line.
+func (n *GameNode) End() *GameNode {
+	node := n
+	for node.Next() != nil {
+		node = node.Next()
+	}
+
+	return node
+}
+
+func (n *GameNode) IsEnd() bool {
+	return len(n.Variations) == 0
+}
+
+// IsMainVariation checks whether the node is the main continuation of its
+// parent.
+func (n *GameNode) IsMainVariation() bool {
+	if n.Parent == nil {
+		return true
+	}
+
+	return n.Parent.Next() == n
+}
+
+// IsMainLine checks whether the node is on the main line of the game.
+func (n *GameNode) IsMainLine() bool {
+	for node := n; node.Parent != nil; node = node.Parent {
+		if !node.IsMainVariation() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MainLine returns the moves of the main continuation from this node.
+func (n *GameNode) MainLine() []core.Move {
+	moves := []core.Move{}
+	for node := n.Next(); node != nil; node = node.Next() {
+		moves = append(moves, node.Move)
+	}
+
+	return moves
+}
+
+func (n *GameNode) Variation(m core.Move) *GameNode {
+	for _, v := range n.Variations {
+		if v.Move == m {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func (n *GameNode) HasVariation(m core.Move) bool {
+	return n.Variation(m) != nil
+}
+
+// AddVariation appends a continuation with the given move.
+func (n *GameNode) AddVariation(m core.Move) *GameNode {
+	node := &GameNode{Parent: n, Move: m, Nags: []int{}}
+	n.Variations = append(n.Variations, node)
+	return node
+}
+
+// AddMainVariation inserts a continuation with the given move as the main
+// one.
+func (n *GameNode) AddMainVariation(m core.Move) *GameNode {
+	node := n.AddVariation(m)
+	n.PromoteToMain(m)
+	return node
+}
+
+func (n *GameNode) RemoveVariation(m core.Move) {
+	for i, v := range n.Variations {
+		if v.Move == m {
+			n.Variations = append(n.Variations[:i], n.Variations[i+1:]...)
+			return
+		}
+	}
+}
+
+func (n *GameNode) PromoteToMain(m core.Move) {
+	for i, v := range n.Variations {
+		if v.Move == m {
+			copy(n.Variations[1:i+1], n.Variations[:i])
+			n.Variations[0] = v
+			return
+		}
+	}
+}
+
+func (n *GameNode) Promote(m core.Move) {
+	for i, v := range n.Variations {
+		if v.Move == m && i > 0 {
+			n.Variations[i-1], n.Variations[i] = n.Variations[i], n.Variations[i-1]
+			return
+		}
+	}
+}
+
+func (n *GameNode) Demote(m core.Move) {
+	for i, v := range n.Variations {
+		if v.Move == m && i < len(n.Variations)-1 {
+			n.Variations[i+1], n.Variations[i] = n.Variations[i], n.Variations[i+1]
+			return
+		}
+	}
+}
+
+// Game is the root of a game tree together with its headers.
+type Game struct {
+	GameNode
+
+	Headers Headers
+
+	// Errors encountered while parsing the game
+	Errors []error
+}
+
+func NewGame() *Game {
+	g := &Game{Headers: NewHeaders()}
+	g.Nags = []int{}
+	g.game = g
+	return g
+}
+
+// NewGameFromBoard creates a game starting at the given position, with
+// the moves of its move stack as main line.
+func NewGameFromBoard(b *core.Board) *Game {
+	g := NewGame()
+
+	moves := b.MoveStack()
+	root := core.NewBoardFromBoard(b)
+	for range moves {
+		root.Pop()
+	}
+
+	if root.IsChess960() {
+		g.Headers.Set("Variant", "Chess960")
+	}
+
+	if root.FEN(false, "legal", core.NoPiece) != core.StartingFEN {
+		g.Headers.Set("SetUp", "1")
+		g.Headers.Set("FEN", root.FEN(false, "legal", core.NoPiece))
+	}
+
+	node := &g.GameNode
+	for _, m := range moves {
+		node = node.AddVariation(m)
+	}
+
+	g.Headers.Set("Result", b.Result(false))
+	return g
+}
@@ -0,0 +1,284 @@
+package pgn
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type tokenKind uint8
+
+const (
+	tokenEOF tokenKind = iota
+	tokenTag
+	tokenComment
+	tokenNag
+	tokenOpen
+	tokenClose
+	tokenMoveNumber
+	tokenResult
+	tokenSan
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value string
+
+	// Position of the first character of the token
+	line   int
+	column int
+	offset int64
+}
+
+var moveNumberRegexp = regexp.MustCompile("^[0-9]+\\.*")
+
+var suffixNags = map[string]int{
+	"!":  NagGoodMove,
+	"?":  NagMistake,
+	"!!": NagBrilliantMove,
+	"??": NagBlunder,
+	"!?": NagSpeculativeMove,
+	"?!": NagDubiousMove,
+}
+
+// lexer splits PGN text into tokens and keeps track of their position.
+type lexer struct {
+	r *bufio.Reader
+
+	line   int
+	column int
+	offset int64
+
+	pending []token
+}
+
+func newLexer(r io.Reader) *lexer {
+	return &lexer{r: bufio.NewReader(r), line: 1, column: 1}
+}
+
+func (l *lexer) readByte() (byte, bool) {
+	c, err := l.r.ReadByte()
+	if err != nil {
+		return 0, false
+	}
+
+	l.offset++
+	if c == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+
+	return c, true
+}
+
+func (l *lexer) peekByte() (byte, bool) {
+	bs, err := l.r.Peek(1)
+	if err != nil {
+		return 0, false
+	}
+
+	return bs[0], true
+}
+
+func (l *lexer) skipLine() {
+	for {
+		c, ok := l.readByte()
+		if !ok || c == '\n' {
+			return
+		}
+	}
+}
+
+func (l *lexer) unread(t token) {
+	l.pending = append(l.pending, t)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '{', '}', '(', ')', '[', ']', ';', '$', '!', '?', '"', '%':
+		return true
+	}
+
+	return isSpace(c)
+}
+
+func (l *lexer) next() token {
+	if len(l.pending) > 0 {
+		t := l.pending[len(l.pending)-1]
+		l.pending = l.pending[:len(l.pending)-1]
+		return t
+	}
+
+	for {
+		c, ok := l.peekByte()
+		if !ok {
+			return token{kind: tokenEOF, line: l.line, column: l.column, offset: l.offset}
+		}
+
+		if isSpace(c) {
+			l.readByte()
+			continue
+		}
+
+		// Escaped lines and rest of line comments are ignored
+		if (c == '%' && l.column == 1) || c == ';' {
+			l.skipLine()
+			continue
+		}
+
+		// Byte order marks
+		if c == 0xef && l.offset == 0 {
+			if bs, err := l.r.Peek(3); err == nil && string(bs) == "\xef\xbb\xbf" {
+				l.readByte()
+				l.readByte()
+				l.readByte()
+				continue
+			}
+		}
+
+		break
+	}
+
+	t := token{line: l.line, column: l.column, offset: l.offset}
+	c, _ := l.readByte()
+
+	switch c {
+	case '[':
+		t.kind = tokenTag
+		t.text, t.value = l.readTag()
+	case '{':
+		t.kind = tokenComment
+		t.text = l.readUntil('}')
+	case '(':
+		t.kind = tokenOpen
+		t.text = "("
+	case ')':
+		t.kind = tokenClose
+		t.text = ")"
+	case '$':
+		t.kind = tokenNag
+		t.text = "$" + l.readWhile(func(c byte) bool { return c >= '0' && c <= '9' })
+		nag, _ := strconv.Atoi(t.text[1:])
+		t.value = strconv.Itoa(nag)
+	case '!', '?':
+		t.kind = tokenNag
+		t.text = string(c) + l.readWhile(func(c byte) bool { return c == '!' || c == '?' })
+		if nag, ok := suffixNags[t.text]; ok {
+			t.value = strconv.Itoa(nag)
+		}
+	default:
+		t.text = string(c) + l.readWhile(func(c byte) bool { return !isDelimiter(c) })
+		l.classifySymbol(&t)
+	}
+
+	return t
+}
+
+// classifySymbol tells apart move numbers, results and SAN. Move numbers
+// glued to the move ("12.e4") are split off.
+func (l *lexer) classifySymbol(t *token) {
+	switch t.text {
+	case "1-0", "0-1", "1/2-1/2", "*":
+		t.kind = tokenResult
+		return
+	}
+
+	if n := moveNumberRegexp.FindString(t.text); n != "" {
+		if n == t.text {
+			t.kind = tokenMoveNumber
+			return
+		}
+
+		if strings.HasSuffix(n, ".") {
+			san := token{
+				kind:   tokenSan,
+				text:   t.text[len(n):],
+				line:   t.line,
+				column: t.column + len(n),
+				offset: t.offset + int64(len(n)),
+			}
+			l.unread(san)
+
+			t.kind = tokenMoveNumber
+			t.text = n
+			return
+		}
+	}
+
+	t.kind = tokenSan
+}
+
+func (l *lexer) readWhile(f func(c byte) bool) string {
+	builder := []byte{}
+
+	for {
+		c, ok := l.peekByte()
+		if !ok || !f(c) {
+			break
+		}
+
+		l.readByte()
+		builder = append(builder, c)
+	}
+
+	return string(builder)
+}
+
+func (l *lexer) readUntil(end byte) string {
+	builder := []byte{}
+
+	for {
+		c, ok := l.readByte()
+		if !ok || c == end {
+			break
+		}
+
+		builder = append(builder, c)
+	}
+
+	return string(builder)
+}
+
+// readTag reads the rest of a tag pair after the opening bracket.
+func (l *lexer) readTag() (string, string) {
+	l.readWhile(isSpace)
+	name := l.readWhile(func(c byte) bool { return !isSpace(c) && c != '"' && c != ']' })
+	l.readWhile(isSpace)
+
+	value := []byte{}
+	if c, ok := l.peekByte(); ok && c == '"' {
+		l.readByte()
+
+		for {
+			c, ok := l.readByte()
+			if !ok || c == '"' || c == '\n' {
+				break
+			}
+
+			if c == '\\' {
+				if next, ok := l.peekByte(); ok && (next == '"' || next == '\\') {
+					l.readByte()
+					c = next
+				}
+			}
+
+			value = append(value, c)
+		}
+	}
+
+	l.readWhile(func(c byte) bool { return c != ']' && c != '\n' })
+	if c, ok := l.peekByte(); ok && c == ']' {
+		l.readByte()
+	}
+
+	return name, string(value)
+}
@@ -0,0 +1,644 @@
+package pgn
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+const kasparovTopalov = `[Event "Hoogovens A Tournament"]
+[Site "Wijk aan Zee NED"]
+[Date "1999.01.20"]
+[EventDate "1999.01.16"]
+[Round "4"]
+[Result "1-0"]
+[White "Garry Kasparov"]
+[Black "Veselin Topalov"]
+[ECO "B07"]
+[WhiteElo "2812"]
+[BlackElo "2700"]
+[PlyCount "87"]
+
+1. e4 d6 2. d4 Nf6 3. Nc3 g6 4. Be3 Bg7 5. Qd2 c6 6. f3 b5 7. Nge2 Nbd7 8. Bh6
+Bxh6 9. Qxh6 Bb7 10. a3 e5 11. O-O-O Qe7 12. Kb1 a6 13. Nc1 O-O-O 14. Nb3 exd4
+15. Rxd4 c5 16. Rd1 Nb6 17. g3 Kb8 18. Na5 Ba8 19. Bh3 d5 20. Qf4+ Ka7 21. Rhe1
+d4 22. Nd5 Nbxd5 23. exd5 Qd6 24. Rxd4 cxd4 25. Re7+ Kb6 26. Qxd4+ Kxa5 27. b4+
+Ka4 28. Qc3 Qxd5 29. Ra7 Bb7 30. Rxb7 Qc4 31. Qxf6 Kxa3 32. Qxa6+ Kxb4 33. c3+
+Kxc3 34. Qa1+ Kd2 35. Qb2+ Kd1 36. Bf1 Rd2 37. Rd7 Rxd7 38. Bxc4 bxc4 39. Qxh8
+Rd3 40. Qa8 c3 41. Qa4+ Ke1 42. f4 f5 43. Kc1 Rd2 44. Qa7 1-0
+`
+
+func TestHeaders(t *testing.T) {
+	h := NewHeaders()
+	h.Set("WhiteElo", "2800")
+	h.Set("Annotator", "X")
+	h.Set("Event", "Test")
+
+	names := strings.Join(h.Names(), ",")
+	if names != "Event,Site,Date,Round,White,Black,Result,WhiteElo,Annotator" {
+		t.Errorf("header order not matching: %v", names)
+	}
+
+	if v, _ := h.Get("Event"); v != "Test" {
+		t.Errorf("header value not matching: %v", v)
+	}
+
+	h.Delete("WhiteElo")
+	if _, ok := h.Get("WhiteElo"); ok || h.Len() != 8 {
+		t.Errorf("header not deleted")
+	}
+}
+
+func TestReadGame(t *testing.T) {
+	t.Run("headers and mainline", func(t *testing.T) {
+		g, err := ReadGame(strings.NewReader(kasparovTopalov))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(g.Errors) != 0 {
+			t.Errorf("unexpected parse errors: %v", g.Errors)
+		}
+
+		if v, _ := g.Headers.Get("White"); v != "Garry Kasparov" {
+			t.Errorf("white header not matching: %v", v)
+		}
+
+		if v, _ := g.Headers.Get("ECO"); v != "B07" {
+			t.Errorf("ECO header not matching: %v", v)
+		}
+
+		if len(g.MainLine()) != 87 {
+			t.Errorf("expected 87 plies, got %v", len(g.MainLine()))
+		}
+
+		end := g.End()
+		board := end.Board()
+		if board.FEN(false, "legal", core.NoPiece) != "8/Q6p/6p1/5p2/5P2/2p3P1/3r3P/2K1k3 b - - 3 44" {
+			t.Errorf("final position not matching: %v", board.FEN(false, "legal", core.NoPiece))
+		}
+
+		if end.San() != "Qa7" || end.Ply() != 87 || !end.IsMainLine() {
+			t.Errorf("end node not matching")
+		}
+	})
+
+	t.Run("variations comments and nags", func(t *testing.T) {
+		pgn := `{Game comment} 1. e4 $1 {Best by test} e5 ( {Sicilian} 1... c5 2. Nf3 (2. c3!? d5) 2... d6 ) 2. Nf3?! Nc6 *`
+		g, err := ReadGame(strings.NewReader(pgn))
+		if err != nil || len(g.Errors) != 0 {
+			t.Fatalf("unexpected error: %v %v", err, g.Errors)
+		}
+
+		if g.Comment != "Game comment" {
+			t.Errorf("game comment not matching: %v", g.Comment)
+		}
+
+		e4 := g.Next()
+		if e4.San() != "e4" || e4.Comment != "Best by test" || len(e4.Nags) != 1 || e4.Nags[0] != NagGoodMove {
+			t.Errorf("e4 node not matching")
+		}
+
+		if len(e4.Variations) != 2 {
+			t.Fatalf("expected 2 variations after e4, got %v", len(e4.Variations))
+		}
+
+		c5 := e4.Variations[1]
+		if c5.San() != "c5" || c5.StartingComment != "Sicilian" || c5.IsMainLine() {
+			t.Errorf("c5 node not matching")
+		}
+
+		nf3 := c5.Next()
+		if len(nf3.Variations) != 1 || nf3.Next().San() != "d6" {
+			t.Errorf("sicilian mainline not matching")
+		}
+
+		c3 := c5.Variations[1]
+		if c3.San() != "c3" || len(c3.Nags) != 1 || c3.Nags[0] != NagSpeculativeMove || c3.Next().San() != "d5" {
+			t.Errorf("c3 variation not matching")
+		}
+
+		mainNf3 := e4.Next().Next()
+		if mainNf3.San() != "Nf3" || mainNf3.Nags[0] != NagDubiousMove || mainNf3.Next().San() != "Nc6" {
+			t.Errorf("mainline not matching")
+		}
+
+		if v, _ := g.Headers.Get("Result"); v != "*" {
+			t.Errorf("result not matching: %v", v)
+		}
+	})
+
+	t.Run("multiple games", func(t *testing.T) {
+		pgn := "[Event \"A\"]\n\n1. d4 d5 1/2-1/2\n\n[Event \"B\"]\n[FEN \"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1\"]\n\n1. e4 Kd7 *\n"
+		r := NewReader(strings.NewReader(pgn))
+
+		a, err := r.ReadGame()
+		if err != nil || len(a.MainLine()) != 2 {
+			t.Fatalf("first game not matching")
+		}
+		if v, _ := a.Headers.Get("Result"); v != "1/2-1/2" {
+			t.Errorf("result of first game not matching: %v", v)
+		}
+
+		b, err := r.ReadGame()
+		if err != nil || len(b.MainLine()) != 2 {
+			t.Fatalf("second game not matching")
+		}
+		board := b.End().Board()
+		if board.FEN(false, "legal", core.NoPiece) != "8/3k4/8/8/4P3/8/8/4K3 w - - 1 2" {
+			t.Errorf("second game position not matching: %v", board.FEN(false, "legal", core.NoPiece))
+		}
+
+		if _, err := r.ReadGame(); err != io.EOF {
+			t.Errorf("expected EOF, got %v", err)
+		}
+	})
+
+	t.Run("en passant and promotion", func(t *testing.T) {
+		pgn := "[FEN \"4k3/1P6/8/8/3p4/8/4P3/4K3 w - - 0 1\"]\n1. e4 dxe3 2. b8=Q+ *"
+		g, _ := ReadGame(strings.NewReader(pgn))
+		if len(g.Errors) != 0 || len(g.MainLine()) != 3 {
+			t.Fatalf("unexpected errors: %v", g.Errors)
+		}
+
+		board := g.End().Board()
+		if !board.IsCheck() || board.FEN(false, "legal", core.NoPiece) != "1Q2k3/8/8/8/8/4p3/8/4K3 b - - 0 2" {
+			t.Errorf("position not matching: %v", board.FEN(false, "legal", core.NoPiece))
+		}
+	})
+
+	t.Run("illegal move", func(t *testing.T) {
+		pgn := "1. e4 e5 (1... Ke7 2. Qh5 Kf8??) 2. Nf3 Nf6 3. Nxe5 Ke7 4. Qe2 *"
+		g, err := ReadGame(strings.NewReader(pgn))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(g.Errors) != 1 {
+			t.Fatalf("expected a single error, got %v", g.Errors)
+		}
+
+		if e, ok := g.Errors[0].(*ParseError); !ok || e.Line != 1 || e.Column != 16 {
+			t.Errorf("error position not matching: %v", g.Errors[0])
+		}
+
+		if len(g.MainLine()) != 7 || len(g.Next().Variations) != 1 {
+			t.Errorf("expected parsing to stop at the illegal move")
+		}
+	})
+}
+
+func TestLenientReader(t *testing.T) {
+	t.Run("fixed up moves", func(t *testing.T) {
+		pgn := "1. e4 e5 2. Nf3 Nc6 3. Bc4 Ngf6 4. 0-0 Bc5 5. d4 exd4 6. Nfg5 o-o-o *"
+		g, err := ReadGame(strings.NewReader(pgn))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(g.MainLine()) != 11 {
+			t.Errorf("expected 11 plies, got %d: %v", len(g.MainLine()), g.Errors)
+		}
+
+		if len(g.Errors) != 1 {
+			t.Fatalf("expected a single error, got %v", g.Errors)
+		}
+
+		e, ok := g.Errors[0].(*ParseError)
+		if !ok || e.Token != "o-o-o" || e.MoveNumber != 6 {
+			t.Errorf("error not matching: %v", g.Errors[0])
+		}
+
+		if _, ok := e.Err.(core.SanParseError); !ok {
+			t.Errorf("expected a SanParseError, got %T", e.Err)
+		}
+	})
+
+	t.Run("stray characters", func(t *testing.T) {
+		g, err := ReadGame(strings.NewReader("1. e4 & e5 2. Nf3 *"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(g.Errors) != 1 || len(g.MainLine()) != 3 {
+			t.Errorf("expected stray character to be skipped: %v", g.Errors)
+		}
+
+		if e, ok := g.Errors[0].(*ParseError); !ok || e.Token != "&" || e.Column != 7 {
+			t.Errorf("error not matching: %v", g.Errors[0])
+		}
+	})
+
+	t.Run("missing result", func(t *testing.T) {
+		pgn := "[White \"A\"]\n\n1. e4 e5 Kxe8\n\n[White \"B\"]\n\n1. d4 *\n"
+		r := NewReader(strings.NewReader(pgn))
+
+		g, err := r.ReadGame()
+		if err != nil || len(g.Errors) != 1 || len(g.MainLine()) != 2 {
+			t.Fatalf("first game not matching: %v %v", err, g)
+		}
+
+		g, err = r.ReadGame()
+		if white, _ := g.Headers.Get("White"); err != nil || white != "B" || len(g.MainLine()) != 1 {
+			t.Errorf("second game not matching: %v %v", err, g)
+		}
+	})
+
+	t.Run("invalid fen", func(t *testing.T) {
+		pgn := "[White \"A\"]\n[FEN \"8/8/8/8/8/8/8/8 x - - 0 1\"]\n\n1. e4 *\n\n1. d4 *\n"
+		r := NewReader(strings.NewReader(pgn))
+
+		g, err := r.ReadGame()
+		if err != nil || len(g.Errors) != 1 || len(g.MainLine()) != 0 {
+			t.Fatalf("expected the moves to be skipped: %v %v", err, g)
+		}
+
+		e, ok := g.Errors[0].(*ParseError)
+		if !ok || e.Line != 2 || e.Token != "8/8/8/8/8/8/8/8 x - - 0 1" {
+			t.Fatalf("error not matching: %v", g.Errors[0])
+		}
+
+		if fenError, ok := e.Err.(*core.FENError); !ok || fenError.Field != core.FENFieldTurn || fenError.Position != 16 {
+			t.Errorf("expected a fen error, got %v", e.Err)
+		}
+
+		if g, err := r.ReadGame(); err != nil || len(g.MainLine()) != 1 {
+			t.Errorf("expected the next game, got %v %v", err, g)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		pgn := "1. e4 e5 2. 0-0 (2. Nf3) Nc6 1-0\n\n1. d4 d5 *\n"
+		r := NewReader(strings.NewReader(pgn))
+		r.Strict = true
+
+		g, err := r.ReadGame()
+		e, ok := err.(*ParseError)
+		if !ok || e.Token != "0-0" || e.Line != 1 || e.Column != 13 || e.MoveNumber != 2 {
+			t.Fatalf("expected a parse error, got %v", err)
+		}
+
+		if len(g.MainLine()) != 2 {
+			t.Errorf("expected the partial game, got %v", g)
+		}
+
+		g, err = r.ReadGame()
+		if err != nil || len(g.MainLine()) != 2 {
+			t.Errorf("expected the next game, got %v %v", err, g)
+		}
+
+		if _, err := r.ReadGame(); err != io.EOF {
+			t.Errorf("expected EOF, got %v", err)
+		}
+	})
+}
+
+func TestExport(t *testing.T) {
+	pgn := `[Event "Test"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "A"]
+[Black "B"]
+[Result "*"]
+[Annotator "C"]
+
+{Game comment} 1. e4 $1 {Best by test} 1...e5 ({Sicilian} 1...c5 2. Nf3 (2. c3
+d5) 2...d6) 2. Nf3 {[%clk 0:01:23]} 2...Nc6 $6 *
+`
+
+	g, err := ReadGame(strings.NewReader(pgn))
+	if err != nil || len(g.Errors) != 0 {
+		t.Fatalf("unexpected error: %v %v", err, g.Errors)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		if g.String() != pgn {
+			t.Errorf("exported PGN not matching:\n%v", g.String())
+		}
+	})
+
+	t.Run("comment before a variation of the first move", func(t *testing.T) {
+		g, _ := ReadGame(strings.NewReader("1. e4 ({c} 1. d4) e5 *"))
+		if g.Comment != "" || len(g.Variations) != 2 || g.Variations[1].StartingComment != "c" {
+			t.Fatalf("expected starting comment of 1. d4, got %q", g.Comment)
+		}
+
+		options := DefaultExportOptions()
+		options.Headers = false
+		if exported := g.Export(options); exported != "1. e4 ({c} 1. d4) 1...e5 *\n" {
+			t.Errorf("exported PGN not matching:\n%v", exported)
+		}
+	})
+
+	t.Run("without annotations", func(t *testing.T) {
+		options := DefaultExportOptions()
+		options.Headers = false
+		options.Comments = false
+		options.Variations = false
+		options.Nags = false
+
+		if s := g.Export(options); s != "1. e4 e5 2. Nf3 Nc6 *\n" {
+			t.Errorf("exported PGN not matching: %v", s)
+		}
+	})
+
+	t.Run("without clocks", func(t *testing.T) {
+		options := DefaultExportOptions()
+		options.Headers = false
+		options.Variations = false
+		options.Clocks = false
+
+		if s := g.Export(options); s != "{Game comment} 1. e4 $1 {Best by test} 1...e5 2. Nf3 Nc6 $6 *\n" {
+			t.Errorf("exported PGN not matching: %v", s)
+		}
+	})
+
+	t.Run("move number after variation", func(t *testing.T) {
+		g, _ := ReadGame(strings.NewReader("1. e4 e5 2. Nf3 (2. Bc4) Nc6 *"))
+		options := DefaultExportOptions()
+		options.Headers = false
+
+		if s := g.Export(options); s != "1. e4 e5 2. Nf3 (2. Bc4) 2...Nc6 *\n" {
+			t.Errorf("exported PGN not matching: %v", s)
+		}
+	})
+
+	t.Run("line wrapping", func(t *testing.T) {
+		g, _ := ReadGame(strings.NewReader(kasparovTopalov))
+		for _, line := range strings.Split(g.String(), "\n") {
+			if len(line) > 80 {
+				t.Errorf("line too long: %v", line)
+			}
+		}
+
+		options := DefaultExportOptions()
+		options.Headers = false
+		options.Columns = 0
+		if strings.Count(g.Export(options), "\n") != 1 {
+			t.Errorf("expected a single line of movetext")
+		}
+
+		// Closing parentheses wrap with the last move of the variation
+		g, _ = ReadGame(strings.NewReader("1. e4 (1. d4 (1. c4)) e5 *"))
+		options.Columns = 12
+		if exported := g.Export(options); exported != "1. e4 (1.\nd4) (1. c4)\n1...e5 *\n" {
+			t.Errorf("exported PGN not matching:\n%v", exported)
+		}
+	})
+
+	t.Run("clock", func(t *testing.T) {
+		node := g.Next().Next().Next()
+		if clock, ok := node.Clock(); !ok || clock != 83 {
+			t.Errorf("clock not matching: %v", clock)
+		}
+
+		node.SetClock(3723.5)
+		if node.Comment != "[%clk 1:02:03.5]" {
+			t.Errorf("clock comment not matching: %v", node.Comment)
+		}
+
+		for seconds, clock := range map[float64]string{
+			3.1:     "0:00:03.1",
+			59.999:  "0:01:00",
+			0.05:    "0:00:00.05",
+			7199.25: "1:59:59.25",
+		} {
+			node.SetClock(seconds)
+			if node.Comment != "[%clk "+clock+"]" {
+				t.Errorf("clock comment for %v not matching: %v", seconds, node.Comment)
+			}
+		}
+	})
+
+	t.Run("from board", func(t *testing.T) {
+		b := core.NewBoardFromFEN("4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", false)
+		b.PushSan("e4")
+		b.PushSan("Kd7")
+
+		g := NewGameFromBoard(&b)
+		if v, _ := g.Headers.Get("FEN"); v != "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1" {
+			t.Errorf("FEN header not matching: %v", v)
+		}
+
+		options := DefaultExportOptions()
+		options.Headers = false
+		if s := g.Export(options); s != "1. e4 Kd7 *\n" {
+			t.Errorf("exported PGN not matching: %v", s)
+		}
+	})
+}
+
+// Counts moves of games played by a given player, skipping variations
+type countingVisitor struct {
+	BaseVisitor
+
+	player string
+	white  string
+	moves  int
+	errors int
+	games  int
+}
+
+func (v *countingVisitor) BeginGame() {
+	v.white = ""
+}
+
+func (v *countingVisitor) VisitHeader(name, value string) {
+	if name == "White" {
+		v.white = value
+	}
+}
+
+func (v *countingVisitor) EndHeaders() Skip {
+	if v.white != v.player {
+		return SkipRest
+	}
+
+	v.games++
+	return Continue
+}
+
+func (v *countingVisitor) VisitMove(board *core.Board, move core.Move) {
+	v.moves++
+}
+
+func (v *countingVisitor) BeginVariation() Skip {
+	return SkipRest
+}
+
+func (v *countingVisitor) HandleError(err error) {
+	v.errors++
+}
+
+func TestVisitor(t *testing.T) {
+	pgn := `[White "A"]
+
+1. e4 e5 (1... c5 2. Nf3 (2. c3) d6) 2. Nf3 *
+
+[White "B"]
+
+1. e4 Ke7 2. Qh5 *
+
+[White "A"]
+
+1. d4 (1. c4 e5) d5 2. c4 1-0
+`
+
+	v := &countingVisitor{player: "A"}
+	r := NewReader(strings.NewReader(pgn))
+	for {
+		if err := r.Accept(v); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if v.games != 2 || v.moves != 6 || v.errors != 0 {
+		t.Errorf("visited games not matching: %v games, %v moves, %v errors", v.games, v.moves, v.errors)
+	}
+
+	t.Run("headers", func(t *testing.T) {
+		r := NewReader(strings.NewReader(pgn))
+		whites := []string{}
+		for {
+			h, err := r.ReadHeaders()
+			if err != nil {
+				break
+			}
+
+			white, _ := h.Get("White")
+			whites = append(whites, white)
+
+			if h.Len() != 1 {
+				t.Errorf("expected only the headers of the game")
+			}
+		}
+
+		if strings.Join(whites, ",") != "A,B,A" {
+			t.Errorf("headers not matching: %v", whites)
+		}
+	})
+}
+
+func TestIndex(t *testing.T) {
+	pgn := "\xef\xbb\xbf[Event \"A\"]\n\n1. e4 e5 *\n\n[Event \"B\"]\n\n1. d4 (1. c4) d5 1-0\n\n1. Nf3 Nf6 *\n\n[Event \"D\"]\n[White \"X\"]\n\n1. f4 Ke7?? 0-1\n"
+
+	idx, err := BuildIndex(strings.NewReader(pgn))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if idx.Len() != 4 {
+		t.Fatalf("expected 4 games, got %v", idx.Len())
+	}
+
+	for i, prefix := range []string{"[Event \"A\"]", "[Event \"B\"]", "1. Nf3", "[Event \"D\"]"} {
+		if !strings.HasPrefix(pgn[idx.Entries[i].Offset:], prefix) {
+			t.Errorf("offset of game %v not matching: %v", i, idx.Entries[i].Offset)
+		}
+	}
+
+	if white, _ := idx.Entries[3].Headers.Get("White"); white != "X" {
+		t.Errorf("headers not matching: %v", white)
+	}
+
+	t.Run("persistence", func(t *testing.T) {
+		buf := &strings.Builder{}
+		if _, err := idx.WriteTo(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		loaded, err := ReadIndex(strings.NewReader(buf.String()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if loaded.Len() != idx.Len() {
+			t.Fatalf("index length not matching")
+		}
+
+		for i := range idx.Entries {
+			if loaded.Entries[i].Offset != idx.Entries[i].Offset ||
+				strings.Join(loaded.Entries[i].Headers.Names(), ",") != strings.Join(idx.Entries[i].Headers.Names(), ",") {
+				t.Errorf("entry %v not matching", i)
+			}
+		}
+
+		if _, err := ReadIndex(strings.NewReader("garbage")); err == nil {
+			t.Errorf("expected error")
+		}
+
+		// One entry with one header, whose name claims a huge length
+		for _, l := range []uint64{1 << 62, 1<<64 - 1, 1000} {
+			corrupt := make([]byte, binary.MaxVarintLen64)
+			n := binary.PutUvarint(corrupt, l)
+			data := indexMagic + "\x01\x00\x01" + string(corrupt[:n]) + "Event"
+
+			if _, err := ReadIndex(strings.NewReader(data)); err == nil {
+				t.Errorf("expected error for length %v", l)
+			} else if _, ok := err.(*IndexError); !ok {
+				t.Errorf("expected index error, got %v", err)
+			}
+		}
+	})
+
+	t.Run("seek", func(t *testing.T) {
+		g, err := idx.ReadGame(strings.NewReader(pgn), 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if event, _ := g.Headers.Get("Event"); event != "B" || len(g.MainLine()) != 2 || len(g.Variations) != 2 {
+			t.Errorf("game not matching")
+		}
+
+		g, _ = idx.ReadGame(strings.NewReader(pgn), 3)
+		if len(g.Errors) != 1 {
+			t.Errorf("expected illegal move in last game")
+		}
+
+		if _, err := idx.ReadGame(strings.NewReader(pgn), 4); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("comments", func(t *testing.T) {
+		// Comments after the result belong to the game before, comments
+		// before the tags to the game after
+		pgn := "{intro}\n[Event \"a\"]\n\n1. e4 e5 1-0\n{note}\n\n[Event \"b\"]\n\n1. d4 *\n{one} {two}\n\n1. c4 0-1 {end}\n"
+
+		idx, err := BuildIndex(strings.NewReader(pgn))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if idx.Len() != 3 {
+			t.Fatalf("expected 3 games, got %v", idx.Len())
+		}
+
+		for i, c := range []struct {
+			event    string
+			comment  string
+			lastNode string
+		}{
+			{"a", "intro", "note"},
+			{"b", "", "one two"},
+			{"?", "", "end"},
+		} {
+			g, err := idx.ReadGame(strings.NewReader(pgn), i)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			end := g.End()
+			if event, _ := g.Headers.Get("Event"); event != c.event || g.Comment != c.comment || end.Comment != c.lastNode {
+				t.Errorf("game %v not matching: %v, %q, %q", i, event, g.Comment, end.Comment)
+			}
+		}
+	})
+}
@@ -0,0 +1,356 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// ParseError describes a token of the movetext that could not be parsed.
+type ParseError struct {
+	error
+	description string
+
+	Line   int
+	Column int
+
+	// Fullmove number of the position the token was read in, 0 for errors
+	// in the tag pairs
+	MoveNumber int
+	Token      string
+
+	// Underlying error, usually a core.SanParseError
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	if e.MoveNumber == 0 {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.description)
+	}
+
+	return fmt.Sprintf("%d:%d: move %d: %s", e.Line, e.Column, e.MoveNumber, e.description)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads games one after another from a PGN stream.
+//
+// By default the reader is lenient: common mistakes like zero castling
+// (0-0) or wrong disambiguation are fixed up, stray characters are skipped
+// and illegal moves end only their variation. All problems are reported to
+// the visitor and parsing continues. In strict mode the first problem stops
+// the game, which is skipped up to its end so the next game can be read.
+type Reader struct {
+	lexer *lexer
+
+	Strict bool
+
+	// Byte offset of the game last read
+	gameOffset int64
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{lexer: newLexer(r)}
+}
+
+// NewReaderAt creates a reader for a stream that has been positioned at the
+// given byte offset, so offsets stay relative to the start of the file.
+func NewReaderAt(r io.Reader, offset int64) *Reader {
+	reader := NewReader(r)
+	reader.lexer.offset = offset
+	return reader
+}
+
+// GameOffset returns the byte offset where the game last read starts.
+func (r *Reader) GameOffset() int64 {
+	return r.gameOffset
+}
+
+// Accept parses the next game of the stream and reports its parts to the
+// visitor. Moves are resolved against the position they are played in,
+// unless the visitor skips them. Returns io.EOF when there are no more
+// games, and in strict mode the first *ParseError of the game.
+func (r *Reader) Accept(v Visitor) error {
+	t := r.lexer.next()
+	for t.kind == tokenClose {
+		t = r.lexer.next()
+	}
+
+	// Comments before the tags or first move are about the game, but only
+	// tags and moves begin one
+	r.gameOffset = t.offset
+	comments := []string{}
+	for ; t.kind == tokenMoveNumber || t.kind == tokenComment; t = r.lexer.next() {
+		if t.kind == tokenComment {
+			comments = append(comments, strings.TrimSpace(t.text))
+		}
+	}
+
+	if t.kind == tokenEOF {
+		return io.EOF
+	}
+
+	v.BeginGame()
+
+	// Tag pairs
+	v.BeginHeaders()
+	headers := NewEmptyHeaders()
+	fenTag := t
+	for ; t.kind == tokenTag; t = r.lexer.next() {
+		if t.text == "FEN" {
+			fenTag = t
+		}
+
+		headers.Set(t.text, t.value)
+		v.VisitHeader(t.text, t.value)
+	}
+
+	// Depth of a skipped or broken variation whose contents are ignored
+	skipDepth := -1
+	// Set when the visitor has begun the skipped variation
+	skipBegun := false
+	// First error in strict mode
+	var failure error
+	if v.EndHeaders() == SkipRest {
+		skipDepth = 0
+	}
+
+	// Boards are only set up when moves are parsed
+	boards := []core.Board{}
+	plies := []int{0}
+	if skipDepth < 0 {
+		board, err := headers.ParseBoard()
+		boards = append(boards, board)
+
+		// Moves can not be resolved without the starting position
+		if err != nil {
+			parseError := &ParseError{
+				description: err.Error(),
+				Line:        fenTag.line,
+				Column:      fenTag.column,
+				Token:       fenTag.value,
+				Err:         err,
+			}
+			v.HandleError(parseError)
+
+			skipDepth = 0
+			if r.Strict {
+				failure = parseError
+			}
+		}
+	}
+
+	if skipDepth < 0 {
+		for _, comment := range comments {
+			v.VisitComment(comment)
+		}
+	}
+
+	for ; t.kind != tokenEOF; t = r.lexer.next() {
+		depth := len(plies) - 1
+
+		switch t.kind {
+		case tokenTag:
+			// Tag pairs of the next game
+			r.lexer.unread(t)
+			v.EndGame()
+			return failure
+		case tokenResult:
+			if depth == 0 {
+				v.VisitResult(t.text)
+
+				// Comments after the result still belong to the game
+				for t = r.lexer.next(); t.kind == tokenComment; t = r.lexer.next() {
+					if skipDepth < 0 {
+						v.VisitComment(strings.TrimSpace(t.text))
+					}
+				}
+				r.lexer.unread(t)
+
+				v.EndGame()
+				return failure
+			}
+		case tokenOpen:
+			if skipDepth >= 0 {
+				plies = append(plies, plies[depth])
+				continue
+			}
+
+			if plies[depth] == 0 || v.BeginVariation() == SkipRest {
+				plies = append(plies, plies[depth])
+				boards = append(boards, boards[depth])
+				skipDepth = depth + 1
+				skipBegun = false
+				continue
+			}
+
+			variationBoard := core.NewBoardFromBoard(&boards[depth])
+			variationBoard.Pop()
+			plies = append(plies, plies[depth]-1)
+			boards = append(boards, variationBoard)
+		case tokenClose:
+			if depth == 0 {
+				continue
+			}
+
+			plies = plies[:depth]
+			if skipDepth < 0 || skipDepth == depth {
+				boards = boards[:depth]
+			}
+
+			if skipDepth == depth {
+				skipDepth = -1
+				if !skipBegun {
+					continue
+				}
+			}
+
+			if skipDepth < 0 {
+				v.EndVariation()
+			}
+		case tokenComment:
+			if skipDepth < 0 {
+				v.VisitComment(strings.TrimSpace(t.text))
+			}
+		case tokenNag:
+			if skipDepth < 0 && t.value != "" {
+				nag, _ := strconv.Atoi(t.value)
+				v.VisitNAG(nag)
+			}
+		case tokenSan:
+			if skipDepth >= 0 {
+				continue
+			}
+
+			board := &boards[depth]
+			move, err := r.parseSan(board, t.text)
+			if err != nil {
+				parseError := &ParseError{
+					description: err.Error(),
+					Line:        t.line,
+					Column:      t.column,
+					MoveNumber:  int(board.FullMoveNumber()),
+					Token:       t.text,
+					Err:         err,
+				}
+				v.HandleError(parseError)
+
+				if r.Strict {
+					// Skip to the end of the game
+					failure = parseError
+					skipDepth = 0
+					continue
+				}
+
+				// Stray characters are skipped, an illegal move ends its
+				// variation
+				if !sanLikeRegexp.MatchString(t.text) {
+					continue
+				}
+
+				skipDepth = depth
+				skipBegun = true
+				continue
+			}
+
+			v.VisitMove(board, *move)
+			board.Push(move)
+			plies[depth]++
+		}
+	}
+
+	v.EndGame()
+	return failure
+}
+
+// ReadGame parses the next game of the stream into a game tree. Problems
+// are recorded in Game.Errors. Returns io.EOF when there are no more games,
+// and in strict mode the first *ParseError along with the partial game.
+func (r *Reader) ReadGame() (*Game, error) {
+	builder := NewGameBuilder()
+	if err := r.Accept(builder); err == io.EOF {
+		return nil, err
+	} else if err != nil {
+		return builder.Game(), err
+	}
+
+	return builder.Game(), nil
+}
+
+// ReadHeaders reads the headers of the next game, skipping its moves.
+func (r *Reader) ReadHeaders() (*Headers, error) {
+	builder := NewHeadersBuilder()
+	if err := r.Accept(builder); err != nil {
+		return nil, err
+	}
+
+	headers := builder.Headers()
+	return &headers, nil
+}
+
+var sanLikeRegexp = regexp.MustCompile("^(?:[NBKRQ]?[a-h]?[1-8]?[\\-x]?[a-h][1-8](?:=?[nbrqkNBRQK])?|[PNBRQK]?@[a-h][1-8]|--|Z0|[O0o]-[O0o](?:-[O0o])?)[+#]?$")
+
+var zeroCastlingRegexp = regexp.MustCompile("^[0o]-[0o](-[0o])?([+#]?)$")
+
+var disambiguatedRegexp = regexp.MustCompile("^([NBKRQ])[a-h]?[1-8]?[\\-x]?([a-h][1-8][+#]?)$")
+
+// parseSan resolves a move, fixing up common mistakes unless the reader is
+// strict.
+func (r *Reader) parseSan(board *core.Board, san string) (*core.Move, error) {
+	move, err := board.ParseSan(san)
+	if err == nil || r.Strict {
+		return move, err
+	}
+
+	alternatives := []string{}
+
+	// Castling with zeros or lowercase letters
+	if zeroCastlingRegexp.MatchString(san) {
+		alternatives = append(alternatives, strings.NewReplacer("0", "O", "o", "O").Replace(san))
+	}
+
+	// Wrong or superfluous disambiguation and capture markers
+	if matches := disambiguatedRegexp.FindStringSubmatch(san); matches != nil {
+		alternatives = append(alternatives, matches[1]+matches[2], matches[1]+"x"+matches[2])
+	}
+
+	// Pawn captures with a missing or superfluous capture marker
+	if len(san) >= 3 && san[0] >= 'a' && san[0] <= 'h' {
+		if san[1] == 'x' {
+			alternatives = append(alternatives, san[2:])
+		} else if san[1] >= 'a' && san[1] <= 'h' {
+			alternatives = append(alternatives, san[:1]+"x"+san[1:])
+		}
+	}
+
+	for _, alternative := range alternatives {
+		if move, altErr := board.ParseSan(alternative); altErr == nil {
+			return move, nil
+		}
+	}
+
+	return nil, err
+}
+
+func joinComments(a, b string) string {
+	if a == "" {
+		return b
+	}
+
+	if b == "" {
+		return a
+	}
+
+	return a + " " + b
+}
+
+// ReadGame reads the first game of a PGN stream.
+func ReadGame(r io.Reader) (*Game, error) {
+	return NewReader(r).ReadGame()
+}
@@ -0,0 +1,210 @@
+package pgn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+type IndexError struct {
+	error
+	description string
+}
+
+func (e *IndexError) Error() string {
+	return e.description
+}
+
+// IndexEntry locates a game in a PGN file.
+type IndexEntry struct {
+	Offset  int64
+	Headers Headers
+}
+
+// Skim reads the headers of the next game and where it starts. Moves are
+// skipped without being parsed.
+func (r *Reader) Skim() (*IndexEntry, error) {
+	headers, err := r.ReadHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	return &IndexEntry{Offset: r.GameOffset(), Headers: *headers}, nil
+}
+
+// Index is the list of games of a PGN file, in file order.
+type Index struct {
+	Entries []IndexEntry
+}
+
+// BuildIndex skims through a PGN stream.
+func BuildIndex(r io.Reader) (*Index, error) {
+	idx := &Index{Entries: []IndexEntry{}}
+	reader := NewReader(r)
+
+	for {
+		entry, err := reader.Skim()
+		if err == io.EOF {
+			return idx, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		idx.Entries = append(idx.Entries, *entry)
+	}
+}
+
+func (idx *Index) Len() int {
+	return len(idx.Entries)
+}
+
+// Reader returns a reader positioned at the start of the nth game.
+func (idx *Index) Reader(rs io.ReadSeeker, n int) (*Reader, error) {
+	if n < 0 || n >= len(idx.Entries) {
+		return nil, &IndexError{description: "game index out of range"}
+	}
+
+	offset := idx.Entries[n].Offset
+	if _, err := rs.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return NewReaderAt(rs, offset), nil
+}
+
+// ReadGame parses the nth game.
+func (idx *Index) ReadGame(rs io.ReadSeeker, n int) (*Game, error) {
+	reader, err := idx.Reader(rs, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return reader.ReadGame()
+}
+
+// The on-disk format is the magic, the number of entries and then for each
+// entry its offset, number of headers and header names and values. Numbers
+// are varints, strings are prefixed with their length.
+const indexMagic = "PGNIDX1\n"
+
+// WriteTo persists the index.
+func (idx *Index) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	written := int64(0)
+	buf := make([]byte, binary.MaxVarintLen64)
+
+	writeUvarint := func(x uint64) error {
+		n := binary.PutUvarint(buf, x)
+		written += int64(n)
+		_, err := bw.Write(buf[:n])
+		return err
+	}
+
+	writeString := func(s string) error {
+		if err := writeUvarint(uint64(len(s))); err != nil {
+			return err
+		}
+		n, err := bw.WriteString(s)
+		written += int64(n)
+		return err
+	}
+
+	n, err := bw.WriteString(indexMagic)
+	written += int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	if err := writeUvarint(uint64(len(idx.Entries))); err != nil {
+		return written, err
+	}
+
+	for _, e := range idx.Entries {
+		if err := writeUvarint(uint64(e.Offset)); err != nil {
+			return written, err
+		}
+
+		names := e.Headers.Names()
+		if err := writeUvarint(uint64(len(names))); err != nil {
+			return written, err
+		}
+
+		for _, name := range names {
+			value, _ := e.Headers.Get(name)
+			if err := writeString(name); err != nil {
+				return written, err
+			}
+			if err := writeString(value); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, bw.Flush()
+}
+
+// ReadIndex loads an index persisted with WriteTo.
+func ReadIndex(r io.Reader) (*Index, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(indexMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != indexMagic {
+		return nil, &IndexError{description: "not a pgn index"}
+	}
+
+	readString := func() (string, error) {
+		l, err := binary.ReadUvarint(br)
+		if err != nil {
+			return "", err
+		}
+
+		// The length is not trusted, the buffer only grows with the data
+		// actually read
+		if l > math.MaxInt64 {
+			return "", io.ErrUnexpectedEOF
+		}
+
+		var s bytes.Buffer
+		_, err = io.CopyN(&s, br, int64(l))
+		return s.String(), err
+	}
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, &IndexError{description: "truncated pgn index"}
+	}
+
+	idx := &Index{Entries: []IndexEntry{}}
+	for i := uint64(0); i < count; i++ {
+		offset, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, &IndexError{description: "truncated pgn index"}
+		}
+
+		headerCount, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, &IndexError{description: "truncated pgn index"}
+		}
+
+		headers := NewEmptyHeaders()
+		for j := uint64(0); j < headerCount; j++ {
+			name, err := readString()
+			if err != nil {
+				return nil, &IndexError{description: "truncated pgn index"}
+			}
+
+			value, err := readString()
+			if err != nil {
+				return nil, &IndexError{description: "truncated pgn index"}
+			}
+
+			headers.Set(name, value)
+		}
+
+		idx.Entries = append(idx.Entries, IndexEntry{Offset: int64(offset), Headers: headers})
+	}
+
+	return idx, nil
+}
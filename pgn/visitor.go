@@ -0,0 +1,178 @@
+package pgn
+
+import (
+	"github.com/captainsano/golang-chess/core"
+)
+
+type Skip bool
+
+const (
+	Continue Skip = false
+	SkipRest Skip = true
+)
+
+// Visitor receives the parts of a game while it is being parsed, so games
+// can be processed without building a tree in memory.
+type Visitor interface {
+	BeginGame()
+
+	BeginHeaders()
+	VisitHeader(name, value string)
+	// Returning SkipRest skips the movetext, moves are not parsed at all.
+	EndHeaders() Skip
+
+	// The board is in the position before the move and must not be
+	// modified.
+	VisitMove(board *core.Board, move core.Move)
+	VisitComment(comment string)
+	VisitNAG(nag int)
+
+	// Returning SkipRest skips the variation and everything nested in it.
+	BeginVariation() Skip
+	EndVariation()
+
+	VisitResult(result string)
+
+	// Called with tokens that can not be parsed, see Reader for how parsing
+	// continues.
+	HandleError(err error)
+
+	EndGame()
+}
+
+// BaseVisitor implements Visitor doing nothing, for embedding in visitors
+// that only care about some parts of a game.
+type BaseVisitor struct{}
+
+func (v *BaseVisitor) BeginGame()                                  {}
+func (v *BaseVisitor) BeginHeaders()                               {}
+func (v *BaseVisitor) VisitHeader(name, value string)              {}
+func (v *BaseVisitor) EndHeaders() Skip                            { return Continue }
+func (v *BaseVisitor) VisitMove(board *core.Board, move core.Move) {}
+func (v *BaseVisitor) VisitComment(comment string)                 {}
+func (v *BaseVisitor) VisitNAG(nag int)                            {}
+func (v *BaseVisitor) BeginVariation() Skip                        { return Continue }
+func (v *BaseVisitor) EndVariation()                               {}
+func (v *BaseVisitor) VisitResult(result string)                   {}
+func (v *BaseVisitor) HandleError(err error)                       {}
+func (v *BaseVisitor) EndGame()                                    {}
+
+// GameBuilder is the visitor building a game tree.
+type GameBuilder struct {
+	game *Game
+
+	nodes []*GameNode
+	// Set when a move has been played in the current variation
+	inVariation     bool
+	startingComment string
+}
+
+func NewGameBuilder() *GameBuilder {
+	return &GameBuilder{}
+}
+
+// Game returns the last game built.
+func (b *GameBuilder) Game() *Game {
+	return b.game
+}
+
+func (b *GameBuilder) BeginGame() {
+	b.game = NewGame()
+	b.nodes = []*GameNode{&b.game.GameNode}
+	b.inVariation = false
+	b.startingComment = ""
+}
+
+// Headers not present in the game keep the defaults of the Seven Tag
+// Roster.
+func (b *GameBuilder) BeginHeaders() {}
+
+func (b *GameBuilder) VisitHeader(name, value string) {
+	b.game.Headers.Set(name, value)
+}
+
+func (b *GameBuilder) EndHeaders() Skip {
+	return Continue
+}
+
+func (b *GameBuilder) node() *GameNode {
+	return b.nodes[len(b.nodes)-1]
+}
+
+func (b *GameBuilder) VisitMove(board *core.Board, move core.Move) {
+	node := b.node().AddVariation(move)
+	node.StartingComment = b.startingComment
+	b.nodes[len(b.nodes)-1] = node
+
+	b.startingComment = ""
+	b.inVariation = true
+}
+
+func (b *GameBuilder) VisitComment(comment string) {
+	node := b.node()
+
+	// Before the first move of the game the comment is about the game,
+	// before the first move of any other variation it is a starting comment
+	if !b.inVariation && (node.Parent != nil || len(node.Variations) > 0) {
+		b.startingComment = joinComments(b.startingComment, comment)
+	} else {
+		node.Comment = joinComments(node.Comment, comment)
+	}
+}
+
+func (b *GameBuilder) VisitNAG(nag int) {
+	node := b.node()
+	node.Nags = append(node.Nags, nag)
+}
+
+func (b *GameBuilder) BeginVariation() Skip {
+	b.nodes = append(b.nodes, b.node().Parent)
+	b.inVariation = false
+	return Continue
+}
+
+func (b *GameBuilder) EndVariation() {
+	b.nodes = b.nodes[:len(b.nodes)-1]
+	b.inVariation = true
+	b.startingComment = ""
+}
+
+func (b *GameBuilder) VisitResult(result string) {
+	if r, _ := b.game.Headers.Get("Result"); r == "" || r == "*" {
+		b.game.Headers.Set("Result", result)
+	}
+}
+
+func (b *GameBuilder) HandleError(err error) {
+	b.game.Errors = append(b.game.Errors, err)
+}
+
+func (b *GameBuilder) EndGame() {}
+
+// HeadersBuilder collects the headers of a game and skips its moves.
+type HeadersBuilder struct {
+	BaseVisitor
+
+	headers Headers
+}
+
+func NewHeadersBuilder() *HeadersBuilder {
+	return &HeadersBuilder{}
+}
+
+// Headers returns the headers of the last game visited.
+func (b *HeadersBuilder) Headers() Headers {
+	return b.headers
+}
+
+func (b *HeadersBuilder) BeginHeaders() {
+	b.headers = NewEmptyHeaders()
+}
+
+func (b *HeadersBuilder) VisitHeader(name, value string) {
+	b.headers.Set(name, value)
+}
+
+func (b *HeadersBuilder) EndHeaders() Skip {
+	return SkipRest
+}
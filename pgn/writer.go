@@ -0,0 +1,280 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// ExportOptions select what ends up in the exported PGN.
+type ExportOptions struct {
+	Headers    bool
+	Comments   bool
+	Variations bool
+	Nags       bool
+
+	// Keep [%clk ...] and [%emt ...] commands in comments
+	Clocks bool
+
+	// Maximum line length, 0 disables wrapping
+	Columns int
+}
+
+func DefaultExportOptions() ExportOptions {
+	return ExportOptions{
+		Headers:    true,
+		Comments:   true,
+		Variations: true,
+		Nags:       true,
+		Clocks:     true,
+		Columns:    80,
+	}
+}
+
+var clockRegexp = regexp.MustCompile("\\[%(clk|emt)\\s+([^\\]]*)\\]")
+
+// Clock returns the remaining time in seconds from the [%clk h:mm:ss]
+// command of the node comment.
+func (n *GameNode) Clock() (float64, bool) {
+	for _, match := range clockRegexp.FindAllStringSubmatch(n.Comment, -1) {
+		if match[1] == "clk" {
+			return parseClock(match[2])
+		}
+	}
+
+	return 0, false
+}
+
+// SetClock replaces the [%clk] command of the node comment.
+func (n *GameNode) SetClock(seconds float64) {
+	comment := strings.TrimSpace(clockRegexp.ReplaceAllStringFunc(n.Comment, func(c string) string {
+		if strings.HasPrefix(c, "[%clk") {
+			return ""
+		}
+		return c
+	}))
+
+	n.Comment = joinComments("[%clk "+formatClock(seconds)+"]", comment)
+}
+
+func parseClock(s string) (float64, bool) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+
+	seconds := 0.0
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, false
+		}
+		seconds = seconds*60 + v
+	}
+
+	return seconds, true
+}
+
+// formatClock formats seconds as h:mm:ss, with up to two decimals.
+func formatClock(seconds float64) string {
+	centiseconds := int64(math.Floor(seconds*100 + 0.5))
+	s := centiseconds / 100
+	clock := fmt.Sprintf("%d:%02d:%02d", s/3600, (s/60)%60, s%60)
+	if frac := centiseconds % 100; frac > 0 {
+		clock += strings.TrimRight(fmt.Sprintf(".%02d", frac), "0")
+	}
+
+	return clock
+}
+
+// exporter collects movetext tokens and wraps them into lines.
+type exporter struct {
+	options ExportOptions
+
+	lines []string
+	line  string
+
+	// Force a move number before the next black move
+	forceMoveNumber bool
+	openVariation   bool
+}
+
+func (e *exporter) put(token string) {
+	if e.openVariation {
+		token = "(" + token
+		e.openVariation = false
+	}
+
+	if e.line == "" {
+		e.line = token
+		return
+	}
+
+	if e.options.Columns > 0 && len(e.line)+1+len(token) > e.options.Columns {
+		e.lines = append(e.lines, e.line)
+		e.line = token
+		return
+	}
+
+	e.line += " " + token
+}
+
+func (e *exporter) startVariation() {
+	e.openVariation = true
+	e.forceMoveNumber = true
+}
+
+// endVariation closes the variation after its last token, which moves to
+// the next line with the parenthesis if the line gets too long.
+func (e *exporter) endVariation() {
+	e.line += ")"
+
+	if e.options.Columns > 0 && len(e.line) > e.options.Columns {
+		if i := strings.LastIndex(e.line, " "); i >= 0 {
+			e.lines = append(e.lines, e.line[:i])
+			e.line = e.line[i+1:]
+		}
+	}
+
+	e.forceMoveNumber = true
+}
+
+func (e *exporter) putComment(comment string) {
+	if !e.options.Clocks {
+		comment = clockRegexp.ReplaceAllString(comment, "")
+	}
+
+	comment = strings.TrimSpace(strings.Replace(comment, "}", "", -1))
+	if !e.options.Comments || comment == "" {
+		return
+	}
+
+	// Comments are wrapped word by word
+	words := strings.Fields(comment)
+	words[0] = "{" + words[0]
+	words[len(words)-1] += "}"
+	for _, w := range words {
+		e.put(w)
+	}
+
+	e.forceMoveNumber = true
+}
+
+func (e *exporter) putMove(board *core.Board, node *GameNode) {
+	san := board.San(&node.Move)
+
+	if board.Turn() == core.White {
+		e.put(fmt.Sprintf("%d.", board.FullMoveNumber()))
+		e.put(san)
+	} else if e.forceMoveNumber {
+		e.put(fmt.Sprintf("%d...%s", board.FullMoveNumber(), san))
+	} else {
+		e.put(san)
+	}
+
+	e.forceMoveNumber = false
+
+	if e.options.Nags {
+		for _, nag := range node.Nags {
+			e.put("$" + strconv.Itoa(nag))
+		}
+	}
+
+	e.putComment(node.Comment)
+}
+
+// exportNode writes the continuations of the node with the board set to
+// its position. The board is restored before returning.
+func (e *exporter) exportNode(board *core.Board, node *GameNode) {
+	plies := 0
+	defer func() {
+		for ; plies > 0; plies-- {
+			board.Pop()
+		}
+	}()
+
+	for node.Next() != nil {
+		main := node.Next()
+
+		e.putMove(board, main)
+
+		if e.options.Variations {
+			for _, variation := range node.Variations[1:] {
+				e.startVariation()
+				e.putComment(variation.StartingComment)
+				e.putMove(board, variation)
+
+				board.Push(&variation.Move)
+				e.exportNode(board, variation)
+				board.Pop()
+
+				e.endVariation()
+			}
+		}
+
+		board.Push(&main.Move)
+		plies++
+		node = main
+	}
+}
+
+func escapeTagValue(v string) string {
+	return strings.Replace(strings.Replace(v, "\\", "\\\\", -1), "\"", "\\\"", -1)
+}
+
+// Export serializes the game to PGN.
+func (g *Game) Export(options ExportOptions) string {
+	builder := []string{}
+
+	if options.Headers {
+		for _, name := range g.Headers.Names() {
+			value, _ := g.Headers.Get(name)
+			builder = append(builder, fmt.Sprintf("[%s \"%s\"]\n", name, escapeTagValue(value)))
+		}
+
+		if len(builder) > 0 {
+			builder = append(builder, "\n")
+		}
+	}
+
+	e := exporter{options: options, forceMoveNumber: true}
+	e.putComment(g.Comment)
+
+	board := g.Headers.Board()
+	e.exportNode(&board, &g.GameNode)
+
+	result, ok := g.Headers.Get("Result")
+	if !ok || result == "" {
+		result = "*"
+	}
+	e.put(result)
+
+	e.lines = append(e.lines, e.line)
+	builder = append(builder, strings.Join(e.lines, "\n"), "\n")
+
+	return strings.Join(builder, "")
+}
+
+func (g *Game) String() string {
+	return g.Export(DefaultExportOptions())
+}
+
+// Writer writes games to a PGN stream, separated by empty lines.
+type Writer struct {
+	w       io.Writer
+	Options ExportOptions
+}
+
+func NewWriter(w io.Writer, options ExportOptions) *Writer {
+	return &Writer{w: w, Options: options}
+}
+
+func (w *Writer) WriteGame(g *Game) error {
+	_, err := io.WriteString(w.w, g.Export(w.Options)+"\n")
+	return err
+}
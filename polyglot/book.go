@@ -0,0 +1,295 @@
+package polyglot
+
+import (
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"os"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+type BookError struct {
+	error
+	description string
+}
+
+func (e *BookError) Error() string {
+	return e.description
+}
+
+// Each entry of a book is 16 bytes: key, move, weight and learn, big endian.
+const entrySize = 16
+
+// Entry is a move of an opening book.
+type Entry struct {
+	Key     uint64
+	RawMove uint16
+	Weight  uint16
+	Learn   uint32
+
+	// The raw move decoded for the position it was looked up in
+	Move core.Move
+}
+
+func decodeEntry(buf []byte) Entry {
+	return Entry{
+		Key:     binary.BigEndian.Uint64(buf[0:8]),
+		RawMove: binary.BigEndian.Uint16(buf[8:10]),
+		Weight:  binary.BigEndian.Uint16(buf[10:12]),
+		Learn:   binary.BigEndian.Uint32(buf[12:16]),
+	}
+}
+
+// decodeMove converts a raw move to a move of the board. Polyglot encodes
+// castling as the king capturing its own rook, which is only how Chess960
+// boards represent it.
+func decodeMove(board *core.Board, raw uint16) core.Move {
+	toSquare := core.Square(raw & 0x3f)
+	fromSquare := core.Square((raw >> 6) & 0x3f)
+
+	promotion := core.NoPiece
+	if p := (raw >> 12) & 0x7; p != 0 {
+		promotion = core.PieceType(p + 1)
+	}
+
+	if !board.IsChess960() && promotion == core.NoPiece {
+		if p := board.PieceAt(fromSquare); p != nil && p.Type == core.King {
+			switch {
+			case fromSquare == core.E1 && toSquare == core.H1:
+				toSquare = core.G1
+			case fromSquare == core.E1 && toSquare == core.A1:
+				toSquare = core.C1
+			case fromSquare == core.E8 && toSquare == core.H8:
+				toSquare = core.G8
+			case fromSquare == core.E8 && toSquare == core.A8:
+				toSquare = core.C8
+			}
+		}
+	}
+
+	return core.Move{FromSquare: fromSquare, ToSquare: toSquare, Promotion: promotion, Drop: core.NoPiece}
+}
+
+// encodeMove converts a move of the board to a raw move, with castling as
+// the king capturing its own rook.
+func encodeMove(board *core.Board, move core.Move) uint16 {
+	toSquare := move.ToSquare
+	if !board.IsChess960() {
+		if board.IsKingsideCastling(&move) {
+			toSquare = core.NewSquare(core.FileH, toSquare.Rank())
+		} else if board.IsQueensideCastling(&move) {
+			toSquare = core.NewSquare(core.FileA, toSquare.Rank())
+		}
+	}
+
+	raw := uint16(move.FromSquare)<<6 | uint16(toSquare)
+	if move.Promotion != core.NoPiece {
+		raw |= uint16(move.Promotion-1) << 12
+	}
+
+	return raw
+}
+
+func (e *Entry) encode(buf []byte) {
+	binary.BigEndian.PutUint64(buf[0:8], e.Key)
+	binary.BigEndian.PutUint16(buf[8:10], e.RawMove)
+	binary.BigEndian.PutUint16(buf[10:12], e.Weight)
+	binary.BigEndian.PutUint32(buf[12:16], e.Learn)
+}
+
+// Reader looks up positions in a Polyglot book. Entries are sorted by key,
+// so lookups are binary searches and the book is never loaded as a whole.
+type Reader struct {
+	r      io.ReaderAt
+	size   int64
+	closer io.Closer
+}
+
+// NewReader creates a reader for a book of the given size in bytes.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size%entrySize != 0 {
+		return nil, &BookError{description: "invalid polyglot book size"}
+	}
+
+	return &Reader{r: r, size: size}, nil
+}
+
+// Open opens a book file. The reader must be closed after use.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	reader, err := NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	reader.closer = f
+	return reader, nil
+}
+
+func (r *Reader) Close() error {
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+
+	return nil
+}
+
+// Len returns the number of entries of the book.
+func (r *Reader) Len() int {
+	return int(r.size / entrySize)
+}
+
+func (r *Reader) entryAt(i int) (Entry, error) {
+	buf := make([]byte, entrySize)
+	if _, err := r.r.ReadAt(buf, int64(i)*entrySize); err != nil {
+		return Entry{}, err
+	}
+
+	return decodeEntry(buf), nil
+}
+
+// lowerBound returns the index of the first entry with at least the key.
+func (r *Reader) lowerBound(key uint64) (int, error) {
+	lo, hi := 0, r.Len()
+	for lo < hi {
+		mid := (lo + hi) / 2
+
+		e, err := r.entryAt(mid)
+		if err != nil {
+			return 0, err
+		}
+
+		if e.Key < key {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo, nil
+}
+
+// FindAll returns the entries for the position in book order, leaving out
+// entries below the minimum weight, excluded moves and moves that are
+// illegal on the board.
+func (r *Reader) FindAll(board *core.Board, minimumWeight uint16, exclude map[core.Move]bool) ([]Entry, error) {
+	key := ZobristHash(board)
+
+	i, err := r.lowerBound(key)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for ; i < r.Len(); i++ {
+		e, err := r.entryAt(i)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.Key != key {
+			break
+		}
+
+		if e.Weight < minimumWeight {
+			continue
+		}
+
+		e.Move = decodeMove(board, e.RawMove)
+		if exclude[e.Move] || !board.IsLegal(&e.Move) {
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+func notFound() error {
+	return &BookError{description: "position not in book"}
+}
+
+// Find returns the entry with the highest weight, the first one on ties.
+func (r *Reader) Find(board *core.Board, minimumWeight uint16, exclude map[core.Move]bool) (*Entry, error) {
+	entries, err := r.FindAll(board, minimumWeight, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Entry
+	for i := range entries {
+		if best == nil || entries[i].Weight > best.Weight {
+			best = &entries[i]
+		}
+	}
+
+	if best == nil {
+		return nil, notFound()
+	}
+
+	return best, nil
+}
+
+// Choice returns a uniformly random entry, using rng or the default source
+// when nil.
+func (r *Reader) Choice(board *core.Board, exclude map[core.Move]bool, rng *rand.Rand) (*Entry, error) {
+	entries, err := r.FindAll(board, 1, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, notFound()
+	}
+
+	return &entries[intn(rng, len(entries))], nil
+}
+
+// WeightedChoice returns a random entry with the chance of each entry
+// proportional to its weight, using rng or the default source when nil.
+func (r *Reader) WeightedChoice(board *core.Board, exclude map[core.Move]bool, rng *rand.Rand) (*Entry, error) {
+	entries, err := r.FindAll(board, 1, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, e := range entries {
+		total += int(e.Weight)
+	}
+
+	if total == 0 {
+		return nil, notFound()
+	}
+
+	choice := intn(rng, total)
+	for i := range entries {
+		choice -= int(entries[i].Weight)
+		if choice < 0 {
+			return &entries[i], nil
+		}
+	}
+
+	return &entries[len(entries)-1], nil
+}
+
+func intn(rng *rand.Rand, n int) int {
+	if rng == nil {
+		return rand.Intn(n)
+	}
+
+	return rng.Intn(n)
+}
@@ -0,0 +1,340 @@
+package polyglot
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/captainsano/golang-chess/core"
+	"github.com/captainsano/golang-chess/pgn"
+)
+
+// Stats counts the games a move was played in, from the point of view of
+// the side making the move.
+type Stats struct {
+	Games  int
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+// WeightFunc computes the weight of a move. Weights of a position are
+// scaled down when they do not fit 16 bits.
+type WeightFunc func(s Stats) uint64
+
+// LearnFunc computes the learn field of a move.
+type LearnFunc func(s Stats) uint32
+
+// WeightWinsDraws counts a win twice and a draw once, like Polyglot does.
+func WeightWinsDraws(s Stats) uint64 {
+	return uint64(2*s.Wins + s.Draws)
+}
+
+func WeightWins(s Stats) uint64 {
+	return uint64(s.Wins)
+}
+
+func WeightGames(s Stats) uint64 {
+	return uint64(s.Games)
+}
+
+// LearnWinsDraws stores the wins in the high and the draws in the low 16
+// bits of the learn field.
+func LearnWinsDraws(s Stats) uint32 {
+	return uint32(clamp16(uint64(s.Wins)))<<16 | uint32(clamp16(uint64(s.Draws)))
+}
+
+func clamp16(x uint64) uint16 {
+	if x > 0xffff {
+		return 0xffff
+	}
+
+	return uint16(x)
+}
+
+// BuilderOptions select the games and moves that go into a book.
+type BuilderOptions struct {
+	// Minimum Elo of both players, 0 accepts unrated games
+	MinRating int
+
+	// Accepted results, all results when empty
+	Results []string
+
+	// Number of plies of the main line to add, 0 for all
+	MaxPly int
+
+	// Moves played in fewer games are left out
+	MinGames int
+
+	// Defaults to WeightWinsDraws
+	Weight WeightFunc
+
+	// Defaults to a learn field of 0
+	Learn LearnFunc
+}
+
+func DefaultBuilderOptions() BuilderOptions {
+	return BuilderOptions{
+		Results: []string{"1-0", "0-1", "1/2-1/2"},
+		Weight:  WeightWinsDraws,
+	}
+}
+
+type bookMove struct {
+	key uint64
+	raw uint16
+}
+
+// Builder accumulates move statistics from games and writes them as a
+// Polyglot book.
+type Builder struct {
+	Options BuilderOptions
+
+	stats map[bookMove]*Stats
+	games int
+}
+
+func NewBuilder(options BuilderOptions) *Builder {
+	return &Builder{Options: options, stats: map[bookMove]*Stats{}}
+}
+
+// Games returns the number of games added.
+func (b *Builder) Games() int {
+	return b.games
+}
+
+func (b *Builder) acceptsHeaders(headers *pgn.Headers) bool {
+	if b.Options.MinRating > 0 {
+		for _, name := range []string{"WhiteElo", "BlackElo"} {
+			value, _ := headers.Get(name)
+			if elo, err := strconv.Atoi(value); err != nil || elo < b.Options.MinRating {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (b *Builder) acceptsResult(result string) bool {
+	if len(b.Options.Results) == 0 {
+		return true
+	}
+
+	for _, r := range b.Options.Results {
+		if r == result {
+			return true
+		}
+	}
+
+	return false
+}
+
+// played is a move of a game waiting for the result.
+type played struct {
+	bookMove
+	turn core.Color
+}
+
+func (b *Builder) addMoves(moves []played, result string) {
+	b.games++
+
+	for _, m := range moves {
+		s, ok := b.stats[m.bookMove]
+		if !ok {
+			s = &Stats{}
+			b.stats[m.bookMove] = s
+		}
+
+		s.Games++
+		switch result {
+		case "1/2-1/2":
+			s.Draws++
+		case "1-0", "0-1":
+			if (result == "1-0") == (m.turn == core.White) {
+				s.Wins++
+			} else {
+				s.Losses++
+			}
+		}
+	}
+}
+
+func (b *Builder) maxPlyReached(plies int) bool {
+	return b.Options.MaxPly > 0 && plies >= b.Options.MaxPly
+}
+
+// gameResult returns the Result header of a game, or the result token of
+// its movetext when the header is missing or "*".
+func gameResult(header, movetext string) string {
+	if header == "" || header == "*" {
+		return movetext
+	}
+
+	return header
+}
+
+// AddGame adds the main line of a game. Returns false when the game is
+// filtered out.
+func (b *Builder) AddGame(g *pgn.Game) bool {
+	// Parsed games keep the result token of their movetext in the header, so
+	// only the "*" written for a missing header is left
+	header, _ := g.Headers.Get("Result")
+	result := gameResult(header, "*")
+	if !b.acceptsHeaders(&g.Headers) || !b.acceptsResult(result) {
+		return false
+	}
+
+	board := g.Headers.Board()
+	moves := []played{}
+	for node := g.Next(); node != nil && !b.maxPlyReached(len(moves)); node = node.Next() {
+		moves = append(moves, played{bookMove{ZobristHash(&board), encodeMove(&board, node.Move)}, board.Turn()})
+		board.Push(&node.Move)
+	}
+
+	b.addMoves(moves, result)
+	return true
+}
+
+// collector is the visitor adding games of a PGN stream to a builder.
+type collector struct {
+	pgn.BaseVisitor
+
+	builder *Builder
+	headers pgn.Headers
+	result  string
+	token   string
+	moves   []played
+	skip    bool
+}
+
+func (c *collector) BeginGame() {
+	c.headers = pgn.NewEmptyHeaders()
+	c.result = ""
+	c.token = "*"
+	c.moves = []played{}
+	c.skip = false
+}
+
+func (c *collector) VisitHeader(name, value string) {
+	c.headers.Set(name, value)
+	if name == "Result" {
+		c.result = value
+	}
+}
+
+func (c *collector) EndHeaders() pgn.Skip {
+	c.skip = !c.builder.acceptsHeaders(&c.headers)
+	if c.skip {
+		return pgn.SkipRest
+	}
+
+	return pgn.Continue
+}
+
+func (c *collector) VisitMove(board *core.Board, move core.Move) {
+	if !c.builder.maxPlyReached(len(c.moves)) {
+		c.moves = append(c.moves, played{bookMove{ZobristHash(board), encodeMove(board, move)}, board.Turn()})
+	}
+}
+
+func (c *collector) BeginVariation() pgn.Skip {
+	return pgn.SkipRest
+}
+
+func (c *collector) VisitResult(result string) {
+	c.token = result
+}
+
+func (c *collector) EndGame() {
+	result := gameResult(c.result, c.token)
+	if !c.skip && c.builder.acceptsResult(result) {
+		c.builder.addMoves(c.moves, result)
+	}
+}
+
+// AddPGN adds the main lines of all games of a PGN stream. Moves are
+// collected without building game trees.
+func (b *Builder) AddPGN(r io.Reader) error {
+	reader := pgn.NewReader(r)
+	c := &collector{builder: b}
+
+	for {
+		if err := reader.Accept(c); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// Entries returns the book entries sorted by key and by descending weight
+// within a position.
+func (b *Builder) Entries() []Entry {
+	weight := b.Options.Weight
+	if weight == nil {
+		weight = WeightWinsDraws
+	}
+
+	entries := []Entry{}
+	weights := []uint64{}
+	maxWeights := map[uint64]uint64{}
+
+	for m, s := range b.stats {
+		if s.Games < b.Options.MinGames {
+			continue
+		}
+
+		e := Entry{Key: m.key, RawMove: m.raw}
+		if b.Options.Learn != nil {
+			e.Learn = b.Options.Learn(*s)
+		}
+
+		w := weight(*s)
+		if w > maxWeights[m.key] {
+			maxWeights[m.key] = w
+		}
+
+		entries = append(entries, e)
+		weights = append(weights, w)
+	}
+
+	for i := range entries {
+		w := weights[i]
+		if max := maxWeights[entries[i].Key]; max > 0xffff {
+			w = w * 0xffff / max
+		}
+		entries[i].Weight = uint16(w)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key != entries[j].Key {
+			return entries[i].Key < entries[j].Key
+		}
+		if entries[i].Weight != entries[j].Weight {
+			return entries[i].Weight > entries[j].Weight
+		}
+		return entries[i].RawMove < entries[j].RawMove
+	})
+
+	return entries
+}
+
+// WriteTo writes the book in the Polyglot format.
+func (b *Builder) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	written := int64(0)
+	buf := make([]byte, entrySize)
+
+	for _, e := range b.Entries() {
+		e.encode(buf)
+		n, err := bw.Write(buf)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, bw.Flush()
+}
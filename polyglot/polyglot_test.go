@@ -0,0 +1,358 @@
+package polyglot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/captainsano/golang-chess/core"
+	"github.com/captainsano/golang-chess/pgn"
+)
+
+func TestZobristHash(t *testing.T) {
+	// Test vectors from the Polyglot book format specification
+	testCases := []struct {
+		moves []string
+		hash  uint64
+	}{
+		{[]string{}, 0x463b96181691fc9c},
+		{[]string{"e2e4"}, 0x823c9b50fd114196},
+		{[]string{"e2e4", "d7d5"}, 0x0756b94461c50fb0},
+		{[]string{"e2e4", "d7d5", "e4e5"}, 0x662fafb965db29d4},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5"}, 0x22a48b5a8e47ff78},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2"}, 0x652a607ca3f242c1},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2", "e8f7"}, 0x00fdd303c946bdd9},
+		{[]string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4"}, 0x3c8123ea7b067637},
+		{[]string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4", "b4c3", "a1a3"}, 0x5c3f9b829b279560},
+	}
+
+	for _, tc := range testCases {
+		b := core.NewDefaultBoard()
+		for _, uci := range tc.moves {
+			if _, err := b.PushUci(uci); err != nil {
+				t.Fatalf("illegal move %s: %v", uci, err)
+			}
+		}
+
+		if hash := ZobristHash(&b); hash != tc.hash {
+			t.Errorf("%v: expected %016x, got %016x", tc.moves, tc.hash, hash)
+		}
+	}
+}
+
+type testEntry struct {
+	fen    string
+	raw    string
+	weight uint16
+}
+
+// encodeRaw packs a uci move the way Polyglot does, castling included.
+func encodeRaw(uci string) uint16 {
+	from := core.NewSquareFromName(uci[0:2])
+	to := core.NewSquareFromName(uci[2:4])
+
+	raw := uint16(from)<<6 | uint16(to)
+	if len(uci) == 5 {
+		raw |= uint16(core.NewPieceFromSymbol(uci[4:]).Type-1) << 12
+	}
+
+	return raw
+}
+
+func buildBook(t *testing.T, entries []testEntry) *Reader {
+	data := [][]byte{}
+	for _, e := range entries {
+		b := core.NewBoardFromFEN(e.fen, false)
+		buf := make([]byte, entrySize)
+		binary.BigEndian.PutUint64(buf[0:8], ZobristHash(&b))
+		binary.BigEndian.PutUint16(buf[8:10], encodeRaw(e.raw))
+		binary.BigEndian.PutUint16(buf[10:12], e.weight)
+		data = append(data, buf)
+	}
+
+	sort.SliceStable(data, func(i, j int) bool {
+		return binary.BigEndian.Uint64(data[i]) < binary.BigEndian.Uint64(data[j])
+	})
+
+	book := bytes.Join(data, nil)
+	reader, err := NewReader(bytes.NewReader(book), int64(len(book)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return reader
+}
+
+func TestReader(t *testing.T) {
+	castlingFEN := "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"
+	promotionFEN := "8/4P3/8/8/8/8/k7/4K3 w - - 0 1"
+
+	book := buildBook(t, []testEntry{
+		{core.StartingFEN, "e2e4", 10},
+		{core.StartingFEN, "d2d4", 30},
+		{core.StartingFEN, "g1f3", 0},
+		{core.StartingFEN, "e2e5", 50},
+		{castlingFEN, "e1h1", 1},
+		{castlingFEN, "e1a1", 1},
+		{promotionFEN, "e7e8q", 2},
+		{promotionFEN, "e7e8n", 1},
+	})
+
+	if book.Len() != 8 {
+		t.Errorf("expected 8 entries, got %d", book.Len())
+	}
+
+	start := core.NewDefaultBoard()
+
+	t.Run("find all", func(t *testing.T) {
+		entries, err := book.FindAll(&start, 0, nil)
+		if err != nil || len(entries) != 3 {
+			t.Fatalf("expected the legal entries, got %v %v", entries, err)
+		}
+
+		if entries, _ := book.FindAll(&start, 1, nil); len(entries) != 2 {
+			t.Errorf("expected the minimum weight to apply, got %v", entries)
+		}
+
+		exclude := map[core.Move]bool{{FromSquare: core.D2, ToSquare: core.D4}: true}
+		if entries, _ := book.FindAll(&start, 1, exclude); len(entries) != 1 || entries[0].Move.Uci() != "e2e4" {
+			t.Errorf("expected d2d4 to be excluded, got %v", entries)
+		}
+	})
+
+	t.Run("find", func(t *testing.T) {
+		e, err := book.Find(&start, 1, nil)
+		if err != nil || e.Move.Uci() != "d2d4" || e.Weight != 30 {
+			t.Errorf("expected the best move, got %v %v", e, err)
+		}
+
+		b := core.NewBoardFromFEN("4k3/8/8/8/8/8/8/4K3 w - - 0 1", false)
+		if _, err := book.Find(&b, 1, nil); err == nil {
+			t.Errorf("expected position not to be in book")
+		}
+	})
+
+	t.Run("castling", func(t *testing.T) {
+		b := core.NewBoardFromFEN(castlingFEN, false)
+		entries, _ := book.FindAll(&b, 0, nil)
+		if len(entries) != 2 {
+			t.Fatalf("expected both castling moves, got %v", entries)
+		}
+
+		for _, e := range entries {
+			if !b.IsLegal(&e.Move) || (e.Move.Uci() != "e1g1" && e.Move.Uci() != "e1c1") {
+				t.Errorf("castling not decoded: %v", e.Move)
+			}
+		}
+
+		chess960 := core.NewBoardFromFEN(castlingFEN, true)
+		entries, _ = book.FindAll(&chess960, 0, nil)
+		if len(entries) != 2 || !chess960.IsLegal(&entries[0].Move) || entries[0].Move.ToSquare.File() == core.FileG {
+			t.Errorf("expected king to rook castling in chess960, got %v", entries)
+		}
+	})
+
+	t.Run("promotion", func(t *testing.T) {
+		b := core.NewBoardFromFEN(promotionFEN, false)
+		e, err := book.Find(&b, 1, nil)
+		if err != nil || e.Move.Uci() != "e7e8q" {
+			t.Errorf("promotion not decoded: %v %v", e, err)
+		}
+	})
+
+	t.Run("weighted choice", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1))
+		counts := map[string]int{}
+		for i := 0; i < 4000; i++ {
+			e, err := book.WeightedChoice(&start, nil, rng)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			counts[e.Move.Uci()]++
+		}
+
+		if counts["g1f3"] != 0 || counts["d2d4"] < 2*counts["e2e4"] {
+			t.Errorf("choice not weighted: %v", counts)
+		}
+
+		exclude := map[core.Move]bool{{FromSquare: core.D2, ToSquare: core.D4}: true}
+		if e, err := book.Choice(&start, exclude, rng); err != nil || e.Move.Uci() != "e2e4" {
+			t.Errorf("expected the only remaining move, got %v %v", e, err)
+		}
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		if _, err := NewReader(bytes.NewReader(make([]byte, 17)), 17); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
+
+const builderPGN = `[White "A"]
+[Black "B"]
+[WhiteElo "2500"]
+[BlackElo "2400"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 Nf6 4. O-O (4. d3) Be7 1-0
+
+[White "C"]
+[Black "D"]
+[WhiteElo "2600"]
+[BlackElo "2550"]
+[Result "1/2-1/2"]
+
+1. e4 c5 2. Nf3 1/2-1/2
+
+[White "E"]
+[Black "F"]
+[WhiteElo "2450"]
+[BlackElo "2700"]
+[Result "0-1"]
+
+1. d4 d5 0-1
+
+[White "G"]
+[Black "H"]
+[WhiteElo "1500"]
+[BlackElo "1500"]
+[Result "1-0"]
+
+1. h4 h5 1-0
+
+[White "I"]
+[Black "J"]
+[Result "*"]
+
+1. e4 *
+`
+
+func TestBuilder(t *testing.T) {
+	options := DefaultBuilderOptions()
+	options.MinRating = 2000
+	options.Learn = LearnWinsDraws
+
+	builder := NewBuilder(options)
+	if err := builder.AddPGN(strings.NewReader(builderPGN)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if builder.Games() != 3 {
+		t.Errorf("expected 3 games to pass the filters, got %d", builder.Games())
+	}
+
+	buf := &bytes.Buffer{}
+	n, err := builder.WriteTo(buf)
+	if err != nil || n != int64(buf.Len()) || n != 12*entrySize {
+		t.Fatalf("expected 12 entries, got %d bytes: %v", n, err)
+	}
+
+	book, _ := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+
+	t.Run("sorted", func(t *testing.T) {
+		for i := 1; i < book.Len(); i++ {
+			a, _ := book.entryAt(i - 1)
+			b, _ := book.entryAt(i)
+			if a.Key > b.Key {
+				t.Fatalf("entries not sorted at %d", i)
+			}
+		}
+	})
+
+	t.Run("statistics", func(t *testing.T) {
+		start := core.NewDefaultBoard()
+		entries, err := book.FindAll(&start, 0, nil)
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("expected e4 and d4, got %v %v", entries, err)
+		}
+
+		// e4 won once and drew once, d4 lost once
+		if entries[0].Move.Uci() != "e2e4" || entries[0].Weight != 3 || entries[0].Learn != 1<<16|1 {
+			t.Errorf("e4 not matching: %+v", entries[0])
+		}
+
+		if entries[1].Move.Uci() != "d2d4" || entries[1].Weight != 0 {
+			t.Errorf("d4 not matching: %+v", entries[1])
+		}
+
+		start.PushUci("d2d4")
+		if e, err := book.Find(&start, 1, nil); err != nil || e.Move.Uci() != "d7d5" || e.Weight != 2 {
+			t.Errorf("d5 not matching: %v %v", e, err)
+		}
+	})
+
+	t.Run("castling round trip", func(t *testing.T) {
+		b := core.NewDefaultBoard()
+		for _, uci := range []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "g8f6"} {
+			b.PushUci(uci)
+		}
+
+		e, err := book.Find(&b, 1, nil)
+		if err != nil || e.Move.Uci() != "e1g1" || e.RawMove&0x3f != uint16(core.H1) {
+			t.Errorf("castling not matching: %v %v", e, err)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		options := DefaultBuilderOptions()
+		options.Results = []string{"1-0"}
+		options.MaxPly = 2
+
+		builder := NewBuilder(options)
+		builder.AddPGN(strings.NewReader(builderPGN))
+		if builder.Games() != 2 || len(builder.Entries()) != 4 {
+			t.Errorf("expected the first two plies of two games, got %d %v", builder.Games(), builder.Entries())
+		}
+	})
+
+	t.Run("add game", func(t *testing.T) {
+		g, _ := pgn.ReadGame(strings.NewReader(builderPGN))
+
+		builder := NewBuilder(DefaultBuilderOptions())
+		if !builder.AddGame(g) {
+			t.Fatalf("expected game to be added")
+		}
+
+		if len(builder.Entries()) != 8 {
+			t.Errorf("expected the main line only, got %v", builder.Entries())
+		}
+	})
+
+	t.Run("results", func(t *testing.T) {
+		for _, c := range []struct {
+			pgn, result string
+		}{
+			{"1. e4 *", "*"},
+			{"[Result \"*\"]\n\n1. e4 1-0", "1-0"},
+			{"[Result \"0-1\"]\n\n1. e4 1-0", "0-1"},
+		} {
+			options := DefaultBuilderOptions()
+			options.Results = []string{c.result}
+
+			builder := NewBuilder(options)
+			builder.AddPGN(strings.NewReader(c.pgn))
+			if builder.Games() != 1 {
+				t.Errorf("expected AddPGN to score %q as %s", c.pgn, c.result)
+			}
+
+			g, _ := pgn.ReadGame(strings.NewReader(c.pgn))
+			if !NewBuilder(options).AddGame(g) {
+				t.Errorf("expected AddGame to score %q as %s", c.pgn, c.result)
+			}
+		}
+
+		options := DefaultBuilderOptions()
+		options.Results = []string{"*"}
+
+		g := pgn.NewGame()
+		g.Headers = pgn.NewEmptyHeaders()
+		m, _ := core.NewMoveFromUci("e2e4")
+		g.AddVariation(*m)
+		if !NewBuilder(options).AddGame(g) {
+			t.Error("expected a game without a Result header to be scored as *")
+		}
+	})
+}
@@ -0,0 +1,12 @@
+package polyglot
+
+import (
+	"github.com/captainsano/golang-chess/core"
+)
+
+// ZobristHash returns the Polyglot key of the position. Boards maintain
+// the key themselves, with the en passant rule and castling encoding of the
+// Polyglot format.
+func ZobristHash(board *core.Board) uint64 {
+	return board.ZobristHash()
+}
@@ -0,0 +1,423 @@
+// Package raster renders boards as PNG images and games as animated GIFs,
+// with the options of SVG boards.
+package raster
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/captainsano/golang-chess/core"
+	"github.com/captainsano/golang-chess/svg"
+)
+
+// Options of rendered images. The piece set of the SVG options is ignored,
+// pieces are always drawn with the Cburnett sprites.
+type Options struct {
+	svg.Options
+
+	// Delay between the frames of animations, 1 second if 0
+	Delay time.Duration
+}
+
+// Samples per pixel along each axis, for anti-aliasing
+const samples = 4
+
+var pieceImages = func() map[string]image.Image {
+	images := map[string]image.Image{}
+	for symbol, data := range sprites {
+		b, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			panic(err)
+		}
+
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			panic(err)
+		}
+		images[symbol] = img
+	}
+	return images
+}()
+
+// parseColor parses colors of the forms #rgb, #rgba, #rrggbb and #rrggbbaa.
+func parseColor(s string) (color.NRGBA, bool) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 || len(s) == 4 {
+		long := ""
+		for _, c := range s {
+			long += string(c) + string(c)
+		}
+		s = long
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if len(s) != 8 || err != nil {
+		return color.NRGBA{}, false
+	}
+
+	return color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, true
+}
+
+// canvas draws in the coordinates of SVG boards.
+type canvas struct {
+	img   *image.RGBA
+	scale float64
+
+	sprites map[spriteKey]*image.RGBA
+}
+
+// rect returns the pixels of a rectangle.
+func (c *canvas) rect(x0, y0, x1, y1 float64) image.Rectangle {
+	round := func(v float64) int {
+		return int(math.Floor(v*c.scale + 0.5))
+	}
+	return image.Rect(round(x0), round(y0), round(x1), round(y1)).Intersect(c.img.Bounds())
+}
+
+func (c *canvas) fill(r image.Rectangle, col color.Color) {
+	draw.Draw(c.img, r, &image.Uniform{col}, image.Point{}, draw.Over)
+}
+
+// blend draws a color over a pixel with the given coverage.
+func (c *canvas) blend(x, y int, col color.NRGBA, coverage float64) {
+	if coverage <= 0 {
+		return
+	}
+
+	a := float64(col.A) / 255 * coverage
+	dst := c.img.RGBAAt(x, y)
+	mix := func(s, d uint8) uint8 {
+		return uint8(float64(s)*a + float64(d)*(1-a) + 0.5)
+	}
+	c.img.SetRGBA(x, y, color.RGBA{mix(col.R, dst.R), mix(col.G, dst.G), mix(col.B, dst.B), mix(255, dst.A)})
+}
+
+// shape draws the points of a bounding box for which inside is true.
+func (c *canvas) shape(x0, y0, x1, y1 float64, col color.NRGBA, inside func(x, y float64) bool) {
+	r := c.rect(x0, y0, x1+1, y1+1)
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			n := 0
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					x := (float64(px) + (float64(sx)+0.5)/samples) / c.scale
+					y := (float64(py) + (float64(sy)+0.5)/samples) / c.scale
+					if inside(x, y) {
+						n++
+					}
+				}
+			}
+			c.blend(px, py, col, float64(n)/(samples*samples))
+		}
+	}
+}
+
+// scale returns an image scaled to a size.
+func scale(img image.Image, w, h int) *image.RGBA {
+	src := img.Bounds()
+	sx, sy := float64(src.Dx())/float64(w), float64(src.Dy())/float64(h)
+
+	scaled := image.NewRGBA(image.Rect(0, 0, w, h))
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			var red, green, blue, alpha uint32
+			for j := 0; j < samples; j++ {
+				for i := 0; i < samples; i++ {
+					x := src.Min.X + int((float64(px)+(float64(i)+0.5)/samples)*sx)
+					y := src.Min.Y + int((float64(py)+(float64(j)+0.5)/samples)*sy)
+					cr, cg, cb, ca := img.At(x, y).RGBA()
+					red, green, blue, alpha = red+cr, green+cg, blue+cb, alpha+ca
+				}
+			}
+
+			n := uint32(samples*samples) * 0x101
+			scaled.SetRGBA(px, py, color.RGBA{uint8(red / n), uint8(green / n), uint8(blue / n), uint8(alpha / n)})
+		}
+	}
+
+	return scaled
+}
+
+type spriteKey struct {
+	symbol string
+	w, h   int
+}
+
+// sprite draws a piece scaled to a rectangle. Scaled sprites are cached.
+func (c *canvas) sprite(r image.Rectangle, symbol string) {
+	key := spriteKey{symbol, r.Dx(), r.Dy()}
+	scaled, ok := c.sprites[key]
+	if !ok {
+		scaled = scale(pieceImages[symbol], r.Dx(), r.Dy())
+		c.sprites[key] = scaled
+	}
+
+	draw.Draw(c.img, r, scaled, image.Point{}, draw.Over)
+}
+
+// text draws a file or rank name centered on a point.
+func (c *canvas) text(name string, cx, cy float64, col color.NRGBA) {
+	const cell = 1.3
+	glyph := glyphs[name]
+	x0, y0 := cx-2.5*cell, cy-3.5*cell
+
+	c.shape(x0, y0, x0+5*cell, y0+7*cell, col, func(x, y float64) bool {
+		i, j := int(math.Floor((x-x0)/cell)), int(math.Floor((y-y0)/cell))
+		return i >= 0 && i < 5 && j >= 0 && j < 7 && glyph[j][i] == '#'
+	})
+}
+
+// check draws the radial gradient of a king in check.
+func (c *canvas) check(x, y float64) {
+	r := c.rect(x, y, x+svg.SquareSize, y+svg.SquareSize)
+	cx, cy, radius := (x+svg.SquareSize/2.0)*c.scale, (y+svg.SquareSize/2.0)*c.scale, svg.SquareSize/2.0*c.scale
+
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			d := math.Hypot(float64(px)+0.5-cx, float64(py)+0.5-cy) / radius
+			switch {
+			case d < 0.5:
+				c.blend(px, py, color.NRGBA{uint8(0xff - 0x30*d), 0, 0, 0xff}, 1)
+			case d < 1:
+				c.blend(px, py, color.NRGBA{uint8(0xe7 - 0x92*(d-0.5)), 0, 0, 0xff}, 2*(1-d))
+			}
+		}
+	}
+}
+
+// arrow draws an arrow like SVG boards.
+func (c *canvas) arrow(o *svg.Options, arrow svg.Arrow) {
+	col, ok := parseColor(arrow.Color)
+	if arrow.Color == "" {
+		col, ok = parseColor(svg.Green)
+	}
+	if !ok {
+		return
+	}
+
+	_, tx, ty := o.Geometry(arrow.Tail)
+	_, hx, hy := o.Geometry(arrow.Head)
+	xtail, ytail := float64(tx)+svg.SquareSize/2.0, float64(ty)+svg.SquareSize/2.0
+	xhead, yhead := float64(hx)+svg.SquareSize/2.0, float64(hy)+svg.SquareSize/2.0
+
+	if arrow.Tail == arrow.Head {
+		r, w := svg.SquareSize*0.9/2, svg.SquareSize*0.1
+		c.shape(xhead-r-w, yhead-r-w, xhead+r+w, yhead+r+w, col, func(x, y float64) bool {
+			return math.Abs(math.Hypot(x-xhead, y-yhead)-r) <= w/2
+		})
+		return
+	}
+
+	markerSize := 0.75 * svg.SquareSize
+	markerMargin := 0.1 * svg.SquareSize
+	width := 0.2 * svg.SquareSize
+
+	dx, dy := xhead-xtail, yhead-ytail
+	hypot := math.Hypot(dx, dy)
+	ux, uy := dx/hypot, dy/hypot
+
+	// Position along the arrow and distance from its axis
+	axis := func(x, y float64) (float64, float64) {
+		return (x-xtail)*ux + (y-ytail)*uy, math.Abs((x-xtail)*uy - (y-ytail)*ux)
+	}
+	shaft := hypot - markerSize - markerMargin
+	tip := hypot - markerMargin
+
+	c.shape(math.Min(xtail, xhead)-markerSize, math.Min(ytail, yhead)-markerSize, math.Max(xtail, xhead)+markerSize, math.Max(ytail, yhead)+markerSize, col, func(x, y float64) bool {
+		along, across := axis(x, y)
+		if along >= 0 && along < shaft {
+			return across <= width/2
+		}
+		return along >= shaft && along <= tip && across <= (tip-along)/2
+	})
+}
+
+// Board renders the pieces of a board.
+func Board(board *core.BaseBoard, options *Options) *image.RGBA {
+	if options == nil {
+		options = &Options{}
+	}
+
+	return render(board, &options.Options, map[spriteKey]*image.RGBA{})
+}
+
+func render(board *core.BaseBoard, o *svg.Options, sprites map[spriteKey]*image.RGBA) *image.RGBA {
+	full, _, _ := o.Geometry(core.A1)
+	size := o.Size
+	if size == 0 {
+		size = full
+	}
+
+	c := &canvas{img: image.NewRGBA(image.Rect(0, 0, size, size)), scale: float64(size) / float64(full), sprites: sprites}
+
+	if o.Coordinates {
+		col, _ := parseColor(svg.MarginColor)
+		c.fill(c.img.Bounds(), col)
+	}
+
+	for s := core.A1; s <= core.H8; s++ {
+		_, x, y := o.Geometry(s)
+		r := c.rect(float64(x), float64(y), float64(x+svg.SquareSize), float64(y+svg.SquareSize))
+
+		col, _ := parseColor(o.SquareColor(s))
+		c.fill(r, col)
+
+		if fill, ok := parseColor(o.Fill[s]); ok {
+			c.fill(r, fill)
+		}
+
+		if o.Check != nil && *o.Check == s {
+			c.check(float64(x), float64(y))
+		}
+
+		if p := board.PieceAt(s); p != nil {
+			c.sprite(r, p.Symbol())
+		}
+	}
+
+	if o.Coordinates {
+		col, _ := parseColor(svg.CoordinateColor)
+		margin := float64(full-8*svg.SquareSize) / 2
+
+		for i := 0; i < 8; i++ {
+			_, x, y := o.Geometry(core.NewSquare(core.File(i), core.Rank(i)))
+			cx, cy := float64(x)+svg.SquareSize/2.0, float64(y)+svg.SquareSize/2.0
+
+			for _, ty := range []float64{margin / 2, float64(full) - margin/2} {
+				c.text(core.File(i).Name(), cx, ty, col)
+			}
+			for _, tx := range []float64{margin / 2, float64(full) - margin/2} {
+				c.text(core.Rank(i).Name(), tx, cy, col)
+			}
+		}
+	}
+
+	for _, arrow := range o.Arrows {
+		c.arrow(o, arrow)
+	}
+
+	return c.img
+}
+
+// Position renders a board with its last move and a king in check
+// highlighted, unless the options set them.
+func Position(board *core.Board, options *Options) *image.RGBA {
+	o := Options{}
+	if options != nil {
+		o = *options
+	}
+
+	return position(board, &o.Options, map[spriteKey]*image.RGBA{})
+}
+
+func position(board *core.Board, options *svg.Options, sprites map[spriteKey]*image.RGBA) *image.RGBA {
+	o := svg.PositionOptions(board, options)
+	bb := board.BaseBoard()
+	return render(&bb, &o, sprites)
+}
+
+// WritePNG writes a PNG image of a position.
+func WritePNG(w io.Writer, board *core.Board, options *Options) error {
+	return png.Encode(w, Position(board, options))
+}
+
+// palette returns the most frequent colors of images.
+func palette(images []*image.RGBA) color.Palette {
+	counts := map[color.RGBA]int{}
+	for _, img := range images {
+		for i := 0; i+3 < len(img.Pix); i += 4 {
+			counts[color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}]++
+		}
+	}
+
+	colors := make([]color.RGBA, 0, len(counts))
+	for col := range counts {
+		colors = append(colors, col)
+	}
+	sort.Slice(colors, func(i, j int) bool {
+		if counts[colors[i]] != counts[colors[j]] {
+			return counts[colors[i]] > counts[colors[j]]
+		}
+		a, b := colors[i], colors[j]
+		return a.R < b.R || (a.R == b.R && (a.G < b.G || (a.G == b.G && (a.B < b.B || (a.B == b.B && a.A < b.A)))))
+	})
+	if len(colors) > 256 {
+		colors = colors[:256]
+	}
+
+	p := make(color.Palette, len(colors))
+	for i, col := range colors {
+		p[i] = col
+	}
+	return p
+}
+
+// Game returns an animation of the moves of a board: a frame of the
+// starting position and one after each move. The last move and a king in
+// check are highlighted in each frame.
+func Game(board *core.Board, options *Options) *gif.GIF {
+	o := Options{}
+	if options != nil {
+		o = *options
+	}
+	o.LastMove, o.Check = nil, nil
+
+	delay := int(o.Delay / (10 * time.Millisecond))
+	if o.Delay == 0 {
+		delay = 100
+	}
+
+	b := core.NewBoardFromBoard(board)
+	moves := b.MoveStack()
+	for range moves {
+		b.Pop()
+	}
+
+	sprites := map[spriteKey]*image.RGBA{}
+	frames := []*image.RGBA{position(&b, &o.Options, sprites)}
+	for i := range moves {
+		b.Push(&moves[i])
+		frames = append(frames, position(&b, &o.Options, sprites))
+	}
+
+	p := palette(frames)
+	cache := map[color.RGBA]uint8{}
+
+	g := &gif.GIF{}
+	for _, frame := range frames {
+		paletted := image.NewPaletted(frame.Bounds(), p)
+		for i, j := 0, 0; i+3 < len(frame.Pix); i, j = i+4, j+1 {
+			col := color.RGBA{frame.Pix[i], frame.Pix[i+1], frame.Pix[i+2], frame.Pix[i+3]}
+			idx, ok := cache[col]
+			if !ok {
+				idx = uint8(p.Index(col))
+				cache[col] = idx
+			}
+			paletted.Pix[j] = idx
+		}
+
+		g.Image = append(g.Image, paletted)
+		g.Delay = append(g.Delay, delay)
+	}
+
+	return g
+}
+
+// WriteGIF writes an animated GIF of the moves of a board.
+func WriteGIF(w io.Writer, board *core.Board, options *Options) error {
+	return gif.EncodeAll(w, Game(board, options))
+}
@@ -0,0 +1,194 @@
+package raster
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/captainsano/golang-chess/core"
+	"github.com/captainsano/golang-chess/svg"
+)
+
+func TestParseColor(t *testing.T) {
+	for s, expected := range map[string]color.NRGBA{
+		"#fff":      {0xff, 0xff, 0xff, 0xff},
+		"#f008":     {0xff, 0x00, 0x00, 0x88},
+		"#d18b47":   {0xd1, 0x8b, 0x47, 0xff},
+		"#15781b80": {0x15, 0x78, 0x1b, 0x80},
+	} {
+		if c, ok := parseColor(s); !ok || c != expected {
+			t.Errorf("%s: expected %v, got %v", s, expected, c)
+		}
+	}
+
+	for _, s := range []string{"", "red", "#12345", "#gggggg"} {
+		if _, ok := parseColor(s); ok {
+			t.Errorf("%s: expected invalid color", s)
+		}
+	}
+}
+
+// at returns the color of the center of a square.
+func at(img image.Image, o *svg.Options, s core.Square) color.RGBA {
+	full, x, y := o.Geometry(s)
+	k := float64(img.Bounds().Dx()) / float64(full)
+	c := img.At(int((float64(x)+22.5)*k), int((float64(y)+22.5)*k))
+	return color.RGBAModel.Convert(c).(color.RGBA)
+}
+
+// ink returns the color of the piece on a square, by counting its white and
+// black pixels, or "" if there are none.
+func ink(img image.Image, o *svg.Options, s core.Square) string {
+	full, x, y := o.Geometry(s)
+	k := float64(img.Bounds().Dx()) / float64(full)
+
+	white, black := 0, 0
+	r := image.Rect(int(float64(x)*k), int(float64(y)*k), int(float64(x+svg.SquareSize)*k), int(float64(y+svg.SquareSize)*k))
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			c := color.RGBAModel.Convert(img.At(px, py)).(color.RGBA)
+			if c.R > 0xf0 && c.G > 0xf0 && c.B > 0xf0 {
+				white++
+			} else if c.R < 0x20 && c.G < 0x20 && c.B < 0x20 {
+				black++
+			}
+		}
+	}
+
+	switch {
+	case white == 0 && black == 0:
+		return ""
+	case white > black:
+		return "white"
+	}
+	return "black"
+}
+
+func TestBoard(t *testing.T) {
+	b := core.NewBaseBoard(core.StartingBoardFEN)
+	img := Board(&b, nil)
+
+	if size := img.Bounds().Size(); size.X != 360 || size.Y != 360 {
+		t.Errorf("unexpected size: %v", size)
+	}
+
+	o := &svg.Options{}
+	light, _ := parseColor(svg.LightColor)
+	if c := at(img, o, core.E4); c != (color.RGBA{light.R, light.G, light.B, 0xff}) {
+		t.Errorf("expected light square on e4, got %v", c)
+	}
+	if c := ink(img, o, core.E8); c != "black" {
+		t.Errorf("expected black king on e8, got %s", c)
+	}
+	if c := ink(img, o, core.E1); c != "white" {
+		t.Errorf("expected white king on e1, got %s", c)
+	}
+
+	// Flipped and scaled with a margin
+	b = core.NewBaseBoard("4k3/8/8/8/8/8/8/R3K3")
+	o = &svg.Options{Size: 195, Flipped: true, Coordinates: true, Fill: map[core.Square]string{core.H8: "#00f"}}
+	img = Board(&b, &Options{Options: *o})
+
+	if size := img.Bounds().Size(); size.X != 195 {
+		t.Errorf("unexpected size: %v", size)
+	}
+	if c := img.At(2, 2).(color.RGBA); c != (color.RGBA{0x21, 0x21, 0x21, 0xff}) {
+		t.Errorf("expected margin, got %v", c)
+	}
+	if _, x, y := o.Geometry(core.H8); x != 15 || y != 330 {
+		t.Errorf("expected h8 at the bottom left, got %d, %d", x, y)
+	}
+	if c := at(img, o, core.H8); c != (color.RGBA{0, 0, 0xff, 0xff}) {
+		t.Errorf("expected fill of h8, got %v", c)
+	}
+	if c := ink(img, o, core.A1); c != "white" {
+		t.Errorf("expected white rook on a1, got %s", c)
+	}
+	if c := ink(img, o, core.A8); c != "" {
+		t.Errorf("expected empty a8, got %s", c)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	b := core.NewDefaultBoard()
+	for _, san := range []string{"f3", "e5", "g4", "Qh4"} {
+		if _, err := b.PushSan(san); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o := svg.PositionOptions(&b, nil)
+	img := Position(&b, &Options{Options: svg.Options{Arrows: []svg.Arrow{{Tail: core.A3, Head: core.A6, Color: svg.Blue}}}})
+
+	// Red around the king
+	full, x, y := o.Geometry(core.E1)
+	if c := img.At(x+4, y+22).(color.RGBA); c.R < 0xb0 || c.G > 0x70 {
+		t.Errorf("expected check on e1, got %v", c)
+	}
+	if full != 360 {
+		t.Errorf("unexpected size: %d", full)
+	}
+
+	lastMove, _ := parseColor(svg.DarkLastMoveColor)
+	if _, x, y := o.Geometry(core.H4); img.At(x+1, y+1) != (color.RGBA{lastMove.R, lastMove.G, lastMove.B, 0xff}) {
+		t.Errorf("expected last move on h4, got %v", img.At(x+1, y+1))
+	}
+
+	if c := at(img, &o, core.A4); c.B < c.R || c.B < c.G {
+		t.Errorf("expected arrow on a4, got %v", c)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, &b, nil); err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err := png.Decode(&buf); err != nil || decoded.Bounds() != img.Bounds() {
+		t.Errorf("unexpected png: %v", err)
+	}
+}
+
+func TestGame(t *testing.T) {
+	b := core.NewDefaultBoard()
+	for _, san := range []string{"e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6", "Qxf7"} {
+		if _, err := b.PushSan(san); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGIF(&buf, &b, &Options{Options: svg.Options{Size: 180}, Delay: 2 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(g.Image) != 8 {
+		t.Errorf("expected 8 frames, got %d", len(g.Image))
+	}
+	for i, delay := range g.Delay {
+		if delay != 200 {
+			t.Errorf("frame %d: expected delay of 2 seconds, got %d", i, delay)
+		}
+	}
+
+	// The starting position, then the queen on f7
+	o := &svg.Options{Size: 180}
+	if c := ink(g.Image[0], o, core.F7); c != "black" {
+		t.Errorf("expected black pawn on f7, got %s", c)
+	}
+	if c := ink(g.Image[7], o, core.F7); c != "white" {
+		t.Errorf("expected white queen on f7, got %s", c)
+	}
+
+	// The board is unchanged
+	if len(b.MoveStack()) != 7 || !b.IsCheckmate() {
+		t.Error("expected board to be unchanged")
+	}
+}
@@ -0,0 +1,311 @@
+package raster
+
+// Sprites of the pieces as PNG images of 90x90 pixels, keyed by symbol,
+// rasterized from the Cburnett piece set of the svg package.
+var sprites = map[string]string{
+	"P": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAAEmklEQVR42uycsUtcSRjAf3cb0GMDKifswQprsZADBS1SbCFc" +
+		"s5A/IAGLCBu4IoWFhYVlBAtLyyuuCggWFilSpIiQYlMGIqxwd2hhIWxhjjWoeHDIHN/x3stkbr28Z/btvtn3/WAgxJlvvv05" +
+		"zsybfe99i9IXVLSKVtGKilbRKloVqGgVrahoFa2iFRWtohUVraJzzh2Pcr0H3AeqAMAR8A74Q3+NvaEBtABzQ2kFdZRb8j3w" +
+		"+n8Eu+V10CaTfJNhyW+BHwEAisUi9Xqd2dlZAA4ODtjb2+Py8hKL34EF4E8dp/GIRnKhUDCrq6um0+kYF/k/+ZnUcUa2EoOG" +
+		"LXl3d9d8CanjyNY5OwbRwiejNS5S11kglS9s4QxgisVi1+niJqSutAHCcg/0guUm7gMA/y584+PjsRtKXWnTLZaK/i9VACDa" +
+		"XSTBaVNV0Tkka6KPAQj2yUlx2hzrr/dmhnYxzCLR9m5tbS22aKmr27v4FIGmfcESF+eCpRnEUrpQAn4DTFhmZmZii5a6dtsg" +
+		"Vkm1fs6YK3l5edmcn5/HFi11pU0X2WOq9xMv7eliZ2fH3BZp60wjL1UvYB8iAV8l2ZZtx9RDJhgB2rc5REp4yNQO+sotTwED" +
+		"mFKpZK6urnomWmJJTCAsT/MsOtrKbW1tmV4jMZ0tXy4ZsxfA09PTnouWmM7COJbHs44aAMD8/DyTk5M970BiSuxufeZJdNUW" +
+		"nRZO7GoeRUdDeGpqKrVOnNiTeRSdKwYp+gMAwMnJSWqdOLE/5FH0MQDA/v5+ap04sXP5ZYBu7/rER+AtwPX1Ndvb2z3vQGJK" +
+		"bICgr4/klOgSvFwu9/wSXGLqJTigh0r95WfAhCXOvXZx7sWzYwZ9KMCrFA/+X6neT1SAvwETllarlViytLFjBDErWfiAWbgy" +
+		"fAi8c5+nubi4SByoS5s7QeyHeR7FBeBXZwSaubm5r546JIYbN+irkDfJReANYMJSqVR6shDaC6LEdGS/ydP9Hp/dJAOYxcXF" +
+		"RLcWJLkFQWI7snNxc03BHcmbm5smbaSPLiN7qKeRX+wPnMb3hDG/PzRBLkPJY/uDbmxsmH4jfTqyHw+b5DLQsefkQeHM2Z0g" +
+		"t6Hhhb27SGPhS7JAOruRF8Mi+SfAhKXZbJpBIznYOQU5es97wACm0WiYrCC5AGF577vkB4ABzMjIiGm325kRLblITkBYHvgs" +
+		"OjqVW1lZMVlDchqGU76yffR5eHiYOdGSk3OkWvbx9O4JAMFTsNVqNXMjQXJynrZ94qPoRwAAS0tLmf2zc3J75O20IQvOIPfN" +
+		"cfbVzqJY9mlE1wEAFhYWuHv3bmZHhOQmOXbL3QfRtSjrej3zf35OjjUvRddqtcyLdnKs+fTyqitgFKDT6SR678YgODs7Y2Ji" +
+		"AgDgL+A7H0Z0JZRcKpUyL5ngpSqSK0CQe8UH0dMABPtUX3BynfZK9PT0tDeinVy9ED0a/WN01BvRTq49TzyNl8D+AABwdHTE" +
+		"+vq6F6Il126fIcs8S/A+0ayWZz5MHcPw+MKxD1PHc4A0FpQ+Sn6O4if6nKGKVtGKilbRKloVqGgVrSTnnwEAapMp+o/bHokA" +
+		"AAAASUVORK5CYII=",
+	"N": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAAHoklEQVR42uybYWRcXRrHfzZhyoSUliwJCSkps0xJeUtCypa+" +
+		"pLSk9CW1KfshIUtKSz5kSVdKln5IacnSZVRDlnzokg8NsdqVrXxoSaup7GptQ8psNeTD7CvWNM567jt33pPTO8mkmXvm3uT8" +
+		"OWQm986585vnPud5nvPcX+BkRQ60A+1AOznQDrQD7RA40A60kwPtQDvQTlZUE5Nr/A3QCfwb2HQ/284aBt4AC0Bvmec0Ay8A" +
+		"BSggCzQ4lKXVByhjTO9yR7UB2YDzJh3O0hoNAObDDlIDsAYoQNXU1Ojn5GLi8qqiMUABqrGx0YQ9/PXhzAMKUMlkUi0sLKjm" +
+		"5mb9nLRDGqxJQAHq3r17qr+/XwH+yAPt/Kx+3ZLn5+eV6OLFi/o5PXEDUGtpnv8AAHz8+JH79++zvLzM8+fP/WuYAv4CAAwA" +
+		"ANy8eZNz584B0NTUhKazwCrwT+BHZ8c/qwdQgGpra1P5fF59+PDBcwslfLfnKjY3N5Wv0dHRUse+Ax4DQ86lQBLYABSgMpmM" +
+		"Ek1MTJQEPTk5qXTJOaWONcYaMAF8dxhdx4/AHPADwPr6OgDXr1/n6NGjrK6ubju4paWFa9eubXvPf/3y5UvP/cg54n62trbM" +
+		"uZrkowtjGfgT8Gfgf4fBohN6TPz06VNVCYkLWlpa8qz/ypUr6tixY6WsPAsMHoaw8IYe3gmgMCSfK1GKRDUl/P8boOOgQm4G" +
+		"coACPL9sQ7lczpuroaEhCPj4QbPuhF6r8CMOm5LIZXx8PMjCFw5S3WRGTz5evHhRtLa1tTWrwGW+8+fPB4WGbXGHPKR/KckI" +
+		"RY8ePVL19fUKUN3d3cq2xLqN2kk2zrAb9Lh5aGhIiWZnZ7/yl76V25Rch+FKskBjHEFnTL/8+fPnoiVXG7RIClUG7DdAMk6Q" +
+		"vwOUP/yikFi1CVnA214cTdiJREK/pkdxAr0IKED19PQU49sga/ZdSjUVkNr3xQFyL6AAz1LevXunROIegqxZ3EkUNDg4qF/b" +
+		"RtT9dULfFRkZGSl+kSDQsiBFRRJry1oC+GMmFttVko1JrKxLQjnfkiXEq9Rtn8lkiq/3o8XFRdMYuqIazm2aZdCgpMH8ASrh" +
+		"WysFu6+vTwH+WIpiA80fgSMA6XSaq1evBh4kuyR1dXUVmVAvrep/70e3b98mkUgAAJwCuqMEOgUUyd69e5fa2vDL3FKT9lWp" +
+		"H08MYWBgQH/rZpTcxiygAK+WYGvx0mvPEg9XStls1kzRI7FT06UXjaQIb6teoe8tVlq9vb2Ra9hZABTgLSQ2ZG7q+sWqSsqI" +
+		"QHJAopqQL+rJiQCw4TLa29sVUBxhpfBGXN1bTdBLtlNp2Rc0E5+wNDY2ps/zOBLWLAtI2AoqSoUJWsoHUXAfVq25FOQwQQe4" +
+		"j27bkM/btOYAyPP6/GFqeHhYn3fCNuh5G9Ysi5yUWQ3IM0AKUIBqbW0NFfSTJ09CS8l3U0qPm8PaYJXyaUdHRxDkGuDXgAJU" +
+		"V1dXqKClLmMkL/W2UvDfAQBcvnzZS1mlvUvSbhnrhVav/ejVq1ecPn3a7zT1dRf4AdgCfgVAoXUsTElqf+bMGf2tThvWXAN8" +
+		"1tu6xPL0BhX5ez/FfCmfGvt4eeCGcR0PbDbjGGvEiNV02/eNQd2g3/LlJRExmtMVkCux0n8Io8ax048P+GP2W+Htpcx2GgAo" +
+		"NodXQuIqpKz69u1b/e1l4DLwL+PwNqAFIJlMmrd1KDp16lQgAys7KNIU7i9a3+o6JKq4c+eOudgoYHqHbf8xc+PXhoyd8tBb" +
+		"Em4EhXUCVtyFjHIhS+wdEFXkgN/uskasAQpQMzMz1kCnUin9OtvDBt1bjjVJyCeWKqVMfxdc18rKSlCH5yLQuvP0DOp3js1+" +
+		"ECOeD73A1L5bDVgsVYcoEYRe1QuAnAdGymihrdcb2W21/vqSHX3AH6M2wrvcTq1cxgq9bePU/BEKn1XuTvOk3siuP0RkQw8e" +
+		"PNCvOxN2wrIF/BWAwqNpX7582XaA+RrgxIkT3vuXLl3i06dPAAD/Bb4H/l5mpXAAgMKe5JEjR7Ap49G7FhtzpoE8oACVTqe9" +
+		"29jvr/B7N/T/BwT9+T1Yclq/i6QeXQ2JyzN6qrEa5u02xJVMT0+b798oc55tD91LkrSxsVEV0FLzAPyxabvJfHM30LKPaMTJ" +
+		"j/fQkLOiL6piVdWU8T2sbgI0AP3AJJApjHH9VjfGSpnVrzY9zfYfuq+2jIf+m4mAglxLuQ/mbHMXUYEskvVmP0lLGC1FfwBW" +
+		"tdX5H8DfyoT8DPglhVrG3NwcnZ2dUTAe7wlfTXVRAA3wcI/HN0cZMsDx48e3vQwzjg5Lx4C5KEPm6/6+uriBrgGmgJOA18kZ" +
+		"RciVULVBXwe+BwCYmpqKLGQjO2yKE+hW4DYAwPDwsLcPGVUZrci1cQL9e715XZrAD7KqBbqxGs3rhxH0VaAWoKOjg7Nnz3LQ" +
+		"VS3QlwAA81EGB7qCSuq7yRcuXHCgQ9JJoBYglUqZqa0DXWHQP/1x8iSHRdVY6osm/P79e27duhULUM+ePYsd6OMAAK9fv/YG" +
+		"ONcRhlYPALfVOFj0QwBbu8khQX6IEzjX4UA7OdAOtJMD7UA70E4OtAPttAf9fwBMcroXp4n7fQAAAABJRU5ErkJggg==",
+	"B": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAAHjUlEQVR42uycb2gU2xnGf3aFDSYQIYGEXukGEkhoAgYqREwg" +
+		"fghEUIgfBAOlRvCDQj5Eun5oicUvFVuwYEG4glKFK3jhViKEoqAQpYLKTVFIJYGmJHITEtmUhCQligmnvMvMdnIym+xudmb2" +
+		"zpwHXvYwc855z3nm7Dvv+fPOTzDwBYZoQ7Qh2sAQbYg2RBsKDNGGaIP8sfdH1NZyoAtoAQD+CTwD/mseY3EQA5LAIqA0WbTu" +
+		"xQxNuyf5OxeCdfmu1Mku9ZHwa+AiAEBzczNnzpzhyJEjLC8vk0qlAAB+DiwDr8zYLMwmZ8zFwMCA+vLli7IhabmmmZFyQ1v+" +
+		"6AEUoJqbmzeR7CRb7gG29IBx7/JFCwDAsWPH2Lt3q4Mk1+SeWxlDtIExHVFFqF6Ge0qc7CRwHQDLvbNt8pMnT3j//j0OXAL+" +
+		"ZMZn4X5+KCYspf4ybAHKds5GmZXXIE/UAN+4jV55+WkvQKd8Y5U1yAH9wIpOYk9Pj3r16lXmZShpuabns8r2Gxq3H8XDOnGn" +
+		"T59W4+PjKhvknuTRy1l1mdGtoRmY0k3EyMiIyhWS18WkTFl1GwDtuqlIJpNqbW1N5QspI2VdTEm7IdlBcnl5uXr8+LHaLaQO" +
+		"qcuQDQCNTpJramq2tcX5QuqSOjWyG6NGcqXTJudD8t27d9NSINlTlu7I4IHTXIyNjeVMMqCAnMmWujUz8iAqM8PjQC8AwP37" +
+		"92lpyW1SNz097ZreDlK36HCg12pDqImOATcAAPr6+jh58qTnSkWH6HLght9rI34T3Qs0AFRWVnL9+nXfFIsu0QlgtaHX7xHm" +
+		"J/4C/BTg8uXL+jbUFty7d49Hjx7x/PnzjHz48GGT+bCvS7q1tTVrXfv27WN9fZ2RkREAgJ8Btwkh6gEFqHg8rhYXF3N+8eUq" +
+		"O70gRafodpSpD6PpOAUAcOLECfbv3+/7kxadotutTV7Dz7N3RwGwXk474ezZsxnzYENMxIsXLwDo7Ozk6NFMldTV1WXKbAfR" +
+		"/fDhQ2eb/hg20zEHKKDgGeCVK1cUoIB0utAZI2DLXNhMRxyoBQBoamoK7GlrumuBeJiIzpCcSCQC/2tpbagNE9EZiItVKMQO" +
+		"u6X9bMOP4biByiSUKrgS8a1xvCwL6vSePUFx4At+ABSQ8yKSFxDdgC0/+NV5P03HKACWmxYUNN2jhBDnAAWotra2wEa06AZs" +
+		"ORdGoquANUAB6unTp76TLDoBW9asNoUSXwMKUIlEYsf1jmJCdIlOwJavCTGqnCdE29vb1crKiuckiw7RpZ08rSLk6AGULfX1" +
+		"9Xmd38gXUrfocOoM4hx1UD7kgHOnBaCtrY2uri7XEIpCJyXPnj3jzZs3+q2LwJ+JEE5nCdL0ShYtnZFEt49EdwfZ0SCnn1XA" +
+		"S6AJIBaLceHCBaqrq4tS+cLCArdu3WJjYwMAYALoAP4TtdE8XOyjYDpcjoYNR43kTZ7H0NCQZ16H1B20xxEkvgcUoM6fP++5" +
+		"Hy06AFu+jwrJm3bDU6mU50SLjqB2v20E8WGUQwBA+sV38+ZNX5SKrtnZWWcb/h32EX3NR5cum1yLgukYKwGix6JgOg4AAAwO" +
+		"DhZtyp3LlPzq1atb2hBmTAWxpaVtYU1FgeghQAHq+PHjam5uznOSRYfoAmwZigLRfYByunj9/f3q7du3RSdY6pS6NddOWW2I" +
+		"xFrHNeA3+sWampr0UumhQ4fSR3AbGho4cCA3czozM8Pk5CTv3r1jdHQ0vUT68eNHt6x/AH4bFaIBOoEbQGsuJ4vKysqord18" +
+		"qGh+fp5Pnz5tOjO9Dd4BF4EXRBSdwF0g5YEbl7Lq7gy6k6V2SucXQAfQav025FecSeClNXrl9x+l0jGviP4K2A9U838cyOK3" +
+		"VwMVlki6ziLY1TjH43EAPn/+nE33jEX4NLAArFqysDUr61Z+GwvAEjBbin/9diAJPADGvZrNVVZWpuO8xVUTkbRc80qf1ZcH" +
+		"wLmgg0B/CfzLq46KS3bw4MH0B6qGh4ddg/DlmtyTPJLXxY0r5n7jld0QXojpKAe+BU5kyyBumsSLOL0EcdPcptuyqlZRUZEW" +
+		"SUs+OZIrku/0XKbZEoohIu6ebGetrq6mRdJu+SWf04tZWlrK5hYCzAAXgL95TXQ58Ny51Cmxe6dOnUr7vR0dHekT9eKKeQEh" +
+		"S8LhsGJRirW/qENcxomJifSByDt37uhfIwP4PfA7r4iOAU+ALgCAZDKZjhfUI6xs/1ZGi9uh72yj2wkpKyNRRqd0WiYhr1+/" +
+		"zmy2ymbu4cOH05MbebjyD5B/xU4THH0U25D2SFk3f11CnC9duqSP9PvAr7x40Jumzrdv386sI0haPrfT2NioYrGYKoFl0F2J" +
+		"9EH6In2SvkkfZZemu7vbl6l8n+4FaJ9o8Ev+bomveqWvLl5On1em49scgyBt/3UG2Go7svvUTsxbdUwDk8AE8NJxLqMK6ACa" +
+		"gAagDqjIIfhH951t7LXaVaH5/9nwV6AX2PBiVMeAQWeYBLAGPAUGgIN+hZN5jLjVlwGrb2taOMagn3H0XwEJooOE1WeDUob5" +
+		"ULch2hBtYIg2RBuiDQWGaEO0gSG6ZPG/AQDGO2fZOW550gAAAABJRU5ErkJggg==",
+	"R": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAACvUlEQVR42uycP27iQBTGf1nlDhRpXEbiCFvgW1Cako7dbjtT" +
+		"rpSCLSlWQlGKiDtQgLgEpRsKqhyAwqtZ4RWJEiDs2PPH3ye9IhZ+fvNj/OUB4/mC1IgEWqAFWhJogRZoIRBogZYEWqAFWhJo" +
+		"gZYEWqBbrtua8mZAcuY1BfBoIY/tfJfk8UIZUF4YmaU8tvNlIVhHYum1ieVrJzWNwal1ANDr9UjT9NWx5XLJarX67zy2812T" +
+		"xxvQZjDj8fjVMfP30YDSU6efymM735s8YYG+QOkZOK7zee3RJ5UkidVzbOeLZkYPBgMAiqK4GEp1ThP5orIO2wN1Ac5762ir" +
+		"ap3RpmX6qFvwTabW0JRf8YnOt8hDsI4igjvd+hhuaip0AnwDAOh0OgyHQy+JTqdTdrvd8aFfwPeQZsUEKKsYjUalbzI1Hdd4" +
+		"qDlIeQu7acg3DcH+ZyP9fp/7+3un7/5ms2E+nwdtFxfNbM9iQmR6BkqgzPPcmWWYawNVPDc1+CY/GW48fPM3MYJutQRaoAVa" +
+		"EmiBFmghEGiBlgRaoAVaEmiBlgRaoAVaEmiBlgRaoEHLdh0skXW1nDfE5bmf1YOHi2ceYoPcAV6AEihns5mzBTTm2kAVL4fa" +
+		"otETUAJlt9st9/u9M9Dm2qYGoIqnWCB/Bcoq1uu185Wkpobjmg41Bv3PMAN+AADc3d2xWCz+hmuZWrbbLQDAb+BnKLsavAc5" +
+		"tGdXshD76CTAyZGEah1wZncC16p7V4NGQZ/ancC16t7VoAnrkARaoKOVLY9+b/etFADPnwl/8yVT+sFTtF7011kEz37X3l/b" +
+		"sI6kBXd+4ot1gOf98jWWYrPtswra5375s7LdX6vraEi3tm+3WGa0jz955S3oOnIfrKNowZ1f+GAdjwARt3lFqD8ItFLqOgRa" +
+		"oCWBFmiBFgKBFmhJoL3VnwEA07oVDBfe33YAAAAASUVORK5CYII=",
+	"Q": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAAKF0lEQVR42uydXWgUWRbHf04Ho7ZsXDqQSIQEElCIYGACWRgh" +
+		"QoQ0RFCWgEIC5iELgRUUfBESyDwE9kVQmH0QNugsI8yr4MsIyogKiitMQGH9iIxCJBlQjKj4Fa3l1NZtb1+rq6u6b6Wrpf5w" +
+		"qepbH33Ov849H7duJ9+QYlWQEp0SnRKdIiU6JTolOqUgJTolOkV0NNSRrBlgN9ALANwCLgIf08doDwPAA8Ax2gPvWOKxpg6s" +
+		"cQD4JWD0rQB54FJqjZVbY0a/NpfLOYcPH3ab7Bv3yoR84IPApNcGQ15XFyR/8CFZtQ9lyB7USV5cXHQUZN8ge/Brdz9xWuMk" +
+		"4ADudSakD1BtMsYHXjXizDp2A10AuVyOO3fu0NraCsCxY8fYvn07z549wztHzr0Q4wM/pXQVWUZHRwE4e/askqHBO2dbPWYx" +
+		"NqzRhuuw6X6+2oLlIjAPuJYno+DIkSNu00YE3jkXS9yjFwBwLVmNKsDdHx0dLXzWz62lv40arW1ZUrX+1ZafJ+6spdJobTM1" +
+		"q1QG2w88tqylWmuyGe0zwO/atb9HyJ2rfeCxZi22LNKWJWR97pFdBYOxXTTFNuSUsP8ylOuLKM93PgR9twou0AoPQVmHzWj9" +
+		"EVgw+sYiEt0bsq8ULvl85yywrcw8iRUeapnejQKNq0g0wETxRxaSUKDYTvSnfYbtwQjy3Pa5/nb4y8kBb4zrp2vAQ+xBwI/o" +
+		"6yFlyQYEsrAB8ajPtdM14MEXNtOa6RL36C5/6edA2N3d7bYKAuJ/KyTaCg/fhAggeWA+YNJ9vkoXNRbFP/f09Lgtop8eBLZV" +
+		"IeO8p2upY2VfPIQJhpc8IfPAFPAjn7EOmI0qdV9fn0l0Y1iie3t73RaR6HEAgMbGxkqInvV0VfjR4yIfImupGC0VBrWC65ie" +
+		"nnba29v16/eXubYQCK9eveq2CAGxRR/2+/fvj+o6Dvro2xKVtErSuz+AZaPvOJCLcpPx8XFfi/NBFtge4Dq2lwmI40ADQH9/" +
+		"P9u2RfIgOU83HcseB7ETDXC3+CPNwMkoNxgbGyOTyQAA7AbaS5xaYLW7u5uNGze6Tfb9zjGQ0XPniYmJqHqe9HQL0j1WoudK" +
+		"FCCh88gtW7aQz+f1rlIsFAVCv/0AP50HtgC0tLQwPDwcRcdBT6cwuq+aRSucipDXmhY2ViIPLQqEfvsBRE/oI6ihIfSbu6yn" +
+		"SxTd4yW6s7PTfQ8HAHQA34e9iVi0WBoA0CpdQa4jgGg/19EO7KnQbXzv6eLqJjrW3HU8ffqU48eL4sUR4NswNxELE0sLcB9f" +
+		"BEK//RIBsXCvoaEhOjo6wur2racDgKub6Fgr11HIPF68eOFa5sDAQIE/YDZsOWpYWh5o87NUFQgVygTEjF4IRbDmjCd7A+Dq" +
+		"JLqJjgCVZhzVEF00hObm5pidndWLgR7dKoIglmY8pDE/32tYsF9f7+ddhoFWgLa2NjPoBuEI0INX2IhOolu1bqNaogsS3L17" +
+		"1yVsZmYGDTMBKVvYnLq3hE/26+v1cxtizSGDYLsn8/+Fn5lxdRLdqnUb1ixaCSPLAHbs2AFAlPJ83759ZkAdNN1BCKLVuVuB" +
+		"XYCbpxsPMQiFMlt0EF103Wpp0UWuAy+4yXAzCpGy5fm6devMoDgeFAhL9KmAOKE/QP2NSAAOerK6sosOahQkznXoVqasASBs" +
+		"eW4QvUcpjk8gVPAJiH/RfXxIay4qs0V2faTYch3V4jngAEVvHl6+fGlOGv1kTir5oa+vz5xIdwBnZGTEKQU5pl3zm9rfunWr" +
+		"7/ny3cak0k/eviuzyK6/QdHOfV4NUdW+M/zCfShLO3WqqLAaLVGMFMFIw7qC/HMZPx3WmvOebACuzPrIseU2bBA9V2KIuSnV" +
+		"yMiIOawDceDAAbLZbDkyyx6T1MxwRaVQkElkNdNAm27DmkUbQgFw8uRJPZsgTFA0Xt9DiUAYdEwmj5qbm0N/r8gosn6hnKWM" +
+		"IzbXoSDKGuV5WZhDvlQg1N2UERA5dOhQpO8UGf0ejE3X0RCX61CQISwLvi9d+vy25+3btzx69IiFhQV3X22XlpYKQ//du3cA" +
+		"7rFdu3YFCiHnKEh6Jr5WmkzFSpq2adMmtwmZ8r06pCot5WZsug4bv8p6DmwCWFxcdPNWmYSZn58vtBs3bnDhQlwL+qtDe3u7" +
+		"u9a6q6vLbbIv7kge/ObNmwEAloE/11rW63pK1dTU5AS8lq+bZqzZuF4tSdW6DoA5Fb3v3bsX+qKmpiZ3OMt8ggRBGQkyzGW4" +
+		"K/+ufLPe74dXr14VpjJXVlYKrkRckXJNco58lv2PH8uvAtN+TYCNQsUG0Utmh6RoMgyFRNWEKGlCqPKdtYI8FEW6+Gxp4uJk" +
+		"K3759evXZXWsBfYDDuB0dnYWVYj1CtFBdImwHCL29A7gMrAC8PDhQ86dO0e9Q3QQXQA83S4nRbYfAAdwMpmMc+LEibq1ZpFd" +
+		"dABU+yFJRpAFrgKOamfOnKk7kkVmXQdPp6wNgtZYJvsXYCde4XD58mV27tzpZgISbCT4SJNAJFmA7OtZwvLystsUZF97Xxcq" +
+		"i1FQRQpQCL6ylUxGBWRpErTl2LVr19zCSMtIrgF54HUS3VtWn6qUXFRy66TnzCKjkTf/ZsuS40SbPk9dh+258SbeCuJIZp8A" +
+		"U8A/zVJX5dMydGUId3hrLdRWH+5+n4Pg53bUZzW/IVu9cJHPjx8/Nm815elQF8jopXl/f39iA6DIZpTadfeHUtr1H+cksZAx" +
+		"XlW9Cbs8olYFSyk81ktXmXNIGgyZljyZY0Gcf7wqC7wCAHjz5o2bRinfKFuZcxA/qs9LA4W0j5Apnp7a6RNQspUJK9mq+WjZ" +
+		"V7FCvmP9+vVo2JjUdC4IhV9SNTY2Om1tbYnLMEQmka3CnzwnAlng1zpM7X6NK3+Ow3VkvApxt99BWXQoQ1dSPGlqOMuQ7/DS" +
+		"POmXIU/IFE9P5fRXYuKiVOWppkalSf+TJ0+Clob9rR6s+e+Ao9rRo0ed8+fPOw8ePHA+fPiQmIxDZBGZRDaRsYIfmdYUGWAR" +
+		"cABncnKybiaU9u7dW8kvamuGAT3QJMmCI87cnUl6Hj1c2BkerunrqqiQ0lxDc5KJ3gFMAFBmdVESYSzS2QP0J1XWfwAO4AwM" +
+		"DDj1iKGhIQdQ7eekWnRhuJVbWZRUTE1N6R97bGcJtvAn4K8AV65c4dOnT67fe//+PRs2bGDt2rWJJFdkvH//Pjdv3uT06dPc" +
+		"vn0bAOCGTateY/mh3SplCblcrlCkSIGiFsiYc9KqXyHMGhD9dRjGghpzLlr61es0acZCGR1jwL+TOvpagP98BUvCfrZNTFwl" +
+		"+BjQA3R5rSPB/7hhBXgEzHvtFnDW9l8PW7PK1r4FaAZavbbR62vwtnjHpV8h7G+LH+muF3gKACwAK95W+pe89tTr+4MUXw/S" +
+		"f3iTEp0SnSIlOiU6JTqlICU6JTpFSnRi8b8BAD8fPDDoVfx4AAAAAElFTkSuQmCC",
+	"K": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAAIMklEQVR42uycf0hUXRrHP+64TDCBgYWCsgoGyhZMULBLBQpv" +
+		"YNAfs7BBsQlGBa9gYVBQUGBR0f6xYFDQhsvuRkKFgS37R28UpBi8QUEGhUbFK+SSpcsUKhYqZ3kud2bPXOc6P5x7Z8bOFx4Y" +
+		"r+ee85zvPec5z3mec++vMPAFhmhDtCHawBBtiDZEGwoM0YZoA0O0ITqHaLXFwGOSFaCKjexiG9G1Lr8N0QYAUFrg+rU6Rm6T" +
+		"y2+AUeC6eaTZkawylFZjOozpKFjEzIDTdIgA9NtiTIcH6AQUoOzfRQNjOgzRhmiDFU50MMnOMGgeYW7RDkST+M1R+38Gy0QA" +
+		"6E1jo9Jrly3ojhQy/g78CQCgrq6OAwcOsHXrVj59+kQ0GgUA+C3wG+BfZmxmjj8CKibt7e1qbm5OxSC/5Zpexr7HIAMEgQ+A" +
+		"AlRra6tyg/wPiMkHs0BmhnZAAaqiokJNTU25Ei3/kzJATMzimAEGAQWorq4ulQpSBojJYCF2qMSDOsPA74FaoBIAmAZGgRHg" +
+		"MfAlRR1zQCnAxMQEa9euXbLw5OQk69atAwCYB36dQr9NwHqgWrtnzNbvGfCuUEdgBXAOeJ9m3PgpcMq+z4kq3WykC4f5qHLU" +
+		"2QzccPHFk8kb4ISLfnlDBzCVRZBeAXNALxDm/6gBFKBqamrSJlrKAjGpsV3XVps0laXMAp1AKJ+mIwT0AH/QL5aXl7Njxw42" +
+		"btxIdXU1AJ8/f+bt27c8e/bMkoWFhWT1SV3HgUl7KgOglEqvIyUJXdkBXAEaFk29igq2b99OQ0MDtbW1lJaW8vXrV0ZHR3n5" +
+		"8iX9/f3MzMw4b3sJtAAv/B7FAX3BAtSGDRtUX19fgq/r5iXcuHFDNTY2Kpct9T7dtRseHk45mqXMUiOzrKxMHTt2TD1//jxl" +
+		"XbOzs+rmzZsqHA4765kCGv0multXQjqRiuBkkI5HIhE3k6IA66GkgpRxI/jcuXMWedng6tWrKhQKOfXa5hfJCTu2dNyvVHjw" +
+		"4IGqq6tLStZSmxWXTYsCrAf4/v37Zesms8Wx0EaBGq9JDuqLy759+1SuICbl4MGDiwhL5XkIETJyAQWoQCBgjcRcQh6Yg+yf" +
+		"vY4TJezYotGoyjW6u7stsoC4DA4Ouj6c+vr6eDmZ5vfu3VNe4OnTp069fvSS6OFcmgw3yKKq20YhM9k2vLm5OYFktweSK4i9" +
+		"B2Iy7BXJv9MXmaXiD7mA2G19BAmp+oJ7+fLl+P8Ay1PwGtJn3Uw5/P+coTOTBSoXEPKAuFy8eDFuM4PBYILX4xf27Nmj63TM" +
+		"C6L7MnG5coVTp04lLHTiEuqdFf89G9cyWzhmUrcXRP+canHyAkLitm3bFKCARW6gn7oIZLEFYvLAiyx4ZfxHZaVvOyPZIvf0" +
+		"9BAMBgF49+4dMUQiEWs77Sfm5+ezui8TokcBAMbGxnztnMQkTp8+vej6mTNn8BsSs9H/9ILoOLsSfPEbx48ftwJCMWzevJlN" +
+		"mzb5rseTJ0/0P0e8IDrewt27d33v4KpVq9i/fz8x7Ny503cdJArp6PtPXhAdb0FCiY4p5BvZuu32G5cuXeLbt28AAEPAay+I" +
+		"/k/sCUo8+fz583xPkHVJiNZ5z+D2jIgG+DMAwPXr13n48OF3Q/ShQ4f48uWLbpt7vCR6ALgFANDS0sL4+PiKJ1m8m/v37+uX" +
+		"2oAFL4kGOAyMA3z8+NFKW01PT69Ykq9cucLZs2eds3og03qyIfq/QAswD/Dq1SuL7JU4ssUmHzlyRL/0E3A6m7qWk5zdo5uR" +
+		"+vp6y/WRpGc2bpP45iKSJJWFR0QeniRO5dyGM2EaCoWs8x7iichOVRLBIrK5kcSwyJo1a7LunGyQLly4oF96DOwEZvLx0H/U" +
+		"83sSE+7t7U0ZL3jz5o2VBZHgkFsKKxcidUsb0pa0mQ4kmbFr1y5nXYO5OHKwXESc5zokjDoxMZHQAYm6nThxwlNi0yFedHDL" +
+		"hj969EhVVVU57/t3vs916AgDd4FaAICysjLL15apLQvKixfuRyIkYCQmJyYy/UXENKxevdoyAU4zIOZGRBZiMS1ickRGRkbi" +
+		"om0uFiscDnP48GHLcxLzdPLkSa5du+Ys9hfgZKYehtcIAf9IZ2SJiZEstaTDZHR5EU+WOqVuaUPachwbSEj+lpeXO69PAJFC" +
+		"X6ybgV+cHZKMiGTNJZ6b7TmL5UDalLZFBz07k0T6kpzdK1h06iNGRpUXGfNsIbqITo4jBL94OYo9j8y0tbVx9OjRJcv47d7J" +
+		"ddFJ2tU2I//08h2YvLx0L5E/iZNIFFAOPepZk3QhxM/Y5L9+nTyIJi8XbdmyhaamJmtTtX79+rxNcd+IHhoa4tatW9y5cycr" +
+		"YrOBtCNy+/btOPG7d+9m7969vicNSjy00WcAGhsbrSlaiO6d1DkwMACAre9ZigydxeLeFetnKVyJLgL3rriJLiL3rrOoF8Mi" +
+		"cu8oaqLBuHcY9w6Me2fcu7SJNu5dPog27p03WPS5SznHLAfL/TzLnGyUiw6iSzF9bjOdV5cXvXstQfaOjg5fzzVLW9JmkgC/" +
+		"snXsoMhRAXQBs0k6aHVckqdyij5Xtjpmm6VOqduFXGXr1OXXi/UlPhJ+CGgDqt0KBQIBy+sQf1e8DtmIiIjn4fyUhHga4nHI" +
+		"pkZEPA7xz8XbcHnXXD9+/Ffgb8BHVjB+ALr19719kA92mz/kq9MleSY9DDQB27WPleQCb4Eh4DHQn4+vEhQa0cky6Q3a12Eq" +
+		"gbXAGlt0fLZlEhgHxmyCR/J1msigAGA+AmuINkQbGKIN0YZoQ4Eh2hBtkDn+NwDnSIYBw0rCTQAAAABJRU5ErkJggg==",
+	"p": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAACaElEQVR42uzcL09bURjH8e/GkrFUVGzJSCqKaMIbQNRhSHgB" +
+		"kwiWIHhJCNSSeQSiogJRu6SiolkyUYdgCbgtQTBzT+gIBdree855zvP7Jo/b7rl8uNx//HmLipKgBS1oJWhBC1oEgha0ErSg" +
+		"Ba0ELWglaEE7752hfd0BdoEeAPAL+AH81Kexno6ACXC/YCbVv1Er9hEYPgP8eIbV/1FLIk+XQA4zFfZyDVdAnj+y1Ss6WgM5" +
+		"jM7Zr2hSA/REjC/fwt3XNDugB5ZF7Wa6reKge5luqzjoYssNepbptoqr2IthjhV5e5fbqaMF3K6/GW6rbakn+rzi+41FM622" +
+		"qeZq14w8j90W70MXDSCHuRAvUNNLJL1keqH3wFUE6KtqLbedREAOc+IZehQReuQVuR0ROUzb4wNL38mayaF7TtZMDv3JyZrJ" +
+		"oV2VEvq3kzWTQ8+crJk8V7d3qXPzwJL6Yvi90LWySy+VInYcAfoYBcCgQeSBeB/qAncNIN9V21bAF+C6wSP6ulrDbRvAWcTb" +
+		"u7NqTVe1gMsEDyyXnn7eoxX5QeXxjDxgbyQ6kp86sos+jZxmgBzmtFTkw4yQwxyWhtwBbjKEvgE6JUGfZ4gc5rwU5L2MkcPs" +
+		"lQA9NgA9to58YAA5zIFl6IEhaLNv+TqGkMN0LH4r66vBg8PiPjM2chSbvihaPG00evpo6tSxj932LUH3DUP3BW0Y+k1DO/sH" +
+		"2DQK/Rf4YOGI7hpGptr3rgXobey3LWhB//elZ71NC9BbBUBvWYBWkaBL+PWFmYW/H/0NwPBFcTb3MSjQqUMJWtCCVoIWtBK0" +
+		"oEvv3wDehlLKF5lCQwAAAABJRU5ErkJggg==",
+	"n": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAAGUElEQVR42uydb0gcRx/HP89zwgkK94CCggcKBi5gwICBBAxP" +
+		"hAYqWDDgy5Na6IsWLBgwYMGAFFtaSIuUvMiLvpDQQAoWDEhBsMUWW3xhQIuFBIxNxIDSBkx6goFTpsxxa/b29v7kcjN7c84X" +
+		"Bnb3dt2dz/72N9+Z2cX/YqVFFrQFbUFbWdAWtAVtEVjQFrSVBW1BW9BWWhQy5BrfBS4CfwIH9rbl1xiwDiwB8SKPaQVWAAEI" +
+		"YAdosihzawgQnnK3wBMVA3Z8jrtlcebWhA8wB7afmoDtHMckDEl5gWgyBzQBjGXvzkKe/QXQaZH661YeaEmgi1f6oABkAQyY" +
+		"BkDXI3gO6MljMf8PNKb3uQbUF/h7f6XLP0DSxvErDRQRpaWWDWAWGLEpBeqAPVWwm5qa3OvbwBRw/qTCvqsCcktLi5Da29sT" +
+		"CwsLYmJiQnR2dgpAAOvAMBA+KZDDOTzxG5eRkRHhpwcPHojR0VERiURE+tzDJ8EWjqpKG1NTUyKfZKSPj4+LcDjsRHh3tUJu" +
+		"BRIKG8NU+pCRvbKykhP4xsaG6O7uFoAAPq+26A57xiqUl66uLjE7O+sLO5lMpnI4IIClaho3mfGDUVdXl4pClcBlgygbRz/N" +
+		"zMw4qWQDiJkOecQPQDweT+VNqbm5OeURPjQ0JBKJRBbspaWl1A0HdkyG3eTnm/v6+rIqLB911bBjsVjKhXi1uLgoQqGQA7vF" +
+		"RNDT3so2NDQcR7Ju0EDK5sko9mp6etrtuetMgny+WBsmwacjSkuRqWJ5eTnrOqRjAQTwrUmgl70VlDD9olnC1+lInO7648eP" +
+		"s9yIqzc5ZALkeC7L5RfNMp3oBu04koODg4zrWV1ddZ6uvUrP1+FcsyJ+oGXDGARkpwwPD2dd09jYmAAEMGPidJUAUlbOiWRp" +
+		"8YKE7BTpOtySNtA1EnipUu3cQTFd5bR3rYgibZ/Mz27dvHlTAAJYNcLOmVJu3LiR1TC6eq19lQS5A0iaClr6a68jcrmhxUoC" +
+		"PWcqZKfIgSZvro5EIs7vFTFTc8l0yE5Ue8dDpCuppBd2lqoBNJBqBL2+GhBAIuipsP5qgex0Yrzq6OgQgHiNdwWVaLWaQAOp" +
+		"KHZrcnJSAAKYtdFcxiLnFt1aX18PPH2sViPo9vb2rPTR2tpaFk9dyhv/bwNnA0xZP6r6w5ubmzx69ChjW29vLwDAZd2grwUI" +
+		"+XvgqsoTzM/Pu1fp6ek5XtTdCwzq0Z4BQsBbKs/T39+fkTp2dnbcv0d0gb7lN0UlZyhkUTi+PAWEAHJN+pZzYsArmbt1jn2E" +
+		"gL+9kOUdd9/9MsNOAqOe6/hG9dOzvb2dAXpgYEAAAhjXkaMvAo24NDg4SHNzM47kstxWJu0DV4CvPNsvq46o+/fvu1e5cOHC" +
+		"8aIO0Oc0tgV/pM/3g2d7DGhTffK1tTX3KmfOnNHKYMIvPytIHXfzTPtP6mh4ZarI0yAqfyXB943QMjaGCeD9Am3EdlDjHq5h" +
+		"0y7VoOMKK7cMtOc/PcM6h03zvOgTV52jHyq4eYfAdeAisJlnv0h6Py168eIFz549y9h26tSp40XVoNeA/TLWZx+4DHwGHBXY" +
+		"9wugGY3a3d11r9LW1na8qBr0EXCvjJB7gV+KHCn8EM3ygo5Go9pAA3wJHJYhXbwD/FZ4VzqBOwSgp0+fulfd/YWoDtC/A5++" +
+		"YR0+LjKSY8B8ER93KpE3Rzc2NmoFDfAJcBV4WcKx93x6erleyLmnOy+7tb+/nytH15YyCVBT4nV8DXwHXAHOpk8OsAt8lCMK" +
+		"HwLvvUYktxGgvBFdW1ubkUmALQLWhI83LfbDnJiq7xFL+STDK9fvXboiulBqeeKKyF+Bn4qE/HOQ6aKQQqEQR0dHlNJu1Ci6" +
+		"ptslfItYUZC99o60xdva2sI7iqmqMSy3GoD5Sovkly/ztvX1poEOAXeA01S5ggZ9Feg1kFvUJNDtZej86KUbjZbctgUJ+rrL" +
+		"fxuhmprSvUNQoFuAQU6QggI9qNBaKtPh4aFxoK+YGJXeEb1KB12neUa9JLmGRcuiIECfNiFteAaRjAV94hQE6P+ZAKa+PruX" +
+		"/fz5c6NAN5oA2jWjkjE7bhLoJyZGtGfG5bXr8J+A6jEU9AzKGwbKbawqU/a/VljQFrSVBW1BW9AWgQVtQVtZ0BWrfwcAO9pz" +
+		"MGH5k84AAAAASUVORK5CYII=",
+	"b": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAAEIElEQVR42uycQWsbRxSAv1SBBGKIigsVJBCBDDHU0EJ9KNgQ" +
+		"HXJLwZdABT3sD+jBAR9z0KEH01v6CxoTSlqSQ0E6tMRgH3Szi3XwwVAXCezSgm3WxS26uLjIzLbySmq1u9qZ0ez74OEFz+7O" +
+		"fDt6M7va0VsIWhDRIlpECyJaRItoUSCiRbQQnesTVNdbwENgDgDYBdaBP+UyjoccsAL4wEUofPW/nGhKLvnVAMHheCWyk7Ey" +
+		"guQgVkRX/JzsRxDtq31AZh2ReAjkI5TPq31EdETmNO2TedFOYbPoXU37ZB6nBkPbkemdJpy5YbF9MJwDbv5/MW6qskJE3gVe" +
+		"REgbQbxQ+woj8BlwFkNyEGfqGMJ/9OJaAsHhqEnv7uc9oDVGyUG01LEFYCFhqhgllSyI5HQli2zgvibJvbLvZ03y7bg52fO8" +
+		"y0iQs29nSfTLuJIDEsh+mZU7w0dAJc6OxWJx4HZEKqoOTovOAc/Mf6B4purirOgKMGPeMzNxP1WT8gLNkyiFPc+7kiLK5fLA" +
+		"bYB2u83a2lrUunyNg5TiDnyjEmOALLmYOh5bePEfu5g6ylEKB2kgnDrKKmVsbm5eRoLUEdTpC9dSx69J7+6q1eo/aaK7nfR4" +
+		"qk5OpY4bQMHCi19QdXNGdMHiT1rBJdFjoZuHB21PAtc0nutiHAfpzq3pGSwnzIEWDjQ+Eh01DnQ1Xmfq2Lbw4m+7KLpuoeg6" +
+		"DjINdCxKGx1VJ+d69Anw3KIL/1zVCVd7tW9Bb/Z19mZTLFkgekl3o03NIZfD37SEnz2PgyEPmp4AX5IhPgH8uM+eYzyj9tU5" +
+		"jWByifK3wCnwvabzVYAfTDXW5O3nNNAAZjWljj1g0eWZxjBqBgbBWtYkL2VpxmGSLYOit7IiuWTBPLqUhVnHfBoDX8QBch74" +
+		"2enuXK/XVy8M062D7nZr/yprY2PjY9MX20QdTMyjfc/z8oZTxynwNo7TsmAwbDmfOoCm+WtNMwuivzPv2Yo6aGHVYNpYJWM8" +
+		"AHY0Ct5R58wsD4CvgKMU5B6pYxsXbNtbOh8Ci8AH6m/UZRj7QANoqr8/2tKwtETfAfLAO/zL3SG3/N0yUyq620Ul+G7Mcx8q" +
+		"4W3gGPhDxXF/Uc5V+YBumVPgFxtFLwAfAfOqJ846ktL2gCawDrwGfjdVkU+Bnyx8ny6N8IGq7lW3twx9O2LLS5GPdEneyqjk" +
+		"3vg8Tck54I1IvvL7Tangidy+8Gx+1pGUhoqJ4lrE1PHNiIsgg/nrIXDe/++hc+peflPHaAP7wB7QAE4AgGlgEZgFZoAiMDXC" +
+		"4p/w3DnguqrXVGj+P4zXQAX4K608/TS0TKIDvAGWgfd1LSdLmRuqLcuqbZ3QzOOpzl9HuAPcIzvcU20WbEZ+qFtEi2hBRIto" +
+		"ES0KRLSIFkS0tfw9AAUg0iy4ynapAAAAAElFTkSuQmCC",
+	"r": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAAB30lEQVR42uzcMW7bMBhA4YfCY/aO0djR3gpk0U205B4Zc40K" +
+		"uUhuQAMdsjqTD+FBhQANRQHXhkPK/KX3AE01WOqDwjACk2/YLAkttNAmtNBCSyC00Ca00EKb0EKb0EKvvE2hcTugufCZA9Bn" +
+		"GCf3eNeMU0UdMFx5dZnGyT1eF2HpaDJ9tsn8fzeF7uFu0DbjGn1t7Y3/Ntd4i4JuKx4PXDpiJbTQQpvQK4NOKTEMQ8hrnHsU" +
+		"6MMCHsAw9/B6w3uKWq7XaE9FB5wCAZ9KvEyaqyfgGAD5OM01dI/Ab2BIKQ21NM4FGKa5PZZGmGN79wn8BN4rfAjep7l9sqBe" +
+		"KlwyXua6eX9gEVpoE1pooSUQWmgTWmihTWihTWihhTahhTahhRbahBbaKoZ+qOk47zgX4GFp0N+B5woftOdpbovpreIDjm9L" +
+		"QX4KcJo0/EnSDvio6RTpmVOlH6XPRm8KI/8K8kD8+GuufTToBgBgt9sF8aYpMSjuo+dLaKGFNqHrbZNxK/fvd+w2pcR2uw0B" +
+		"sd/vx91Re+a3aPsaoCPtly/1v79g098b+uzeM9D++eZ7BNfoqhJaaKFN6GVDH1bg9OV7zLG96wFKvmKsALn3a3JFS4cJLbTQ" +
+		"JrTQJrTQQluR/gwA+x1qWuefyJsAAAAASUVORK5CYII=",
+	"q": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAAIxUlEQVR42uydXUgcWRbHf04LK5SgYNgWFPRBUIiwgREUFCKr" +
+		"D0ICBkYImwl0Ng7ZB3cZgoRZmIXkIZCHCeRhFwYSQhTzsDB5CIwwgi46KETYBg1xWWEEE0wYwyREiCFCKzWcospp2vrs+uyk" +
+		"/nBo7Nxz69x/nXvuuedWdT4hRSRIiU6JTolOkRKdEp0SnVKQEp0SnSIlOrGoriBbM0A/0AUA5IEF4CC9jcHhJPAToJbIT/q/" +
+		"JR5VFeCNJ4E5m9m3DwwCP37ss8KPN2YsdM36ynzos8JpcAUbggoOgxxwQbIhAyHakWgE4Y1feSD6qwhmRSLTu36gzbkZbXrb" +
+		"D9qOMInuCqBt3kMf+RDt8I1qD2EgjtV6Adhw4ZEbetuwESoP5a7WSVnIgrQjtKzFzyCTkpoFYUeoWUvSDCy1x0uWkBSHiWXK" +
+		"bXn0AsWkD8Xj7NyKKwTaZR1BrdY/Ah3AILDPb9hzuW020OPyOzs79n7783Dr3uFgRyA8RFUmPQD+U5LltAG9Hvo44fI7K/SW" +
+		"ZC/Vuk2R1DnsiA4ih3XCFxF69Bdl2hg6D2EsAqW6bz3E2U0T/U2Xuop+rVL9uHg4gqDTGrM+Rp3VaLCxocFZnVEL3bh4sLyI" +
+		"1R39r8e+zPpYdFbjlM0gTzmrs6i3LZdo9LGqYZdZM8CARTVtzGUfdl7Z7qD7tY3u1w667T5nA/oYzaqFA2FW/J6YxNkWZzVa" +
+		"9PZmcsNB93sb3e8ddG/Y6Lq1uzS+PyECTJkY/INPon928IxfbHR/cZiJP/sk+gcTvakoiP7SwuicD6LtYm2Tg56qt/Ea290Q" +
+		"nbPQ+zIKonstLv7GZsBuiP7OQm/YBdHDFrrf+SC6SR+TmV5vFEQrZZDlhuiCxeL0jQuivzmqRoPeZ7lE290khYjwxMaIzyx0" +
+		"ul0QNn5UjVkXerNH1RjX/81Oui1s/cxG5wkRYsphcTLzzJMuBm42iDcu9N54dAZDTlrMBLvFdyroWke5e/pjwM0y++0EPi3J" +
+		"geud1ajX2xr4VO+rHNzUx1DO2CMlGuCCyx2bU/Gn3BJluQWkU7rtBE10uVBcTMstoM5j6DDCwO8AgFsudVS9LbruG5c6xaGj" +
+		"zuJgIJCFsFyPfgesObRpBq6X0Xc9MOLDo0dchptSXNdttsOaPvZIMeXRaz734J2zQAZ470Hnva4z60Hnc4+zrewdoZ8TFrex" +
+		"akKfzl6exR4ETgM1HnRqdJ1BDzrVum0TAY85FqJbywwhNyPSua7bGCrRfqB4mKIF4FsP7aOSb13sHmPZEZazKfhQxNeO0O8p" +
+		"+CofD1bjJDr/ERGdT4muAKL9viykALsfCdG1fjYrfj3azQ7xQ4DvHeEncS8SFQLfYwzizdmNMEaWzWapr6+nsbGRmpoa7dMM" +
+		"29vb7O3taZ87Ozu8fPkyDHM2kkD0uh8yu7q66Ojo0KStrY3W1lZN/ODp06eabGxssL6+rkk+n/dzE9b9klQVhPMBz51uWiaT" +
+		"0Ujt6+vTpKenx9JLw4J4/fLyMktLS5oI+QcHjg+T7gPNwMu4iQb4J/DX0i8bGho4c+YMQ0NDDA4OaqHALV69eqWJkLO/v8/z" +
+		"589N2zU3N1NdXa3dtGPHjmniFhJq5ubmmJmZ4eHDh7x+/dqs2b+AvyXBowEUYAboAxgdHeXChQua1woJVtjd3WV1dVUTmd4y" +
+		"1UVk2rvwNMuZI6FHwpCIhKQTJ05oUltba+22+/uat09MTHD37l0AgCVgKI4atBPZi7lcTrXC1taWeu/ePXV0dFQ9fvx45PUK" +
+		"uaZcW2wQW6wgYwAW4ywiOUG5cuXKimFwoVBQ5+fn1fHxcbW9vT1xhSKxSWwTG8VWAzKGoEkO42ckmi5durR2cHBQbxP3Egdj" +
+		"PclkMju3b9/uBF5Ugt1jFVwOHQuDkKqQiM4AS0BPLpfznReHDVl8JycnAZaBvkr7oZSWixcvvlcrBGKry8d4Y6t1WOGZoijb" +
+		"leIVuq3Pwuq/Kkzbgd0KCx21ScuZ3aC3AhfC3kojWQHmK5Do+bA2KZmQ+pzO5XJ/lC241Crevn2baK9oamri3LlzdHZ2tj5+" +
+		"/Pj3Ll5ASgTGSrfhjx49Uq9evap2d3ermUwmds8VG8QWsUlsM9l+Hw+alOoQvPkfpV+KZ4tcu3ZNKyQZJUop4visE3uqe4sN" +
+		"RqnWrsAEjAD/S3LWMQDMAUi2cf78efr7+20reOglUaNALxmAlETlU76XGyN/W1XzpFonpVIhTkqkkuHI3/JpHCg4lU6lcrew" +
+		"sMD9+/eN7GMC+HOSw8aRx77q6urUs2fPqnfu3FE3NzcTs0ERW8QmsU1s9PiSaKwe/Qc3h5gtLS2HoUSmcWdnp6cDgXIgBf61" +
+		"tbXDcCXy7Jnj3qTf4w+3REb0DeDvEjIkVHip3MmqL4Qb54Uy9UWMg1mnDY+EGeOAVsKMEXpEhOAXL154quBJKJmcnPw38Kck" +
+		"ho07xdmG1HdnZ2e1Qns2m01s7iy2iY1ia3FN+vLly/8Pkpwgs46F4pd0xKvlnFAE0KatnM/JoiNZx7t38ex0FUXRsg5ZpMU2" +
+		"CV9myGazGyQUGWBFvGNxcdF2IRLPWVlZ0RajsbExLac1WZB8i/Qpfcs15FpyzWKvNYPYLmNw8W57bDEaIAtMA10Sd0dGRjh9" +
+		"+rSrFA89zZPDWSPOyiImcZaiB2WKUfxgjcRxWVSN+C4Hs25OxI3Ubnp6mgcPHhjxPLHxudSz/wIsAgVAVRRFHR4eVm/duqV5" +
+		"VdwQG8QWsUlsA1SgoNs8GkZpIuyfnm8AzgBDwCBQb8RJSe+MxwAk45CNhXhokJAZIJsgyTyMxxoktStaH3aAOWAGeAiEdsBZ" +
+		"FbGndwF9uvQAjRRBwo2R4klIMEIBcJjqlRIpIQU4DDXyt5HamaR128AysKRLPqpjq6qYw0wW6AI6dGkDWj28JWWFp7psAOu6" +
+		"5P0+1lXJRDvdhHqgEagp9f4SL93TP3fiJDNFApD+F04p0SnRKVKiU6JTolMKosGvAwC0Z5Gfwlvw3gAAAABJRU5ErkJggg==",
+	"k": "" +
+		"iVBORw0KGgoAAAANSUhEUgAAAFoAAABaCAYAAAA4qEECAAAIQ0lEQVR42uycb0hU3fbHP/2mHxMYGBQoGCQUFBQYFBQUJBjY" +
+		"i6AXvigomMDAyMLgvijwgl0KChIsCnph8BAWXfCFD0RYRPiARUTB7VKh5XPJULAosLCLhl725Xs4R/aMMzr/zvzp7i8sGJ2Z" +
+		"vdb+nrXXXmuvM+f/cCgIHNGOaEe0gyPaEe2IdhQ4oh3RDo5oR3QeEfPFIWSSDWDKjexy8+jaFK8d0Q4AsLzE7YsleG59itcA" +
+		"o8Atd0mzI9lkKDEXOlzoKFkEYSAxdEgA/vDFhY4Q0AEYwPivywYudDiiHdEOvzjR0SSVYdRdwvyiFZhMkjdP+u855IgI0JtG" +
+		"odLrf9YhS/yWQVX4m6MrOzRlUYI3OdoyQxSYyILoCbdBZobWLEgOxG2OGWAwB6IHS3FCy0IYsw7YCdQC1QDAD2AUGAaeAN+X" +
+		"GGM2hwOvOeD/l7BvK7ABWGt9Z9y37yXwr1L1wCrgPDCWpte9ANr97yWiJgdvDqQmYcxGoCdFLp5MRoAzKewrmke3AReAlQA1" +
+		"NTVs376dNWvWsHbtWmZmZvj06ROjo6O8evWK79+/J3rf7/73/wkArANGc7SpFhgHjgB/9b13HhUVFWzZsoUNGzZ4dq5atcqz" +
+		"8evXrwwPD/P27VsAgBngEtAJ/LtYXlwB9AGmsrLStLe3m5GREbMUhoaGzLVr18yePXsSvagHqAIiefDoBmDI/t+6des8G589" +
+		"e2ZmZ2cXtXFiYsLcuHHD1NXVGcAAr4G6YpAcCTas1tZWMzk5abLBhw8fTFtbm3ehrJL6cJapXVLRBX306JHJFnfv3jU1NTUG" +
+		"mAL2FDp0dEcikWO3b9/m0KFDcW/8+PGDly9feqFCsmLFCi+EbNq0yVuu+jsR375949KlS1y5coWfP38CzOXa/dm2bZs33u7d" +
+		"u5O9zZs3b7wwMT4+7umvrq727FTY0+tE+zTPhw8fzgH1wNNCeHMTYHp6ehaEhMOHD5toNJrSuyKRiGloaPBCx5cvX5J6uN7P" +
+		"xYOl//LlywvCg/6+d++eZ2NFRcWiY+zatcv09fUt+H5TU1Ow6taFTXIUGNFyt6F4thjBqQhpbm5OGtd1ITIdDzAbN240r1+/" +
+		"XkBQV1dXsPwzEhE+NjYWN1ZjY6MBnoV9iNVaVVUVF5O7u7tz8kB5eUtLy4I4/+LFCyNd6Y6jlTA1NRU3Rn9/v1m/fn1O9skG" +
+		"rdYAWon+RWsJk+ihixcvxoULEZWPTWv16tULlqt2f3npUt/VkrZDhQjXasnXhiqybc/WigOGwiJ5BxCn8MCBA3mbTCDybps0" +
+		"kb158+a0SZZ9VlqWN1HIsL3ad7BQUr4OTSCJsryLUjI7DKTybMXQ6enpuBWWSbjJVGRHAP9i/iUMovu0HAP09vaGNiHA7Nix" +
+		"Iy5ui0Q7WxCh9sTDJhnw5hxA2QvQHUZztlp5ZgDloGHi+fPn7N+/38vLAS8Pv3nzJgGUwwf5rsrnffv28fnz51BtUs49T0Z1" +
+		"dVDq559o+4+5uTnCxtOnTzl+/DgBVDQ0NTXR3NzM3r175+04cuQIHz9+DN2elStXEiBwgHSRSfU1OjMzM38FdRBTCNy5c4ed" +
+		"O3dy8uRJAK5fvx5XXXZ2dvL48eOC2FJbOz99bxUBf4ahp0c7fICBgYFQ46Etis2qGhOhYiebwibbitPeeHVIBbSFVqzYVVLY" +
+		"m48tsVhsAdEHDx4smH47EVD1CRhgYxhE1wCeJwfQmUKhJgrElevKMgqlV95s69YRBPCPMMNUv4qUAFpK6VRu+ZLz58/P69br" +
+		"QumVQ9n1g3+sG+qvC/YA3uG5fSZRqDjZ0dExr1evC6FTFaFdeapyBYYKcWfUXVVFtnIl8mFVicUkWpWnXaGqgQCYXBoAmWA1" +
+		"MKEra6MQZBeS6ESSlfXo4Au4SAHRAMzqnNfG4OBgqJmI2mYBzpw5E5oepbEpzlr6sw0ZuXTBDwJ/7+rq4vTp03GJ/LFjx7h/" +
+		"/37aA1VWVnptLomKApX6EpW5Kk7UqbarMvzKTF3roMuu8lii9pnaVJKEjvuSiEQiXLhwgbNnz8bNp76+nnfv3j0B9hWrG94C" +
+		"zCrdSWwdqanpJ/ULRIfxCj36TDqd82yhsaVDupZqAGjf0cae2J7zPXkQqKDIOABMqcNhn1UH6V8QSzURNQ3CJDYd4mWDfV6t" +
+		"qlP/S3QUdWf8NO5ePkjO1y1hdcDvlZWVteo8Hz16NO5NnfTp9C0VtPz1mUCCDrpCg0KEutCJYUDhRuctCikKLQo5EukJJFnH" +
+		"PYBu5nnw4IFnq931li0KHVevXgXoBM4C/6GEUBHcOK4de7F7PbTRqG2lkCPvCiNb0ZgaWzqkK7GfmAzazP1Q8cVfqSWNxlOn" +
+		"Ti04AVIY0S0KKgAKVeAkltHSLRvsw6GEPqAB+pLcu1eaGBgY6LBTo4S7kYouskU22R0aOUeYXrwspHE7YrHYueXLl3udEP/u" +
+		"o5QoVnoXjUa9poGaB7du3ToH/I0yQ8diHlWK6V25/bY8JdGlmt4ViuhlIRJ9LliaakNt3bo15YeLld6pLWaFtvIMHTqXsDeb" +
+		"Uk3vZKNsLcvQYWcd5ZLeyeayJrpc0ruwiXbpnUvvXHqXFdEuvQOX3rn0zqV3i8KldwWCMSZWbumdbC7HzZD379+3nThxYrIY" +
+		"3puJl8tG2UqZowroAqZLkOhp37YqfiFUAe0ZPP0gTBlb5MkKvxQagO58/t47DZnwdTYUa9LLikx6HVAP7LYeVpIP/Am8Ap4A" +
+		"f1iPqOB/lehknfRN1tNhqoE1wCpfbHzz5SvwCRj3CR4u5rM1HIoM9xBYR7Qj2sER7Yh2RDsKHNGOaIfM8d8BAE/m1TvCY6zH" +
+		"AAAAAElFTkSuQmCC",
+}
+
+// Glyphs of file and rank names, 5x7 cells each.
+var glyphs = map[string][7]string{
+	"a": {".....", ".....", ".###.", "....#", ".####", "#...#", ".####"},
+	"b": {"#....", "#....", "####.", "#...#", "#...#", "#...#", "####."},
+	"c": {".....", ".....", ".####", "#....", "#....", "#....", ".####"},
+	"d": {"....#", "....#", ".####", "#...#", "#...#", "#...#", ".####"},
+	"e": {".....", ".....", ".###.", "#...#", "#####", "#....", ".####"},
+	"f": {"..##.", ".#...", "####.", ".#...", ".#...", ".#...", ".#..."},
+	"g": {".....", ".####", "#...#", "#...#", ".####", "....#", ".###."},
+	"h": {"#....", "#....", "####.", "#...#", "#...#", "#...#", "#...#"},
+	"1": {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	"2": {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	"3": {".###.", "#...#", "....#", "..##.", "....#", "#...#", ".###."},
+	"4": {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	"5": {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	"6": {".###.", "#....", "#....", "####.", "#...#", "#...#", ".###."},
+	"7": {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	"8": {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+}
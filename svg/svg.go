@@ -0,0 +1,280 @@
+// Package svg renders boards as SVG images.
+package svg
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// SquareSize is the size of a square in the coordinates of the images. The
+// board has a margin of a third of a square for coordinates.
+const (
+	SquareSize = 45
+	margin     = 15
+)
+
+// Colors of squares and the check highlight
+const (
+	LightColor         = "#ffce9e"
+	DarkColor          = "#d18b47"
+	LightLastMoveColor = "#cdd16a"
+	DarkLastMoveColor  = "#aaa23b"
+	CoordinateColor    = "#e5e5e5"
+	MarginColor        = "#212121"
+)
+
+// Colors of arrows, with some transparency
+const (
+	Green  = "#15781b80"
+	Red    = "#88202080"
+	Yellow = "#e68f00b3"
+	Blue   = "#00308880"
+)
+
+// Arrow is drawn from the center of the tail square to the head square, or
+// as a circle if they are the same. The default color is Green.
+type Arrow struct {
+	Tail  core.Square
+	Head  core.Square
+	Color string
+}
+
+// Options of rendered boards. The zero value renders the board from the
+// side of white, at its natural size and without highlights.
+type Options struct {
+	// Width and height in pixels, 0 for the natural size
+	Size int
+
+	// Show the board from the side of black
+	Flipped bool
+
+	// Show the names of files and ranks in a margin
+	Coordinates bool
+
+	// Highlight the squares of a move
+	LastMove *core.Move
+
+	// Highlight a king in check
+	Check *core.Square
+
+	Arrows []Arrow
+
+	// Colors of squares, drawn over the square colors
+	Fill map[core.Square]string
+
+	// Drawings of the pieces, Cburnett if nil
+	PieceSet PieceSet
+}
+
+// Geometry returns the size of the board in image coordinates, and the
+// position of the top left corner of a square.
+func (o *Options) Geometry(s core.Square) (size, x, y int) {
+	m := 0
+	if o.Coordinates {
+		m = margin
+	}
+
+	file, rank := int(s.File()), int(s.Rank())
+	if o.Flipped {
+		file, rank = 7-file, 7-rank
+	}
+
+	return 8*SquareSize + 2*m, m + file*SquareSize, m + (7-rank)*SquareSize
+}
+
+// SquareColor returns the color of a square, with the last move highlight.
+func (o *Options) SquareColor(s core.Square) string {
+	light := core.NewBitboardFromSquare(s).IsMaskingBB(core.BBLightsquares)
+	lastMove := o.LastMove != nil && o.LastMove.IsNotNull() && (s == o.LastMove.FromSquare || s == o.LastMove.ToSquare)
+
+	switch {
+	case light && lastMove:
+		return LightLastMoveColor
+	case lastMove:
+		return DarkLastMoveColor
+	case light:
+		return LightColor
+	}
+	return DarkColor
+}
+
+// id returns the element id of a piece, like "white-knight".
+func id(p *core.Piece) string {
+	return p.Color.Name() + "-" + p.Type.Name()
+}
+
+// num formats a coordinate with at most 2 decimals.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Floor(v*100+0.5)/100, 'f', -1, 64)
+}
+
+func start(buf *bytes.Buffer, viewBox, size int) {
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" version="1.1" viewBox="0 0 %d %d" width="%d" height="%d">`, viewBox, viewBox, size, size)
+}
+
+// Piece renders a piece on its own, at the natural size of 45 pixels if size
+// is 0.
+func Piece(p core.Piece, size int, set PieceSet) string {
+	if set == nil {
+		set = Cburnett
+	}
+	if size == 0 {
+		size = SquareSize
+	}
+
+	var buf bytes.Buffer
+	start(&buf, SquareSize, size)
+	fmt.Fprintf(&buf, `<g class="%s %s">%s</g></svg>`, p.Color.Name(), p.Type.Name(), set[p.Symbol()])
+	return buf.String()
+}
+
+// Board renders the pieces of a board.
+func Board(board *core.BaseBoard, options *Options) string {
+	if options == nil {
+		options = &Options{}
+	}
+
+	set := options.PieceSet
+	if set == nil {
+		set = Cburnett
+	}
+
+	full, _, _ := options.Geometry(core.A1)
+	size := options.Size
+	if size == 0 {
+		size = full
+	}
+
+	var buf bytes.Buffer
+	start(&buf, full, size)
+
+	// Definitions of the pieces on the board
+	buf.WriteString("<defs>")
+	defined := map[core.Piece]bool{}
+	for s := core.A1; s <= core.H8; s++ {
+		p := board.PieceAt(s)
+		if p == nil || defined[*p] {
+			continue
+		}
+		defined[*p] = true
+		fmt.Fprintf(&buf, `<g id="%s" class="%s %s">%s</g>`, id(p), p.Color.Name(), p.Type.Name(), set[p.Symbol()])
+	}
+	if options.Check != nil {
+		buf.WriteString(`<radialGradient id="check_gradient"><stop offset="0%" stop-color="#ff0000" stop-opacity="1.0"/><stop offset="50%" stop-color="#e70000" stop-opacity="1.0"/><stop offset="100%" stop-color="#9e0000" stop-opacity="0.0"/></radialGradient>`)
+	}
+	buf.WriteString("</defs>")
+
+	if options.Coordinates {
+		fmt.Fprintf(&buf, `<rect x="0" y="0" width="%d" height="%d" fill="%s"/>`, full, full, MarginColor)
+	}
+
+	for s := core.A1; s <= core.H8; s++ {
+		_, x, y := options.Geometry(s)
+
+		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" class="square %s" fill="%s" stroke="none"/>`, x, y, SquareSize, SquareSize, s.Name(), options.SquareColor(s))
+
+		if fill, ok := options.Fill[s]; ok {
+			fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" class="fill %s" fill="%s" stroke="none"/>`, x, y, SquareSize, SquareSize, s.Name(), fill)
+		}
+
+		if options.Check != nil && *options.Check == s {
+			fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" class="check" fill="url(#check_gradient)"/>`, x, y, SquareSize, SquareSize)
+		}
+	}
+
+	for s := core.A1; s <= core.H8; s++ {
+		if p := board.PieceAt(s); p != nil {
+			_, x, y := options.Geometry(s)
+			fmt.Fprintf(&buf, `<use xlink:href="#%s" transform="translate(%d, %d)"/>`, id(p), x, y)
+		}
+	}
+
+	if options.Coordinates {
+		for i := 0; i < 8; i++ {
+			_, x, y := options.Geometry(core.NewSquare(core.File(i), core.Rank(i)))
+			file, rank := core.File(i).Name(), core.Rank(i).Name()
+
+			for _, ty := range []int{margin / 2, full - margin/2} {
+				fmt.Fprintf(&buf, `<text x="%d" y="%d" font-size="12" font-family="sans-serif" text-anchor="middle" dominant-baseline="central" fill="%s">%s</text>`, x+SquareSize/2, ty, CoordinateColor, file)
+			}
+			for _, tx := range []int{margin / 2, full - margin/2} {
+				fmt.Fprintf(&buf, `<text x="%d" y="%d" font-size="12" font-family="sans-serif" text-anchor="middle" dominant-baseline="central" fill="%s">%s</text>`, tx, y+SquareSize/2, CoordinateColor, rank)
+			}
+		}
+	}
+
+	for _, arrow := range options.Arrows {
+		writeArrow(&buf, options, arrow)
+	}
+
+	buf.WriteString("</svg>")
+	return buf.String()
+}
+
+func writeArrow(buf *bytes.Buffer, options *Options, arrow Arrow) {
+	color := arrow.Color
+	if color == "" {
+		color = Green
+	}
+
+	_, tx, ty := options.Geometry(arrow.Tail)
+	_, hx, hy := options.Geometry(arrow.Head)
+	xtail, ytail := float64(tx)+SquareSize/2.0, float64(ty)+SquareSize/2.0
+	xhead, yhead := float64(hx)+SquareSize/2.0, float64(hy)+SquareSize/2.0
+
+	if arrow.Tail == arrow.Head {
+		fmt.Fprintf(buf, `<circle cx="%s" cy="%s" r="%s" stroke-width="%s" stroke="%s" fill="none" class="circle"/>`, num(xhead), num(yhead), num(SquareSize*0.9/2), num(SquareSize*0.1), color)
+		return
+	}
+
+	markerSize := 0.75 * SquareSize
+	markerMargin := 0.1 * SquareSize
+
+	dx, dy := xhead-xtail, yhead-ytail
+	hypot := math.Hypot(dx, dy)
+
+	shaftX := xhead - dx*(markerSize+markerMargin)/hypot
+	shaftY := yhead - dy*(markerSize+markerMargin)/hypot
+	tipX := xhead - dx*markerMargin/hypot
+	tipY := yhead - dy*markerMargin/hypot
+
+	fmt.Fprintf(buf, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="%s" stroke-linecap="butt" class="arrow"/>`, num(xtail), num(ytail), num(shaftX), num(shaftY), color, num(SquareSize*0.2))
+
+	fmt.Fprintf(buf, `<polygon points="%s,%s %s,%s %s,%s" fill="%s" class="arrow"/>`,
+		num(tipX), num(tipY),
+		num(shaftX+dy*0.5*markerSize/hypot), num(shaftY-dx*0.5*markerSize/hypot),
+		num(shaftX-dy*0.5*markerSize/hypot), num(shaftY+dx*0.5*markerSize/hypot),
+		color)
+}
+
+// PositionOptions returns the options with the last move and a king in
+// check of a board, unless the options set them.
+func PositionOptions(board *core.Board, options *Options) Options {
+	o := Options{}
+	if options != nil {
+		o = *options
+	}
+
+	if stack := board.MoveStack(); o.LastMove == nil && len(stack) > 0 {
+		o.LastMove = &stack[len(stack)-1]
+	}
+
+	bb := board.BaseBoard()
+	if king := bb.King(board.Turn()); o.Check == nil && king != core.SquareNone && board.IsCheck() {
+		o.Check = &king
+	}
+
+	return o
+}
+
+// Position renders a board with its last move and a king in check
+// highlighted, unless the options set them.
+func Position(board *core.Board, options *Options) string {
+	o := PositionOptions(board, options)
+	bb := board.BaseBoard()
+	return Board(&bb, &o)
+}
@@ -0,0 +1,169 @@
+package svg
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// elements parses an image and returns its elements with their attributes.
+func elements(t *testing.T, image string) []map[string]string {
+	elements := []map[string]string{}
+
+	d := xml.NewDecoder(strings.NewReader(image))
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("invalid svg: %v\n%s", err, image)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			e := map[string]string{"": start.Name.Local}
+			for _, attr := range start.Attr {
+				e[attr.Name.Local] = attr.Value
+			}
+			elements = append(elements, e)
+		}
+	}
+
+	return elements
+}
+
+func find(elements []map[string]string, name, class string) []map[string]string {
+	found := []map[string]string{}
+	for _, e := range elements {
+		if e[""] == name && (class == "" || e["class"] == class) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+func TestBoard(t *testing.T) {
+	b := core.NewBaseBoard(core.StartingBoardFEN)
+	es := elements(t, Board(&b, nil))
+
+	if svg := es[0]; svg["viewBox"] != "0 0 360 360" || svg["width"] != "360" {
+		t.Errorf("unexpected size: %v", svg)
+	}
+
+	if n := len(find(es, "rect", "")); n != 64 {
+		t.Errorf("expected 64 squares, got %d", n)
+	}
+
+	if a1 := find(es, "rect", "square a1")[0]; a1["x"] != "0" || a1["y"] != "315" || a1["fill"] != DarkColor {
+		t.Errorf("unexpected a1: %v", a1)
+	}
+
+	if h1 := find(es, "rect", "square h1")[0]; h1["fill"] != LightColor {
+		t.Errorf("unexpected h1: %v", h1)
+	}
+
+	if n := len(find(es, "g", "white knight")); n != 1 {
+		t.Errorf("expected a single definition of the white knight, got %d", n)
+	}
+
+	if n := len(find(es, "use", "")); n != 32 {
+		t.Errorf("expected 32 pieces, got %d", n)
+	}
+
+	if len(find(es, "radialGradient", "")) != 0 || len(find(es, "text", "")) != 0 {
+		t.Error("unexpected check or coordinates")
+	}
+
+	// Empty boards have no definitions
+	empty := core.NewBaseBoard("")
+	if es := elements(t, Board(&empty, nil)); len(find(es, "g", "")) != 0 {
+		t.Error("expected no pieces")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	b := core.NewBaseBoard("4k3/8/8/8/8/8/8/4K3")
+	check := core.E8
+	lastMove, _ := core.NewNormalMove(core.E2, core.E1)
+
+	es := elements(t, Board(&b, &Options{
+		Size:        200,
+		Flipped:     true,
+		Coordinates: true,
+		LastMove:    lastMove,
+		Check:       &check,
+		Arrows:      []Arrow{{Tail: core.E1, Head: core.E4}, {Tail: core.D4, Head: core.D4, Color: Red}},
+		Fill:        map[core.Square]string{core.C3: "#ff000080"},
+	}))
+
+	if svg := es[0]; svg["viewBox"] != "0 0 390 390" || svg["width"] != "200" || svg["height"] != "200" {
+		t.Errorf("unexpected size: %v", svg)
+	}
+
+	// Flipped with a margin
+	if h8 := find(es, "rect", "square h8")[0]; h8["x"] != "15" || h8["y"] != "330" {
+		t.Errorf("unexpected h8: %v", h8)
+	}
+
+	if e1 := find(es, "rect", "square e1")[0]; e1["fill"] != DarkLastMoveColor {
+		t.Errorf("unexpected e1: %v", e1)
+	}
+	if e2 := find(es, "rect", "square e2")[0]; e2["fill"] != LightLastMoveColor {
+		t.Errorf("unexpected e2: %v", e2)
+	}
+
+	if checks := find(es, "rect", "check"); len(checks) != 1 || checks[0]["x"] != "150" || checks[0]["y"] != "330" {
+		t.Errorf("unexpected check: %v", checks)
+	}
+
+	if fills := find(es, "rect", "fill c3"); len(fills) != 1 || fills[0]["fill"] != "#ff000080" {
+		t.Errorf("unexpected fill: %v", fills)
+	}
+
+	if n := len(find(es, "text", "")); n != 32 {
+		t.Errorf("expected 32 coordinates, got %d", n)
+	}
+
+	if lines := find(es, "line", "arrow"); len(lines) != 1 || lines[0]["x1"] != "172.5" || lines[0]["y1"] != "37.5" || lines[0]["stroke"] != Green {
+		t.Errorf("unexpected arrow: %v", lines)
+	}
+
+	if circles := find(es, "circle", "circle"); len(circles) != 1 || circles[0]["cx"] != "217.5" || circles[0]["stroke"] != Red {
+		t.Errorf("unexpected circle: %v", circles)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	b := core.NewDefaultBoard()
+	for _, san := range []string{"f3", "e5", "g4", "Qh4"} {
+		if _, err := b.PushSan(san); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	es := elements(t, Position(&b, &Options{PieceSet: Unicode}))
+
+	if checks := find(es, "rect", "check"); len(checks) != 1 || checks[0]["x"] != "180" || checks[0]["y"] != "315" {
+		t.Errorf("expected check on e1, got %v", checks)
+	}
+
+	if h4 := find(es, "rect", "square h4")[0]; h4["fill"] != DarkLastMoveColor {
+		t.Errorf("unexpected h4: %v", h4)
+	}
+
+	if n := len(find(es, "text", "")); n != 12 {
+		t.Errorf("expected unicode pieces, got %d", n)
+	}
+}
+
+func TestPiece(t *testing.T) {
+	es := elements(t, Piece(core.NewPiece(core.Rook, core.Black), 0, nil))
+	if svg := es[0]; svg["viewBox"] != "0 0 45 45" || svg["width"] != "45" {
+		t.Errorf("unexpected size: %v", svg)
+	}
+	if len(find(es, "g", "black rook")) != 1 {
+		t.Error("expected black rook")
+	}
+}
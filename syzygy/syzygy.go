@@ -0,0 +1,529 @@
+// Package syzygy probes Syzygy endgame tablebases for the win, draw or loss
+// (WDL) of a position and its distance to zeroing the halfmove clock (DTZ).
+package syzygy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+type TablebaseError struct {
+	error
+	description string
+}
+
+func (e *TablebaseError) Error() string {
+	return e.description
+}
+
+// MissingTableError is returned when a position needs a table that was not
+// added.
+type MissingTableError struct {
+	error
+	description string
+}
+
+func (e *MissingTableError) Error() string {
+	return e.description
+}
+
+// WDL is the result of a position for the side to move, with the 50-move
+// rule: a cursed win can only be won without it, a blessed loss is saved by
+// it.
+type WDL int
+
+const (
+	Loss        WDL = -2
+	BlessedLoss WDL = -1
+	Draw        WDL = 0
+	CursedWin   WDL = 1
+	Win         WDL = 2
+)
+
+func (w WDL) String() string {
+	switch w {
+	case Loss:
+		return "loss"
+	case BlessedLoss:
+		return "blessed loss"
+	case Draw:
+		return "draw"
+	case CursedWin:
+		return "cursed win"
+	case Win:
+		return "win"
+	}
+
+	return "unknown"
+}
+
+// probeState tells how a value was found.
+type probeState int
+
+const (
+	probeOK probeState = iota
+
+	// The DTZ table stores the other side to move
+	probeChangeSTM
+
+	// The best move zeroes the halfmove clock, so that the DTZ is known
+	// without the table
+	probeZeroingBestMove
+)
+
+// Tablebase is a set of table files. Tables are opened on first use and may
+// be probed concurrently.
+type Tablebase struct {
+	mu sync.Mutex
+
+	// By file name, like KRvK.rtbw
+	paths  map[string]string
+	tables map[string]*table
+
+	maxPieces int
+}
+
+func NewTablebase() *Tablebase {
+	return &Tablebase{paths: map[string]string{}, tables: map[string]*table{}}
+}
+
+// Open returns a tablebase of the tables in a directory.
+func Open(directory string) (*Tablebase, error) {
+	tb := NewTablebase()
+	if _, err := tb.AddDirectory(directory); err != nil {
+		return nil, err
+	}
+
+	return tb, nil
+}
+
+// validMaterial tells if a table name like KRPvKR is well formed.
+func validMaterial(material string) bool {
+	sides := strings.Split(material, "v")
+	if len(sides) != 2 || len(material)-1 > tbPieces {
+		return false
+	}
+
+	for _, side := range sides {
+		if !strings.HasPrefix(side, "K") || strings.Count(side, "K") != 1 || strings.Trim(side, "KQRBNP") != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AddDirectory adds the tables of a directory and returns their number.
+// Files that are not named like tables are ignored.
+func (tb *Tablebase) AddDirectory(directory string) (int, error) {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return 0, err
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	added := 0
+	for _, f := range files {
+		name := f.Name()
+		material := strings.TrimSuffix(name, filepath.Ext(name))
+		if f.IsDir() || !validMaterial(material) {
+			continue
+		}
+
+		if _, ok := tb.paths[name]; !ok {
+			added++
+		}
+		tb.paths[name] = filepath.Join(directory, name)
+
+		if pieces := len(material) - 1; pieces > tb.maxPieces {
+			tb.maxPieces = pieces
+		}
+	}
+
+	return added, nil
+}
+
+// MaxPieces returns the number of pieces of the largest table added.
+func (tb *Tablebase) MaxPieces() int {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	return tb.maxPieces
+}
+
+// Close closes the open table files. The tablebase may not be probed
+// afterwards.
+func (tb *Tablebase) Close() error {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	var err error
+	for _, t := range tb.tables {
+		if t.closer != nil {
+			if closeErr := t.closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}
+
+	tb.tables = map[string]*table{}
+	return err
+}
+
+// table returns the set up WDL or DTZ table of the material of a board.
+func (tb *Tablebase) table(b *core.Board, dtz bool) (*table, error) {
+	wdlSuffix, dtzSuffix := b.TablebaseSuffixes()
+	wdlMagic, dtzMagic := b.TablebaseMagics()
+	suffix, magic := wdlSuffix, wdlMagic
+	if dtz {
+		suffix, magic = dtzSuffix, dtzMagic
+	}
+
+	key := materialKey(b)
+	sides := strings.Split(key, "v")
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	for _, material := range []string{key, sides[1] + "v" + sides[0]} {
+		name := material + suffix
+		path, ok := tb.paths[name]
+		if !ok {
+			continue
+		}
+
+		t, ok := tb.tables[name]
+		if !ok {
+			t = newTable(material, path, dtz)
+			tb.tables[name] = t
+		}
+
+		if !t.initialized {
+			t.initialized = true
+			t.err = t.open(magic)
+		}
+
+		return t, t.err
+	}
+
+	return nil, &MissingTableError{description: "missing table " + key + suffix}
+}
+
+func (t *table) open(magic [4]byte) error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+
+	if err := t.setup(f, magic); err != nil {
+		f.Close()
+		return err
+	}
+
+	t.closer = f
+	return nil
+}
+
+// probeTable looks up the value of a position in its table. DTZ values are
+// converted to plies with the WDL of the position.
+func (tb *Tablebase) probeTable(b *core.Board, dtz bool, wdl WDL) (int, probeState, error) {
+	// KvK is the only table that is not stored
+	if materialKey(b) == "KvK" {
+		return 0, probeOK, nil
+	}
+
+	t, err := tb.table(b, dtz)
+	if err != nil {
+		return 0, probeOK, err
+	}
+
+	d, idx, ok := t.index(b)
+	if !ok {
+		return 0, probeChangeSTM, nil
+	}
+
+	value, err := t.decompress(d, idx)
+	if err != nil {
+		return 0, probeOK, err
+	}
+
+	if !dtz {
+		return value - 2, probeOK, nil
+	}
+
+	value, err = t.mapScore(d, value, wdl)
+	return value, probeOK, err
+}
+
+func isZeroing(b *core.Board, m *core.Move) bool {
+	return b.IsCapture(m) || b.PieceTypeAt(m.FromSquare) == core.Pawn
+}
+
+// search returns the WDL of a position. Tables do not store positions where
+// captures, or pawn moves with checkZeroing, are best, so these moves are
+// searched first.
+func (tb *Tablebase) search(b *core.Board, checkZeroing bool) (WDL, probeState, error) {
+	moves := b.LegalMoves(nil)
+
+	best := Loss
+	count := 0
+	for i := range moves {
+		m := &moves[i]
+		if !b.IsCapture(m) && (!checkZeroing || b.PieceTypeAt(m.FromSquare) != core.Pawn) {
+			continue
+		}
+		count++
+
+		b.Push(m)
+		value, _, err := tb.search(b, false)
+		b.Pop()
+		if err != nil {
+			return Draw, probeOK, err
+		}
+
+		value = -value
+		if value > best {
+			best = value
+			if value >= Win {
+				return value, probeZeroingBestMove, nil
+			}
+		}
+	}
+
+	// The table is not needed if all moves were searched
+	noMoreMoves := count > 0 && count == len(moves)
+
+	value := best
+	if !noMoreMoves {
+		stored, _, err := tb.probeTable(b, false, Draw)
+		if err != nil {
+			return Draw, probeOK, err
+		}
+		value = WDL(stored)
+	}
+
+	if best >= value {
+		if best > Draw || noMoreMoves {
+			return best, probeZeroingBestMove, nil
+		}
+		return best, probeOK, nil
+	}
+
+	return value, probeOK, nil
+}
+
+// checkBoard returns an error for positions the tables cannot contain.
+func (tb *Tablebase) checkBoard(b *core.Board) error {
+	if b.Variant() != "Standard" {
+		return &TablebaseError{description: "tables of " + b.Variant() + " are not supported"}
+	}
+	if b.CleanCastlingRights() != core.BBVoid {
+		return &TablebaseError{description: "tables do not contain positions with castling rights"}
+	}
+
+	return nil
+}
+
+// ProbeWDL returns the result of a position for the side to move, ignoring
+// the halfmove clock of the position.
+func (tb *Tablebase) ProbeWDL(board *core.Board) (WDL, error) {
+	if err := tb.checkBoard(board); err != nil {
+		return Draw, err
+	}
+
+	b := core.NewBoardFromBoard(board)
+	wdl, _, err := tb.search(&b, false)
+	return wdl, err
+}
+
+func dtzBeforeZeroing(wdl WDL) int {
+	switch wdl {
+	case Win:
+		return 1
+	case CursedWin:
+		return 101
+	case BlessedLoss:
+		return -101
+	case Loss:
+		return -1
+	}
+
+	return 0
+}
+
+func sign(n int) int {
+	if n > 0 {
+		return 1
+	} else if n < 0 {
+		return -1
+	}
+
+	return 0
+}
+
+// ProbeDTZ returns the distance to zeroing of a position in plies: n > 0 if
+// the side to move wins and can capture or push a pawn in n plies, n < 0 if
+// it loses and the opponent can zero in -n plies, and 0 for draws. Cursed
+// wins and blessed losses are beyond 100 plies, so that with the halfmove
+// clock the 50-move rule decides them. Values may be off by one ply, since
+// some tables round to even numbers.
+func (tb *Tablebase) ProbeDTZ(board *core.Board) (int, error) {
+	if err := tb.checkBoard(board); err != nil {
+		return 0, err
+	}
+
+	b := core.NewBoardFromBoard(board)
+	return tb.probeDTZ(&b)
+}
+
+func (tb *Tablebase) probeDTZ(b *core.Board) (int, error) {
+	wdl, state, err := tb.search(b, true)
+	if err != nil || wdl == Draw {
+		return 0, err
+	}
+
+	if state == probeZeroingBestMove {
+		return dtzBeforeZeroing(wdl), nil
+	}
+
+	dtz, state, err := tb.probeTable(b, true, wdl)
+	if err != nil {
+		return 0, err
+	}
+
+	if state != probeChangeSTM {
+		if wdl == BlessedLoss || wdl == CursedWin {
+			dtz += 100
+		}
+		return dtz * sign(int(wdl)), nil
+	}
+
+	// The table stores the other side to move, so look one ply ahead
+	minDTZ := 0xffff
+	moves := b.LegalMoves(nil)
+	for i := range moves {
+		m := &moves[i]
+		zeroing := isZeroing(b, m)
+
+		b.Push(m)
+		if zeroing {
+			var value WDL
+			value, _, err = tb.search(b, false)
+			dtz = -dtzBeforeZeroing(value)
+		} else {
+			dtz, err = tb.probeDTZ(b)
+			dtz = -dtz
+		}
+
+		if dtz == 1 && b.IsCheckmate() {
+			minDTZ = 1
+		}
+		b.Pop()
+
+		if err != nil {
+			return 0, err
+		}
+
+		if !zeroing {
+			dtz += sign(dtz)
+		}
+
+		if dtz < minDTZ && sign(dtz) == sign(int(wdl)) {
+			minDTZ = dtz
+		}
+	}
+
+	// All moves lose, or there are none
+	if minDTZ == 0xffff {
+		return -1, nil
+	}
+
+	return minDTZ, nil
+}
+
+// RootMove is a legal move with its tablebase result.
+type RootMove struct {
+	Move core.Move
+
+	// Result and distance to zeroing after the move for the side playing
+	// it, counting the move. The WDL takes the halfmove clock into account.
+	WDL WDL
+	DTZ int
+
+	// Higher is better: quicker wins, then draws, then slower losses
+	Rank int
+}
+
+const maxDTZ = 1 << 18
+
+// RootMoves returns the legal moves of a position, best first. Wins are
+// ranked by how quickly they zero the halfmove clock, so that playing the
+// first move wins whenever that is possible under the 50-move rule.
+func (tb *Tablebase) RootMoves(board *core.Board) ([]RootMove, error) {
+	if err := tb.checkBoard(board); err != nil {
+		return nil, err
+	}
+
+	b := core.NewBoardFromBoard(board)
+	clock := int(b.HalfMoveClock())
+
+	moves := b.LegalMoves(nil)
+	rootMoves := make([]RootMove, 0, len(moves))
+	for i := range moves {
+		m := &moves[i]
+
+		b.Push(m)
+
+		var dtz int
+		var err error
+		if b.HalfMoveClock() == 0 {
+			var wdl WDL
+			wdl, _, err = tb.search(&b, false)
+			dtz = dtzBeforeZeroing(-wdl)
+		} else if b.HalfMoveClock() >= 100 && !b.IsCheckmate() {
+			dtz = 0
+		} else {
+			dtz, err = tb.probeDTZ(&b)
+			dtz = -dtz
+			dtz += sign(dtz)
+		}
+
+		// Mate is better than any zeroing move
+		if dtz == 2 && b.IsCheckmate() {
+			dtz = 1
+		}
+		b.Pop()
+
+		if err != nil {
+			return nil, err
+		}
+
+		rm := RootMove{Move: *m, DTZ: dtz}
+		switch {
+		case dtz > 0 && dtz+clock <= 100:
+			rm.WDL, rm.Rank = Win, maxDTZ-dtz
+		case dtz > 0:
+			rm.WDL, rm.Rank = CursedWin, maxDTZ/2-(dtz+clock)
+		case dtz < 0 && -dtz+clock <= 100:
+			rm.WDL, rm.Rank = Loss, -maxDTZ-dtz
+		case dtz < 0:
+			rm.WDL, rm.Rank = BlessedLoss, -maxDTZ/2+(-dtz+clock)
+		}
+
+		rootMoves = append(rootMoves, rm)
+	}
+
+	sort.SliceStable(rootMoves, func(i, j int) bool {
+		return rootMoves[i].Rank > rootMoves[j].Rank
+	})
+
+	return rootMoves, nil
+}
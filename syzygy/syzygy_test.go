@@ -0,0 +1,1014 @@
+package syzygy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/captainsano/golang-chess/core"
+	"github.com/captainsano/golang-chess/internal/endgame"
+	"github.com/captainsano/golang-chess/util"
+)
+
+func TestIndexTables(t *testing.T) {
+	maxKK := 0
+	for i := range mapKK {
+		for _, code := range mapKK[i] {
+			maxKK = util.MaxInt(maxKK, code)
+		}
+	}
+	if maxKK != 461 {
+		t.Errorf("expected 462 king placements, got %d", maxKK+1)
+	}
+
+	if mapA1D1D4[int(core.D1)] != 2 || mapA1D1D4[int(core.A1)] != 6 || mapA1D1D4[int(core.D4)] != 9 {
+		t.Errorf("expected diagonal squares last, got d1 %d a1 %d d4 %d", mapA1D1D4[int(core.D1)], mapA1D1D4[int(core.A1)], mapA1D1D4[int(core.D4)])
+	}
+
+	if binomial[2][5] != 10 || binomial[3][64-1] != 39711 {
+		t.Errorf("unexpected binomials %d, %d", binomial[2][5], binomial[3][63])
+	}
+
+	if leadPawnsSize[1][0] != 6 || mapPawns[int(core.A2)] != 47 {
+		t.Errorf("unexpected pawn tables %d, %d", leadPawnsSize[1][0], mapPawns[int(core.A2)])
+	}
+}
+
+// The tables of the tests are written the way the reference implementation
+// reads them, without the encoding of the package, so that errors of both
+// do not cancel out.
+
+// Squares of the a1-d1-d4 triangle with the diagonal last, squares below
+// the a1-h8 diagonal, and squares of both diagonals, like the reference
+// implementation numbers them
+var (
+	refTriangle = [64]int{
+		6, 0, 1, 2, 2, 1, 0, 6,
+		0, 7, 3, 4, 4, 3, 7, 0,
+		1, 3, 8, 5, 5, 8, 3, 1,
+		2, 4, 5, 9, 9, 5, 4, 2,
+		2, 4, 5, 9, 9, 5, 4, 2,
+		1, 3, 8, 5, 5, 8, 3, 1,
+		0, 7, 3, 4, 4, 3, 7, 0,
+		6, 0, 1, 2, 2, 1, 0, 6,
+	}
+
+	refLower = [64]int{
+		28, 0, 1, 2, 3, 4, 5, 6,
+		0, 29, 7, 8, 9, 10, 11, 12,
+		1, 7, 30, 13, 14, 15, 16, 17,
+		2, 8, 13, 31, 18, 19, 20, 21,
+		3, 9, 14, 18, 32, 22, 23, 24,
+		4, 10, 15, 19, 22, 33, 25, 26,
+		5, 11, 16, 20, 23, 25, 34, 27,
+		6, 12, 17, 21, 24, 26, 27, 35,
+	}
+
+	refDiag = [64]int{
+		0, 0, 0, 0, 0, 0, 0, 8,
+		0, 1, 0, 0, 0, 0, 9, 0,
+		0, 0, 2, 0, 0, 10, 0, 0,
+		0, 0, 0, 3, 11, 0, 0, 0,
+		0, 0, 0, 12, 4, 0, 0, 0,
+		0, 0, 13, 0, 0, 5, 0, 0,
+		0, 14, 0, 0, 0, 0, 6, 0,
+		15, 0, 0, 0, 0, 0, 0, 7,
+	}
+)
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// refPieceIndex returns the index of three unique pieces without pawns,
+// by their squares in the order of the table. The squares of the other
+// pieces are mirrored with them.
+func refPieceIndex(p []int) int {
+	if p[0]&0x04 != 0 {
+		for i := range p {
+			p[i] ^= 0x07
+		}
+	}
+	if p[0]&0x20 != 0 {
+		for i := range p {
+			p[i] ^= 0x38
+		}
+	}
+
+	// The first piece off the a1-h8 diagonal goes below it
+	offDiag := func(s int) int { return s>>3 - s&7 }
+	for i := 0; i < 3; i++ {
+		if offDiag(p[i]) > 0 {
+			for j := range p {
+				p[j] = (p[j]>>3 | p[j]<<3) & 63
+			}
+		}
+		if offDiag(p[i]) != 0 {
+			break
+		}
+	}
+
+	i, j := boolInt(p[1] > p[0]), boolInt(p[2] > p[0])+boolInt(p[2] > p[1])
+	switch {
+	case offDiag(p[0]) != 0:
+		return refTriangle[p[0]]*63*62 + (p[1]-i)*62 + p[2] - j
+	case offDiag(p[1]) != 0:
+		return 6*63*62 + refDiag[p[0]]*28*62 + refLower[p[1]]*62 + p[2] - j
+	case offDiag(p[2]) != 0:
+		return 6*63*62 + 4*28*62 + refDiag[p[0]]*7*28 + (refDiag[p[1]]-i)*28 + refLower[p[2]]
+	}
+	return 6*63*62 + 4*28*62 + 4*7*28 + refDiag[p[0]]*7*6 + (refDiag[p[1]]-i)*6 + refDiag[p[2]] - j
+}
+
+// refIndex returns the file of the leading pawn, the index of the pieces
+// by their squares in the order of the table, and the number of indices.
+// The order of the table tells which factor the leading group and the
+// pawns of the other color have, 0xf if there are none.
+func refIndex(p []int, pawns bool, order [2]int) (int, int, int) {
+	p = append([]int{}, p...)
+
+	// A single leading pawn counts from the second rank of its file, the
+	// pawns of the other color take the pawn squares left over, the
+	// pieces the squares left over
+	var digits, ranges []int
+	file, start := 0, 1
+	if pawns {
+		if p[0]&0x04 != 0 {
+			for i := range p {
+				p[i] ^= 0x07
+			}
+		}
+		file, digits, ranges = p[0]&7, []int{p[0]>>3 - 1}, []int{6}
+		if order[1] != 0xf {
+			digits, ranges, start = append(digits, p[1]-boolInt(p[1] > p[0])-8), append(ranges, 47), 2
+		}
+	} else {
+		digits, ranges, start = []int{refPieceIndex(p)}, []int{31332}, 3
+	}
+
+	first := len(digits)
+	for i := start; i < len(p); i++ {
+		digit := p[i]
+		for _, s := range p[:i] {
+			digit -= boolInt(p[i] > s)
+		}
+		digits, ranges = append(digits, digit), append(ranges, 64-i)
+	}
+
+	// The leading group has index 0, the pawns of the other color 1
+	idx, factor := 0, 1
+	for k, next := 0, first; next < len(digits) || k == order[0] || k == order[1]; k++ {
+		i := next
+		switch k {
+		case order[0]:
+			i = 0
+		case order[1]:
+			i = 1
+		default:
+			next++
+		}
+		idx += digits[i] * factor
+		factor *= ranges[i]
+	}
+
+	return file, idx, factor
+}
+
+const (
+	testBlockBits = 6
+	testSpanBits  = 7
+)
+
+// pairs are values compressed like the tables: symbols stand for a value
+// or a pair of symbols, and have a canonical Huffman code in which lower
+// symbols have longer codes.
+type pairs struct {
+	// Set if all values are the same
+	single bool
+	value  int
+
+	// The left and right symbol of each symbol, values having 0xfff on
+	// the right
+	btree [][2]int
+
+	minLen int
+	maxLen int
+	lowest []int
+
+	blocks       [][]byte
+	blockLengths []int
+	sparse       [][2]int
+}
+
+// huffman returns the code lengths of an optimal prefix code for symbols
+// of the weights.
+func huffman(weights []int) []int {
+	weight := append([]int{}, weights...)
+	parent := make([]int, len(weights))
+	active := make([]int, len(weights))
+	for i := range active {
+		active[i], parent[i] = i, -1
+	}
+
+	for len(active) > 1 {
+		sort.SliceStable(active, func(i, j int) bool { return weight[active[i]] < weight[active[j]] })
+		n := len(weight)
+		weight = append(weight, weight[active[0]]+weight[active[1]])
+		parent = append(parent, -1)
+		parent[active[0]], parent[active[1]] = n, n
+		active = append(active[2:], n)
+	}
+
+	lengths := make([]int, len(weights))
+	for i := range lengths {
+		for n := i; parent[n] != -1; n = parent[n] {
+			lengths[i]++
+		}
+	}
+	return lengths
+}
+
+// compress replaces frequent pairs of symbols by new symbols, then writes
+// the codes of the symbols to blocks.
+func compress(values []int) *pairs {
+	p := &pairs{}
+
+	symbols := map[int]int{}
+	stream := make([]int, len(values))
+	for i, v := range values {
+		if _, ok := symbols[v]; !ok {
+			symbols[v] = len(p.btree)
+			p.btree = append(p.btree, [2]int{v, 0xfff})
+		}
+		stream[i] = symbols[v]
+	}
+
+	if len(p.btree) == 1 {
+		p.single, p.value = true, values[0]
+		return p
+	}
+
+	// Values of each symbol
+	length := make([]int, len(p.btree))
+	for i := range length {
+		length[i] = 1
+	}
+
+	for round := 0; round < 32; round++ {
+		count := map[[2]int]int{}
+		best := [2]int{}
+		for i := 0; i+1 < len(stream); i++ {
+			pair := [2]int{stream[i], stream[i+1]}
+			if length[pair[0]]+length[pair[1]] <= 64 {
+				count[pair]++
+				if count[pair] > count[best] {
+					best = pair
+				}
+			}
+		}
+		if count[best] < 16 {
+			break
+		}
+
+		sym := len(p.btree)
+		p.btree = append(p.btree, best)
+		length = append(length, length[best[0]]+length[best[1]])
+
+		n := 0
+		for i := 0; i < len(stream); i, n = i+1, n+1 {
+			if i+1 < len(stream) && stream[i] == best[0] && stream[i+1] == best[1] {
+				stream[n] = sym
+				i++
+			} else {
+				stream[n] = stream[i]
+			}
+		}
+		stream = stream[:n]
+	}
+
+	// Symbols that only occur in pairs still need a code
+	weights := make([]int, len(p.btree))
+	for i := range weights {
+		weights[i] = 1
+	}
+	for _, sym := range stream {
+		weights[sym]++
+	}
+	lengths := huffman(weights)
+
+	order := make([]int, len(lengths))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return lengths[order[i]] > lengths[order[j]] })
+	renumber := make([]int, len(order))
+	for n, sym := range order {
+		renumber[sym] = n
+	}
+
+	btree := make([][2]int, len(p.btree))
+	codeLen := make([]int, len(p.btree))
+	valueLen := make([]int, len(p.btree))
+	for sym, n := range renumber {
+		btree[n] = p.btree[sym]
+		if btree[n][1] != 0xfff {
+			btree[n] = [2]int{renumber[btree[n][0]], renumber[btree[n][1]]}
+		}
+		codeLen[n], valueLen[n] = lengths[sym], length[sym]
+	}
+	p.btree = btree
+	for i := range stream {
+		stream[i] = renumber[stream[i]]
+	}
+
+	// Codes of each length follow the codes of the next length, halved
+	p.minLen, p.maxLen = 64, 0
+	count := map[int]int{}
+	for _, l := range codeLen {
+		p.minLen, p.maxLen = util.MinInt(p.minLen, l), util.MaxInt(p.maxLen, l)
+		count[l]++
+	}
+
+	base := map[int]uint64{}
+	p.lowest = make([]int, p.maxLen-p.minLen+1)
+	longer := 0
+	for l := p.maxLen; l >= p.minLen; l-- {
+		if l < p.maxLen {
+			base[l] = (base[l+1] + uint64(count[l+1])) / 2
+		}
+		p.lowest[l-p.minLen] = longer
+		longer += count[l]
+	}
+
+	// Blocks hold whole symbols
+	starts := []int{}
+	used, pos := 8<<testBlockBits, 0
+	for _, sym := range stream {
+		l := codeLen[sym]
+		if used+l > 8<<testBlockBits {
+			p.blocks = append(p.blocks, make([]byte, 1<<testBlockBits))
+			starts = append(starts, pos)
+			used = 0
+		}
+
+		block := p.blocks[len(p.blocks)-1]
+		code := base[l] + uint64(sym-p.lowest[l-p.minLen])
+		for i := l - 1; i >= 0; i-- {
+			if code>>uint(i)&1 != 0 {
+				block[used/8] |= 0x80 >> uint(used%8)
+			}
+			used++
+		}
+		pos += valueLen[sym]
+	}
+	starts = append(starts, pos)
+
+	for b := range p.blocks {
+		p.blockLengths = append(p.blockLengths, starts[b+1]-starts[b]-1)
+	}
+
+	// The block and offset of the value in the middle of each span
+	span := 1 << testSpanBits
+	for m := span / 2; m-span/2 < len(values); m += span {
+		b := 0
+		for b+1 < len(p.blocks) && starts[b+1] <= m {
+			b++
+		}
+		p.sparse = append(p.sparse, [2]int{b, m - starts[b]})
+	}
+
+	return p
+}
+
+type tableWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *tableWriter) byte(b int) {
+	w.buf.WriteByte(byte(b))
+}
+
+func (w *tableWriter) le16(n int) {
+	binary.Write(&w.buf, binary.LittleEndian, uint16(n))
+}
+
+func (w *tableWriter) le32(n int) {
+	binary.Write(&w.buf, binary.LittleEndian, uint32(n))
+}
+
+func (w *tableWriter) align(n int) {
+	for w.buf.Len()%n != 0 {
+		w.byte(0)
+	}
+}
+
+// testTable is a table with the values by file of the leading pawn and
+// side to move.
+type testTable struct {
+	pawns     bool
+	symmetric bool
+
+	// By file, the orders and the piece codes of each side to move, and
+	// the flags of the values
+	order  [][2][2]int
+	pieces [][2][]int
+	flags  []int
+	data   [][]*pairs
+
+	// DTZ of stored values by file: for wins, losses, cursed wins and
+	// blessed losses
+	maps [][4][]int
+}
+
+func (tt *testTable) bytes(magic [4]byte, dtz bool) []byte {
+	w := &tableWriter{}
+	w.buf.Write(magic[:])
+	w.byte(boolInt(!tt.symmetric) | boolInt(tt.pawns)<<1)
+
+	for f := range tt.data {
+		w.byte(tt.order[f][0][0] | tt.order[f][1][0]<<4)
+		if tt.order[f][0][1] != 0xf {
+			w.byte(tt.order[f][0][1] | tt.order[f][1][1]<<4)
+		}
+		for k := range tt.pieces[f][0] {
+			w.byte(tt.pieces[f][0][k] | tt.pieces[f][1][k]<<4)
+		}
+	}
+	w.align(2)
+
+	for f, sides := range tt.data {
+		for _, p := range sides {
+			if p.single {
+				w.byte(tt.flags[f] | flagSingleValue)
+				w.byte(p.value)
+				continue
+			}
+
+			w.byte(tt.flags[f])
+			w.byte(testBlockBits)
+			w.byte(testSpanBits)
+			w.byte(0)
+			w.le32(len(p.blocks))
+			w.byte(p.maxLen)
+			w.byte(p.minLen)
+			for _, sym := range p.lowest {
+				w.le16(sym)
+			}
+
+			w.le16(len(p.btree))
+			for _, s := range p.btree {
+				w.byte(s[0] & 0xff)
+				w.byte(s[0]>>8 | s[1]&0xf<<4)
+				w.byte(s[1] >> 4)
+			}
+			if len(p.btree)%2 != 0 {
+				w.byte(0)
+			}
+		}
+	}
+
+	if dtz {
+		for f := range tt.data {
+			if tt.flags[f]&flagMapped == 0 {
+				continue
+			}
+
+			wide := tt.flags[f]&flagWide != 0
+			if wide {
+				w.align(2)
+			}
+			for _, values := range tt.maps[f] {
+				if wide {
+					w.le16(len(values))
+				} else {
+					w.byte(len(values))
+				}
+				for _, v := range values {
+					if wide {
+						w.le16(v)
+					} else {
+						w.byte(v)
+					}
+				}
+			}
+		}
+		w.align(2)
+	}
+
+	for _, sides := range tt.data {
+		for _, p := range sides {
+			for _, entry := range p.sparse {
+				w.le32(entry[0])
+				w.le16(entry[1])
+			}
+		}
+	}
+
+	for _, sides := range tt.data {
+		for _, p := range sides {
+			for _, n := range p.blockLengths {
+				w.le16(n)
+			}
+		}
+	}
+
+	for _, sides := range tt.data {
+		for _, p := range sides {
+			w.align(64)
+			for _, block := range p.blocks {
+				w.buf.Write(block)
+			}
+		}
+	}
+
+	return w.buf.Bytes()
+}
+
+// position is a legal position of a solved endgame, with the squares of
+// the pieces by piece code.
+type position struct {
+	squares     [16]int
+	whiteToMove bool
+	wdl         int
+	dtz         int
+}
+
+// threePieces returns the positions of the white king and a piece against
+// the black king.
+func threePieces(e *endgame.Endgame) func(visit func(*position)) {
+	return func(visit func(*position)) {
+		p := &position{}
+		for wk := 0; wk < 64; wk++ {
+			for x := 0; x < 64; x++ {
+				for bk := 0; bk < 64; bk++ {
+					for _, whiteToMove := range []bool{true, false} {
+						if !e.Legal(wk, x, bk, whiteToMove) {
+							continue
+						}
+
+						p.squares[6], p.squares[e.Piece], p.squares[14] = wk, x, bk
+						p.whiteToMove = whiteToMove
+						p.wdl, p.dtz = e.WDL(wk, x, bk, whiteToMove), e.DTZ(wk, x, bk, whiteToMove)
+						visit(p)
+					}
+				}
+			}
+		}
+	}
+}
+
+// fourPieces returns the solved positions of the white king and a piece
+// against the black king and a piece.
+func fourPieces(v *endgame.Versus) func(visit func(*position)) {
+	return func(visit func(*position)) {
+		p := &position{}
+		for wk := 0; wk < 64; wk++ {
+			for x := 0; x < 64; x++ {
+				for bk := 0; bk < 64; bk++ {
+					for y := 0; y < 64; y++ {
+						for _, whiteToMove := range []bool{true, false} {
+							if !v.Legal(wk, x, bk, y, whiteToMove) {
+								continue
+							}
+
+							p.squares[6], p.squares[v.White], p.squares[14], p.squares[8|v.Black] = wk, x, bk, y
+							p.whiteToMove = whiteToMove
+							p.wdl, p.dtz = v.WDL(wk, x, bk, y, whiteToMove), v.DTZ(wk, x, bk, y, whiteToMove)
+							visit(p)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// endgameTable is the layout of the table of an endgame: the piece codes
+// of each side to move, the pawn or leading pieces first, and the orders of
+// the leading group and of the pawns of the other color.
+type endgameTable struct {
+	material  string
+	pawns     bool
+	symmetric bool
+	pieces    [2][]int
+	order     [2][2]int
+	positions func(visit func(*position))
+}
+
+// values returns the values of the positions of the tables by file and
+// side to move, checking that positions with the same index have the same
+// value. Values of indices without position repeat the previous one.
+func (et *endgameTable) values(t *testing.T, sides []bool, value func(*position) int) [][][]int {
+	files := 1
+	if et.pawns {
+		files = 4
+	}
+
+	values := make([][][]int, files)
+	for f := range values {
+		values[f] = make([][]int, len(sides))
+	}
+
+	p := make([]int, len(et.pieces[0]))
+	et.positions(func(pos *position) {
+		for s, whiteToMove := range sides {
+			if pos.whiteToMove != whiteToMove {
+				continue
+			}
+
+			for k, code := range et.pieces[s] {
+				p[k] = pos.squares[code]
+			}
+
+			f, idx, size := refIndex(p, et.pawns, et.order[s])
+			if values[f][s] == nil {
+				values[f][s] = make([]int, size)
+				for i := range values[f][s] {
+					values[f][s][i] = -1
+				}
+			}
+
+			v := value(pos)
+			if idx < 0 || idx >= size {
+				t.Fatalf("%s: index %d of %v out of range", et.material, idx, p)
+			} else if old := values[f][s][idx]; old != -1 && old != v {
+				t.Fatalf("%s: index %d of %v is not unique", et.material, idx, p)
+			}
+			values[f][s][idx] = v
+		}
+	})
+
+	for f := range values {
+		for _, side := range values[f] {
+			previous := 0
+			for i, v := range side {
+				if v == -1 {
+					side[i] = previous
+				}
+				previous = side[i]
+			}
+		}
+	}
+
+	return values
+}
+
+func (et *endgameTable) table(values [][][]int, flags int) *testTable {
+	tt := &testTable{pawns: et.pawns, symmetric: et.symmetric}
+	for f := range values {
+		tt.order = append(tt.order, et.order)
+		tt.pieces = append(tt.pieces, et.pieces)
+		tt.flags = append(tt.flags, flags)
+
+		data := []*pairs{}
+		for _, side := range values[f] {
+			data = append(data, compress(side))
+		}
+		tt.data = append(tt.data, data)
+	}
+
+	return tt
+}
+
+// mapDTZ replaces the DTZ values of wins and losses, losses stored as -1
+// minus the value, by their index in the maps of the file.
+func mapDTZ(values [][][]int) [][4][]int {
+	maps := make([][4][]int, len(values))
+	for f := range values {
+		indices := [2]map[int]int{{}, {}}
+		for j, v := range values[f][0] {
+			result := 0
+			if v < 0 {
+				result, v = 1, -v-1
+			}
+
+			if _, ok := indices[result][v]; !ok {
+				indices[result][v] = len(maps[f][result])
+				maps[f][result] = append(maps[f][result], v)
+			}
+			values[f][0][j] = indices[result][v]
+		}
+	}
+
+	return maps
+}
+
+// writeTables writes the tables of the white king and a piece against the
+// black king, and of KRvKR and KPvKP.
+func writeTables(t *testing.T, directory string) {
+	b := core.NewDefaultBoard()
+	wdlMagic, dtzMagic := b.TablebaseMagics()
+
+	write := func(name string, data []byte) {
+		if err := ioutil.WriteFile(filepath.Join(directory, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Pieces are ordered differently for each table and side to move
+	unordered := [2]int{0, 0xf}
+	tables := []*endgameTable{
+		{"KRvK", false, false, [2][]int{{6, 4, 14}, {14, 4, 6}}, [2][2]int{unordered, unordered}, threePieces(endgame.Solve(core.Rook))},
+		{"KQvK", false, false, [2][]int{{5, 14, 6}, {6, 14, 5}}, [2][2]int{unordered, unordered}, threePieces(endgame.Solve(core.Queen))},
+		{"KBvK", false, false, [2][]int{{3, 6, 14}, {3, 6, 14}}, [2][2]int{unordered, unordered}, threePieces(endgame.Solve(core.Bishop))},
+		{"KNvK", false, false, [2][]int{{2, 6, 14}, {2, 6, 14}}, [2][2]int{unordered, unordered}, threePieces(endgame.Solve(core.Knight))},
+		{"KPvK", true, false, [2][]int{{1, 14, 6}, {1, 6, 14}}, [2][2]int{{1, 0xf}, unordered}, threePieces(endgame.Solve(core.Pawn))},
+		{"KRvKR", false, true, [2][]int{{4, 14, 12, 6}, {6, 12, 4, 14}}, [2][2]int{{1, 0xf}, unordered}, fourPieces(endgame.SolveVersus(core.Rook, core.Rook))},
+		{"KPvKP", true, true, [2][]int{{1, 9, 14, 6}, {9, 1, 6, 14}}, [2][2]int{{1, 0}, {0, 1}}, fourPieces(endgame.SolveVersus(core.Pawn, core.Pawn))},
+	}
+
+	for _, et := range tables {
+		// Symmetric tables store white to move, black to move is looked
+		// up with colors swapped
+		sides := []bool{true, false}
+		if et.symmetric {
+			sides = sides[:1]
+		}
+
+		wdl := et.values(t, sides, func(p *position) int {
+			return 2 + 2*p.wdl
+		})
+		write(et.material+".rtbw", et.table(wdl, 0).bytes(wdlMagic, false))
+
+		// Plies to zeroing less one, being mated counting one ply, and
+		// losses stored as -1 minus the plies
+		dtz := func(p *position) int {
+			switch p.wdl {
+			case 1:
+				return p.dtz - 1
+			case -1:
+				return -util.MaxInt(-p.dtz, 1)
+			}
+			return 0
+		}
+
+		var values [][][]int
+		var flags int
+		switch et.material {
+		case "KRvK":
+			// White to move, who never loses, without maps
+			values = et.values(t, []bool{true}, dtz)
+			flags = flagWinPlies | flagLossPlies
+		case "KQvK":
+			// Black to move with 16 bit maps
+			values = et.values(t, []bool{false}, dtz)
+			flags = flagSTM | flagMapped | flagWide | flagWinPlies | flagLossPlies
+		case "KPvK", "KRvKR":
+			// White to move with 8 bit maps for each file
+			values = et.values(t, []bool{true}, dtz)
+			flags = flagMapped | flagWinPlies | flagLossPlies
+		case "KPvKP":
+			values = et.values(t, []bool{true}, dtz)
+			flags = flagWinPlies | flagLossPlies
+		default:
+			continue
+		}
+
+		var maps [][4][]int
+		if flags&flagMapped != 0 {
+			maps = mapDTZ(values)
+		} else {
+			for f := range values {
+				for i, v := range values[f][0] {
+					if v < 0 {
+						values[f][0][i] = -v - 1
+					}
+				}
+			}
+		}
+		tt := et.table(values, flags)
+		tt.maps = maps
+		write(et.material+".rtbz", tt.bytes(dtzMagic, true))
+	}
+}
+
+func TestProbe(t *testing.T) {
+	directory, err := ioutil.TempDir("", "syzygy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	writeTables(t, directory)
+	ioutil.WriteFile(filepath.Join(directory, "README"), []byte("not a table"), 0644)
+
+	tb, err := Open(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tb.Close()
+
+	if tb.MaxPieces() != 4 {
+		t.Errorf("expected 4 pieces, got %d", tb.MaxPieces())
+	}
+
+	// Random positions of the solved endgames, also looked up with colors
+	// swapped, with their WDL and DTZ
+	r := rand.New(rand.NewSource(1))
+	var samples []func() (core.Board, int, int, bool)
+	for _, pt := range []core.PieceType{core.Rook, core.Queen, core.Bishop, core.Pawn} {
+		e := endgame.Solve(pt)
+		samples = append(samples, func() (core.Board, int, int, bool) {
+			wk, x, bk, whiteToMove := r.Intn(64), r.Intn(64), r.Intn(64), r.Intn(2) == 0
+			if !e.Legal(wk, x, bk, whiteToMove) {
+				return core.Board{}, 0, 0, false
+			}
+			return e.Board(wk, x, bk, whiteToMove, r.Intn(2) == 0), e.WDL(wk, x, bk, whiteToMove), e.DTZ(wk, x, bk, whiteToMove), true
+		})
+	}
+	for _, pt := range []core.PieceType{core.Rook, core.Pawn} {
+		v := endgame.SolveVersus(pt, pt)
+		samples = append(samples, func() (core.Board, int, int, bool) {
+			wk, x, bk, y, whiteToMove := r.Intn(64), r.Intn(64), r.Intn(64), r.Intn(64), r.Intn(2) == 0
+			if !v.Legal(wk, x, bk, y, whiteToMove) {
+				return core.Board{}, 0, 0, false
+			}
+			return v.Board(wk, x, bk, y, whiteToMove, r.Intn(2) == 0), v.WDL(wk, x, bk, y, whiteToMove), v.DTZ(wk, x, bk, y, whiteToMove), true
+		})
+	}
+
+	for _, sample := range samples {
+		for checked := 0; checked < 400; {
+			b, solvedWDL, plies, ok := sample()
+			if !ok {
+				continue
+			}
+			checked++
+
+			expectedWDL, expectedDTZ := Draw, 0
+			switch solvedWDL {
+			case 1:
+				expectedWDL, expectedDTZ = Win, plies
+			case -1:
+				expectedWDL, expectedDTZ = Loss, util.MinInt(plies, -1)
+			}
+
+			wdl, err := tb.ProbeWDL(&b)
+			if err != nil || wdl != expectedWDL {
+				t.Errorf("%s: expected %s, got %s (%v)", b.FEN(false, "legal", core.NoPiece), expectedWDL, wdl, err)
+			}
+
+			dtz, err := tb.ProbeDTZ(&b)
+			if err != nil || dtz != expectedDTZ {
+				t.Errorf("%s: expected dtz %d, got %d (%v)", b.FEN(false, "legal", core.NoPiece), expectedDTZ, dtz, err)
+			}
+
+			if expectedWDL != Win || checked%10 != 0 {
+				continue
+			}
+
+			moves, err := tb.RootMoves(&b)
+			if err != nil || len(moves) != len(b.LegalMoves(nil)) {
+				t.Fatalf("%s: unexpected root moves %v (%v)", b.FEN(false, "legal", core.NoPiece), moves, err)
+			}
+			if moves[0].WDL != Win || moves[0].DTZ != expectedDTZ {
+				t.Errorf("%s: expected a win in %d, got %s in %d with %s", b.FEN(false, "legal", core.NoPiece), expectedDTZ, moves[0].WDL, moves[0].DTZ, moves[0].Move.Uci())
+			}
+		}
+	}
+
+	// En passant captures are not in the tables, they are searched. Here
+	// they win the KPvK endgame that the solver knows.
+	e := endgame.Solve(core.Pawn)
+	for _, fen := range []string{"8/8/8/3pP3/8/8/8/K6k w - d6 0 1", "k6K/8/8/8/3Pp3/8/8/8 b - d3 0 1"} {
+		b := core.NewBoardFromFEN(fen, false)
+		expectedWDL := Draw
+		moves := b.LegalMoves(nil)
+		for i := range moves {
+			if !b.IsEnPassant(&moves[i]) {
+				continue
+			}
+
+			b.Push(&moves[i])
+			c := b.Turn()
+			king, pawn, other := int(b.Pieces(core.King, c.Swap()).Lsb()), int(b.Pieces(core.Pawn, c.Swap()).Lsb()), int(b.Pieces(core.King, c).Lsb())
+			if c == core.White {
+				king, pawn, other = king^56, pawn^56, other^56
+			}
+			if e.WDL(king, pawn, other, false) == -1 {
+				expectedWDL = Win
+			}
+			b.Pop()
+		}
+
+		if expectedWDL != Win {
+			t.Fatalf("%s: expected en passant to win", fen)
+		}
+		if wdl, err := tb.ProbeWDL(&b); err != nil || wdl != Win {
+			t.Errorf("%s: expected win, got %s (%v)", fen, wdl, err)
+		}
+		if dtz, err := tb.ProbeDTZ(&b); err != nil || dtz != 1 {
+			t.Errorf("%s: expected dtz 1, got %d (%v)", fen, dtz, err)
+		}
+	}
+
+	// DTZ tables store one side to move, the other side is looked up one
+	// ply ahead
+	for _, c := range []struct {
+		fen   string
+		state probeState
+	}{
+		{"8/8/8/4k3/8/8/8/3RK3 w - - 0 1", probeOK},
+		{"8/8/8/4k3/8/8/8/3RK3 b - - 0 1", probeChangeSTM},
+		{"8/8/8/4k3/8/8/8/3QK3 w - - 0 1", probeChangeSTM},
+		{"8/8/8/4k3/8/8/8/3QK3 b - - 0 1", probeOK},
+		{"3qk3/8/8/8/8/8/8/4K3 w - - 0 1", probeOK},
+		{"8/8/8/4k3/8/8/4P3/4K3 b - - 0 1", probeChangeSTM},
+	} {
+		b := core.NewBoardFromFEN(c.fen, false)
+		if _, state, err := tb.probeTable(&b, true, Win); err != nil || state != c.state {
+			t.Errorf("%s: expected state %d, got %d (%v)", c.fen, c.state, state, err)
+		}
+	}
+
+	// Values are stored as pairs of symbols with codes of several lengths
+	for _, name := range []string{"KRvK.rtbw", "KQvK.rtbz", "KPvK.rtbw", "KPvK.rtbz", "KRvKR.rtbw", "KRvKR.rtbz", "KPvKP.rtbw"} {
+		table, ok := tb.tables[name]
+		if !ok {
+			t.Errorf("%s: expected the table to be opened", name)
+			continue
+		}
+
+		for f := 0; f < 4 && (f == 0 || table.hasPawns); f++ {
+			d := &table.items[0][f]
+			pairs := false
+			for _, n := range d.symlen {
+				pairs = pairs || n > 0
+			}
+			if !pairs || d.maxSymLen == d.minSymLen {
+				t.Errorf("%s: expected pairs and codes of several lengths in file %d", name, f)
+			}
+		}
+	}
+
+	if d := &tb.tables["KBvK.rtbw"].items[0][0]; d.flags&flagSingleValue == 0 {
+		t.Error("expected KBvK to have a single value")
+	}
+}
+
+func TestTablebaseErrors(t *testing.T) {
+	directory, err := ioutil.TempDir("", "syzygy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	ioutil.WriteFile(filepath.Join(directory, "KQvK.rtbw"), []byte("not a table"), 0644)
+
+	tb, err := Open(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tb.Close()
+
+	b := core.NewBoardFromFEN("8/8/8/4k3/8/8/8/4K3 w - - 0 1", false)
+	if wdl, err := tb.ProbeWDL(&b); err != nil || wdl != Draw {
+		t.Errorf("expected KvK to be a draw, got %s (%v)", wdl, err)
+	}
+
+	b = core.NewBoardFromFEN("8/8/8/4k3/8/8/8/3RK3 w - - 0 1", false)
+	if _, err := tb.ProbeWDL(&b); err == nil {
+		t.Error("expected missing table error")
+	} else if _, ok := err.(*MissingTableError); !ok {
+		t.Errorf("expected missing table error, got %v", err)
+	}
+
+	b = core.NewBoardFromFEN("8/8/8/4k3/8/8/8/3QK3 w - - 0 1", false)
+	if _, err := tb.ProbeDTZ(&b); err == nil {
+		t.Error("expected error for missing DTZ table")
+	}
+	if _, err := tb.ProbeWDL(&b); err == nil {
+		t.Error("expected invalid magic number error")
+	}
+
+	b = core.NewBoardFromFEN("4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", false)
+	if _, err := tb.ProbeWDL(&b); err == nil {
+		t.Error("expected castling rights error")
+	}
+
+	b = core.NewAtomicBoard("8/8/8/4k3/8/8/8/4K3 w - - 0 1", false)
+	if _, err := tb.ProbeWDL(&b); err == nil {
+		t.Error("expected error for an Atomic board")
+	} else if _, ok := err.(*TablebaseError); !ok {
+		t.Errorf("expected tablebase error for an Atomic board, got %v", err)
+	}
+
+	b = core.NewAntichessBoard("8/8/8/4k3/8/8/8/3QK3 w - - 0 1", false)
+	if _, err := tb.ProbeDTZ(&b); err == nil {
+		t.Error("expected error for an Antichess board")
+	} else if _, ok := err.(*TablebaseError); !ok {
+		t.Errorf("expected tablebase error for an Antichess board, got %v", err)
+	}
+
+	if _, err := Open(filepath.Join(directory, "missing")); err == nil {
+		t.Error("expected error opening missing directory")
+	}
+}
@@ -0,0 +1,881 @@
+package syzygy
+
+import (
+	"encoding/binary"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/captainsano/golang-chess/core"
+)
+
+// Tables hold positions of up to 7 pieces.
+const tbPieces = 7
+
+// Flags of the pairs data of a table
+const (
+	flagSTM         = 1
+	flagMapped      = 2
+	flagWinPlies    = 4
+	flagLossPlies   = 8
+	flagWide        = 16
+	flagSingleValue = 128
+)
+
+// Index tables, computed by init
+var (
+	// Squares below the a1-h8 diagonal to 0..27
+	mapB1H1H7 [64]int
+
+	// Squares of the a1-d1-d4 triangle to 0..9, diagonal squares last
+	mapA1D1D4 [64]int
+
+	// The 462 legal placements of two kings, the first in the triangle
+	mapKK [10][64]int
+
+	// binomial[k][n] is the number of ways to choose k of n squares
+	binomial [tbPieces][64]uint64
+
+	// Squares a2-h7 to 0..47, the highest being the leading pawn
+	mapPawns [64]int
+
+	// Index and number of placements of the leading pawns by file
+	leadPawnIdx   [tbPieces][64]uint64
+	leadPawnsSize [tbPieces][4]uint64
+)
+
+func offA1H8(s int) int {
+	return s>>3 - s&7
+}
+
+func init() {
+	code := 0
+	for s := 0; s < 64; s++ {
+		if offA1H8(s) < 0 {
+			mapB1H1H7[s] = code
+			code++
+		}
+	}
+
+	code = 0
+	diagonal := []int{}
+	for s := 0; s <= int(core.D4); s++ {
+		if offA1H8(s) < 0 && s&7 <= 3 {
+			mapA1D1D4[s] = code
+			code++
+		} else if offA1H8(s) == 0 && s&7 <= 3 {
+			diagonal = append(diagonal, s)
+		}
+	}
+	for _, s := range diagonal {
+		mapA1D1D4[s] = code
+		code++
+	}
+
+	// If the first king is on the diagonal, the second may not be above it.
+	// Placements with both kings on the diagonal come last.
+	bothOnDiagonal := [][2]int{}
+	code = 0
+	for idx := 0; idx < 10; idx++ {
+		for s1 := 0; s1 <= int(core.D4); s1++ {
+			if mapA1D1D4[s1] != idx || (idx == 0 && s1 != int(core.B1)) {
+				continue
+			}
+
+			for s2 := 0; s2 < 64; s2++ {
+				if s1 == s2 || core.KingAttacks(core.Square(s1)).IsMaskingBB(core.NewBitboardFromSquare(core.Square(s2))) {
+					continue
+				} else if offA1H8(s1) == 0 && offA1H8(s2) > 0 {
+					continue
+				} else if offA1H8(s1) == 0 && offA1H8(s2) == 0 {
+					bothOnDiagonal = append(bothOnDiagonal, [2]int{idx, s2})
+				} else {
+					mapKK[idx][s2] = code
+					code++
+				}
+			}
+		}
+	}
+	for _, p := range bothOnDiagonal {
+		mapKK[p[0]][p[1]] = code
+		code++
+	}
+
+	binomial[0][0] = 1
+	for n := 1; n < 64; n++ {
+		for k := 0; k < tbPieces && k <= n; k++ {
+			if k > 0 {
+				binomial[k][n] += binomial[k-1][n-1]
+			}
+			if k < n {
+				binomial[k][n] += binomial[k][n-1]
+			}
+		}
+	}
+
+	available := 47
+	for leadPawns := 1; leadPawns < tbPieces; leadPawns++ {
+		for f := 0; f < 4; f++ {
+			idx := uint64(0)
+			for r := 1; r <= 6; r++ {
+				s := r*8 + f
+				if leadPawns == 1 {
+					mapPawns[s] = available
+					mapPawns[s^7] = available - 1
+					available -= 2
+				}
+
+				leadPawnIdx[leadPawns][s] = idx
+				idx += binomial[leadPawns-1][mapPawns[s]]
+			}
+
+			leadPawnsSize[leadPawns][f] = idx
+		}
+	}
+}
+
+// reader reads little and big endian numbers from a table file, keeping the
+// first error.
+type reader struct {
+	r   io.ReaderAt
+	err error
+}
+
+func (r *reader) bytes(offset int64, n int) []byte {
+	buf := make([]byte, n)
+	if r.err == nil && n > 0 {
+		if _, err := r.r.ReadAt(buf, offset); err != nil && err != io.EOF {
+			r.err = err
+		}
+	}
+
+	return buf
+}
+
+func (r *reader) u8(offset int64) int {
+	return int(r.bytes(offset, 1)[0])
+}
+
+func (r *reader) le16(offset int64) int {
+	return int(binary.LittleEndian.Uint16(r.bytes(offset, 2)))
+}
+
+func (r *reader) le32(offset int64) uint32 {
+	return binary.LittleEndian.Uint32(r.bytes(offset, 4))
+}
+
+// pairsData describes the compressed values of one side and file of a
+// table.
+type pairsData struct {
+	flags int
+
+	// Piece codes in encoding order: 1-6 for white pawn to king, 9-14 for
+	// black
+	pieces [tbPieces]int
+
+	// Length of each group of pieces, zero terminated, and the factor of
+	// its index. The last factor is the size of the table.
+	groupLen [tbPieces + 1]int
+	groupIdx [tbPieces + 1]uint64
+
+	sizeofBlock int64
+	span        uint64
+	numBlocks   int64
+
+	// Offsets of the sparse index, block lengths and blocks in the file
+	sparseIndex     int64
+	sparseIndexSize int64
+	blockLength     int64
+	blockLengthSize int64
+	data            int64
+
+	// Canonical Huffman code of the symbols
+	minSymLen int
+	maxSymLen int
+	lowestSym []uint64
+	base64    []uint64
+
+	// Symbols are pairs of symbols, or leaves holding a value. symlen is
+	// the number of values of a symbol minus one.
+	btree  []byte
+	symlen []int
+
+	// Offsets of the DTZ value maps by WDL, see mapScore
+	mapIdx [4]int
+}
+
+func (d *pairsData) left(sym int) int {
+	return int(d.btree[3*sym+1]&0xf)<<8 | int(d.btree[3*sym])
+}
+
+func (d *pairsData) right(sym int) int {
+	return int(d.btree[3*sym+2])<<4 | int(d.btree[3*sym+1]>>4)
+}
+
+// setGroups splits the pieces into groups and orders their indices. The
+// leading group is the unique pieces or the kings without pawns, the
+// leading pawns otherwise.
+func (t *table) setGroups(d *pairsData, order [2]int, f int) {
+	firstLen := 2
+	if t.hasPawns {
+		firstLen = 0
+	} else if t.hasUniquePieces {
+		firstLen = 3
+	}
+
+	n := 0
+	d.groupLen[n] = 1
+	for i := 1; i < t.pieceCount; i++ {
+		firstLen--
+		if firstLen > 0 || d.pieces[i] == d.pieces[i-1] {
+			d.groupLen[n]++
+		} else {
+			n++
+			d.groupLen[n] = 1
+		}
+	}
+	n++
+	d.groupLen[n] = 0
+
+	pp := t.hasPawns && t.pawnCount[1] > 0
+	next := 1
+	freeSquares := 64 - d.groupLen[0]
+	if pp {
+		next = 2
+		freeSquares -= d.groupLen[1]
+	}
+
+	idx := uint64(1)
+	for k := 0; next < n || k == order[0] || k == order[1]; k++ {
+		switch {
+		case k == order[0]:
+			d.groupIdx[0] = idx
+			if t.hasPawns {
+				idx *= leadPawnsSize[d.groupLen[0]][f]
+			} else if t.hasUniquePieces {
+				idx *= 31332
+			} else {
+				idx *= 462
+			}
+		case k == order[1]:
+			d.groupIdx[1] = idx
+			idx *= binomial[d.groupLen[1]][48-d.groupLen[0]]
+		default:
+			d.groupIdx[next] = idx
+			idx *= binomial[d.groupLen[next]][freeSquares]
+			freeSquares -= d.groupLen[next]
+			next++
+		}
+	}
+
+	d.groupIdx[n] = idx
+}
+
+// setSizes reads the sizes and the Huffman code of the pairs data at the
+// offset and returns the offset after it.
+func (t *table) setSizes(r *reader, d *pairsData, offset int64) int64 {
+	d.flags = r.u8(offset)
+	offset++
+
+	if d.flags&flagSingleValue != 0 {
+		d.minSymLen = r.u8(offset)
+		return offset + 1
+	}
+
+	n := 0
+	for d.groupLen[n] != 0 {
+		n++
+	}
+	tbSize := d.groupIdx[n]
+
+	d.sizeofBlock = 1 << uint(r.u8(offset))
+	d.span = 1 << uint(r.u8(offset+1))
+	d.sparseIndexSize = int64((tbSize + d.span - 1) / d.span)
+	padding := r.u8(offset + 2)
+	d.numBlocks = int64(r.le32(offset + 3))
+	d.blockLengthSize = d.numBlocks + int64(padding)
+	d.maxSymLen = r.u8(offset + 7)
+	d.minSymLen = r.u8(offset + 8)
+	offset += 9
+
+	if d.maxSymLen < d.minSymLen || d.maxSymLen-d.minSymLen > 64 {
+		r.err = &TablebaseError{description: "invalid symbol lengths in " + t.name}
+		return offset
+	}
+
+	// Longer codes have lower values, base64[i] is the lowest code of
+	// length minSymLen+i padded to 64 bits
+	count := d.maxSymLen - d.minSymLen + 1
+	d.lowestSym = make([]uint64, count)
+	d.base64 = make([]uint64, count)
+	for i := range d.lowestSym {
+		d.lowestSym[i] = uint64(r.le16(offset + int64(2*i)))
+	}
+	for i := count - 2; i >= 0; i-- {
+		d.base64[i] = (d.base64[i+1] + d.lowestSym[i] - d.lowestSym[i+1]) / 2
+	}
+	for i := range d.base64 {
+		d.base64[i] <<= uint(64 - i - d.minSymLen)
+	}
+	offset += int64(2 * count)
+
+	symbols := r.le16(offset)
+	offset += 2
+	d.btree = r.bytes(offset, 3*symbols)
+	d.symlen = make([]int, symbols)
+
+	visited := make([]bool, symbols)
+	for sym := 0; sym < symbols && r.err == nil; sym++ {
+		if !visited[sym] {
+			d.symlen[sym] = d.setSymlen(r, sym, visited)
+		}
+	}
+
+	return offset + int64(3*symbols) + int64(symbols&1)
+}
+
+func (d *pairsData) setSymlen(r *reader, sym int, visited []bool) int {
+	visited[sym] = true
+
+	right := d.right(sym)
+	if right == 0xfff {
+		return 0
+	}
+
+	left := d.left(sym)
+	if left >= len(d.symlen) || right >= len(d.symlen) {
+		r.err = &TablebaseError{description: "invalid symbol tree"}
+		return 0
+	}
+
+	if !visited[left] {
+		d.symlen[left] = d.setSymlen(r, left, visited)
+	}
+	if !visited[right] {
+		d.symlen[right] = d.setSymlen(r, right, visited)
+	}
+
+	return d.symlen[left] + d.symlen[right] + 1
+}
+
+// table is a WDL or DTZ table file, read on first use.
+type table struct {
+	name string
+	path string
+	dtz  bool
+
+	// Material of the white and black sides of the name, like KRvK, and
+	// the mirrored material, KvKR. They are equal for symmetric tables.
+	key  string
+	key2 string
+
+	pieceCount      int
+	hasPawns        bool
+	hasUniquePieces bool
+
+	// Pawns of the leading color, then of the other
+	pawnCount [2]int
+
+	// Set up on first use, with the error if that failed
+	initialized bool
+	err         error
+	file        io.ReaderAt
+	closer      io.Closer
+
+	// By side to move and file of the leading pawn
+	items [2][4]pairsData
+
+	// Offset of the value maps of DTZ tables
+	dtzMap int64
+}
+
+// newTable describes the table of a material like "KRPvKR".
+func newTable(material, path string, dtz bool) *table {
+	sides := strings.Split(material, "v")
+	t := &table{name: material, path: path, dtz: dtz, key: material, key2: sides[1] + "v" + sides[0]}
+
+	t.pieceCount = len(material) - 1
+	t.hasPawns = strings.Contains(material, "P")
+
+	whitePawns, blackPawns := strings.Count(sides[0], "P"), strings.Count(sides[1], "P")
+	if blackPawns == 0 || (whitePawns > 0 && blackPawns >= whitePawns) {
+		t.pawnCount = [2]int{whitePawns, blackPawns}
+	} else {
+		t.pawnCount = [2]int{blackPawns, whitePawns}
+	}
+
+	for _, side := range sides {
+		for _, c := range "QRBNP" {
+			if strings.Count(side, string(c)) == 1 {
+				t.hasUniquePieces = true
+			}
+		}
+	}
+
+	return t
+}
+
+// setup reads the header of the table, checking its magic number.
+func (t *table) setup(r io.ReaderAt, magic [4]byte) error {
+	t.file = r
+	rd := &reader{r: r}
+
+	if m := rd.bytes(0, 4); rd.err != nil || string(m) != string(magic[:]) {
+		if rd.err != nil {
+			return rd.err
+		}
+		return &TablebaseError{description: "invalid magic number in " + t.path}
+	}
+
+	flags := rd.u8(4)
+	if (flags&2 != 0) != t.hasPawns || (!t.dtz && (flags&1 != 0) != (t.key != t.key2)) {
+		return &TablebaseError{description: "table does not match its name: " + t.path}
+	}
+
+	sides := 1
+	if !t.dtz && t.key != t.key2 {
+		sides = 2
+	}
+
+	maxFile := 0
+	if t.hasPawns {
+		maxFile = 3
+	}
+
+	pp := 0
+	if t.hasPawns && t.pawnCount[1] > 0 {
+		pp = 1
+	}
+
+	offset := int64(5)
+	for f := 0; f <= maxFile; f++ {
+		b0 := rd.u8(offset)
+		order := [2][2]int{{b0 & 0xf, 0xf}, {b0 >> 4, 0xf}}
+		if pp == 1 {
+			b1 := rd.u8(offset + 1)
+			order[0][1], order[1][1] = b1&0xf, b1>>4
+		}
+		offset += int64(1 + pp)
+
+		for k := 0; k < t.pieceCount; k++ {
+			b := rd.u8(offset)
+			t.items[0][f].pieces[k] = b & 0xf
+			t.items[1][f].pieces[k] = b >> 4
+			offset++
+		}
+
+		for i := 0; i < sides; i++ {
+			t.setGroups(&t.items[i][f], order[i], f)
+		}
+	}
+
+	offset += offset & 1
+
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			offset = t.setSizes(rd, &t.items[i][f], offset)
+		}
+	}
+
+	if t.dtz {
+		offset = t.setDTZMap(rd, offset, maxFile)
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			d := &t.items[i][f]
+			d.sparseIndex = offset
+			offset += 6 * d.sparseIndexSize
+		}
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			d := &t.items[i][f]
+			d.blockLength = offset
+			offset += 2 * d.blockLengthSize
+		}
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			d := &t.items[i][f]
+			offset = (offset + 0x3f) &^ 0x3f
+			d.data = offset
+			offset += d.numBlocks * d.sizeofBlock
+		}
+	}
+
+	return rd.err
+}
+
+// setDTZMap reads the offsets of the maps from stored values to DTZ values
+// of each file.
+func (t *table) setDTZMap(rd *reader, offset int64, maxFile int) int64 {
+	t.dtzMap = offset
+
+	for f := 0; f <= maxFile; f++ {
+		d := &t.items[0][f]
+		if d.flags&flagMapped == 0 {
+			continue
+		}
+
+		if d.flags&flagWide != 0 {
+			offset += offset & 1
+			for i := 0; i < 4; i++ {
+				d.mapIdx[i] = int((offset-t.dtzMap)/2 + 1)
+				offset += 2*int64(rd.le16(offset)) + 2
+			}
+		} else {
+			for i := 0; i < 4; i++ {
+				d.mapIdx[i] = int(offset - t.dtzMap + 1)
+				offset += int64(rd.u8(offset)) + 1
+			}
+		}
+	}
+
+	return offset + offset&1
+}
+
+// decompress returns the stored value at an index.
+func (t *table) decompress(d *pairsData, idx uint64) (int, error) {
+	if d.flags&flagSingleValue != 0 {
+		return d.minSymLen, nil
+	}
+
+	rd := &reader{r: t.file}
+
+	// The sparse index points to the block of the value in the middle of
+	// each span
+	k := int64(idx / d.span)
+	entry := d.sparseIndex + 6*k
+	block := int64(rd.le32(entry))
+	offset := rd.le16(entry+4) + int(idx%d.span) - int(d.span/2)
+
+	blockLength := func(block int64) int {
+		if block < 0 || block >= d.blockLengthSize {
+			rd.err = &TablebaseError{description: "corrupt sparse index in " + t.path}
+			return 0
+		}
+		return rd.le16(d.blockLength + 2*block)
+	}
+
+	for offset < 0 && rd.err == nil {
+		block--
+		offset += blockLength(block) + 1
+	}
+
+	for rd.err == nil {
+		length := blockLength(block)
+		if offset <= length {
+			break
+		}
+		offset -= length + 1
+		block++
+	}
+
+	data := rd.bytes(d.data+block*d.sizeofBlock, int(d.sizeofBlock))
+	if rd.err != nil {
+		return 0, rd.err
+	}
+
+	word := func(i int) uint64 {
+		if i+4 > len(data) {
+			return 0
+		}
+		return uint64(binary.BigEndian.Uint32(data[i:]))
+	}
+
+	buf64 := word(0)<<32 | word(4)
+	next := 8
+	bufSize := 64
+
+	var sym int
+	for {
+		length := 0
+		for length+1 < len(d.base64) && buf64 < d.base64[length] {
+			length++
+		}
+
+		sym = int((buf64-d.base64[length])>>uint(64-length-d.minSymLen)) + int(d.lowestSym[length])
+		if sym >= len(d.symlen) {
+			return 0, &TablebaseError{description: "corrupt block in " + t.path}
+		}
+
+		if offset < d.symlen[sym]+1 {
+			break
+		}
+
+		offset -= d.symlen[sym] + 1
+		length += d.minSymLen
+		buf64 <<= uint(length)
+		bufSize -= length
+
+		if bufSize <= 32 {
+			bufSize += 32
+			buf64 |= word(next) << uint(64-bufSize)
+			next += 4
+		}
+	}
+
+	// Expand the pairs down to the leaf holding the value
+	for d.symlen[sym] != 0 {
+		left := d.left(sym)
+		if offset < d.symlen[left]+1 {
+			sym = left
+		} else {
+			offset -= d.symlen[left] + 1
+			sym = d.right(sym)
+		}
+	}
+
+	return d.left(sym), nil
+}
+
+// pieceCode returns the code of a piece in table headers.
+func pieceCode(p *core.Piece) int {
+	if p.Color == core.Black {
+		return int(p.Type) | 8
+	}
+	return int(p.Type)
+}
+
+// sortSquares sorts squares in place by a key.
+func sortSquares(squares []int, key func(int) int) {
+	sort.SliceStable(squares, func(i, j int) bool {
+		return key(squares[i]) < key(squares[j])
+	})
+}
+
+// index returns the pairs data and the index of the position, or ok false
+// if the table stores the other side to move. A position of the stronger
+// side is looked up with colors swapped.
+func (t *table) index(b *core.Board) (*pairsData, uint64, bool) {
+	material := materialKey(b)
+	flip := (t.key == t.key2 && b.Turn() == core.Black) || material != t.key
+
+	flipColor, flipSquares := 0, 0
+	if flip {
+		flipColor, flipSquares = 8, 56
+	}
+
+	stm := 0
+	if b.Turn() == core.Black {
+		stm = 1
+	}
+	if flip {
+		stm ^= 1
+	}
+
+	squares := make([]int, 0, tbPieces)
+	pieces := make([]int, 0, tbPieces)
+
+	tbFile := 0
+	leadPawns := core.BBVoid
+	if t.hasPawns {
+		color := core.White
+		if (t.items[0][0].pieces[0]^flipColor)&8 != 0 {
+			color = core.Black
+		}
+
+		leadPawns = b.Pieces(core.Pawn, color)
+		for bb := leadPawns; bb != core.BBVoid; {
+			squares = append(squares, int(bb.PopLsb())^flipSquares)
+			pieces = append(pieces, 0)
+		}
+
+		lead := 0
+		for i := range squares {
+			if mapPawns[squares[i]] > mapPawns[squares[lead]] {
+				lead = i
+			}
+		}
+		squares[0], squares[lead] = squares[lead], squares[0]
+
+		tbFile = squares[0] & 7
+		if tbFile > 3 {
+			tbFile = 7 - tbFile
+		}
+	}
+	leadPawnsCnt := len(squares)
+
+	// DTZ tables only store one side to move
+	d := &t.items[stm%2][tbFile]
+	if t.dtz {
+		d = &t.items[0][tbFile]
+		if d.flags&flagSTM != stm && !(t.key == t.key2 && !t.hasPawns) {
+			return nil, 0, false
+		}
+	}
+
+	occupied := core.BBVoid
+	for pt := core.Pawn; pt <= core.King; pt++ {
+		occupied |= b.Pieces(pt, core.White) | b.Pieces(pt, core.Black)
+	}
+
+	for bb := occupied &^ leadPawns; bb != core.BBVoid; {
+		s := bb.PopLsb()
+		squares = append(squares, int(s)^flipSquares)
+		pieces = append(pieces, pieceCode(b.PieceAt(s))^flipColor)
+	}
+
+	return d, t.encode(d, squares, pieces, leadPawnsCnt), true
+}
+
+// encode returns the index of the squares of pieces, the leading pawns
+// first.
+func (t *table) encode(d *pairsData, squares, pieces []int, leadPawnsCnt int) uint64 {
+	// Order the pieces like the table does
+	for i := leadPawnsCnt; i < len(squares)-1; i++ {
+		for j := i + 1; j < len(squares); j++ {
+			if d.pieces[i] == pieces[j] {
+				pieces[i], pieces[j] = pieces[j], pieces[i]
+				squares[i], squares[j] = squares[j], squares[i]
+				break
+			}
+		}
+	}
+
+	// Mirror the leading piece to the a-d files
+	if squares[0]&7 > 3 {
+		for i := range squares {
+			squares[i] ^= 7
+		}
+	}
+
+	var idx uint64
+	if t.hasPawns {
+		idx = leadPawnIdx[leadPawnsCnt][squares[0]]
+		sortSquares(squares[1:leadPawnsCnt], func(s int) int { return mapPawns[s] })
+		for i := 1; i < leadPawnsCnt; i++ {
+			idx += binomial[i][mapPawns[squares[i]]]
+		}
+	} else {
+		idx = t.leadingIndex(d, squares)
+	}
+
+	idx *= d.groupIdx[0]
+
+	// The remaining groups are placed on the squares left over
+	remainingPawns := t.hasPawns && t.pawnCount[1] > 0
+	start := d.groupLen[0]
+	for next := 1; d.groupLen[next] != 0; next++ {
+		group := squares[start : start+d.groupLen[next]]
+		sort.Ints(group)
+
+		n := uint64(0)
+		for i, s := range group {
+			adjust := 0
+			for _, previous := range squares[:start] {
+				if s > previous {
+					adjust++
+				}
+			}
+
+			free := s - adjust
+			if remainingPawns {
+				free -= 8
+			}
+			n += binomial[i+1][free]
+		}
+
+		remainingPawns = false
+		idx += n * d.groupIdx[next]
+		start += d.groupLen[next]
+	}
+
+	return idx
+}
+
+// leadingIndex returns the index of the leading group of a table without
+// pawns, mirroring the squares so that the leading piece is in the a1-d1-d4
+// triangle.
+func (t *table) leadingIndex(d *pairsData, squares []int) uint64 {
+	if squares[0]>>3 > 3 {
+		for i := range squares {
+			squares[i] ^= 56
+		}
+	}
+
+	// The first piece of the group off the diagonal goes below it
+	for i := 0; i < d.groupLen[0]; i++ {
+		if offA1H8(squares[i]) == 0 {
+			continue
+		}
+
+		if offA1H8(squares[i]) > 0 {
+			for j := i; j < len(squares); j++ {
+				squares[j] = ((squares[j] >> 3) | (squares[j] << 3)) & 63
+			}
+		}
+		break
+	}
+
+	if !t.hasUniquePieces {
+		return uint64(mapKK[mapA1D1D4[squares[0]]][squares[1]])
+	}
+
+	adjust1, adjust2 := 0, 0
+	if squares[1] > squares[0] {
+		adjust1 = 1
+	}
+	if squares[2] > squares[0] {
+		adjust2++
+	}
+	if squares[2] > squares[1] {
+		adjust2++
+	}
+
+	rank := func(s int) int { return s >> 3 }
+
+	switch {
+	case offA1H8(squares[0]) != 0:
+		return uint64((mapA1D1D4[squares[0]]*63+squares[1]-adjust1)*62 + squares[2] - adjust2)
+	case offA1H8(squares[1]) != 0:
+		return uint64((6*63+rank(squares[0])*28+mapB1H1H7[squares[1]])*62 + squares[2] - adjust2)
+	case offA1H8(squares[2]) != 0:
+		return uint64(6*63*62 + 4*28*62 + rank(squares[0])*7*28 + (rank(squares[1])-adjust1)*28 + mapB1H1H7[squares[2]])
+	default:
+		return uint64(6*63*62 + 4*28*62 + 4*7*28 + rank(squares[0])*7*6 + (rank(squares[1])-adjust1)*6 + rank(squares[2]) - adjust2)
+	}
+}
+
+// mapScore converts a stored DTZ value to plies, for a position with the
+// given WDL.
+func (t *table) mapScore(d *pairsData, value int, wdl WDL) (int, error) {
+	wdlMap := [...]int{1, 3, 0, 2, 0}
+
+	if d.flags&flagMapped != 0 {
+		rd := &reader{r: t.file}
+		i := d.mapIdx[wdlMap[wdl+2]] + value
+		if d.flags&flagWide != 0 {
+			value = rd.le16(t.dtzMap + 2*int64(i))
+		} else {
+			value = rd.u8(t.dtzMap + int64(i))
+		}
+
+		if rd.err != nil {
+			return 0, rd.err
+		}
+	}
+
+	if (wdl == Win && d.flags&flagWinPlies == 0) || (wdl == Loss && d.flags&flagLossPlies == 0) ||
+		wdl == CursedWin || wdl == BlessedLoss {
+		value *= 2
+	}
+
+	return value + 1, nil
+}
+
+// materialKey returns the material of the board like "KRvK", white first.
+func materialKey(b *core.Board) string {
+	return materialName(b, core.White) + "v" + materialName(b, core.Black)
+}
+
+func materialName(b *core.Board, c core.Color) string {
+	name := ""
+	for pt := core.King; pt >= core.Pawn; pt-- {
+		name += strings.Repeat(strings.ToUpper(pt.Symbol()), b.Pieces(pt, c).PopCount())
+	}
+	return name
+}